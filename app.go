@@ -4,37 +4,120 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"math"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
-	"photoTidyGo/internal/config"
-	"photoTidyGo/internal/media"
-	"photoTidyGo/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/applog"
+	"github.com/deadlyedge/wails-tabs/internal/backup"
+	"github.com/deadlyedge/wails-tabs/internal/bench"
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/rpc"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/volume"
 )
 
+// errBusy is returned when a job is requested while another one is running.
+var errBusy = errors.New("another scan or tidy run is in progress")
+
 // App struct holds global application state.
 type App struct {
-	ctx          context.Context
-	projectRoot  string
+	ctx context.Context
+	// mode is development, portable or installed; see resolveLocation.
+	mode string
+	// dataRoot anchors relative database, log and cache folders.
+	dataRoot     string
 	settingsPath string
 	settings     *config.Settings
 	store        *storage.Store
 	scanner      *media.Scanner
 	tidy         *media.TidyExecutor
+	remover      *media.Remover
+	gate         *media.PauseGate
+	throttle     *media.Throttle
+	logger       *applog.Logger
+	recovery     RecoveryReport
+	markerPath   string
+	// jobMu serialises scans and tidy runs, including scheduled ones.
+	jobMu sync.Mutex
+	// settingsMu guards swapping settings, which the watchers read from
+	// their own goroutines; see currentSettings.
+	settingsMu sync.RWMutex
+	// cancelMu guards cancelScan, which aborts the running scan, or pauses
+	// it given media.ErrScanPaused.
+	cancelMu   sync.Mutex
+	cancelScan context.CancelCauseFunc
+	// offlineMu guards offlinePath, the share a paused job is waiting for.
+	offlineMu   sync.Mutex
+	offlinePath string
+	// backfillMu guards cancelBackfill, which stops the running backfill.
+	backfillMu     sync.Mutex
+	cancelBackfill context.CancelFunc
+	// uploadMu guards cancelUpload, which stops the running library backup.
+	uploadMu     sync.Mutex
+	cancelUpload context.CancelFunc
+	// verifyMu guards cancelVerify, which stops the running verification.
+	verifyMu     sync.Mutex
+	cancelVerify context.CancelFunc
+	// facesMu guards cancelFaces, which stops the running face analysis.
+	facesMu     sync.Mutex
+	cancelFaces context.CancelFunc
+	// labelsMu guards cancelLabels, which stops the running image labelling.
+	labelsMu     sync.Mutex
+	cancelLabels context.CancelFunc
+	// ocrMu guards cancelOCR, which stops the running text recognition.
+	ocrMu     sync.Mutex
+	cancelOCR context.CancelFunc
+	// phashMu guards cancelPHash, which stops the running perceptual
+	// hashing of images and videos.
+	phashMu     sync.Mutex
+	cancelPHash context.CancelFunc
+	// priorityMu guards background, load and priorityErr, the state of the
+	// low job priority.
+	priorityMu  sync.Mutex
+	background  bool
+	load        float64
+	priorityErr string
+	// exiftool, ffprobe, rclone, tesseract and gphoto2 are the detected
+	// optional tools; probe wraps ffprobe while it is available.
+	exiftool  media.ToolInfo
+	ffprobe   media.ToolInfo
+	probe     *media.FFprobe
+	rclone    media.ToolInfo
+	tesseract media.ToolInfo
+	gphoto2   media.ToolInfo
+	// launchFolders are the folders named on the command line.
+	launchFolders []string
+	// hub passes emitted events on to gRPC streams.
+	hub events.Hub
+	// rpcMu guards rpcServer, the running gRPC service, and rpcConfig, the
+	// settings it was started with.
+	rpcMu     sync.Mutex
+	rpcServer *rpc.Server
+	rpcConfig config.GRPCConfig
+	// libraryKey unlocked the encrypted library named by keyAccount; it is
+	// kept so a settings reload reopens that library unlocked.
+	libraryKey []byte
+	keyAccount string
 }
 
 // NewApp creates a new App application struct.
 func NewApp() *App {
-	root, err := os.Getwd()
-	if err != nil {
-		root = "."
-	}
+	loc := resolveLocation()
 
 	return &App{
-		projectRoot:  root,
-		settingsPath: filepath.Join(root, "settings.toml"),
+		mode:         loc.mode,
+		dataRoot:     loc.dataRoot,
+		settingsPath: loc.settingsPath,
+		gate:         media.NewPauseGate(),
+		throttle:     media.NewThrottle(),
+		logger:       applog.Discard(),
 	}
 }
 
@@ -45,17 +128,33 @@ func (a *App) startup(ctx context.Context) {
 	if err := a.reloadSettings(); err != nil {
 		runtime.LogErrorf(ctx, "failed to load settings: %v", err)
 	} else {
-		runtime.LogInfo(ctx, "settings loaded")
+		a.logger.Info("settings loaded", "path", a.settingsPath)
+		a.recovery = a.checkIntegrity()
+		a.applyRetention()
+		a.resumeBackfills()
 	}
+
+	runtime.OnFileDrop(ctx, a.onFileDrop)
+
+	go a.watchBattery()
+	go a.watchLoad()
+	go a.watchVolumes()
+	go a.runScheduler()
+	go a.runVerifyScheduler()
+	go a.runMaintenanceScheduler()
+	go a.watchSettings()
 }
 
 // shutdown cleans up resources when the application exits.
 func (a *App) shutdown(ctx context.Context) {
+	a.stopGRPC()
 	if a.store != nil {
 		if err := a.store.Close(); err != nil {
-			runtime.LogErrorf(ctx, "close store: %v", err)
+			a.logger.Error("close store", "error", err)
 		}
 	}
+	a.clearSessionMarker()
+	_ = a.logger.Close()
 }
 
 // reloadSettings loads settings.toml and prepares the sqlite store.
@@ -65,7 +164,7 @@ func (a *App) reloadSettings() error {
 		return err
 	}
 
-	dbPath := cfg.DatabasePath(a.projectRoot)
+	dbPath := cfg.DatabasePath(a.dataRoot)
 	store, err := storage.New(dbPath)
 	if err != nil {
 		return fmt.Errorf("initialise store: %w", err)
@@ -75,63 +174,758 @@ func (a *App) reloadSettings() error {
 		_ = a.store.Close()
 	}
 
+	if logDir := cfg.LogDir(a.dataRoot); logDir != a.logger.Dir() {
+		logger, err := applog.Open(logDir)
+		if err != nil {
+			runtime.LogErrorf(a.ctx, "open log file: %v", err)
+		} else {
+			a.clearSessionMarker()
+			_ = a.logger.Close()
+			a.logger = logger
+		}
+	}
+
+	a.settingsMu.Lock()
 	a.settings = cfg
+	a.settingsMu.Unlock()
+	a.throttle.SetLimits(media.ThrottleLimits{
+		MBPerSec:    cfg.Throttle.MBPerSec,
+		FileDelayMS: cfg.Throttle.FileDelayMS,
+	})
 	a.store = store
+	a.unlockStore(dbPath)
 	a.scanner = media.NewScanner(store)
 	a.tidy = media.NewTidyExecutor(store)
+	a.remover = media.NewRemover(store)
+	a.detectTools()
+	a.applyPriority()
+	a.serveGRPC()
 	return nil
 }
 
+// currentSettings returns the loaded settings for background watchers that
+// run outside jobMu; nil before the first load.
+func (a *App) currentSettings() *config.Settings {
+	a.settingsMu.RLock()
+	defer a.settingsMu.RUnlock()
+	return a.settings
+}
+
+// updateSettings applies change to a copy of the loaded settings and swaps
+// it in, so settings read through currentSettings are never changed under
+// their readers.
+func (a *App) updateSettings(change func(*config.Settings)) {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	if a.settings == nil {
+		return
+	}
+	updated := *a.settings
+	change(&updated)
+	a.settings = &updated
+}
+
+// currentProbe returns the ffprobe wrapper for the media handler, which
+// serves requests outside jobMu; nil while ffprobe is missing.
+func (a *App) currentProbe() *media.FFprobe {
+	a.settingsMu.RLock()
+	defer a.settingsMu.RUnlock()
+	return a.probe
+}
+
+// applyRetention archives and prunes completed actions past the retention
+// window and purges media rows trashed longer than the trash window.
+func (a *App) applyRetention() {
+	archived, purged, err := a.pruneExpired()
+	if err != nil {
+		a.logger.Error("apply retention", "error", err)
+	}
+	if archived > 0 {
+		a.logger.Info("archived expired actions", "count", archived)
+	}
+	if purged > 0 {
+		a.logger.Info("purged trash", "count", purged)
+	}
+}
+
+// pruneExpired archives the completed actions and purges the trashed media
+// rows the retention settings expire, returning how many of each went.
+func (a *App) pruneExpired() (archived, purged int, err error) {
+	if a.store == nil || a.settings == nil {
+		return 0, 0, nil
+	}
+	if days := a.settings.Retention.TrashDays; days > 0 {
+		if purged, err = a.store.PurgeTrash(a.ctx, time.Now().AddDate(0, 0, -days)); err != nil {
+			return 0, 0, err
+		}
+	}
+	if days := a.settings.Retention.ActionDays; days > 0 {
+		cutoff := time.Now().AddDate(0, 0, -days)
+		if archived, err = a.store.ArchiveExpiredActions(a.ctx, cutoff, a.settings.ArchivePath(a.dataRoot)); err != nil {
+			return 0, purged, err
+		}
+	}
+	return archived, purged, nil
+}
+
 // GetSettings returns the current configuration for the UI.
 func (a *App) GetSettings() config.Settings {
-	if a.settings == nil {
+	settings := a.currentSettings()
+	if settings == nil {
 		return config.Settings{}
 	}
-	return *a.settings
+	return *settings
 }
 
 // ReloadSettings triggers a reload from disk, useful after manual edits.
 func (a *App) ReloadSettings() (config.Settings, error) {
+	if !a.jobMu.TryLock() {
+		return config.Settings{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
 	if err := a.reloadSettings(); err != nil {
 		return config.Settings{}, err
 	}
 	return *a.settings, nil
 }
 
+// ListFeatureFlags returns every experimental flag and whether it is enabled.
+func (a *App) ListFeatureFlags() []config.FeatureFlag {
+	if a.settings == nil {
+		return nil
+	}
+	return a.settings.FeatureFlags()
+}
+
+// SetFeatureFlag persists a flag to settings.toml and reloads the settings.
+func (a *App) SetFeatureFlag(name string, enabled bool) ([]config.FeatureFlag, error) {
+	if !a.jobMu.TryLock() {
+		return nil, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := config.SetFeature(a.settingsPath, name, enabled); err != nil {
+		return nil, err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return nil, err
+	}
+	return a.settings.FeatureFlags(), nil
+}
+
+// ListProfiles returns the settings profiles and which one is active.
+func (a *App) ListProfiles() []config.ProfileInfo {
+	if a.settings == nil {
+		return nil
+	}
+	return a.settings.ListProfiles()
+}
+
+// SetActiveProfile switches to the named profile ("default" for the base
+// settings), persists the choice and reloads the settings.
+func (a *App) SetActiveProfile(name string) (config.Settings, error) {
+	if !a.jobMu.TryLock() {
+		return config.Settings{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := config.SetActiveProfile(a.settingsPath, name); err != nil {
+		return config.Settings{}, err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return config.Settings{}, err
+	}
+	a.logger.Info("profile activated", "profile", a.settings.ProfileName())
+	return *a.settings, nil
+}
+
+// GetThrottle returns the IO limits applied to scans and tidy runs.
+func (a *App) GetThrottle() media.ThrottleLimits {
+	return a.throttle.Limits()
+}
+
+// SetThrottle changes the IO limits, including for a job already running,
+// and persists them to settings.toml.
+func (a *App) SetThrottle(limits media.ThrottleLimits) (media.ThrottleLimits, error) {
+	if err := config.SetThrottle(a.settingsPath, limits.MBPerSec, limits.FileDelayMS); err != nil {
+		return a.throttle.Limits(), err
+	}
+	a.throttle.SetLimits(limits)
+	a.updateSettings(func(settings *config.Settings) {
+		settings.Throttle.MBPerSec = limits.MBPerSec
+		settings.Throttle.FileDelayMS = limits.FileDelayMS
+	})
+	a.logger.Info("throttle changed", "mbPerSec", limits.MBPerSec, "fileDelayMs", limits.FileDelayMS)
+	return a.throttle.Limits(), nil
+}
+
 // RunScan starts a synchronous media scan based on the current settings.
 func (a *App) RunScan() (media.Summary, error) {
+	return a.scan(false)
+}
+
+func (a *App) scan(incremental bool) (media.Summary, error) {
 	if a.scanner == nil || a.settings == nil {
 		return media.Summary{}, errors.New("scanner not initialised")
 	}
+	if !a.jobMu.TryLock() {
+		return media.Summary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	return a.scanSources(media.Options{Sources: a.settings.EffectiveSources(), Incremental: incremental})
+}
+
+// ImportFolders scans folders outside the configured sources, such as a
+// memory card. policy "skip" leaves files already in the library out and
+// reports them; "copy" records them anyway.
+func (a *App) ImportFolders(sources []string, policy string) (media.Summary, error) {
+	if a.scanner == nil || a.settings == nil {
+		return media.Summary{}, errors.New("scanner not initialised")
+	}
+	known, err := media.ParseImportPolicy(policy)
+	if err != nil {
+		return media.Summary{}, err
+	}
+	if !a.jobMu.TryLock() {
+		return media.Summary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	return a.scanSources(media.Options{Sources: sources, Known: known})
+}
+
+// ImportTakeout scans an extracted Google Takeout export, taking capture
+// times and geodata from the JSON sidecars where the files lack them. mode
+// "write" also writes the merged values into the files (requires exiftool).
+func (a *App) ImportTakeout(sources []string, mode string) (media.Summary, error) {
+	if a.scanner == nil || a.settings == nil {
+		return media.Summary{}, errors.New("scanner not initialised")
+	}
+	takeout, err := media.ParseTakeoutMode(mode)
+	if err != nil {
+		return media.Summary{}, err
+	}
+	if takeout == "" {
+		takeout = media.TakeoutMerge
+	}
+	if takeout == media.TakeoutWrite {
+		if err := a.checkWritable(); err != nil {
+			return media.Summary{}, err
+		}
+	}
+	if !a.jobMu.TryLock() {
+		return media.Summary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	return a.scanSources(media.Options{Sources: sources, Takeout: takeout})
+}
+
+// scanSources runs a scan of job.Sources with the job's import options and
+// everything else taken from the settings; callers must hold jobMu.
+func (a *App) scanSources(job media.Options) (media.Summary, error) {
+	return a.scanWith(a.scanner, job, false)
+}
+
+// scanWith runs the scan of scanSources with scanner, which a staging scan
+// points at the staging database; callers must hold jobMu.
+func (a *App) scanWith(scanner *media.Scanner, job media.Options, staging bool) (media.Summary, error) {
+	jobID := events.NewJobID("scan")
+	stats := media.NewJobStats("scan", 0)
+	stopStats := a.streamStats(jobID, stats)
+	defer stopStats()
+
+	opts := job
+	opts.Extensions = a.settings.NormalisedExtensions()
+	opts.FollowSymlinks = a.settings.Scan.FollowSymlinks
+	opts.SkipFolders = a.settings.EffectiveSkipFolders()
+	opts.JobID = jobID
+	opts.MemoryBudgetMB = a.settings.Scan.MemoryBudgetMB
+	opts.Parallel = a.settings.Scan.Parallel
+	// Overrides are keyed the way sources reach the scanner, with remotes
+	// replaced by their mirrors.
+	opts.PerSource = make(map[string]media.SourceOptions)
+	for path, folder := range a.settings.SourceOverrides() {
+		opts.PerSource[a.localSources([]string{path})[0]] = media.SourceOptions{
+			Extensions:     folder.Extensions,
+			Exclude:        folder.Exclude,
+			FollowSymlinks: folder.FollowSymlinks,
+		}
+	}
+	opts.ProfileDir = a.settings.LogDir(a.dataRoot)
+	opts.Stats = stats
+	opts.Gate = a.gate
+	opts.Throttle = a.throttle
+	opts.OnOffline = a.offlineHandler(jobID)
+	opts.PreCount = a.settings.Scan.PreCount
+	opts.Archives = a.settings.Scan.Archives
+	validate, err := media.ParseValidationLevel(a.settings.Scan.Validate)
+	if err != nil {
+		return media.Summary{}, err
+	}
+	opts.Validate = validate
+	if opts.Junk, err = media.NewJunkMatcher(a.settings.EffectiveJunkPatterns()); err != nil {
+		return media.Summary{}, err
+	}
+	zone, cameras, err := a.settings.TimeZones()
+	if err != nil {
+		return media.Summary{}, err
+	}
+	opts.TimeZone = zone
+	opts.CameraZones = make(map[string]*time.Location, len(cameras))
+	for camera, loc := range cameras {
+		opts.CameraZones[media.CameraKey("", camera)] = loc
+	}
+	if a.exiftool.Available {
+		et, err := media.StartExiftool(a.exiftool.Path)
+		if err != nil {
+			a.logger.Warn("exiftool unavailable for scan", "jobId", jobID, "error", err)
+		} else {
+			defer et.Close()
+			opts.Exiftool = et
+		}
+	}
+	opts.FFprobe = a.probe
+
+	ctx, cancel := context.WithCancelCause(a.ctx)
+	a.cancelMu.Lock()
+	a.cancelScan = cancel
+	a.cancelMu.Unlock()
+	defer func() {
+		a.cancelMu.Lock()
+		a.cancelScan = nil
+		a.cancelMu.Unlock()
+		cancel(nil)
+	}()
 
-	opts := media.Options{
-		Sources:        a.settings.EffectiveSources(),
-		Extensions:     a.settings.NormalisedExtensions(),
-		FollowSymlinks: a.settings.Scan.FollowSymlinks,
+	if opts.Sources, err = a.pullRemoteSources(ctx, jobID, opts.Sources); err != nil {
+		a.logger.Error("scan stopped", "jobId", jobID, "error", err)
+		a.notifyJob(config.NotifyScan, "Scan", err, "")
+		return media.Summary{}, err
 	}
 
-	return a.scanner.Scan(a.ctx, opts, func(p media.Progress) {
-		runtime.EventsEmit(a.ctx, "scan:progress", p)
+	a.logger.Info("scan started", "jobId", jobID, "sources", opts.Sources, "incremental", opts.Incremental, "takeout", opts.Takeout, "staging", staging)
+	summary, err := scanner.Scan(ctx, opts, func(p media.Progress) {
+		a.emit(jobID, events.ScanProgress, p)
 	})
+	if summary.Paused {
+		a.logger.Info("scan paused", "jobId", jobID, "sessionId", summary.SessionID, "persisted", summary.FilesPersisted)
+	} else if err != nil {
+		a.logger.Error("scan stopped", "jobId", jobID, "error", err, "persisted", summary.FilesPersisted)
+	} else {
+		a.logger.Info("scan finished", "jobId", jobID,
+			"discovered", summary.FilesDiscovered,
+			"persisted", summary.FilesPersisted,
+			"known", summary.FilesKnown,
+			"corrupt", summary.FilesCorrupt,
+			"junk", summary.FilesJunk,
+			"errors", summary.ErrorCount,
+			"cancelled", summary.Cancelled,
+			"paused", summary.Paused,
+			"durationMs", summary.DurationMS,
+			"peakMemoryMb", summary.PeakMemoryMB,
+		)
+		if summary.HeapProfile != "" {
+			a.logger.Warn("scan overran its memory budget", "jobId", jobID, "profile", summary.HeapProfile)
+		}
+		for _, msg := range summary.Errors {
+			a.logger.Warn("scan error", "jobId", jobID, "error", msg)
+		}
+		if !staging {
+			a.recordSnapshot(storage.SnapshotScan, 0)
+		}
+	}
+	if !summary.Paused && !summary.Cancelled {
+		a.notifyJob(config.NotifyScan, "Scan", err, fmt.Sprintf("%d files added, %d already in the library, %d errors",
+			summary.FilesPersisted, summary.FilesKnown, summary.ErrorCount))
+	}
+	// Cancelled or paused rather than shut down: return partial results.
+	if errors.Is(err, context.Canceled) && a.ctx.Err() == nil {
+		return summary, nil
+	}
+	return summary, err
+}
+
+// CancelScan aborts the running scan. The scan returns the files processed
+// so far with cancelled set. It reports whether a scan was running.
+func (a *App) CancelScan() bool {
+	a.cancelMu.Lock()
+	defer a.cancelMu.Unlock()
+	if a.cancelScan == nil {
+		return false
+	}
+	a.cancelScan(nil)
+	return true
+}
+
+// PauseScan stops the running scan so that ResumeScan can continue it later,
+// even after a restart. The scan returns the files processed so far with
+// paused set. It reports whether a scan was running.
+func (a *App) PauseScan() bool {
+	a.cancelMu.Lock()
+	defer a.cancelMu.Unlock()
+	if a.cancelScan == nil {
+		return false
+	}
+	a.cancelScan(media.ErrScanPaused)
+	return true
+}
+
+// ResumeScan continues the latest paused scan, or one cut short when the app
+// stopped, from where it got to in each source.
+func (a *App) ResumeScan() (media.Summary, error) {
+	if a.scanner == nil || a.settings == nil {
+		return media.Summary{}, errors.New("scanner not initialised")
+	}
+	if !a.jobMu.TryLock() {
+		return media.Summary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	session, found, err := a.store.LatestResumableScan(a.ctx)
+	if err != nil {
+		return media.Summary{}, err
+	}
+	if !found {
+		return media.Summary{}, errors.New("no paused scan to resume")
+	}
+	a.logger.Info("scan resuming", "sessionId", session.ID, "sources", session.Sources)
+	return a.scanSources(media.Options{Resume: session.ID})
 }
 
 // ExecuteTidy moves selected media files into the target structure.
-func (a *App) ExecuteTidy(requests []media.MoveRequest, dryRun bool) (media.TidySummary, error) {
+// safety is one of "fast", "standard" or "paranoid"; empty means standard.
+func (a *App) ExecuteTidy(requests []media.MoveRequest, dryRun bool, safety media.SafetyLevel) (media.TidySummary, error) {
+	return a.runTidy(requests, dryRun, safety, false, nil)
+}
+
+// ExecuteTidyTransactional moves a batch all-or-nothing: the first failure
+// undoes the moves already made and the summary reports why it stopped.
+func (a *App) ExecuteTidyTransactional(requests []media.MoveRequest, dryRun bool, safety media.SafetyLevel) (media.TidySummary, error) {
+	return a.runTidy(requests, dryRun, safety, true, nil)
+}
+
+// ExecuteTidyByFilter moves every media file matching filter into the
+// target structure. Matches are streamed from the store in batches, so the
+// frontend need not collect IDs; filter.Limit and filter.Offset are ignored.
+func (a *App) ExecuteTidyByFilter(filter storage.MediaFilter, dryRun bool, safety media.SafetyLevel) (media.TidySummary, error) {
+	if a.tidy == nil || a.settings == nil || a.store == nil {
+		return media.TidySummary{}, errors.New("tidy executor not initialised")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.TidySummary{}, err
+		}
+	}
+	if !a.jobMu.TryLock() {
+		return media.TidySummary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	cursor, err := a.store.OpenMediaCursor(a.ctx, filter)
+	if err != nil {
+		return media.TidySummary{}, err
+	}
+	return a.tidyJob(cursor.Total, dryRun, safety, false, nil, func(opts media.TidyOptions, onProgress func(media.TidyProgress)) (media.TidySummary, error) {
+		return a.tidy.ExecuteCursor(a.ctx, opts, cursor, onProgress)
+	})
+}
+
+// RetryFailedActions re-attempts failed move and quarantine actions, either
+// all failures of a run or the listed action IDs. Each retry is recorded as a
+// new action linked to the one it retries.
+func (a *App) RetryFailedActions(runID int64, actionIDs []int64, safety media.SafetyLevel) (media.TidySummary, error) {
+	if a.store == nil {
+		return media.TidySummary{}, errors.New("store not initialised")
+	}
+
+	failed, err := a.store.ListFailedActions(a.ctx, runID, actionIDs)
+	if err != nil {
+		return media.TidySummary{}, err
+	}
+
+	retryOf := make(map[int64]int64, len(failed))
+	requests := make([]media.MoveRequest, 0, len(failed))
+	for _, action := range failed {
+		if !action.MediaID.Valid {
+			continue
+		}
+		if _, seen := retryOf[action.MediaID.Int64]; seen {
+			continue
+		}
+		retryOf[action.MediaID.Int64] = action.ID
+		requests = append(requests, media.MoveRequest{MediaID: action.MediaID.Int64})
+	}
+
+	return a.runTidy(requests, false, safety, false, retryOf)
+}
+
+func (a *App) runTidy(requests []media.MoveRequest, dryRun bool, safety media.SafetyLevel, transactional bool, retryOf map[int64]int64) (media.TidySummary, error) {
 	if a.tidy == nil || a.settings == nil {
 		return media.TidySummary{}, errors.New("tidy executor not initialised")
 	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.TidySummary{}, err
+		}
+	}
+	if !a.jobMu.TryLock() {
+		return media.TidySummary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	return a.tidyFiles(requests, dryRun, safety, transactional, retryOf)
+}
+
+// tidyFiles runs the tidy executor; callers must hold jobMu.
+func (a *App) tidyFiles(requests []media.MoveRequest, dryRun bool, safety media.SafetyLevel, transactional bool, retryOf map[int64]int64) (media.TidySummary, error) {
+	return a.tidyJob(len(requests), dryRun, safety, transactional, retryOf, func(opts media.TidyOptions, onProgress func(media.TidyProgress)) (media.TidySummary, error) {
+		return a.tidy.Execute(a.ctx, opts, requests, onProgress)
+	})
+}
+
+// tidyJob wraps one executor call over total files with the backup, stats,
+// progress events and logging every tidy shares; callers must hold jobMu.
+func (a *App) tidyJob(total int, dryRun bool, safety media.SafetyLevel, transactional bool, retryOf map[int64]int64, execute func(media.TidyOptions, func(media.TidyProgress)) (media.TidySummary, error)) (media.TidySummary, error) {
+	if !dryRun && a.settings.Database.BackupBeforeTidy {
+		if _, err := a.autoBackup("pre-tidy"); err != nil {
+			return media.TidySummary{}, fmt.Errorf("pre-tidy backup: %w", err)
+		}
+	}
+
+	jobID := events.NewJobID("tidy")
+	backend, err := a.targetBackend(jobID)
+	if err != nil {
+		return media.TidySummary{}, err
+	}
+	stats := media.NewJobStats("tidy", total)
+	stopStats := a.streamStats(jobID, stats)
+	defer stopStats()
 
 	opts := media.TidyOptions{
-		TargetBase: a.settings.Target.BaseFolder,
-		Pattern:    a.settings.Target.Pattern,
-		DryRun:     dryRun,
+		Stats:         stats,
+		Gate:          a.gate,
+		Throttle:      a.throttle,
+		OnOffline:     a.offlineHandler(jobID),
+		TargetBase:    a.settings.Target.BaseFolder,
+		Backend:       backend,
+		Pattern:       a.settings.Target.Pattern,
+		DryRun:        dryRun,
+		Safety:        safety,
+		Workers:       a.settings.Target.Workers,
+		QuarantineDir: a.settings.Target.QuarantineFolder,
+		RetryOf:       retryOf,
+		Transactional: transactional,
+		Logger:        a.logger.With("jobId", jobID),
+		Normalization: media.UnicodeForm(a.settings.Target.Normalization),
+		CaseMode:      media.CaseMode(strings.ToLower(a.settings.Target.CaseMode)),
+
+		RemoveDuplicateSource: a.settings.Target.RemoveDuplicateSource,
+	}
+
+	a.logger.Info("tidy started", "jobId", jobID, "files", total, "dryRun", dryRun, "safety", safety)
+	summary, err := execute(opts, func(p media.TidyProgress) {
+		a.emit(jobID, events.TidyProgress, p)
+	})
+	if err != nil {
+		a.logger.Error("tidy stopped", "jobId", jobID, "runId", summary.RunID, "error", err)
+	} else {
+		a.logger.Info("tidy finished", "jobId", jobID, "runId", summary.RunID,
+			"moved", summary.Moved,
+			"skipped", summary.Skipped,
+			"failed", summary.Failed,
+			"quarantined", summary.Quarantined,
+			"aborted", summary.Aborted,
+			"rolledBack", summary.RolledBack,
+			"durationMs", summary.DurationMS,
+		)
+		if !dryRun {
+			a.recordSnapshot(storage.SnapshotTidy, 0)
+			if a.settings.Backup.AfterTidy && a.settings.Backup.Bucket != "" && !media.IsRemote(a.settings.Target.BaseFolder) && summary.RunID != 0 {
+				go a.backupRun(summary.RunID)
+			}
+		}
+	}
+	name := "Tidy"
+	if dryRun {
+		name = "Tidy dry run"
+	}
+	a.notifyJob(config.NotifyTidy, name, err, fmt.Sprintf("%d files moved, %d skipped, %d failed", summary.Moved, summary.Skipped, summary.Failed))
+	return summary, err
+}
+
+// CheckTarget verifies that the configured target base, including network
+// shares, is reachable and writable.
+func (a *App) CheckTarget() error {
+	if a.settings == nil {
+		return errors.New("settings not loaded")
+	}
+	backend, err := a.targetBackend("")
+	if err != nil {
+		return err
 	}
+	return media.PreflightTarget(backend, a.settings.Target.BaseFolder)
+}
 
-	return a.tidy.Execute(a.ctx, opts, requests, func(p media.TidyProgress) {
-		runtime.EventsEmit(a.ctx, "tidy:progress", p)
+// targetBackend returns the file system the configured target lives on.
+// Transfers to an rclone remote report rclone:progress under jobID.
+func (a *App) targetBackend(jobID string) (media.Backend, error) {
+	if base := a.settings.Target.BaseFolder; media.IsRcloneRemote(base) {
+		rclone, err := a.rcloneClient()
+		if err != nil {
+			return nil, err
+		}
+		return rclone.Backend(base, func(p media.RcloneProgress) {
+			a.emit(jobID, events.RcloneProgress, p)
+		}), nil
+	}
+	return media.NewBackend(a.settings.Target.BaseFolder, media.BackendCredentials{
+		Username: a.settings.Target.WebDAVUser,
+		Password: a.settings.Target.WebDAVPassword,
 	})
 }
 
+// removalBackend returns the target backend deletes of tidied files go
+// through. Without one, such as when rclone is missing, remote files are
+// reported as failed and local ones are still removed.
+func (a *App) removalBackend() media.Backend {
+	backend, err := a.targetBackend("")
+	if err != nil {
+		a.logger.Warn("target backend unavailable for removal", "error", err)
+		return nil
+	}
+	return backend
+}
+
+// ListActions pages through the recorded file actions, newest first.
+func (a *App) ListActions(filter storage.ActionFilter, page storage.Page) (storage.ActionPage, error) {
+	if a.store == nil {
+		return storage.ActionPage{}, errors.New("store not initialised")
+	}
+	return a.store.ListActions(a.ctx, filter, page)
+}
+
+// RollbackRun restores files and library paths touched by a tidy run. It
+// refuses while another job runs.
+func (a *App) RollbackRun(runID int64) (media.RollbackSummary, error) {
+	if a.tidy == nil || a.settings == nil {
+		return media.RollbackSummary{}, errors.New("tidy executor not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return media.RollbackSummary{}, err
+	}
+	if !a.jobMu.TryLock() {
+		return media.RollbackSummary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+	backend, err := a.targetBackend("")
+	if err != nil {
+		return media.RollbackSummary{}, err
+	}
+	return a.tidy.Rollback(a.ctx, runID, backend)
+}
+
+// BenchmarkStorage measures hash, SQLite and copy throughput against the
+// configured target volume.
+func (a *App) BenchmarkStorage(sizeMB int) (bench.Result, error) {
+	target := ""
+	if a.settings != nil {
+		target = a.settings.Target.BaseFolder
+	}
+	return bench.Run(a.ctx, bench.Options{TargetDir: target, SizeMB: sizeMB})
+}
+
+// BackupDatabase writes a snapshot of the library database. When destPath is
+// empty the snapshot goes to the backups folder. It returns the written path.
+func (a *App) BackupDatabase(destPath string) (string, error) {
+	if a.store == nil {
+		return "", errors.New("store not initialised")
+	}
+	if destPath == "" {
+		return a.autoBackup("manual")
+	}
+	if err := a.store.Backup(a.ctx, destPath); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// RestoreDatabase validates the backup at srcPath and swaps it in for the
+// current library database. It refuses while a job is running, since the
+// store is closed and reopened underneath it.
+func (a *App) RestoreDatabase(srcPath string) error {
+	if a.settings == nil {
+		return errors.New("settings not loaded")
+	}
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+	if err := storage.ValidateBackup(srcPath); err != nil {
+		return err
+	}
+	if !a.jobMu.TryLock() {
+		return errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if a.store != nil {
+		if err := a.store.Close(); err != nil {
+			return fmt.Errorf("close store: %w", err)
+		}
+		a.store = nil
+	}
+	if err := storage.RestoreFile(srcPath, a.settings.DatabasePath(a.dataRoot)); err != nil {
+		// Reopen whatever is on disk so the app stays usable.
+		_ = a.reloadSettings()
+		return err
+	}
+	return a.reloadSettings()
+}
+
+func (a *App) autoBackup(reason string) (string, error) {
+	name := fmt.Sprintf("%s-%s.db", reason, time.Now().Format("20060102-150405"))
+	dest := filepath.Join(a.settings.BackupDir(a.dataRoot), name)
+	if err := a.store.Backup(a.ctx, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// recordSnapshot stores the library totals for growth tracking. Failures are
+// logged only; they must not fail the job that triggered the snapshot.
+func (a *App) recordSnapshot(reason string, reclaimed int64) {
+	if err := a.store.RecordLibrarySnapshot(a.ctx, reason, reclaimed); err != nil {
+		a.logger.Error("record library snapshot", "reason", reason, "error", err)
+	}
+}
+
+// streamStats emits job:stats once per second until the returned stop
+// function is called, which also emits a final snapshot.
+func (a *App) streamStats(jobID string, stats *media.JobStats) func() {
+	ctx, cancel := context.WithCancel(a.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stats.Stream(ctx, time.Second, func(s media.StatsSnapshot) {
+			a.emit(jobID, events.JobStats, s)
+		})
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// emit sends an event wrapped in the versioned envelope to the window and
+// any gRPC streams. jobID is empty for events that do not belong to a job.
+func (a *App) emit(jobID, name string, payload interface{}) {
+	env := events.Wrap(jobID, name, payload)
+	runtime.EventsEmit(a.ctx, name, env)
+	a.hub.Publish(env)
+}
+
 // ListDuplicateGroups returns duplicate media grouped by hash.
 func (a *App) ListDuplicateGroups() ([]storage.DuplicateGroup, error) {
 	if a.store == nil {
@@ -140,7 +934,342 @@ func (a *App) ListDuplicateGroups() ([]storage.DuplicateGroup, error) {
 	return a.store.ListDuplicateGroups(a.ctx)
 }
 
-// Greet returns a greeting for the given name.
-func (a *App) Greet(name string) string {
-	return fmt.Sprintf("Hello %s, It's show time for you !", name)
+// ListDuplicateGroupsPage pages through the duplicate groups in scope
+// without loading the whole set. sort is "wasted" (reclaimable bytes, the
+// default) or "size" (number of copies).
+func (a *App) ListDuplicateGroupsPage(scope storage.DuplicateScope, sort string, page storage.Page) (storage.DuplicatePage, error) {
+	if a.store == nil {
+		return storage.DuplicatePage{}, errors.New("store not initialised")
+	}
+	return a.store.ListDuplicateGroupsPage(a.ctx, scope, sort, page)
+}
+
+// AcknowledgeDuplicates marks copies of a duplicate group as intentional so
+// listings and counts skip them: the whole group when mediaIDs is empty, or
+// the listed copies, e.g. a pair. It returns how many copies were marked.
+func (a *App) AcknowledgeDuplicates(hash string, mediaIDs []int64) (int, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	return a.store.AcknowledgeDuplicates(a.ctx, hash, mediaIDs)
+}
+
+// ClearDuplicateAcknowledgement makes acknowledged copies count as duplicates
+// again.
+func (a *App) ClearDuplicateAcknowledgement(hash string, mediaIDs []int64) (int, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	return a.store.ClearDuplicateAcknowledgement(a.ctx, hash, mediaIDs)
+}
+
+// GetDuplicateSummary totals the duplicate groups in scope and the space
+// resolving them would free.
+func (a *App) GetDuplicateSummary(scope storage.DuplicateScope) (storage.DuplicateSummary, error) {
+	if a.store == nil {
+		return storage.DuplicateSummary{}, errors.New("store not initialised")
+	}
+	return a.store.DuplicateSummary(a.ctx, scope)
+}
+
+// RepairPathCase merges library rows whose paths differ only by letter case.
+func (a *App) RepairPathCase() (int, error) {
+	if a.scanner == nil {
+		return 0, errors.New("scanner not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+	return a.scanner.RepairPathCase(a.ctx)
+}
+
+// ListMedia returns library entries matching the filter.
+func (a *App) ListMedia(filter storage.MediaFilter) ([]storage.MediaFile, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListMedia(a.ctx, filter)
+}
+
+// ListScanSessions returns the latest scans with their summaries, newest
+// first, including scans still running or cut short.
+func (a *App) ListScanSessions(limit int) ([]storage.ScanSession, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListScanSessions(a.ctx, limit)
+}
+
+// ListScanErrors returns a page of the errors a scan session ran into.
+func (a *App) ListScanErrors(sessionID int64, page storage.Page) ([]storage.ScanError, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListScanErrors(a.ctx, sessionID, page)
+}
+
+// ExportScanErrors writes the errors of a scan session to path for
+// troubleshooting. format is "text", "csv" or empty to go by the extension.
+// It returns how many errors were written.
+func (a *App) ExportScanErrors(sessionID int64, path, format string) (int, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	if strings.TrimSpace(path) == "" {
+		return 0, errors.New("report path is required")
+	}
+	n, err := media.WriteScanErrorReport(a.ctx, a.store, sessionID, path, format)
+	if err != nil {
+		a.logger.Error("scan error export failed", "sessionId", sessionID, "path", path, "error", err)
+		return n, err
+	}
+	a.logger.Info("scan errors exported", "sessionId", sessionID, "path", path, "errors", n)
+	return n, nil
+}
+
+// ListCorruptFiles returns a page of the files that failed validation during
+// a scan (see scan.validate). Tidy quarantines them when a quarantine folder
+// is set and leaves them in place otherwise.
+func (a *App) ListCorruptFiles(page storage.Page) ([]storage.MediaFile, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListCorruptMedia(a.ctx, page)
+}
+
+// GetTimeline groups the media matching filter by "year", "month" or "day"
+// with counts and a few representative files per period. filter.Limit and
+// filter.Offset page through periods for infinite scrolling.
+func (a *App) GetTimeline(granularity string, filter storage.MediaFilter) (storage.TimelinePage, error) {
+	if a.store == nil {
+		return storage.TimelinePage{}, errors.New("store not initialised")
+	}
+	return a.store.Timeline(a.ctx, granularity, filter)
+}
+
+// GetLibraryGrowth charts the library across "year", "month" (the default)
+// or "day" periods: file and byte totals, duplicates, and the space removals
+// reclaimed, oldest period first.
+func (a *App) GetLibraryGrowth(granularity string) ([]storage.GrowthPoint, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	if granularity == "" {
+		granularity = storage.GranularityMonth
+	}
+	return a.store.LibraryGrowth(a.ctx, granularity)
+}
+
+// GetFolderSizes totals the stored media below root per folder, depth
+// levels deep, so the UI can render a treemap of where the library lives.
+func (a *App) GetFolderSizes(root string, depth int) (storage.FolderSize, error) {
+	if a.store == nil {
+		return storage.FolderSize{}, errors.New("store not initialised")
+	}
+	return a.store.FolderSizes(a.ctx, root, depth)
+}
+
+// SearchMedia finds media by file name, path, camera, capture date or tags.
+// Words match as prefixes and quoted text as a phrase.
+func (a *App) SearchMedia(query string, page storage.Page) (storage.SearchPage, error) {
+	if a.store == nil {
+		return storage.SearchPage{}, errors.New("store not initialised")
+	}
+	return a.store.SearchMedia(a.ctx, query, page)
+}
+
+// mapThumbnailSize is the edge of the thumbnails shown on map markers.
+const mapThumbnailSize = 256
+
+// GetMapClusters returns the geo-tagged media inside bounds grouped into
+// markers for the given map zoom, each with a representative thumbnail.
+func (a *App) GetMapClusters(bounds storage.MapBounds, zoom int) ([]storage.MapCluster, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	clusters, err := a.store.MapClusters(a.ctx, bounds, zoom)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		clusters[i].Thumbnail = thumbnailURL(clusters[i].RepresentativeID, mapThumbnailSize)
+	}
+	return clusters, nil
+}
+
+// GetMediaExif returns every EXIF tag recorded for a media file.
+func (a *App) GetMediaExif(mediaID int64) (map[string]string, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.GetMediaExif(a.ctx, mediaID)
+}
+
+// UpdateMediaMetadata stores user corrections to the capture time, camera
+// fields and time offset of a media file and returns the corrected row.
+// Corrections survive rescans; an empty value reverts to the extracted one.
+func (a *App) UpdateMediaMetadata(mediaID int64, fields storage.MetadataEdit) (storage.MediaFile, error) {
+	if a.store == nil {
+		return storage.MediaFile{}, errors.New("store not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return storage.MediaFile{}, err
+	}
+	if err := a.store.UpdateMediaMetadata(a.ctx, mediaID, fields); err != nil {
+		return storage.MediaFile{}, err
+	}
+	a.logger.Info("media metadata edited", "mediaId", mediaID)
+	return a.mediaFile(a.ctx, mediaID)
+}
+
+// ShiftMediaTime moves the capture time of the given files by hours, for
+// example when a camera clock was left on another time zone. Shifts add up
+// and can be undone with the opposite shift. It returns the files changed.
+func (a *App) ShiftMediaTime(mediaIDs []int64, hours float64) (int, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+	minutes := int(math.Round(hours * 60))
+	n, err := a.store.ShiftMediaTime(a.ctx, mediaIDs, minutes)
+	if err != nil {
+		return 0, err
+	}
+	a.logger.Info("media time shifted", "files", n, "minutes", minutes)
+	return n, nil
+}
+
+// ListBurstGroups returns shots taken in quick succession on the same camera.
+func (a *App) ListBurstGroups() ([]storage.BurstGroup, error) {
+	if a.store == nil || a.settings == nil {
+		return nil, errors.New("store not initialised")
+	}
+	window := time.Duration(a.settings.Scan.BurstWindowSeconds) * time.Second
+	return a.store.ListBurstGroups(a.ctx, window, 2)
+}
+
+// DeleteMedia removes the given media files from disk and the library.
+func (a *App) DeleteMedia(ids []int64, dryRun bool) (media.RemovalSummary, error) {
+	if a.remover == nil {
+		return media.RemovalSummary{}, errors.New("remover not initialised")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.RemovalSummary{}, err
+		}
+	}
+	summary, err := a.remover.DeleteMedia(a.ctx, ids, a.removalBackend(), dryRun)
+	if !dryRun && summary.Removed > 0 {
+		a.recordSnapshot(storage.SnapshotRemove, summary.BytesReclaimed)
+	}
+	return summary, err
+}
+
+// ResolveDuplicates keeps one copy in each named duplicate group and removes
+// the rest.
+func (a *App) ResolveDuplicates(resolutions []media.DuplicateResolution, dryRun bool) (media.RemovalSummary, error) {
+	if a.remover == nil {
+		return media.RemovalSummary{}, errors.New("remover not initialised")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.RemovalSummary{}, err
+		}
+	}
+	summary, err := a.remover.ResolveDuplicates(a.ctx, resolutions, a.removalBackend(), dryRun)
+	if !dryRun && summary.Removed > 0 {
+		a.recordSnapshot(storage.SnapshotRemove, summary.BytesReclaimed)
+	}
+	return summary, err
+}
+
+// CleanEmptyDirs removes folders left empty below the configured sources.
+func (a *App) CleanEmptyDirs(dryRun bool) (media.RemovalSummary, error) {
+	if a.remover == nil || a.settings == nil {
+		return media.RemovalSummary{}, errors.New("remover not initialised")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.RemovalSummary{}, err
+		}
+	}
+	return a.remover.CleanEmptyDirs(a.ctx, a.localSources(a.settings.EffectiveSources()), dryRun)
+}
+
+// ListJunkFiles returns the temporary, partial and empty files scans found
+// (see scan.junkPatterns), which CleanJunk removes.
+func (a *App) ListJunkFiles() ([]storage.JunkFile, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListJunk(a.ctx)
+}
+
+// CleanJunk removes the junk files scans found.
+func (a *App) CleanJunk(dryRun bool) (media.RemovalSummary, error) {
+	if a.remover == nil {
+		return media.RemovalSummary{}, errors.New("remover not initialised")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return media.RemovalSummary{}, err
+		}
+	}
+	summary, err := a.remover.CleanJunk(a.ctx, dryRun)
+	if !dryRun && summary.Removed > 0 {
+		a.recordSnapshot(storage.SnapshotRemove, summary.BytesReclaimed)
+	}
+	return summary, err
+}
+
+// GetEventSchemas describes every event and summary payload with its schema version.
+func (a *App) GetEventSchemas() []events.Schema {
+	return []events.Schema{
+		events.Describe(events.ScanProgress, events.KindEvent, events.ScanProgressVersion, media.Progress{}),
+		events.Describe(events.TidyProgress, events.KindEvent, events.TidyProgressVersion, media.TidyProgress{}),
+		events.Describe(events.JobStats, events.KindEvent, events.JobStatsVersion, media.StatsSnapshot{}),
+		events.Describe(events.ScheduleActivity, events.KindEvent, events.ScheduleActivityVersion, ScheduleActivity{}),
+		events.Describe(events.PowerState, events.KindEvent, events.PowerStateVersion, PowerState{}),
+		events.Describe(events.StartupRecovery, events.KindEvent, events.StartupRecoveryVersion, RecoveryReport{}),
+		events.Describe(events.SettingsChanged, events.KindEvent, events.SettingsChangedVersion, SettingsChanged{}),
+		events.Describe(events.NetworkState, events.KindEvent, events.NetworkStateVersion, NetworkState{}),
+		events.Describe(events.VolumeState, events.KindEvent, events.VolumeStateVersion, VolumeState{}),
+		events.Describe(events.BackfillProgress, events.KindEvent, events.BackfillProgressVersion, storage.BackfillState{}),
+		events.Describe(events.BackupProgress, events.KindEvent, events.BackupProgressVersion, backup.Progress{}),
+		events.Describe(events.RcloneProgress, events.KindEvent, events.RcloneProgressVersion, media.RcloneProgress{}),
+		events.Describe(events.VerifyProgress, events.KindEvent, events.VerifyProgressVersion, media.VerifyProgress{}),
+		events.Describe(events.FacesProgress, events.KindEvent, events.FacesProgressVersion, media.FaceProgress{}),
+		events.Describe(events.LabelsProgress, events.KindEvent, events.LabelsProgressVersion, media.LabelProgress{}),
+		events.Describe(events.OCRProgress, events.KindEvent, events.OCRProgressVersion, media.OCRProgress{}),
+		events.Describe(events.PHashProgress, events.KindEvent, events.PHashProgressVersion, media.PHashProgress{}),
+		events.Describe(events.DeviceProgress, events.KindEvent, events.DeviceProgressVersion, media.DeviceProgress{}),
+		events.Describe(events.CardInserted, events.KindEvent, events.CardInsertedVersion, volume.Info{}),
+		events.Describe(events.OpenFolders, events.KindEvent, events.OpenFoldersVersion, OpenFolders{}),
+		events.Describe(events.SourcesDropped, events.KindEvent, events.SourcesDroppedVersion, DroppedSources{}),
+		events.Describe(events.LibraryLocked, events.KindEvent, events.LibraryLockedVersion, EncryptionState{}),
+		events.Describe("RunScan", events.KindSummary, events.ScanSummaryVersion, media.Summary{}),
+		events.Describe("ExecuteTidy", events.KindSummary, events.TidySummaryVersion, media.TidySummary{}),
+		events.Describe("ListDuplicateGroups", events.KindSummary, events.DuplicateGroupsVersion, storage.DuplicateGroup{}),
+		events.Describe("ListDuplicateGroupsPage", events.KindSummary, events.DuplicatePageVersion, storage.DuplicatePage{}),
+		events.Describe("GetDuplicateSummary", events.KindSummary, events.DuplicateSummaryVersion, storage.DuplicateSummary{}),
+		events.Describe("RemovalSummary", events.KindSummary, events.RemovalSummaryVersion, media.RemovalSummary{}),
+		events.Describe("BackupLibrary", events.KindSummary, events.BackupSummaryVersion, backup.Summary{}),
+		events.Describe("ExportManifest", events.KindSummary, events.ManifestSummaryVersion, backup.ManifestSummary{}),
+		events.Describe("VerifyLibrary", events.KindSummary, events.VerifySummaryVersion, media.VerifySummary{}),
+		events.Describe("RunMaintenance", events.KindSummary, events.MaintenanceVersion, MaintenanceSummary{}),
+		events.Describe("ImportPhotosLibrary", events.KindSummary, events.PhotosImportVersion, PhotosImportSummary{}),
+		events.Describe("AnalyseFaces", events.KindSummary, events.FacesSummaryVersion, media.FaceSummary{}),
+		events.Describe("ImportDevice", events.KindSummary, events.DeviceImportVersion, DeviceImportSummary{}),
+		events.Describe("ImportCard", events.KindSummary, events.CardImportVersion, CardImportSummary{}),
+		events.Describe("GetStaging", events.KindSummary, events.StagingInfoVersion, StagingInfo{}),
+		events.Describe("CommitStaging", events.KindSummary, events.StagingCommitVersion, StagingCommitSummary{}),
+		events.Describe("ImportLightroomCatalog", events.KindSummary, events.LightroomImportVersion, media.LightroomSummary{}),
+		events.Describe("ClassifyImages", events.KindSummary, events.LabelsSummaryVersion, media.LabelSummary{}),
+		events.Describe("RecogniseText", events.KindSummary, events.OCRSummaryVersion, media.OCRSummary{}),
+		events.Describe("HashSimilarImages", events.KindSummary, events.PHashSummaryVersion, media.PHashSummary{}),
+		events.Describe("FindSimilar", events.KindSummary, events.SimilarImagesVersion, media.SimilarImage{}),
+		events.Describe("ListSimilarVideoGroups", events.KindSummary, events.SimilarVideosVersion, media.SimilarVideoGroup{}),
+	}
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/applog"
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/desktop"
+	"github.com/deadlyedge/wails-tabs/internal/diagnostics"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// Build metadata, overridden at build time with
+// -ldflags "-X main.version=1.2.3 -X main.buildDate=2024-01-01".
+var (
+	version   = "dev"
+	buildDate = ""
+)
+
+// AppInfo describes the running build for the About screen and bug reports.
+type AppInfo struct {
+	Version       string          `json:"version"`
+	BuildDate     string          `json:"buildDate"`
+	Commit        string          `json:"commit"`
+	GoVersion     string          `json:"goVersion"`
+	Platform      string          `json:"platform"`
+	Profile       string          `json:"profile"`
+	Mode          string          `json:"mode"`
+	SettingsPath  string          `json:"settingsPath"`
+	LibraryPath   string          `json:"libraryPath"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Features      map[string]bool `json:"features"`
+}
+
+// GetAppInfo returns version, paths and detected optional tools.
+func (a *App) GetAppInfo() AppInfo {
+	info := AppInfo{
+		Version:       version,
+		BuildDate:     buildDate,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		Profile:       config.DefaultProfile,
+		Mode:          a.mode,
+		SettingsPath:  a.settingsPath,
+		SchemaVersion: storage.SchemaVersion,
+		Features: map[string]bool{
+			"exiftool": a.exiftool.Available,
+			"ffprobe":  a.ffprobe.Available,
+		},
+	}
+
+	if a.settings != nil {
+		info.Profile = a.settings.ProfileName()
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+
+	if a.settings != nil {
+		info.LibraryPath = a.settings.DatabasePath(a.dataRoot)
+	}
+	return info
+}
+
+// CreateDiagnosticsBundle zips logs, settings, recent job history and
+// environment details for bug reports. When destPath is empty the bundle is
+// written next to the database. It returns the path of the created file.
+func (a *App) CreateDiagnosticsBundle(destPath string, redactPaths bool) (string, error) {
+	if a.settings == nil {
+		return "", errors.New("settings not loaded")
+	}
+
+	if destPath == "" {
+		name := fmt.Sprintf("diagnostics-%s.zip", time.Now().Format("20060102-150405"))
+		destPath = filepath.Join(filepath.Dir(a.settings.DatabasePath(a.dataRoot)), name)
+	}
+
+	history := storage.ActionPage{}
+	if a.store != nil {
+		var err error
+		if history, err = a.store.ListActions(a.ctx, storage.ActionFilter{}, storage.Page{Limit: 500}); err != nil {
+			return "", err
+		}
+	}
+
+	env := a.GetAppInfo()
+	if redactPaths {
+		env.SettingsPath = filepath.Base(env.SettingsPath)
+		env.LibraryPath = filepath.Base(env.LibraryPath)
+	}
+
+	err := diagnostics.Write(destPath, diagnostics.Bundle{
+		SettingsPath: a.settingsPath,
+		RedactPaths:  redactPaths,
+		LogDir:       a.settings.LogDir(a.dataRoot),
+		Environment:  env,
+		JobHistory:   history.Actions,
+	})
+	if err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// GetRecentLogs returns up to n log entries at or above level, newest first.
+func (a *App) GetRecentLogs(n int, level string) ([]applog.Entry, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings not loaded")
+	}
+	return applog.Recent(a.settings.LogDir(a.dataRoot), n, level)
+}
+
+// OpenLogFolder opens the log folder in the platform file manager.
+func (a *App) OpenLogFolder() error {
+	if a.settings == nil {
+		return errors.New("settings not loaded")
+	}
+	dir := a.settings.LogDir(a.dataRoot)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return desktop.Open(dir)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// photosSource is recorded as the source of albums and tags imported from
+// Apple Photos.
+const photosSource = "apple-photos"
+
+// photosFavoriteTag is the tag given to assets marked as favourites.
+const photosFavoriteTag = "favorite"
+
+// PhotosImportSummary reports an Apple Photos library import.
+type PhotosImportSummary struct {
+	Assets int              `json:"assets"`
+	Pull   media.PhotosPull `json:"pull"`
+	Scan   media.Summary    `json:"scan"`
+	// Dated counts files whose capture time was taken from Photos, where it
+	// differed from the one in the file.
+	Dated     int `json:"dated"`
+	Favorites int `json:"favorites"`
+	Albums    int `json:"albums"`
+}
+
+// ImportPhotosLibrary imports an Apple Photos library: originals are copied
+// out of the .photoslibrary bundle and scanned, then take the capture times
+// as adjusted in Photos, a "favorite" tag for favourites and their album
+// memberships. The bundle is only read; tidy runs move the copies. A repeat
+// import copies only originals added or changed since.
+func (a *App) ImportPhotosLibrary(bundle string) (PhotosImportSummary, error) {
+	var summary PhotosImportSummary
+	if a.scanner == nil || a.store == nil || a.settings == nil {
+		return summary, errors.New("scanner not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return summary, err
+	}
+	bundle = filepath.Clean(strings.TrimSpace(bundle))
+	if !a.jobMu.TryLock() {
+		return summary, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	lib, err := media.ReadPhotosLibrary(a.ctx, bundle)
+	if err != nil {
+		return summary, err
+	}
+	summary.Assets = len(lib.Assets)
+
+	dest := filepath.Join(a.settings.PhotosDir(a.dataRoot), strings.TrimSuffix(filepath.Base(bundle), ".photoslibrary"))
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return summary, err
+	}
+	local, pull, err := media.PullPhotosLibrary(a.ctx, a.store, lib, dest, a.settings.NormalisedExtensions(), a.gate, a.throttle)
+	summary.Pull = pull
+	if err != nil {
+		return summary, err
+	}
+	a.logger.Info("photos library copied", "bundle", bundle, "dest", dest, "copied", pull.Copied, "missing", pull.Missing, "failed", pull.Failed)
+
+	if summary.Scan, err = a.scanSources(media.Options{Sources: []string{dest}}); err != nil || summary.Scan.Cancelled {
+		return summary, err
+	}
+
+	files, err := a.store.ListMediaUnder(a.ctx, dest)
+	if err != nil {
+		return summary, err
+	}
+	byPath := make(map[string]storage.MediaFile, len(files))
+	for _, file := range files {
+		byPath[file.Path] = file
+	}
+	ids := make(map[string]int64, len(local))
+	var favorites []int64
+	for _, asset := range lib.Assets {
+		file, ok := byPath[local[asset.UUID]]
+		if !ok {
+			continue
+		}
+		ids[asset.UUID] = file.ID
+		if asset.Favorite {
+			favorites = append(favorites, file.ID)
+		}
+		// Corrections made in this app win over those made in Photos.
+		if asset.TakenAt.IsZero() || file.Edited {
+			continue
+		}
+		wall := asset.WallClock()
+		if file.TakenAt.Valid && file.TakenAt.Time.Equal(wall) {
+			continue
+		}
+		takenAt := wall.Format("2006-01-02 15:04:05")
+		if err := a.store.UpdateMediaMetadata(a.ctx, file.ID, storage.MetadataEdit{TakenAt: &takenAt}); err != nil {
+			return summary, err
+		}
+		summary.Dated++
+	}
+
+	if err := a.store.TagMedia(a.ctx, photosFavoriteTag, photosSource, favorites); err != nil {
+		return summary, err
+	}
+	summary.Favorites = len(favorites)
+	for _, album := range lib.Albums {
+		members := make([]int64, 0, len(album.Assets))
+		for _, uuid := range album.Assets {
+			if id, ok := ids[uuid]; ok {
+				members = append(members, id)
+			}
+		}
+		if _, err := a.store.SaveAlbum(a.ctx, photosSource, album.UUID, album.Title, members); err != nil {
+			return summary, err
+		}
+		summary.Albums++
+	}
+	a.logger.Info("photos library imported", "bundle", bundle, "assets", summary.Assets, "dated", summary.Dated, "favorites", summary.Favorites, "albums", summary.Albums)
+	return summary, nil
+}
+
+// ListAlbums returns the albums imported from other photo managers.
+func (a *App) ListAlbums() ([]storage.Album, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListAlbums(a.ctx)
+}
+
+// ListAlbumMedia returns the files of an album, oldest first.
+func (a *App) ListAlbumMedia(albumID int64) ([]storage.MediaFile, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListAlbumMedia(a.ctx, albumID)
+}
+
+// ListMediaTags returns the tags of a file.
+func (a *App) ListMediaTags(mediaID int64) ([]string, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListMediaTags(a.ctx, mediaID)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// backfillRetry is how long a backfill waits before checking again whether
+// a scan or tidy run has released the job lock.
+const backfillRetry = 2 * time.Second
+
+// backfillThumbnailSize is the thumbnail edge the thumbnails job prepares,
+// matching the map markers.
+const backfillThumbnailSize = mapThumbnailSize
+
+// ListBackfills returns the progress of every backfill job.
+func (a *App) ListBackfills() ([]storage.BackfillState, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListBackfills(a.ctx)
+}
+
+// StartBackfill runs the given backfill jobs one after another in the
+// background, all of them when jobs is empty. Each yields to scans and tidy
+// runs between batches and reports backfill:progress events.
+func (a *App) StartBackfill(jobs []string) error {
+	if a.store == nil || a.settings == nil {
+		return errors.New("store not initialised")
+	}
+	if len(jobs) == 0 {
+		jobs = storage.BackfillJobs()
+	}
+	for _, job := range jobs {
+		if !slices.Contains(storage.BackfillJobs(), job) {
+			return fmt.Errorf("unknown backfill job %q", job)
+		}
+	}
+
+	a.backfillMu.Lock()
+	defer a.backfillMu.Unlock()
+	if a.cancelBackfill != nil {
+		return errors.New("a backfill is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelBackfill = cancel
+	go a.runBackfills(ctx, jobs)
+	return nil
+}
+
+// CancelBackfill stops the running backfill after the file in progress. Its
+// progress is kept, so starting the job again resumes it. It reports whether
+// a backfill was running.
+func (a *App) CancelBackfill() bool {
+	a.backfillMu.Lock()
+	defer a.backfillMu.Unlock()
+	if a.cancelBackfill == nil {
+		return false
+	}
+	a.cancelBackfill()
+	return true
+}
+
+// resumeBackfills restarts the jobs a previous session left unfinished.
+func (a *App) resumeBackfills() {
+	jobs, err := a.store.ListRunningBackfills(a.ctx)
+	if err != nil {
+		a.logger.Error("list interrupted backfills", "error", err)
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	if err := a.StartBackfill(jobs); err != nil {
+		a.logger.Error("resume backfills", "jobs", jobs, "error", err)
+	}
+}
+
+func (a *App) runBackfills(ctx context.Context, jobs []string) {
+	defer func() {
+		a.backfillMu.Lock()
+		a.cancelBackfill()
+		a.cancelBackfill = nil
+		a.backfillMu.Unlock()
+	}()
+
+	backfiller := media.NewBackfiller(a.store)
+	opts := media.BackfillOptions{
+		Gate:          a.gate,
+		Throttle:      a.throttle,
+		Acquire:       a.acquireIdle,
+		ThumbnailDir:  a.settings.ThumbnailDir(a.dataRoot),
+		ThumbnailSize: backfillThumbnailSize,
+	}
+	for _, job := range jobs {
+		jobID := events.NewJobID("backfill")
+		a.logger.Info("backfill started", "jobId", jobID, "job", job)
+		state, err := backfiller.Run(ctx, job, opts, func(s storage.BackfillState) {
+			a.emit(jobID, events.BackfillProgress, s)
+		})
+		if err != nil {
+			a.logger.Warn("backfill stopped", "jobId", jobID, "job", job, "error", err, "processed", state.Processed)
+			return
+		}
+		a.logger.Info("backfill finished", "jobId", jobID, "job", job,
+			"processed", state.Processed,
+			"updated", state.Updated,
+			"failed", state.Failed,
+		)
+	}
+}
+
+// acquireIdle waits until no scan or tidy run holds jobMu and takes it.
+func (a *App) acquireIdle(ctx context.Context) (func(), error) {
+	for !a.jobMu.TryLock() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backfillRetry):
+		}
+	}
+	return a.jobMu.Unlock, nil
+}
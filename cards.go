@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/volume"
+)
+
+// CardImportSummary reports every stage of a memory card import.
+type CardImportSummary struct {
+	Card    volume.Info          `json:"card"`
+	Pull    media.DevicePull     `json:"pull"`
+	Scan    media.Summary        `json:"scan"`
+	Tidy    media.TidySummary    `json:"tidy"`
+	Cleanup media.RemovalSummary `json:"cleanup"`
+	// Cleared is set when the imported files were deleted from the card.
+	Cleared *media.CardCleanup `json:"cleared,omitempty"`
+}
+
+// ListCards returns the mounted removable volumes holding a DCIM folder,
+// such as camera memory cards.
+func (a *App) ListCards() ([]volume.Info, error) {
+	mounted, err := volume.Mounted()
+	if err != nil {
+		return nil, err
+	}
+	var cards []volume.Info
+	for _, v := range mounted {
+		if v.Removable && media.HasCardFolder(v.MountPoint) {
+			cards = append(cards, v)
+		}
+	}
+	return cards, nil
+}
+
+// ImportCard imports the memory card mounted at mountPoint: the photos and
+// videos in its DCIM folder that are new to the library are copied into a
+// staging folder beside the database, reporting device:progress, scanned
+// and tidied into the target structure. Files an earlier import copied are
+// not read again. With clear set, files are then deleted from the card once
+// their content is verified to be in the library.
+func (a *App) ImportCard(mountPoint string, clear bool) (CardImportSummary, error) {
+	var summary CardImportSummary
+	if a.scanner == nil || a.store == nil || a.remover == nil || a.settings == nil {
+		return summary, errors.New("scanner not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return summary, err
+	}
+	mountPoint = filepath.Clean(strings.TrimSpace(mountPoint))
+	if !media.HasCardFolder(mountPoint) {
+		return summary, fmt.Errorf("%s has no %s folder", mountPoint, media.CardFolder)
+	}
+	card, err := volume.Of(mountPoint)
+	if err != nil && !errors.Is(err, volume.ErrUnsupported) {
+		return summary, err
+	}
+	if card.MountPoint != mountPoint {
+		// A card folder that is not a mount point of its own, or a
+		// platform without volume detection, is keyed by its path.
+		card = volume.Info{ID: mountPoint, MountPoint: mountPoint}
+	}
+	summary.Card = card
+	if !a.jobMu.TryLock() {
+		return summary, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	dest := filepath.Join(a.settings.DevicesDir(a.dataRoot), media.CardStaging(card.Label, card.ID))
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return summary, err
+	}
+	extensions := a.settings.NormalisedExtensions()
+	jobID := events.NewJobID("card")
+	a.logger.Info("card import started", "jobId", jobID, "card", card.ID, "mountPoint", mountPoint, "dest", dest)
+	summary.Pull, err = media.PullCard(a.ctx, a.store, mountPoint, "card:"+card.ID, dest, extensions, a.gate, a.throttle,
+		func(p media.DeviceProgress) { a.emit(jobID, events.DeviceProgress, p) })
+	if err != nil {
+		a.logger.Error("card import stopped", "jobId", jobID, "error", err, "copied", summary.Pull.Copied)
+		return summary, err
+	}
+	a.logger.Info("card files copied", "jobId", jobID, "card", card.ID,
+		"copied", summary.Pull.Copied, "known", summary.Pull.Known, "unchanged", summary.Pull.Unchanged, "failed", summary.Pull.Failed)
+
+	if summary.Scan, err = a.scanSources(media.Options{Sources: []string{dest}}); err != nil || summary.Scan.Cancelled {
+		return summary, err
+	}
+	files, err := a.store.ListMediaUnder(a.ctx, dest)
+	if err != nil {
+		return summary, err
+	}
+	requests := make([]media.MoveRequest, 0, len(files))
+	for _, file := range files {
+		requests = append(requests, media.MoveRequest{MediaID: file.ID})
+	}
+	if summary.Tidy, err = a.tidyFiles(requests, false, media.SafetyStandard, false, nil); err != nil {
+		return summary, err
+	}
+	if summary.Cleanup, err = a.remover.CleanEmptyDirs(a.ctx, []string{dest}, false); err != nil {
+		return summary, err
+	}
+
+	if !clear {
+		return summary, nil
+	}
+	cleared, err := media.ClearCard(a.ctx, a.store, mountPoint, extensions, a.gate, a.throttle)
+	summary.Cleared = &cleared
+	if err != nil {
+		return summary, err
+	}
+	a.logger.Info("card cleared", "jobId", jobID, "card", card.ID, "deleted", cleared.Deleted, "kept", cleared.Kept, "failed", cleared.Failed)
+	return summary, nil
+}
+
+// checkCards emits card:inserted for every card mounted since the last
+// check; inserted holds the mount points of the cards seen so far.
+func (a *App) checkCards(inserted map[string]bool) {
+	cards, err := a.ListCards()
+	if err != nil {
+		return
+	}
+	present := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		present[card.MountPoint] = true
+		if inserted[card.MountPoint] {
+			continue
+		}
+		a.logger.Info("card inserted", "card", card.ID, "label", card.Label, "mountPoint", card.MountPoint)
+		a.emit("", events.CardInserted, card)
+	}
+	for mountPoint := range inserted {
+		delete(inserted, mountPoint)
+	}
+	for mountPoint := range present {
+		inserted[mountPoint] = true
+	}
+}
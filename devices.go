@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// DeviceImportSummary reports an import from a camera or phone.
+type DeviceImportSummary struct {
+	Device media.Device     `json:"device"`
+	Pull   media.DevicePull `json:"pull"`
+	Scan   media.Summary    `json:"scan"`
+}
+
+// deviceTool wraps the detected gphoto2, or explains why there is none.
+func (a *App) deviceTool() (*media.Gphoto2, error) {
+	if !a.gphoto2.Available {
+		if a.gphoto2.Disabled {
+			return nil, errors.New("gphoto2 is turned off in the tools settings")
+		}
+		return nil, errors.New("gphoto2 was not found; install it or set its path in the tools settings")
+	}
+	return media.NewGphoto2(a.gphoto2.Path), nil
+}
+
+// ListDevices returns the cameras and phones attached over USB.
+func (a *App) ListDevices() ([]media.Device, error) {
+	g, err := a.deviceTool()
+	if err != nil {
+		return nil, err
+	}
+	return g.Devices(a.ctx)
+}
+
+// ImportDevice copies the photos and videos of the device at port that are
+// new to the library into a staging folder beside the database, reporting
+// device:progress, and scans them so tidy runs file them like any other
+// source. Files an earlier import copied are not downloaded again.
+func (a *App) ImportDevice(port string) (DeviceImportSummary, error) {
+	var summary DeviceImportSummary
+	if a.scanner == nil || a.store == nil || a.settings == nil {
+		return summary, errors.New("scanner not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return summary, err
+	}
+	g, err := a.deviceTool()
+	if err != nil {
+		return summary, err
+	}
+	port = strings.TrimSpace(port)
+	if !a.jobMu.TryLock() {
+		return summary, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	devices, err := g.Devices(a.ctx)
+	if err != nil {
+		return summary, err
+	}
+	found := false
+	for _, device := range devices {
+		if device.Port == port {
+			summary.Device, found = device, true
+			break
+		}
+	}
+	if !found {
+		return summary, fmt.Errorf("no device attached at %s", port)
+	}
+
+	dest := filepath.Join(a.settings.DevicesDir(a.dataRoot), summary.Device.Folder())
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return summary, err
+	}
+	jobID := events.NewJobID("device")
+	a.logger.Info("device import started", "jobId", jobID, "device", summary.Device.Model, "port", port, "dest", dest)
+	summary.Pull, err = media.PullDevice(a.ctx, a.store, g, summary.Device, dest, a.settings.NormalisedExtensions(), a.gate, a.throttle,
+		func(p media.DeviceProgress) { a.emit(jobID, events.DeviceProgress, p) })
+	if err != nil {
+		a.logger.Error("device import stopped", "jobId", jobID, "error", err, "copied", summary.Pull.Copied)
+		return summary, err
+	}
+	a.logger.Info("device files copied", "jobId", jobID, "device", summary.Device.Model,
+		"copied", summary.Pull.Copied, "known", summary.Pull.Known, "unchanged", summary.Pull.Unchanged, "failed", summary.Pull.Failed)
+
+	summary.Scan, err = a.scanSources(media.Options{Sources: []string{dest}})
+	return summary, err
+}
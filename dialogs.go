@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/deadlyedge/wails-tabs/internal/desktop"
+	"github.com/deadlyedge/wails-tabs/internal/fsinfo"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// PickFolder opens the native folder dialog. purpose is "source", "target"
+// or "database" and selects the title and starting folder. An empty result
+// means the user cancelled.
+func (a *App) PickFolder(purpose string) (string, error) {
+	var title, start string
+	switch purpose {
+	case "source":
+		title = "Select source folder"
+		if a.settings != nil {
+			if sources := a.settings.EffectiveSources(); len(sources) > 0 {
+				start = sources[0]
+			}
+		}
+	case "target":
+		title = "Select target folder"
+		if a.settings != nil {
+			start = a.settings.Target.BaseFolder
+		}
+	case "database":
+		title = "Select database folder"
+		if a.settings != nil {
+			start = filepath.Dir(a.settings.DatabasePath(a.dataRoot))
+		}
+	default:
+		return "", fmt.Errorf("unknown folder purpose %q", purpose)
+	}
+
+	if a.ctx == nil {
+		return "", errors.New("runtime not ready")
+	}
+	if start != "" && !fsinfo.Inspect(start).Exists {
+		start = ""
+	}
+
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                title,
+		DefaultDirectory:     start,
+		CanCreateDirectories: true,
+	})
+}
+
+// ValidatePath reports existence, writability and free space of a folder so
+// settings forms can give immediate feedback.
+func (a *App) ValidatePath(path string) fsinfo.PathStatus {
+	return fsinfo.Inspect(path)
+}
+
+// RevealInExplorer shows the media file in the platform file manager.
+func (a *App) RevealInExplorer(mediaID int64) error {
+	path, err := a.mediaPath(mediaID)
+	if err != nil {
+		return err
+	}
+	return desktop.Reveal(path)
+}
+
+// OpenMedia opens the media file with the default viewer.
+func (a *App) OpenMedia(mediaID int64) error {
+	path, err := a.mediaPath(mediaID)
+	if err != nil {
+		return err
+	}
+	return desktop.Open(path)
+}
+
+func (a *App) mediaPath(mediaID int64) (string, error) {
+	file, err := a.mediaFile(a.ctx, mediaID)
+	return file.Path, err
+}
+
+func (a *App) mediaFile(ctx context.Context, mediaID int64) (storage.MediaFile, error) {
+	if a.store == nil {
+		return storage.MediaFile{}, errors.New("store not initialised")
+	}
+	files, err := a.store.GetMediaByIDs(ctx, []int64{mediaID})
+	if err != nil {
+		return storage.MediaFile{}, err
+	}
+	file, ok := files[mediaID]
+	if !ok {
+		return storage.MediaFile{}, fmt.Errorf("media %d not found", mediaID)
+	}
+	return file, nil
+}
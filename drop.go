@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// DroppedSources reports what became of folders dropped onto the window.
+type DroppedSources struct {
+	// Added are the folders appended to the scan sources and Listed those
+	// that already were sources.
+	Added  []string `json:"added"`
+	Listed []string `json:"listed"`
+	// Rejected maps paths that are not usable folders to the reason.
+	Rejected map[string]string `json:"rejected,omitempty"`
+	// Scan is set when the drop action scanned the folders right away.
+	Scan  *media.Summary `json:"scan,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// AddDroppedSources takes folders dropped onto the window. Depending on
+// scan.dropAction they are appended to the scan sources, or scanned at once
+// without changing the settings. Files and missing paths are rejected.
+func (a *App) AddDroppedSources(paths []string) (DroppedSources, error) {
+	var result DroppedSources
+	if a.settings == nil {
+		return result, errors.New("settings not loaded")
+	}
+	folders, rejected := droppedFolders(paths)
+	if len(rejected) > 0 {
+		result.Rejected = rejected
+	}
+	if len(folders) == 0 {
+		return result, errors.New("no folders were dropped")
+	}
+
+	if strings.EqualFold(strings.TrimSpace(a.settings.Scan.DropAction), "scan") {
+		a.logger.Info("dropped folders scanning", "folders", folders)
+		summary, err := a.ImportFolders(folders, "")
+		result.Scan = &summary
+		return result, err
+	}
+
+	// Reloading replaces the store, which a running job still uses.
+	if !a.jobMu.TryLock() {
+		return result, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	added, err := config.AddSourceFolders(a.settingsPath, folders)
+	if err != nil {
+		return result, err
+	}
+	result.Added = added
+	for _, folder := range folders {
+		if !slices.Contains(added, folder) {
+			result.Listed = append(result.Listed, folder)
+		}
+	}
+	a.logger.Info("dropped folders added to sources", "added", added, "listed", result.Listed)
+	if len(added) > 0 {
+		if err := a.reloadSettings(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// onFileDrop handles Wails file-drop events and tells the window the
+// outcome with sources:dropped, since no binding call awaits it.
+func (a *App) onFileDrop(x, y int, paths []string) {
+	go func() {
+		result, err := a.AddDroppedSources(paths)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		a.emit("", events.SourcesDropped, result)
+	}()
+}
+
+// droppedFolders splits paths into existing folders, cleaned, and the rest
+// with the reason each was rejected.
+func droppedFolders(paths []string) ([]string, map[string]string) {
+	var folders []string
+	rejected := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			rejected[path] = "not an absolute path"
+			continue
+		}
+		path = filepath.Clean(path)
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			rejected[path] = err.Error()
+		case !info.IsDir():
+			rejected[path] = "not a folder"
+		case !seen[path]:
+			seen[path] = true
+			folders = append(folders, path)
+		}
+	}
+	return folders, rejected
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/keychain"
+)
+
+// minPassphrase is the shortest passphrase EnableEncryption accepts.
+const minPassphrase = 8
+
+// EncryptionState reports whether the library seals face data, people's
+// names and OCR text, and whether it still waits for its passphrase. It is
+// also the payload of library:locked.
+type EncryptionState struct {
+	Encrypted bool `json:"encrypted"`
+	Locked    bool `json:"locked"`
+}
+
+// GetEncryptionState reports the encryption of the open library.
+func (a *App) GetEncryptionState() EncryptionState {
+	if a.store == nil {
+		return EncryptionState{}
+	}
+	return EncryptionState{Encrypted: a.store.Encrypted(), Locked: a.store.Locked()}
+}
+
+// UnlockLibrary unlocks an encrypted library with its passphrase. With
+// remember set the key is kept in the OS keychain, so later starts unlock
+// without asking.
+func (a *App) UnlockLibrary(passphrase string, remember bool) (EncryptionState, error) {
+	if a.store == nil || a.settings == nil {
+		return EncryptionState{}, errors.New("store not initialised")
+	}
+	if !a.store.Locked() {
+		return a.GetEncryptionState(), nil
+	}
+	key, err := a.store.UnlockPassphrase(a.ctx, passphrase)
+	if err != nil {
+		return a.GetEncryptionState(), err
+	}
+	a.keepLibraryKey(key, remember)
+	a.logger.Info("library unlocked", "remembered", remember)
+	return a.GetEncryptionState(), nil
+}
+
+// EnableEncryption seals face data, people's names and OCR text under a
+// key derived from passphrase, which cannot be recovered if forgotten.
+// Sealed OCR text is no longer searchable. With remember set the key is
+// kept in the OS keychain. It refuses while a job, face analysis or text
+// recognition runs.
+func (a *App) EnableEncryption(passphrase string, remember bool) (EncryptionState, error) {
+	if a.store == nil || a.settings == nil {
+		return EncryptionState{}, errors.New("store not initialised")
+	}
+	if len([]rune(passphrase)) < minPassphrase {
+		return a.GetEncryptionState(), fmt.Errorf("the passphrase needs at least %d characters", minPassphrase)
+	}
+	if err := a.checkWritable(); err != nil {
+		return a.GetEncryptionState(), err
+	}
+	release, err := a.holdSealedWriters()
+	if err != nil {
+		return a.GetEncryptionState(), err
+	}
+	defer release()
+
+	key, err := a.store.EnableEncryption(a.ctx, passphrase)
+	if err != nil {
+		if key == nil {
+			return a.GetEncryptionState(), err
+		}
+		// The columns are sealed; only the clean-up afterwards failed.
+		a.logger.Warn("library encrypted with leftovers", "error", err)
+	}
+	a.keepLibraryKey(key, remember)
+	a.logger.Info("library encrypted", "remembered", remember)
+	return a.GetEncryptionState(), nil
+}
+
+// DisableEncryption writes the sealed columns back in the clear and drops
+// the key from the OS keychain. The library must be unlocked. It refuses
+// while a job, face analysis or text recognition runs.
+func (a *App) DisableEncryption() (EncryptionState, error) {
+	if a.store == nil || a.settings == nil {
+		return EncryptionState{}, errors.New("store not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return a.GetEncryptionState(), err
+	}
+	release, err := a.holdSealedWriters()
+	if err != nil {
+		return a.GetEncryptionState(), err
+	}
+	defer release()
+
+	if err := a.store.DisableEncryption(a.ctx); err != nil {
+		return a.GetEncryptionState(), err
+	}
+	account := keyAccount(a.settings.DatabasePath(a.dataRoot))
+	if err := keychain.Delete(account); err != nil {
+		a.logger.Warn("forget library key", "error", err)
+	}
+	a.libraryKey, a.keyAccount = nil, ""
+	a.logger.Info("library decrypted")
+	return a.GetEncryptionState(), nil
+}
+
+// holdSealedWriters takes jobMu and keeps face analysis and text
+// recognition, which write sealed columns outside it, from starting until
+// release is called.
+func (a *App) holdSealedWriters() (release func(), err error) {
+	if !a.jobMu.TryLock() {
+		return nil, errBusy
+	}
+	a.facesMu.Lock()
+	a.ocrMu.Lock()
+	release = func() {
+		a.ocrMu.Unlock()
+		a.facesMu.Unlock()
+		a.jobMu.Unlock()
+	}
+	if a.cancelFaces != nil || a.cancelOCR != nil {
+		release()
+		return nil, errors.New("stop face analysis and text recognition first")
+	}
+	return release, nil
+}
+
+// unlockStore unlocks a freshly opened encrypted store with the key of an
+// earlier unlock or, failing that, the one kept in the OS keychain. A store
+// neither opens stays locked and the frontend is asked for the passphrase.
+func (a *App) unlockStore(dbPath string) {
+	if !a.store.Locked() {
+		return
+	}
+	account := keyAccount(dbPath)
+	if a.libraryKey != nil && a.keyAccount == account && a.store.Unlock(a.ctx, a.libraryKey) == nil {
+		return
+	}
+	secret, err := keychain.Get(account)
+	if err == nil {
+		key, err := hex.DecodeString(secret)
+		if err == nil {
+			err = a.store.Unlock(a.ctx, key)
+		}
+		if err == nil {
+			a.libraryKey, a.keyAccount = key, account
+			return
+		}
+		a.logger.Warn("library key from keychain rejected", "error", err)
+	} else if !errors.Is(err, keychain.ErrNotFound) {
+		a.logger.Warn("library key unavailable", "error", err)
+	}
+	a.logger.Info("library locked, waiting for its passphrase")
+	a.emit("", events.LibraryLocked, a.GetEncryptionState())
+}
+
+// keepLibraryKey remembers key for reloads of the same library and, with
+// remember set, in the OS keychain. A keychain failure only means asking
+// again next start, so it is logged.
+func (a *App) keepLibraryKey(key []byte, remember bool) {
+	account := keyAccount(a.settings.DatabasePath(a.dataRoot))
+	a.libraryKey, a.keyAccount = key, account
+	if !remember {
+		return
+	}
+	if err := keychain.Set(account, hex.EncodeToString(key)); err != nil {
+		a.logger.Warn("remember library key", "error", err)
+	}
+}
+
+// keyAccount names the keychain entry of the library at dbPath, so each
+// library keeps a key of its own.
+func keyAccount(dbPath string) string {
+	if abs, err := filepath.Abs(dbPath); err == nil {
+		dbPath = abs
+	}
+	sum := sha256.Sum256([]byte(dbPath))
+	return "library_" + hex.EncodeToString(sum[:8])
+}
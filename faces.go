@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// AnalyseFaces sends images not yet analysed to the configured face
+// backend, up to limit (0 for all), and clusters the faces found into
+// people. It requires the faceDetection feature flag and reports
+// faces:progress events.
+func (a *App) AnalyseFaces(limit int) (media.FaceSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return media.FaceSummary{}, errors.New("store not initialised")
+	}
+	if !a.settings.FeatureEnabled(config.FeatureFaceDetection) {
+		return media.FaceSummary{}, errors.New("face detection is turned off in the feature flags")
+	}
+	embedder, err := media.NewFaceEmbedder(a.settings.Faces.Endpoint, a.settings.Faces.Command)
+	if err != nil {
+		return media.FaceSummary{}, err
+	}
+
+	a.facesMu.Lock()
+	if a.cancelFaces != nil {
+		a.facesMu.Unlock()
+		return media.FaceSummary{}, errors.New("a face analysis is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelFaces = cancel
+	a.facesMu.Unlock()
+	defer func() {
+		a.facesMu.Lock()
+		a.cancelFaces()
+		a.cancelFaces = nil
+		a.facesMu.Unlock()
+	}()
+
+	jobID := events.NewJobID("faces")
+	a.logger.Info("face analysis started", "jobId", jobID, "backend", embedder.Name(), "limit", limit)
+	opts := media.FaceOptions{Threshold: a.settings.Faces.Threshold, Limit: limit, Gate: a.gate, Throttle: a.throttle}
+	summary, err := media.AnalyseFaces(ctx, a.store, embedder, opts, func(p media.FaceProgress) {
+		a.emit(jobID, events.FacesProgress, p)
+	})
+	if err != nil {
+		a.notifyJob(config.NotifyAnalysis, "Face analysis", err, "")
+		a.logger.Error("face analysis stopped", "jobId", jobID, "error", err, "analysed", summary.Analysed)
+		return summary, err
+	}
+	a.logger.Info("face analysis finished", "jobId", jobID,
+		"analysed", summary.Analysed,
+		"faces", summary.Faces,
+		"failed", summary.Failed,
+		"newPeople", summary.NewPeople,
+		"durationMs", summary.DurationMS,
+	)
+	for _, msg := range summary.Errors {
+		a.logger.Warn("face analysis error", "jobId", jobID, "error", msg)
+	}
+	a.notifyJob(config.NotifyAnalysis, "Face analysis", nil, fmt.Sprintf("%d images analysed, %d faces found, %d failed", summary.Analysed, summary.Faces, summary.Failed))
+	return summary, nil
+}
+
+// CancelFaces stops the running face analysis after the image in progress.
+// It reports whether an analysis was running.
+func (a *App) CancelFaces() bool {
+	a.facesMu.Lock()
+	defer a.facesMu.Unlock()
+	if a.cancelFaces == nil {
+		return false
+	}
+	a.cancelFaces()
+	return true
+}
+
+// ListPeople returns the people found by face analysis, most photographed
+// first.
+func (a *App) ListPeople() ([]storage.Person, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListPeople(a.ctx)
+}
+
+// ListMediaByPerson returns a page of the files showing a person.
+func (a *App) ListMediaByPerson(personID int64, page storage.Page) ([]storage.MediaFile, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListMediaByPerson(a.ctx, personID, page)
+}
+
+// RenamePerson names a person. Tidy patterns place files by the name of
+// the largest named face with {{.Person}}.
+func (a *App) RenamePerson(personID int64, name string) error {
+	if a.store == nil {
+		return errors.New("store not initialised")
+	}
+	return a.store.RenamePerson(a.ctx, personID, name)
+}
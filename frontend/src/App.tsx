@@ -4,10 +4,20 @@ import { TargetPicker } from "./views/targetPicker"
 import { Rules } from "./views/rules"
 import { Excute } from "./views/excute"
 import { Monitor } from "./views/monitor"
+import { Unlock } from "./views/unlock"
 import { Separator } from "@/components/ui/separator"
-import { GetSettings, RunScan, ReloadSettings, ListDuplicateGroups, ExecuteTidy } from "../wailsjs/go/main/App"
-import type { config, media, storage } from "../wailsjs/go/models"
+import {
+  GetSettings,
+  RunScan,
+  ReloadSettings,
+  ListDuplicateGroups,
+  ExecuteTidy,
+  GetEncryptionState,
+} from "../wailsjs/go/main/App"
+import { media } from "../wailsjs/go/models"
+import type { config, main, storage } from "../wailsjs/go/models"
 import { EventsOff, EventsOn } from "../wailsjs/runtime/runtime"
+import { LibraryLocked, ScanProgress, TidyProgress, type Envelope } from "@/lib/events"
 
 function App() {
   const [settings, setSettings] = useState<config.Settings | null>(null)
@@ -19,22 +29,29 @@ function App() {
   const [loadingScan, setLoadingScan] = useState(false)
   const [loadingTidy, setLoadingTidy] = useState(false)
   const [error, setError] = useState<string | null>(null)
+  const [locked, setLocked] = useState(false)
 
   useEffect(() => {
     refreshSettings()
+    GetEncryptionState().then((state) => setLocked(state.locked))
 
-    const offScan = EventsOn("scan:progress", (payload) => {
-      setScanProgress(payload)
+    const offScan = EventsOn(ScanProgress, (event: Envelope) => {
+      setScanProgress(event.payload)
     })
-    const offTidy = EventsOn("tidy:progress", (payload) => {
-      setTidyProgress(payload)
+    const offTidy = EventsOn(TidyProgress, (event: Envelope) => {
+      setTidyProgress(event.payload)
+    })
+    const offLocked = EventsOn(LibraryLocked, (event: Envelope<main.EncryptionState>) => {
+      setLocked(event.payload.locked)
     })
 
     return () => {
-      EventsOff("scan:progress")
-      EventsOff("tidy:progress")
+      EventsOff(ScanProgress)
+      EventsOff(TidyProgress)
+      EventsOff(LibraryLocked)
       if (typeof offScan === "function") offScan()
       if (typeof offTidy === "function") offTidy()
+      if (typeof offLocked === "function") offLocked()
     }
   }, [])
 
@@ -91,7 +108,7 @@ function App() {
     setError(null)
     setLoadingTidy(true)
     try {
-      const summary = await ExecuteTidy(requests, true)
+      const summary = await ExecuteTidy(requests, true, media.SafetyLevel.STANDARD)
       setTidySummary(summary)
     } catch (err) {
       setError(String(err))
@@ -101,7 +118,7 @@ function App() {
   }
 
   const sources = settings?.Scan?.SourceFolders?.length
-    ? settings.Scan.SourceFolders
+    ? settings.Scan.SourceFolders.map((folder) => folder.Path)
     : settings?.History?.LastSourceFolder ?? []
 
   const extensions = settings?.Scan?.IncludeExtensions ?? []
@@ -136,6 +153,7 @@ function App() {
           />
         </div>
       </div>
+      {locked && <Unlock onUnlocked={(state) => setLocked(state.locked)} />}
     </main>
   )
 }
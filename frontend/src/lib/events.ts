@@ -0,0 +1,34 @@
+// Event channel names emitted by the Go backend. Keep in sync with
+// internal/events/schema.go.
+export const ScanProgress = "scan:progress"
+export const TidyProgress = "tidy:progress"
+export const JobStats = "job:stats"
+export const PowerState = "power:state"
+export const ScheduleActivity = "schedule:activity"
+export const StartupRecovery = "startup:recovery"
+export const SettingsChanged = "settings:changed"
+export const NetworkState = "network:state"
+export const BackfillProgress = "backfill:progress"
+export const BackupProgress = "backup:progress"
+export const RcloneProgress = "rclone:progress"
+export const VerifyProgress = "verify:progress"
+export const FacesProgress = "faces:progress"
+export const LabelsProgress = "labels:progress"
+export const OCRProgress = "ocr:progress"
+export const PHashProgress = "phash:progress"
+export const VolumeState = "volume:state"
+export const DeviceProgress = "device:progress"
+export const CardInserted = "card:inserted"
+export const OpenFolders = "open:folders"
+export const SourcesDropped = "sources:dropped"
+export const LibraryLocked = "library:locked"
+
+// Envelope wraps every event payload. jobId groups the events of one scan or
+// tidy run; sequence increases across all events of a session.
+export interface Envelope<T = unknown> {
+  jobId?: string
+  type: string
+  version: number
+  sequence: number
+  payload: T
+}
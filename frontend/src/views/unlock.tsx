@@ -0,0 +1,60 @@
+import { useState, type FormEvent } from "react"
+import { Button } from "@/components/ui/button"
+import { UnlockLibrary } from "../../wailsjs/go/main/App"
+import type { main } from "../../wailsjs/go/models"
+
+type UnlockProps = {
+  onUnlocked: (state: main.EncryptionState) => void
+}
+
+export function Unlock({ onUnlocked }: UnlockProps) {
+  const [passphrase, setPassphrase] = useState("")
+  const [remember, setRemember] = useState(false)
+  const [busy, setBusy] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  const handleSubmit = async (event: FormEvent) => {
+    event.preventDefault()
+    setBusy(true)
+    setError(null)
+    try {
+      const state = await UnlockLibrary(passphrase, remember)
+      setPassphrase("")
+      onUnlocked(state)
+    } catch (err) {
+      setError(String(err))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  return (
+    <div className='fixed inset-0 z-50 flex items-center justify-center bg-slate-950/90'>
+      <form
+        onSubmit={handleSubmit}
+        className='w-96 flex flex-col rounded border border-slate-700/40 bg-slate-900 p-4 gap-3'>
+        <div className='text-sm font-semibold text-slate-300'>Library Locked</div>
+        <div className='text-xs text-slate-400'>
+          Face data, people's names and recognised text are encrypted. Enter the passphrase to open them.
+        </div>
+        <input
+          type='password'
+          autoFocus
+          value={passphrase}
+          onChange={(event) => setPassphrase(event.target.value)}
+          className='rounded border border-slate-700 bg-slate-950 px-2 py-1 text-sm text-slate-100'
+        />
+        <label className='flex items-center gap-2 text-xs text-slate-300'>
+          <input type='checkbox' checked={remember} onChange={(event) => setRemember(event.target.checked)} />
+          Remember in the system keychain
+        </label>
+        {error && <div className='text-xs text-red-400'>{error}</div>}
+        <div className='flex justify-end'>
+          <Button type='submit' disabled={busy || passphrase === ""}>
+            {busy ? "Unlocking…" : "Unlock"}
+          </Button>
+        </div>
+      </form>
+    </div>
+  )
+}
@@ -1,17 +1,260 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
 import {media} from '../models';
-import {config} from '../models';
+import {backup} from '../models';
+import {bench} from '../models';
 import {storage} from '../models';
+import {config} from '../models';
+import {events} from '../models';
+import {applog} from '../models';
+import {volume} from '../models';
+import {fsinfo} from '../models';
+
+export function AcknowledgeDuplicates(arg1:string,arg2:Array<number>):Promise<number>;
+
+export function AddDroppedSources(arg1:Array<string>):Promise<main.DroppedSources>;
+
+export function AnalyseFaces(arg1:number):Promise<media.FaceSummary>;
+
+export function BackupDatabase(arg1:string):Promise<string>;
+
+export function BackupLibrary():Promise<backup.Summary>;
+
+export function BenchmarkStorage(arg1:number):Promise<bench.Result>;
+
+export function CancelBackfill():Promise<boolean>;
+
+export function CancelClassify():Promise<boolean>;
+
+export function CancelFaces():Promise<boolean>;
+
+export function CancelHashSimilarImages():Promise<boolean>;
+
+export function CancelLibraryBackup():Promise<boolean>;
+
+export function CancelOCR():Promise<boolean>;
+
+export function CancelScan():Promise<boolean>;
+
+export function CancelVerify():Promise<boolean>;
+
+export function CheckBackupBucket():Promise<void>;
+
+export function CheckTarget():Promise<void>;
+
+export function ClassifyImages(arg1:number):Promise<media.LabelSummary>;
+
+export function CleanEmptyDirs(arg1:boolean):Promise<media.RemovalSummary>;
+
+export function CleanJunk(arg1:boolean):Promise<media.RemovalSummary>;
+
+export function ClearDuplicateAcknowledgement(arg1:string,arg2:Array<number>):Promise<number>;
+
+export function CommitStaging():Promise<main.StagingCommitSummary>;
+
+export function CreateDiagnosticsBundle(arg1:string,arg2:boolean):Promise<string>;
+
+export function DeleteMedia(arg1:Array<number>,arg2:boolean):Promise<media.RemovalSummary>;
+
+export function DisableEncryption():Promise<main.EncryptionState>;
+
+export function DiscardStaging():Promise<void>;
+
+export function EnableEncryption(arg1:string,arg2:boolean):Promise<main.EncryptionState>;
+
+export function ExecuteTidy(arg1:Array<media.MoveRequest>,arg2:boolean,arg3:media.SafetyLevel):Promise<media.TidySummary>;
+
+export function ExecuteTidyByFilter(arg1:storage.MediaFilter,arg2:boolean,arg3:media.SafetyLevel):Promise<media.TidySummary>;
 
-export function ExecuteTidy(arg1:Array<media.MoveRequest>,arg2:boolean):Promise<media.TidySummary>;
+export function ExecuteTidyTransactional(arg1:Array<media.MoveRequest>,arg2:boolean,arg3:media.SafetyLevel):Promise<media.TidySummary>;
+
+export function ExportManifest(arg1:string,arg2:string,arg3:boolean):Promise<backup.ManifestSummary>;
+
+export function ExportScanErrors(arg1:number,arg2:string,arg3:string):Promise<number>;
+
+export function FindSimilar(arg1:number,arg2:number,arg3:number):Promise<Array<media.SimilarImage>>;
+
+export function GetAppInfo():Promise<main.AppInfo>;
+
+export function GetDefaultSettings():Promise<config.Settings>;
+
+export function GetDuplicateSummary(arg1:storage.DuplicateScope):Promise<storage.DuplicateSummary>;
+
+export function GetEncryptionState():Promise<main.EncryptionState>;
+
+export function GetEventSchemas():Promise<Array<events.Schema>>;
+
+export function GetFolderSizes(arg1:string,arg2:number):Promise<storage.FolderSize>;
+
+export function GetLaunchFolders():Promise<Array<string>>;
+
+export function GetLibraryGrowth(arg1:string):Promise<Array<storage.GrowthPoint>>;
+
+export function GetMapClusters(arg1:storage.MapBounds,arg2:number):Promise<Array<storage.MapCluster>>;
+
+export function GetMediaExif(arg1:number):Promise<Record<string, string>>;
+
+export function GetMediaRating(arg1:number):Promise<storage.MediaRating>;
+
+export function GetMediaText(arg1:number):Promise<string>;
+
+export function GetPriority():Promise<main.PriorityState>;
+
+export function GetReadOnly():Promise<boolean>;
+
+export function GetRecentLogs(arg1:number,arg2:string):Promise<Array<applog.Entry>>;
+
+export function GetRecoveryReport():Promise<main.RecoveryReport>;
 
 export function GetSettings():Promise<config.Settings>;
 
-export function Greet(arg1:string):Promise<string>;
+export function GetStaging():Promise<main.StagingInfo>;
+
+export function GetThrottle():Promise<media.ThrottleLimits>;
+
+export function GetTimeline(arg1:string,arg2:storage.MediaFilter):Promise<storage.TimelinePage>;
+
+export function GetTools():Promise<Array<media.ToolInfo>>;
+
+export function HashSimilarImages(arg1:number):Promise<media.PHashSummary>;
+
+export function ImportCard(arg1:string,arg2:boolean):Promise<main.CardImportSummary>;
+
+export function ImportDevice(arg1:string):Promise<main.DeviceImportSummary>;
+
+export function ImportFolders(arg1:Array<string>,arg2:string):Promise<media.Summary>;
+
+export function ImportInbox(arg1:boolean):Promise<main.InboxSummary>;
+
+export function ImportLightroomCatalog(arg1:string):Promise<media.LightroomSummary>;
+
+export function ImportPhotosLibrary(arg1:string):Promise<main.PhotosImportSummary>;
+
+export function ImportTakeout(arg1:Array<string>,arg2:string):Promise<media.Summary>;
+
+export function InitializeSettings(arg1:config.Settings):Promise<config.Settings>;
+
+export function IsFirstRun():Promise<boolean>;
+
+export function ListActions(arg1:storage.ActionFilter,arg2:storage.Page):Promise<storage.ActionPage>;
+
+export function ListAlbumMedia(arg1:number):Promise<Array<storage.MediaFile>>;
+
+export function ListAlbums():Promise<Array<storage.Album>>;
+
+export function ListBackfills():Promise<Array<storage.BackfillState>>;
+
+export function ListBurstGroups():Promise<Array<storage.BurstGroup>>;
+
+export function ListCards():Promise<Array<volume.Info>>;
+
+export function ListCorruptFiles(arg1:storage.Page):Promise<Array<storage.MediaFile>>;
+
+export function ListDevices():Promise<Array<media.Device>>;
 
 export function ListDuplicateGroups():Promise<Array<storage.DuplicateGroup>>;
 
+export function ListDuplicateGroupsPage(arg1:storage.DuplicateScope,arg2:string,arg3:storage.Page):Promise<storage.DuplicatePage>;
+
+export function ListFeatureFlags():Promise<Array<config.FeatureFlag>>;
+
+export function ListJunkFiles():Promise<Array<storage.JunkFile>>;
+
+export function ListMedia(arg1:storage.MediaFilter):Promise<Array<storage.MediaFile>>;
+
+export function ListMediaByPerson(arg1:number,arg2:storage.Page):Promise<Array<storage.MediaFile>>;
+
+export function ListMediaTags(arg1:number):Promise<Array<string>>;
+
+export function ListNotifications():Promise<Array<config.Notification>>;
+
+export function ListPeople():Promise<Array<storage.Person>>;
+
+export function ListProfiles():Promise<Array<config.ProfileInfo>>;
+
+export function ListRcloneRemotes():Promise<Array<string>>;
+
+export function ListScanErrors(arg1:number,arg2:storage.Page):Promise<Array<storage.ScanError>>;
+
+export function ListScanSessions(arg1:number):Promise<Array<storage.ScanSession>>;
+
+export function ListSimilarVideoGroups(arg1:number):Promise<Array<media.SimilarVideoGroup>>;
+
+export function ListStagedMedia(arg1:storage.MediaFilter):Promise<Array<storage.MediaFile>>;
+
+export function ListTrashedMedia(arg1:storage.Page):Promise<storage.TrashPage>;
+
+export function ListVerificationIssues():Promise<Array<storage.VerificationResult>>;
+
+export function ListVolumes():Promise<Array<storage.Volume>>;
+
+export function OpenLogFolder():Promise<void>;
+
+export function OpenMedia(arg1:number):Promise<void>;
+
+export function PauseScan():Promise<boolean>;
+
+export function PickFolder(arg1:string):Promise<string>;
+
+export function PurgeTrash(arg1:number):Promise<number>;
+
+export function RebindVolume(arg1:string,arg2:string):Promise<number>;
+
+export function RecogniseText(arg1:number):Promise<media.OCRSummary>;
+
 export function ReloadSettings():Promise<config.Settings>;
 
+export function RenamePerson(arg1:number,arg2:string):Promise<void>;
+
+export function RepairPathCase():Promise<number>;
+
+export function ResolveDuplicates(arg1:Array<media.DuplicateResolution>,arg2:boolean):Promise<media.RemovalSummary>;
+
+export function RestoreDatabase(arg1:string):Promise<void>;
+
+export function RestoreMedia(arg1:Array<number>):Promise<storage.TrashRestore>;
+
+export function ResumeOffline():Promise<void>;
+
+export function ResumeScan():Promise<media.Summary>;
+
+export function RetryFailedActions(arg1:number,arg2:Array<number>,arg3:media.SafetyLevel):Promise<media.TidySummary>;
+
+export function RevealInExplorer(arg1:number):Promise<void>;
+
+export function RollbackRun(arg1:number):Promise<media.RollbackSummary>;
+
+export function RunMaintenance(arg1:boolean):Promise<main.MaintenanceSummary>;
+
 export function RunScan():Promise<media.Summary>;
+
+export function ScanFolders(arg1:Array<string>,arg2:main.ScanFolderOptions):Promise<media.Summary>;
+
+export function SearchMedia(arg1:string,arg2:storage.Page):Promise<storage.SearchPage>;
+
+export function SetActiveProfile(arg1:string):Promise<config.Settings>;
+
+export function SetFeatureFlag(arg1:string,arg2:boolean):Promise<Array<config.FeatureFlag>>;
+
+export function SetNotification(arg1:string,arg2:boolean):Promise<Array<config.Notification>>;
+
+export function SetPriority(arg1:string):Promise<main.PriorityState>;
+
+export function SetReadOnly(arg1:boolean):Promise<boolean>;
+
+export function SetThrottle(arg1:media.ThrottleLimits):Promise<media.ThrottleLimits>;
+
+export function SetToolPath(arg1:string,arg2:string):Promise<Array<media.ToolInfo>>;
+
+export function ShiftMediaTime(arg1:Array<number>,arg2:number):Promise<number>;
+
+export function StartBackfill(arg1:Array<string>):Promise<void>;
+
+export function UnlockLibrary(arg1:string,arg2:boolean):Promise<main.EncryptionState>;
+
+export function UpdateMediaMetadata(arg1:number,arg2:storage.MetadataEdit):Promise<storage.MediaFile>;
+
+export function ValidatePath(arg1:string):Promise<fsinfo.PathStatus>;
+
+export function VerifyLibrary(arg1:number):Promise<media.VerifySummary>;
@@ -2,26 +2,498 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function ExecuteTidy(arg1, arg2) {
-  return window['go']['main']['App']['ExecuteTidy'](arg1, arg2);
+export function AcknowledgeDuplicates(arg1, arg2) {
+  return window['go']['main']['App']['AcknowledgeDuplicates'](arg1, arg2);
+}
+
+export function AddDroppedSources(arg1) {
+  return window['go']['main']['App']['AddDroppedSources'](arg1);
+}
+
+export function AnalyseFaces(arg1) {
+  return window['go']['main']['App']['AnalyseFaces'](arg1);
+}
+
+export function BackupDatabase(arg1) {
+  return window['go']['main']['App']['BackupDatabase'](arg1);
+}
+
+export function BackupLibrary() {
+  return window['go']['main']['App']['BackupLibrary']();
+}
+
+export function BenchmarkStorage(arg1) {
+  return window['go']['main']['App']['BenchmarkStorage'](arg1);
+}
+
+export function CancelBackfill() {
+  return window['go']['main']['App']['CancelBackfill']();
+}
+
+export function CancelClassify() {
+  return window['go']['main']['App']['CancelClassify']();
+}
+
+export function CancelFaces() {
+  return window['go']['main']['App']['CancelFaces']();
+}
+
+export function CancelHashSimilarImages() {
+  return window['go']['main']['App']['CancelHashSimilarImages']();
+}
+
+export function CancelLibraryBackup() {
+  return window['go']['main']['App']['CancelLibraryBackup']();
+}
+
+export function CancelOCR() {
+  return window['go']['main']['App']['CancelOCR']();
+}
+
+export function CancelScan() {
+  return window['go']['main']['App']['CancelScan']();
+}
+
+export function CancelVerify() {
+  return window['go']['main']['App']['CancelVerify']();
+}
+
+export function CheckBackupBucket() {
+  return window['go']['main']['App']['CheckBackupBucket']();
+}
+
+export function CheckTarget() {
+  return window['go']['main']['App']['CheckTarget']();
+}
+
+export function ClassifyImages(arg1) {
+  return window['go']['main']['App']['ClassifyImages'](arg1);
+}
+
+export function CleanEmptyDirs(arg1) {
+  return window['go']['main']['App']['CleanEmptyDirs'](arg1);
+}
+
+export function CleanJunk(arg1) {
+  return window['go']['main']['App']['CleanJunk'](arg1);
+}
+
+export function ClearDuplicateAcknowledgement(arg1, arg2) {
+  return window['go']['main']['App']['ClearDuplicateAcknowledgement'](arg1, arg2);
+}
+
+export function CommitStaging() {
+  return window['go']['main']['App']['CommitStaging']();
+}
+
+export function CreateDiagnosticsBundle(arg1, arg2) {
+  return window['go']['main']['App']['CreateDiagnosticsBundle'](arg1, arg2);
+}
+
+export function DeleteMedia(arg1, arg2) {
+  return window['go']['main']['App']['DeleteMedia'](arg1, arg2);
+}
+
+export function DisableEncryption() {
+  return window['go']['main']['App']['DisableEncryption']();
+}
+
+export function DiscardStaging() {
+  return window['go']['main']['App']['DiscardStaging']();
+}
+
+export function EnableEncryption(arg1, arg2) {
+  return window['go']['main']['App']['EnableEncryption'](arg1, arg2);
+}
+
+export function ExecuteTidy(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExecuteTidy'](arg1, arg2, arg3);
+}
+
+export function ExecuteTidyByFilter(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExecuteTidyByFilter'](arg1, arg2, arg3);
+}
+
+export function ExecuteTidyTransactional(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExecuteTidyTransactional'](arg1, arg2, arg3);
+}
+
+export function ExportManifest(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportManifest'](arg1, arg2, arg3);
+}
+
+export function ExportScanErrors(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportScanErrors'](arg1, arg2, arg3);
+}
+
+export function FindSimilar(arg1, arg2, arg3) {
+  return window['go']['main']['App']['FindSimilar'](arg1, arg2, arg3);
+}
+
+export function GetAppInfo() {
+  return window['go']['main']['App']['GetAppInfo']();
+}
+
+export function GetDefaultSettings() {
+  return window['go']['main']['App']['GetDefaultSettings']();
+}
+
+export function GetDuplicateSummary(arg1) {
+  return window['go']['main']['App']['GetDuplicateSummary'](arg1);
+}
+
+export function GetEncryptionState() {
+  return window['go']['main']['App']['GetEncryptionState']();
+}
+
+export function GetEventSchemas() {
+  return window['go']['main']['App']['GetEventSchemas']();
+}
+
+export function GetFolderSizes(arg1, arg2) {
+  return window['go']['main']['App']['GetFolderSizes'](arg1, arg2);
+}
+
+export function GetLaunchFolders() {
+  return window['go']['main']['App']['GetLaunchFolders']();
+}
+
+export function GetLibraryGrowth(arg1) {
+  return window['go']['main']['App']['GetLibraryGrowth'](arg1);
+}
+
+export function GetMapClusters(arg1, arg2) {
+  return window['go']['main']['App']['GetMapClusters'](arg1, arg2);
+}
+
+export function GetMediaExif(arg1) {
+  return window['go']['main']['App']['GetMediaExif'](arg1);
+}
+
+export function GetMediaRating(arg1) {
+  return window['go']['main']['App']['GetMediaRating'](arg1);
+}
+
+export function GetMediaText(arg1) {
+  return window['go']['main']['App']['GetMediaText'](arg1);
+}
+
+export function GetPriority() {
+  return window['go']['main']['App']['GetPriority']();
+}
+
+export function GetReadOnly() {
+  return window['go']['main']['App']['GetReadOnly']();
+}
+
+export function GetRecentLogs(arg1, arg2) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1, arg2);
+}
+
+export function GetRecoveryReport() {
+  return window['go']['main']['App']['GetRecoveryReport']();
 }
 
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
 
-export function Greet(arg1) {
-  return window['go']['main']['App']['Greet'](arg1);
+export function GetStaging() {
+  return window['go']['main']['App']['GetStaging']();
+}
+
+export function GetThrottle() {
+  return window['go']['main']['App']['GetThrottle']();
+}
+
+export function GetTimeline(arg1, arg2) {
+  return window['go']['main']['App']['GetTimeline'](arg1, arg2);
+}
+
+export function GetTools() {
+  return window['go']['main']['App']['GetTools']();
+}
+
+export function HashSimilarImages(arg1) {
+  return window['go']['main']['App']['HashSimilarImages'](arg1);
+}
+
+export function ImportCard(arg1, arg2) {
+  return window['go']['main']['App']['ImportCard'](arg1, arg2);
+}
+
+export function ImportDevice(arg1) {
+  return window['go']['main']['App']['ImportDevice'](arg1);
+}
+
+export function ImportFolders(arg1, arg2) {
+  return window['go']['main']['App']['ImportFolders'](arg1, arg2);
+}
+
+export function ImportInbox(arg1) {
+  return window['go']['main']['App']['ImportInbox'](arg1);
+}
+
+export function ImportLightroomCatalog(arg1) {
+  return window['go']['main']['App']['ImportLightroomCatalog'](arg1);
+}
+
+export function ImportPhotosLibrary(arg1) {
+  return window['go']['main']['App']['ImportPhotosLibrary'](arg1);
+}
+
+export function ImportTakeout(arg1, arg2) {
+  return window['go']['main']['App']['ImportTakeout'](arg1, arg2);
+}
+
+export function InitializeSettings(arg1) {
+  return window['go']['main']['App']['InitializeSettings'](arg1);
+}
+
+export function IsFirstRun() {
+  return window['go']['main']['App']['IsFirstRun']();
+}
+
+export function ListActions(arg1, arg2) {
+  return window['go']['main']['App']['ListActions'](arg1, arg2);
+}
+
+export function ListAlbumMedia(arg1) {
+  return window['go']['main']['App']['ListAlbumMedia'](arg1);
+}
+
+export function ListAlbums() {
+  return window['go']['main']['App']['ListAlbums']();
+}
+
+export function ListBackfills() {
+  return window['go']['main']['App']['ListBackfills']();
+}
+
+export function ListBurstGroups() {
+  return window['go']['main']['App']['ListBurstGroups']();
+}
+
+export function ListCards() {
+  return window['go']['main']['App']['ListCards']();
+}
+
+export function ListCorruptFiles(arg1) {
+  return window['go']['main']['App']['ListCorruptFiles'](arg1);
+}
+
+export function ListDevices() {
+  return window['go']['main']['App']['ListDevices']();
 }
 
 export function ListDuplicateGroups() {
   return window['go']['main']['App']['ListDuplicateGroups']();
 }
 
+export function ListDuplicateGroupsPage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ListDuplicateGroupsPage'](arg1, arg2, arg3);
+}
+
+export function ListFeatureFlags() {
+  return window['go']['main']['App']['ListFeatureFlags']();
+}
+
+export function ListJunkFiles() {
+  return window['go']['main']['App']['ListJunkFiles']();
+}
+
+export function ListMedia(arg1) {
+  return window['go']['main']['App']['ListMedia'](arg1);
+}
+
+export function ListMediaByPerson(arg1, arg2) {
+  return window['go']['main']['App']['ListMediaByPerson'](arg1, arg2);
+}
+
+export function ListMediaTags(arg1) {
+  return window['go']['main']['App']['ListMediaTags'](arg1);
+}
+
+export function ListNotifications() {
+  return window['go']['main']['App']['ListNotifications']();
+}
+
+export function ListPeople() {
+  return window['go']['main']['App']['ListPeople']();
+}
+
+export function ListProfiles() {
+  return window['go']['main']['App']['ListProfiles']();
+}
+
+export function ListRcloneRemotes() {
+  return window['go']['main']['App']['ListRcloneRemotes']();
+}
+
+export function ListScanErrors(arg1, arg2) {
+  return window['go']['main']['App']['ListScanErrors'](arg1, arg2);
+}
+
+export function ListScanSessions(arg1) {
+  return window['go']['main']['App']['ListScanSessions'](arg1);
+}
+
+export function ListSimilarVideoGroups(arg1) {
+  return window['go']['main']['App']['ListSimilarVideoGroups'](arg1);
+}
+
+export function ListStagedMedia(arg1) {
+  return window['go']['main']['App']['ListStagedMedia'](arg1);
+}
+
+export function ListTrashedMedia(arg1) {
+  return window['go']['main']['App']['ListTrashedMedia'](arg1);
+}
+
+export function ListVerificationIssues() {
+  return window['go']['main']['App']['ListVerificationIssues']();
+}
+
+export function ListVolumes() {
+  return window['go']['main']['App']['ListVolumes']();
+}
+
+export function OpenLogFolder() {
+  return window['go']['main']['App']['OpenLogFolder']();
+}
+
+export function OpenMedia(arg1) {
+  return window['go']['main']['App']['OpenMedia'](arg1);
+}
+
+export function PauseScan() {
+  return window['go']['main']['App']['PauseScan']();
+}
+
+export function PickFolder(arg1) {
+  return window['go']['main']['App']['PickFolder'](arg1);
+}
+
+export function PurgeTrash(arg1) {
+  return window['go']['main']['App']['PurgeTrash'](arg1);
+}
+
+export function RebindVolume(arg1, arg2) {
+  return window['go']['main']['App']['RebindVolume'](arg1, arg2);
+}
+
+export function RecogniseText(arg1) {
+  return window['go']['main']['App']['RecogniseText'](arg1);
+}
+
 export function ReloadSettings() {
   return window['go']['main']['App']['ReloadSettings']();
 }
 
+export function RenamePerson(arg1, arg2) {
+  return window['go']['main']['App']['RenamePerson'](arg1, arg2);
+}
+
+export function RepairPathCase() {
+  return window['go']['main']['App']['RepairPathCase']();
+}
+
+export function ResolveDuplicates(arg1, arg2) {
+  return window['go']['main']['App']['ResolveDuplicates'](arg1, arg2);
+}
+
+export function RestoreDatabase(arg1) {
+  return window['go']['main']['App']['RestoreDatabase'](arg1);
+}
+
+export function RestoreMedia(arg1) {
+  return window['go']['main']['App']['RestoreMedia'](arg1);
+}
+
+export function ResumeOffline() {
+  return window['go']['main']['App']['ResumeOffline']();
+}
+
+export function ResumeScan() {
+  return window['go']['main']['App']['ResumeScan']();
+}
+
+export function RetryFailedActions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RetryFailedActions'](arg1, arg2, arg3);
+}
+
+export function RevealInExplorer(arg1) {
+  return window['go']['main']['App']['RevealInExplorer'](arg1);
+}
+
+export function RollbackRun(arg1) {
+  return window['go']['main']['App']['RollbackRun'](arg1);
+}
+
+export function RunMaintenance(arg1) {
+  return window['go']['main']['App']['RunMaintenance'](arg1);
+}
+
 export function RunScan() {
   return window['go']['main']['App']['RunScan']();
 }
+
+export function ScanFolders(arg1, arg2) {
+  return window['go']['main']['App']['ScanFolders'](arg1, arg2);
+}
+
+export function SearchMedia(arg1, arg2) {
+  return window['go']['main']['App']['SearchMedia'](arg1, arg2);
+}
+
+export function SetActiveProfile(arg1) {
+  return window['go']['main']['App']['SetActiveProfile'](arg1);
+}
+
+export function SetFeatureFlag(arg1, arg2) {
+  return window['go']['main']['App']['SetFeatureFlag'](arg1, arg2);
+}
+
+export function SetNotification(arg1, arg2) {
+  return window['go']['main']['App']['SetNotification'](arg1, arg2);
+}
+
+export function SetPriority(arg1) {
+  return window['go']['main']['App']['SetPriority'](arg1);
+}
+
+export function SetReadOnly(arg1) {
+  return window['go']['main']['App']['SetReadOnly'](arg1);
+}
+
+export function SetThrottle(arg1) {
+  return window['go']['main']['App']['SetThrottle'](arg1);
+}
+
+export function SetToolPath(arg1, arg2) {
+  return window['go']['main']['App']['SetToolPath'](arg1, arg2);
+}
+
+export function ShiftMediaTime(arg1, arg2) {
+  return window['go']['main']['App']['ShiftMediaTime'](arg1, arg2);
+}
+
+export function StartBackfill(arg1) {
+  return window['go']['main']['App']['StartBackfill'](arg1);
+}
+
+export function UnlockLibrary(arg1, arg2) {
+  return window['go']['main']['App']['UnlockLibrary'](arg1, arg2);
+}
+
+export function UpdateMediaMetadata(arg1, arg2) {
+  return window['go']['main']['App']['UpdateMediaMetadata'](arg1, arg2);
+}
+
+export function ValidatePath(arg1) {
+  return window['go']['main']['App']['ValidatePath'](arg1);
+}
+
+export function VerifyLibrary(arg1) {
+  return window['go']['main']['App']['VerifyLibrary'](arg1);
+}
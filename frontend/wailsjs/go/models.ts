@@ -1,77 +1,2309 @@
+export namespace applog {
+	
+	export class Entry {
+	    // Go type: time
+	    time: any;
+	    level: string;
+	    message: string;
+	    attrs?: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.level = source["level"];
+	        this.message = source["message"];
+	        this.attrs = source["attrs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace backup {
+	
+	export class ManifestSummary {
+	    path: string;
+	    algorithm: string;
+	    files: number;
+	    skipped: number;
+	    failed: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ManifestSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.algorithm = source["algorithm"];
+	        this.files = source["files"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class Summary {
+	    total: number;
+	    uploaded: number;
+	    skipped: number;
+	    failed: number;
+	    bytesUploaded: number;
+	    durationMs: number;
+	    destination: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.uploaded = source["uploaded"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.bytesUploaded = source["bytesUploaded"];
+	        this.durationMs = source["durationMs"];
+	        this.destination = source["destination"];
+	    }
+	}
+
+}
+
+export namespace bench {
+	
+	export class HashResult {
+	    algorithm: string;
+	    mbPerSec: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HashResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.algorithm = source["algorithm"];
+	        this.mbPerSec = source["mbPerSec"];
+	    }
+	}
+	export class Result {
+	    sampleMb: number;
+	    hashes: HashResult[];
+	    insertsPerSec: number;
+	    copyMbPerSec: number;
+	    targetDir: string;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sampleMb = source["sampleMb"];
+	        this.hashes = this.convertValues(source["hashes"], HashResult);
+	        this.insertsPerSec = source["insertsPerSec"];
+	        this.copyMbPerSec = source["copyMbPerSec"];
+	        this.targetDir = source["targetDir"];
+	        this.durationMs = source["durationMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace config {
 	
-	export class DatabaseConfig {
-	    BaseFolder: string;
-	    FileName: string;
+	export class BackupConfig {
+	    Endpoint: string;
+	    Region: string;
+	    Bucket: string;
+	    Prefix: string;
+	    AccessKeyID: string;
+	    SecretAccessKey: string;
+	    PathStyle: boolean;
+	    PartSizeMB: number;
+	    AfterTidy: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Endpoint = source["Endpoint"];
+	        this.Region = source["Region"];
+	        this.Bucket = source["Bucket"];
+	        this.Prefix = source["Prefix"];
+	        this.AccessKeyID = source["AccessKeyID"];
+	        this.SecretAccessKey = source["SecretAccessKey"];
+	        this.PathStyle = source["PathStyle"];
+	        this.PartSizeMB = source["PartSizeMB"];
+	        this.AfterTidy = source["AfterTidy"];
+	    }
+	}
+	export class ClassifierConfig {
+	    Model: string;
+	    Command: string[];
+	    MinScore: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClassifierConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Model = source["Model"];
+	        this.Command = source["Command"];
+	        this.MinScore = source["MinScore"];
+	    }
+	}
+	export class DatabaseConfig {
+	    BaseFolder: string;
+	    FileName: string;
+	    BackupBeforeTidy: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DatabaseConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.BaseFolder = source["BaseFolder"];
+	        this.FileName = source["FileName"];
+	        this.BackupBeforeTidy = source["BackupBeforeTidy"];
+	    }
+	}
+	export class FacesConfig {
+	    Endpoint: string;
+	    Command: string[];
+	    Threshold: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FacesConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Endpoint = source["Endpoint"];
+	        this.Command = source["Command"];
+	        this.Threshold = source["Threshold"];
+	    }
+	}
+	export class FeatureFlag {
+	    name: string;
+	    description: string;
+	    default: boolean;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FeatureFlag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.default = source["default"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class GRPCConfig {
+	    Enabled: boolean;
+	    Address: string;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GRPCConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Enabled = source["Enabled"];
+	        this.Address = source["Address"];
+	        this.Token = source["Token"];
+	    }
+	}
+	export class HistoryConfig {
+	    LastSourceFolder: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.LastSourceFolder = source["LastSourceFolder"];
+	    }
+	}
+	export class Notification {
+	    job: string;
+	    description: string;
+	    default: boolean;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Notification(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job = source["job"];
+	        this.description = source["description"];
+	        this.default = source["default"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class OCRConfig {
+	    Languages: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OCRConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Languages = source["Languages"];
+	    }
+	}
+	export class PowerConfig {
+	    PauseOnBattery: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PowerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.PauseOnBattery = source["PauseOnBattery"];
+	    }
+	}
+	export class TargetConfig {
+	    BaseFolder: string;
+	    WebDAVUser: string;
+	    WebDAVPassword: string;
+	    Pattern: string;
+	    QuarantineFolder: string;
+	    Workers: number;
+	    Normalization: string;
+	    CaseMode: string;
+	    RemoveDuplicateSource: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TargetConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.BaseFolder = source["BaseFolder"];
+	        this.WebDAVUser = source["WebDAVUser"];
+	        this.WebDAVPassword = source["WebDAVPassword"];
+	        this.Pattern = source["Pattern"];
+	        this.QuarantineFolder = source["QuarantineFolder"];
+	        this.Workers = source["Workers"];
+	        this.Normalization = source["Normalization"];
+	        this.CaseMode = source["CaseMode"];
+	        this.RemoveDuplicateSource = source["RemoveDuplicateSource"];
+	    }
+	}
+	export class SourceFolder {
+	    Path: string;
+	    Extensions: string[];
+	    Exclude: string[];
+	    FollowSymlinks?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SourceFolder(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Extensions = source["Extensions"];
+	        this.Exclude = source["Exclude"];
+	        this.FollowSymlinks = source["FollowSymlinks"];
+	    }
+	}
+	export class ScanConfig {
+	    SourceFolders: SourceFolder[];
+	    IncludeExtensions: string[];
+	    FollowSymlinks: boolean;
+	    BurstWindowSeconds: number;
+	    PreCount: boolean;
+	    InboxFolder: string;
+	    Archives: boolean;
+	    TimeZone: string;
+	    CameraTimeZones: Record<string, string>;
+	    Validate: string;
+	    JunkPatterns: string[];
+	    SkipFolders: string[];
+	    MemoryBudgetMB: number;
+	    Parallel: number;
+	    DropAction: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.SourceFolders = this.convertValues(source["SourceFolders"], SourceFolder);
+	        this.IncludeExtensions = source["IncludeExtensions"];
+	        this.FollowSymlinks = source["FollowSymlinks"];
+	        this.BurstWindowSeconds = source["BurstWindowSeconds"];
+	        this.PreCount = source["PreCount"];
+	        this.InboxFolder = source["InboxFolder"];
+	        this.Archives = source["Archives"];
+	        this.TimeZone = source["TimeZone"];
+	        this.CameraTimeZones = source["CameraTimeZones"];
+	        this.Validate = source["Validate"];
+	        this.JunkPatterns = source["JunkPatterns"];
+	        this.SkipFolders = source["SkipFolders"];
+	        this.MemoryBudgetMB = source["MemoryBudgetMB"];
+	        this.Parallel = source["Parallel"];
+	        this.DropAction = source["DropAction"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Profile {
+	    Scan: ScanConfig;
+	    Target: TargetConfig;
+	    History: HistoryConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Scan = this.convertValues(source["Scan"], ScanConfig);
+	        this.Target = this.convertValues(source["Target"], TargetConfig);
+	        this.History = this.convertValues(source["History"], HistoryConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileInfo {
+	    name: string;
+	    active: boolean;
+	    sourceFolders: string[];
+	    targetFolder: string;
+	    pattern: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.active = source["active"];
+	        this.sourceFolders = source["sourceFolders"];
+	        this.targetFolder = source["targetFolder"];
+	        this.pattern = source["pattern"];
+	    }
+	}
+	export class RetentionConfig {
+	    ActionDays: number;
+	    ArchiveFolder: string;
+	    TrashDays: number;
+	    VacuumFreePercent: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetentionConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ActionDays = source["ActionDays"];
+	        this.ArchiveFolder = source["ArchiveFolder"];
+	        this.TrashDays = source["TrashDays"];
+	        this.VacuumFreePercent = source["VacuumFreePercent"];
+	    }
+	}
+	
+	export class ScheduleConfig {
+	    Scan: string;
+	    AutoTidy: boolean;
+	    Verify: string;
+	    VerifySamplePercent: number;
+	    Maintenance: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScheduleConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Scan = source["Scan"];
+	        this.AutoTidy = source["AutoTidy"];
+	        this.Verify = source["Verify"];
+	        this.VerifySamplePercent = source["VerifySamplePercent"];
+	        this.Maintenance = source["Maintenance"];
+	    }
+	}
+	export class ToolsConfig {
+	    Exiftool: string;
+	    FFprobe: string;
+	    Rclone: string;
+	    Tesseract: string;
+	    Gphoto2: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolsConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Exiftool = source["Exiftool"];
+	        this.FFprobe = source["FFprobe"];
+	        this.Rclone = source["Rclone"];
+	        this.Tesseract = source["Tesseract"];
+	        this.Gphoto2 = source["Gphoto2"];
+	    }
+	}
+	export class ThrottleConfig {
+	    MBPerSec: number;
+	    FileDelayMS: number;
+	    Priority: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ThrottleConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.MBPerSec = source["MBPerSec"];
+	        this.FileDelayMS = source["FileDelayMS"];
+	        this.Priority = source["Priority"];
+	    }
+	}
+	export class Settings {
+	    Backup: BackupConfig;
+	    Classifier: ClassifierConfig;
+	    Database: DatabaseConfig;
+	    Faces: FacesConfig;
+	    GRPC: GRPCConfig;
+	    History: HistoryConfig;
+	    OCR: OCRConfig;
+	    Power: PowerConfig;
+	    Retention: RetentionConfig;
+	    Schedule: ScheduleConfig;
+	    Scan: ScanConfig;
+	    Target: TargetConfig;
+	    Throttle: ThrottleConfig;
+	    Tools: ToolsConfig;
+	    Features: Record<string, boolean>;
+	    Notifications: Record<string, boolean>;
+	    ReadOnly: boolean;
+	    ActiveProfile: string;
+	    Profiles: Record<string, Profile>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Backup = this.convertValues(source["Backup"], BackupConfig);
+	        this.Classifier = this.convertValues(source["Classifier"], ClassifierConfig);
+	        this.Database = this.convertValues(source["Database"], DatabaseConfig);
+	        this.Faces = this.convertValues(source["Faces"], FacesConfig);
+	        this.GRPC = this.convertValues(source["GRPC"], GRPCConfig);
+	        this.History = this.convertValues(source["History"], HistoryConfig);
+	        this.OCR = this.convertValues(source["OCR"], OCRConfig);
+	        this.Power = this.convertValues(source["Power"], PowerConfig);
+	        this.Retention = this.convertValues(source["Retention"], RetentionConfig);
+	        this.Schedule = this.convertValues(source["Schedule"], ScheduleConfig);
+	        this.Scan = this.convertValues(source["Scan"], ScanConfig);
+	        this.Target = this.convertValues(source["Target"], TargetConfig);
+	        this.Throttle = this.convertValues(source["Throttle"], ThrottleConfig);
+	        this.Tools = this.convertValues(source["Tools"], ToolsConfig);
+	        this.Features = source["Features"];
+	        this.Notifications = source["Notifications"];
+	        this.ReadOnly = source["ReadOnly"];
+	        this.ActiveProfile = source["ActiveProfile"];
+	        this.Profiles = this.convertValues(source["Profiles"], Profile, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+
+}
+
+export namespace events {
+	
+	export class Field {
+	    name: string;
+	    type: string;
+	    optional?: boolean;
+	    fields?: Field[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Field(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.optional = source["optional"];
+	        this.fields = this.convertValues(source["fields"], Field);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Schema {
+	    name: string;
+	    kind: string;
+	    version: number;
+	    fields: Field[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Schema(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.version = source["version"];
+	        this.fields = this.convertValues(source["fields"], Field);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace fsinfo {
+	
+	export class PathStatus {
+	    path: string;
+	    exists: boolean;
+	    isDir: boolean;
+	    writable: boolean;
+	    freeBytes: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PathStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.exists = source["exists"];
+	        this.isDir = source["isDir"];
+	        this.writable = source["writable"];
+	        this.freeBytes = source["freeBytes"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
+
+export namespace main {
+	
+	export class AppInfo {
+	    version: string;
+	    buildDate: string;
+	    commit: string;
+	    goVersion: string;
+	    platform: string;
+	    profile: string;
+	    mode: string;
+	    settingsPath: string;
+	    libraryPath: string;
+	    schemaVersion: number;
+	    features: Record<string, boolean>;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.buildDate = source["buildDate"];
+	        this.commit = source["commit"];
+	        this.goVersion = source["goVersion"];
+	        this.platform = source["platform"];
+	        this.profile = source["profile"];
+	        this.mode = source["mode"];
+	        this.settingsPath = source["settingsPath"];
+	        this.libraryPath = source["libraryPath"];
+	        this.schemaVersion = source["schemaVersion"];
+	        this.features = source["features"];
+	    }
+	}
+	export class CardImportSummary {
+	    card: volume.Info;
+	    pull: media.DevicePull;
+	    scan: media.Summary;
+	    tidy: media.TidySummary;
+	    cleanup: media.RemovalSummary;
+	    cleared?: media.CardCleanup;
+	
+	    static createFrom(source: any = {}) {
+	        return new CardImportSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.card = this.convertValues(source["card"], volume.Info);
+	        this.pull = this.convertValues(source["pull"], media.DevicePull);
+	        this.scan = this.convertValues(source["scan"], media.Summary);
+	        this.tidy = this.convertValues(source["tidy"], media.TidySummary);
+	        this.cleanup = this.convertValues(source["cleanup"], media.RemovalSummary);
+	        this.cleared = this.convertValues(source["cleared"], media.CardCleanup);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeviceImportSummary {
+	    device: media.Device;
+	    pull: media.DevicePull;
+	    scan: media.Summary;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceImportSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.device = this.convertValues(source["device"], media.Device);
+	        this.pull = this.convertValues(source["pull"], media.DevicePull);
+	        this.scan = this.convertValues(source["scan"], media.Summary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DroppedSources {
+	    added: string[];
+	    listed: string[];
+	    rejected?: Record<string, string>;
+	    scan?: media.Summary;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DroppedSources(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.listed = source["listed"];
+	        this.rejected = source["rejected"];
+	        this.scan = this.convertValues(source["scan"], media.Summary);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EncryptionState {
+	    encrypted: boolean;
+	    locked: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.encrypted = source["encrypted"];
+	        this.locked = source["locked"];
+	    }
+	}
+	export class InboxSummary {
+	    scan: media.Summary;
+	    duplicates: media.RemovalSummary;
+	    tidy: media.TidySummary;
+	    cleanup: media.RemovalSummary;
+	
+	    static createFrom(source: any = {}) {
+	        return new InboxSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.scan = this.convertValues(source["scan"], media.Summary);
+	        this.duplicates = this.convertValues(source["duplicates"], media.RemovalSummary);
+	        this.tidy = this.convertValues(source["tidy"], media.TidySummary);
+	        this.cleanup = this.convertValues(source["cleanup"], media.RemovalSummary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MaintenanceSummary {
+	    archivedActions: number;
+	    purgedTrash: number;
+	    releasedClaims: number;
+	    vacuumed: boolean;
+	    before: storage.DatabaseStats;
+	    after: storage.DatabaseStats;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MaintenanceSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.archivedActions = source["archivedActions"];
+	        this.purgedTrash = source["purgedTrash"];
+	        this.releasedClaims = source["releasedClaims"];
+	        this.vacuumed = source["vacuumed"];
+	        this.before = this.convertValues(source["before"], storage.DatabaseStats);
+	        this.after = this.convertValues(source["after"], storage.DatabaseStats);
+	        this.durationMs = source["durationMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PhotosImportSummary {
+	    assets: number;
+	    pull: media.PhotosPull;
+	    scan: media.Summary;
+	    dated: number;
+	    favorites: number;
+	    albums: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PhotosImportSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.assets = source["assets"];
+	        this.pull = this.convertValues(source["pull"], media.PhotosPull);
+	        this.scan = this.convertValues(source["scan"], media.Summary);
+	        this.dated = source["dated"];
+	        this.favorites = source["favorites"];
+	        this.albums = source["albums"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PriorityState {
+	    priority: string;
+	    background: boolean;
+	    load: number;
+	    backoffMs: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PriorityState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.priority = source["priority"];
+	        this.background = source["background"];
+	        this.load = source["load"];
+	        this.backoffMs = source["backoffMs"];
+	        this.error = source["error"];
+	    }
+	}
+	export class RecoveryReport {
+	    // Go type: time
+	    checkedAt: any;
+	    integrityOk: boolean;
+	    integrity?: string[];
+	    pendingActions: number;
+	    interruptedRuns?: number[];
+	    uncleanShutdown: boolean;
+	    suggestions: string[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecoveryReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	        this.integrityOk = source["integrityOk"];
+	        this.integrity = source["integrity"];
+	        this.pendingActions = source["pendingActions"];
+	        this.interruptedRuns = source["interruptedRuns"];
+	        this.uncleanShutdown = source["uncleanShutdown"];
+	        this.suggestions = source["suggestions"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScanFolderOptions {
+	    incremental: boolean;
+	    policy: string;
+	    staging: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanFolderOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.incremental = source["incremental"];
+	        this.policy = source["policy"];
+	        this.staging = source["staging"];
+	    }
+	}
+	export class StagingCommitSummary {
+	    commit: storage.StagingCommit;
+	    sources: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new StagingCommitSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.commit = this.convertValues(source["commit"], storage.StagingCommit);
+	        this.sources = source["sources"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StagingInfo {
+	    staged: storage.StagingSummary;
+	    sources: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new StagingInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.staged = this.convertValues(source["staged"], storage.StagingSummary);
+	        this.sources = source["sources"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace media {
+	
+	export enum SafetyLevel {
+	    FAST = "fast",
+	    STANDARD = "standard",
+	    PARANOID = "paranoid",
+	}
+	export class CardCleanup {
+	    deleted: number;
+	    kept: number;
+	    failed: number;
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CardCleanup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.deleted = source["deleted"];
+	        this.kept = source["kept"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class Device {
+	    model: string;
+	    port: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Device(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.model = source["model"];
+	        this.port = source["port"];
+	    }
+	}
+	export class DevicePull {
+	    listed: number;
+	    copied: number;
+	    known: number;
+	    unchanged: number;
+	    failed: number;
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DevicePull(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.listed = source["listed"];
+	        this.copied = source["copied"];
+	        this.known = source["known"];
+	        this.unchanged = source["unchanged"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class DuplicateResolution {
+	    hash: string;
+	    keepId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateResolution(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hash = source["hash"];
+	        this.keepId = source["keepId"];
+	    }
+	}
+	export class FaceSummary {
+	    analysed: number;
+	    faces: number;
+	    failed: number;
+	    skipped: number;
+	    clustered: number;
+	    newPeople: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FaceSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.analysed = source["analysed"];
+	        this.faces = source["faces"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.clustered = source["clustered"];
+	        this.newPeople = source["newPeople"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class KnownFile {
+	    path: string;
+	    libraryId: number;
+	    libraryPath: string;
+	    sizeBytes: number;
+	    skipped: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new KnownFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.libraryId = source["libraryId"];
+	        this.libraryPath = source["libraryPath"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class LabelSummary {
+	    labelled: number;
+	    labels: number;
+	    failed: number;
+	    skipped: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LabelSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.labelled = source["labelled"];
+	        this.labels = source["labels"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class LightroomSummary {
+	    images: number;
+	    byPath: number;
+	    byHash: number;
+	    unmatched: number;
+	    skipped: number;
+	    keywords: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LightroomSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = source["images"];
+	        this.byPath = source["byPath"];
+	        this.byHash = source["byHash"];
+	        this.unmatched = source["unmatched"];
+	        this.skipped = source["skipped"];
+	        this.keywords = source["keywords"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class MoveRequest {
+	    mediaId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MoveRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mediaId = source["mediaId"];
+	    }
+	}
+	export class OCRSummary {
+	    read: number;
+	    withText: number;
+	    failed: number;
+	    skipped: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OCRSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.read = source["read"];
+	        this.withText = source["withText"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class PHashSummary {
+	    hashed: number;
+	    failed: number;
+	    skipped: number;
+	    errors?: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PHashSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hashed = source["hashed"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class PhotosPull {
+	    copied: number;
+	    unchanged: number;
+	    missing: number;
+	    failed: number;
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PhotosPull(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.copied = source["copied"];
+	        this.unchanged = source["unchanged"];
+	        this.missing = source["missing"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class RemovedFile {
+	    mediaId?: number;
+	    path: string;
+	    sizeBytes: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemovedFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mediaId = source["mediaId"];
+	        this.path = source["path"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.error = source["error"];
+	    }
+	}
+	export class RemovalSummary {
+	    dryRun: boolean;
+	    files: RemovedFile[];
+	    removed: number;
+	    failed: number;
+	    bytesReclaimed: number;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemovalSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dryRun = source["dryRun"];
+	        this.files = this.convertValues(source["files"], RemovedFile);
+	        this.removed = source["removed"];
+	        this.failed = source["failed"];
+	        this.bytesReclaimed = source["bytesReclaimed"];
+	        this.durationMs = source["durationMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RollbackSummary {
+	    runId: number;
+	    restored: number;
+	    failed: number;
+	    errors: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RollbackSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.runId = source["runId"];
+	        this.restored = source["restored"];
+	        this.failed = source["failed"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class SimilarImage {
+	    file: storage.MediaFile;
+	    distance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimilarImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = this.convertValues(source["file"], storage.MediaFile);
+	        this.distance = source["distance"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SimilarVideoGroup {
+	    files: storage.MediaFile[];
+	    distance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimilarVideoGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = this.convertValues(source["files"], storage.MediaFile);
+	        this.distance = source["distance"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SourceSummary {
+	    source: string;
+	    filesDiscovered: number;
+	    filesPersisted: number;
+	    filesSkipped: number;
+	    filesUnchanged: number;
+	    filesCorrupt: number;
+	    filesJunk: number;
+	    symlinkLoops: number;
+	    errorCount: number;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SourceSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.filesDiscovered = source["filesDiscovered"];
+	        this.filesPersisted = source["filesPersisted"];
+	        this.filesSkipped = source["filesSkipped"];
+	        this.filesUnchanged = source["filesUnchanged"];
+	        this.filesCorrupt = source["filesCorrupt"];
+	        this.filesJunk = source["filesJunk"];
+	        this.symlinkLoops = source["symlinkLoops"];
+	        this.errorCount = source["errorCount"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class Summary {
+	    filesDiscovered: number;
+	    filesPersisted: number;
+	    filesSkipped: number;
+	    filesUnchanged: number;
+	    filesCorrupt: number;
+	    filesJunk: number;
+	    filesKnown: number;
+	    known?: KnownFile[];
+	    symlinkLoops?: string[];
+	    errorCount: number;
+	    errors: string[];
+	    durationMs: number;
+	    duplicateGroups: number;
+	    cancelled?: boolean;
+	    paused?: boolean;
+	    peakMemoryMb: number;
+	    heapProfile?: string;
+	    sessionId: number;
+	    sources?: SourceSummary[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filesDiscovered = source["filesDiscovered"];
+	        this.filesPersisted = source["filesPersisted"];
+	        this.filesSkipped = source["filesSkipped"];
+	        this.filesUnchanged = source["filesUnchanged"];
+	        this.filesCorrupt = source["filesCorrupt"];
+	        this.filesJunk = source["filesJunk"];
+	        this.filesKnown = source["filesKnown"];
+	        this.known = this.convertValues(source["known"], KnownFile);
+	        this.symlinkLoops = source["symlinkLoops"];
+	        this.errorCount = source["errorCount"];
+	        this.errors = source["errors"];
+	        this.durationMs = source["durationMs"];
+	        this.duplicateGroups = source["duplicateGroups"];
+	        this.cancelled = source["cancelled"];
+	        this.paused = source["paused"];
+	        this.peakMemoryMb = source["peakMemoryMb"];
+	        this.heapProfile = source["heapProfile"];
+	        this.sessionId = source["sessionId"];
+	        this.sources = this.convertValues(source["sources"], SourceSummary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ThrottleLimits {
+	    mbPerSec: number;
+	    fileDelayMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ThrottleLimits(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mbPerSec = source["mbPerSec"];
+	        this.fileDelayMs = source["fileDelayMs"];
+	    }
+	}
+	export class TidySummary {
+	    total: number;
+	    moved: number;
+	    skipped: number;
+	    failed: number;
+	    quarantined: number;
+	    deduplicated: number;
+	    runId?: number;
+	    durationMs: number;
+	    dryRun: boolean;
+	    targetBase: string;
+	    aborted?: string;
+	    rolledBack?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TidySummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.moved = source["moved"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.quarantined = source["quarantined"];
+	        this.deduplicated = source["deduplicated"];
+	        this.runId = source["runId"];
+	        this.durationMs = source["durationMs"];
+	        this.dryRun = source["dryRun"];
+	        this.targetBase = source["targetBase"];
+	        this.aborted = source["aborted"];
+	        this.rolledBack = source["rolledBack"];
+	    }
+	}
+	export class ToolInfo {
+	    name: string;
+	    available: boolean;
+	    disabled?: boolean;
+	    path?: string;
+	    version?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.available = source["available"];
+	        this.disabled = source["disabled"];
+	        this.path = source["path"];
+	        this.version = source["version"];
+	        this.error = source["error"];
+	    }
+	}
+	export class VerifySummary {
+	    checked: number;
+	    ok: number;
+	    mismatched: number;
+	    changed: number;
+	    missing: number;
+	    failed: number;
+	    skipped: number;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VerifySummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checked = source["checked"];
+	        this.ok = source["ok"];
+	        this.mismatched = source["mismatched"];
+	        this.changed = source["changed"];
+	        this.missing = source["missing"];
+	        this.failed = source["failed"];
+	        this.skipped = source["skipped"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+
+}
+
+export namespace sql {
+	
+	export class NullFloat64 {
+	    Float64: number;
+	    Valid: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NullFloat64(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Float64 = source["Float64"];
+	        this.Valid = source["Valid"];
+	    }
+	}
+	export class NullInt64 {
+	    Int64: number;
+	    Valid: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NullInt64(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Int64 = source["Int64"];
+	        this.Valid = source["Valid"];
+	    }
+	}
+	export class NullString {
+	    String: string;
+	    Valid: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NullString(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.String = source["String"];
+	        this.Valid = source["Valid"];
+	    }
+	}
+	export class NullTime {
+	    // Go type: time
+	    Time: any;
+	    Valid: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NullTime(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Time = this.convertValues(source["Time"], null);
+	        this.Valid = source["Valid"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace storage {
+	
+	export class ActionFilter {
+	    status: string;
+	    actionType: string;
+	    runId: number;
+	    from: string;
+	    to: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.actionType = source["actionType"];
+	        this.runId = source["runId"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class ActionRecord {
+	    id: number;
+	    mediaId?: number;
+	    runId?: number;
+	    retryOf?: number;
+	    sourcePath: string;
+	    targetPath?: string;
+	    actionType: string;
+	    status: string;
+	    errorMsg?: string;
+	    hashMd5?: string;
+	    createdAt: string;
+	    executedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.mediaId = source["mediaId"];
+	        this.runId = source["runId"];
+	        this.retryOf = source["retryOf"];
+	        this.sourcePath = source["sourcePath"];
+	        this.targetPath = source["targetPath"];
+	        this.actionType = source["actionType"];
+	        this.status = source["status"];
+	        this.errorMsg = source["errorMsg"];
+	        this.hashMd5 = source["hashMd5"];
+	        this.createdAt = source["createdAt"];
+	        this.executedAt = source["executedAt"];
+	    }
+	}
+	export class ActionPage {
+	    actions: ActionRecord[];
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.actions = this.convertValues(source["actions"], ActionRecord);
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Album {
+	    id: number;
+	    source: string;
+	    name: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Album(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.source = source["source"];
+	        this.name = source["name"];
+	        this.count = source["count"];
+	    }
+	}
+	export class BackfillState {
+	    job: string;
+	    status: string;
+	    lastId: number;
+	    processed: number;
+	    updated: number;
+	    failed: number;
+	    remaining: number;
+	    updatedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackfillState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job = source["job"];
+	        this.status = source["status"];
+	        this.lastId = source["lastId"];
+	        this.processed = source["processed"];
+	        this.updated = source["updated"];
+	        this.failed = source["failed"];
+	        this.remaining = source["remaining"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class MediaFile {
+	    ID: number;
+	    Path: string;
+	    HashMD5: string;
+	    SizeBytes: number;
+	    // Go type: time
+	    ModTime: any;
+	    TakenAt: sql.NullTime;
+	    TakenAtUTC: sql.NullTime;
+	    UTCOffsetMinutes: sql.NullInt64;
+	    CameraMake: sql.NullString;
+	    CameraModel: sql.NullString;
+	    MimeType: sql.NullString;
+	    Width: number;
+	    Height: number;
+	    Category: string;
+	    Latitude: sql.NullFloat64;
+	    Longitude: sql.NullFloat64;
+	    Device: sql.NullInt64;
+	    Inode: sql.NullInt64;
+	    TimeOffsetMinutes: number;
+	    Edited: boolean;
+	    Person: string;
+	    Tags: string[];
+	    Corrupt: string;
+	    VolumeID: string;
+	    VolumePath: string;
+	    Offline: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Path = source["Path"];
+	        this.HashMD5 = source["HashMD5"];
+	        this.SizeBytes = source["SizeBytes"];
+	        this.ModTime = this.convertValues(source["ModTime"], null);
+	        this.TakenAt = this.convertValues(source["TakenAt"], sql.NullTime);
+	        this.TakenAtUTC = this.convertValues(source["TakenAtUTC"], sql.NullTime);
+	        this.UTCOffsetMinutes = this.convertValues(source["UTCOffsetMinutes"], sql.NullInt64);
+	        this.CameraMake = this.convertValues(source["CameraMake"], sql.NullString);
+	        this.CameraModel = this.convertValues(source["CameraModel"], sql.NullString);
+	        this.MimeType = this.convertValues(source["MimeType"], sql.NullString);
+	        this.Width = source["Width"];
+	        this.Height = source["Height"];
+	        this.Category = source["Category"];
+	        this.Latitude = this.convertValues(source["Latitude"], sql.NullFloat64);
+	        this.Longitude = this.convertValues(source["Longitude"], sql.NullFloat64);
+	        this.Device = this.convertValues(source["Device"], sql.NullInt64);
+	        this.Inode = this.convertValues(source["Inode"], sql.NullInt64);
+	        this.TimeOffsetMinutes = source["TimeOffsetMinutes"];
+	        this.Edited = source["Edited"];
+	        this.Person = source["Person"];
+	        this.Tags = source["Tags"];
+	        this.Corrupt = source["Corrupt"];
+	        this.VolumeID = source["VolumeID"];
+	        this.VolumePath = source["VolumePath"];
+	        this.Offline = source["Offline"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BurstGroup {
+	    CameraMake: string;
+	    CameraModel: string;
+	    // Go type: time
+	    Start: any;
+	    // Go type: time
+	    End: any;
+	    Files: MediaFile[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BurstGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CameraMake = source["CameraMake"];
+	        this.CameraModel = source["CameraModel"];
+	        this.Start = this.convertValues(source["Start"], null);
+	        this.End = this.convertValues(source["End"], null);
+	        this.Files = this.convertValues(source["Files"], MediaFile);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DatabaseStats {
+	    sizeBytes: number;
+	    freeBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DatabaseStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sizeBytes = source["sizeBytes"];
+	        this.freeBytes = source["freeBytes"];
+	    }
+	}
+	export class DuplicateGroup {
+	    Hash: string;
+	    Files: MediaFile[];
+	    SuggestedKeeperID: number;
+	    WastedBytes: number;
+	    Acknowledged: number[];
+	    Hardlinks: number[][];
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Hash = source["Hash"];
+	        this.Files = this.convertValues(source["Files"], MediaFile);
+	        this.SuggestedKeeperID = source["SuggestedKeeperID"];
+	        this.WastedBytes = source["WastedBytes"];
+	        this.Acknowledged = source["Acknowledged"];
+	        this.Hardlinks = source["Hardlinks"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DuplicatePage {
+	    groups: DuplicateGroup[];
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicatePage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.groups = this.convertValues(source["groups"], DuplicateGroup);
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DuplicateScope {
+	    paths: string[];
+	    from: string;
+	    to: string;
+	    acrossFolders: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateScope(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.acrossFolders = source["acrossFolders"];
+	    }
+	}
+	export class DuplicateSummary {
+	    groups: number;
+	    files: number;
+	    wastedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.groups = source["groups"];
+	        this.files = source["files"];
+	        this.wastedBytes = source["wastedBytes"];
+	    }
+	}
+	export class FolderSize {
+	    path: string;
+	    name: string;
+	    files: number;
+	    bytes: number;
+	    children: FolderSize[];
 	
 	    static createFrom(source: any = {}) {
-	        return new DatabaseConfig(source);
+	        return new FolderSize(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.BaseFolder = source["BaseFolder"];
-	        this.FileName = source["FileName"];
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.files = source["files"];
+	        this.bytes = source["bytes"];
+	        this.children = this.convertValues(source["children"], FolderSize);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class HistoryConfig {
-	    LastSourceFolder: string[];
+	export class GrowthPoint {
+	    period: string;
+	    totalFiles: number;
+	    totalBytes: number;
+	    duplicateGroups: number;
+	    wastedBytes: number;
+	    reclaimedBytes: number;
+	    snapshots: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new HistoryConfig(source);
+	        return new GrowthPoint(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.LastSourceFolder = source["LastSourceFolder"];
+	        this.period = source["period"];
+	        this.totalFiles = source["totalFiles"];
+	        this.totalBytes = source["totalBytes"];
+	        this.duplicateGroups = source["duplicateGroups"];
+	        this.wastedBytes = source["wastedBytes"];
+	        this.reclaimedBytes = source["reclaimedBytes"];
+	        this.snapshots = source["snapshots"];
 	    }
 	}
-	export class ScanConfig {
-	    SourceFolders: string[];
-	    IncludeExtensions: string[];
-	    FollowSymlinks: boolean;
+	export class JunkFile {
+	    path: string;
+	    sizeBytes: number;
+	    reason: string;
+	    foundAt: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new ScanConfig(source);
+	        return new JunkFile(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.SourceFolders = source["SourceFolders"];
-	        this.IncludeExtensions = source["IncludeExtensions"];
-	        this.FollowSymlinks = source["FollowSymlinks"];
+	        this.path = source["path"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.reason = source["reason"];
+	        this.foundAt = source["foundAt"];
 	    }
 	}
-	export class TargetConfig {
-	    BaseFolder: string;
-	    Pattern: string;
+	export class MapBounds {
+	    north: number;
+	    south: number;
+	    east: number;
+	    west: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new TargetConfig(source);
+	        return new MapBounds(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.BaseFolder = source["BaseFolder"];
-	        this.Pattern = source["Pattern"];
+	        this.north = source["north"];
+	        this.south = source["south"];
+	        this.east = source["east"];
+	        this.west = source["west"];
 	    }
 	}
-	export class Settings {
-	    Database: DatabaseConfig;
-	    History: HistoryConfig;
-	    Scan: ScanConfig;
-	    Target: TargetConfig;
+	export class MapCluster {
+	    latitude: number;
+	    longitude: number;
+	    count: number;
+	    representativeId: number;
+	    bounds: MapBounds;
+	    thumbnail: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new Settings(source);
+	        return new MapCluster(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Database = this.convertValues(source["Database"], DatabaseConfig);
-	        this.History = this.convertValues(source["History"], HistoryConfig);
-	        this.Scan = this.convertValues(source["Scan"], ScanConfig);
-	        this.Target = this.convertValues(source["Target"], TargetConfig);
+	        this.latitude = source["latitude"];
+	        this.longitude = source["longitude"];
+	        this.count = source["count"];
+	        this.representativeId = source["representativeId"];
+	        this.bounds = this.convertValues(source["bounds"], MapBounds);
+	        this.thumbnail = source["thumbnail"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -92,101 +2324,229 @@ export namespace config {
 		    return a;
 		}
 	}
-
-}
-
-export namespace media {
 	
-	export class MoveRequest {
+	export class MediaFilter {
+	    category: string;
+	    orientation: string;
+	    lens: string;
+	    isoMin: number;
+	    isoMax: number;
+	    limit: number;
+	    offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.orientation = source["orientation"];
+	        this.lens = source["lens"];
+	        this.isoMin = source["isoMin"];
+	        this.isoMax = source["isoMax"];
+	        this.limit = source["limit"];
+	        this.offset = source["offset"];
+	    }
+	}
+	export class MediaRating {
 	    mediaId: number;
+	    rating: number;
+	    flag: string;
+	    colorLabel: string;
+	    developed: boolean;
+	    source: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new MoveRequest(source);
+	        return new MediaRating(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.mediaId = source["mediaId"];
+	        this.rating = source["rating"];
+	        this.flag = source["flag"];
+	        this.colorLabel = source["colorLabel"];
+	        this.developed = source["developed"];
+	        this.source = source["source"];
 	    }
 	}
-	export class Summary {
-	    filesDiscovered: number;
-	    filesPersisted: number;
-	    filesSkipped: number;
-	    errors: string[];
-	    durationMs: number;
-	    duplicateGroups: number;
+	export class MetadataEdit {
+	    takenAt?: string;
+	    cameraMake?: string;
+	    cameraModel?: string;
+	    timeOffsetMinutes?: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new Summary(source);
+	        return new MetadataEdit(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.filesDiscovered = source["filesDiscovered"];
-	        this.filesPersisted = source["filesPersisted"];
-	        this.filesSkipped = source["filesSkipped"];
-	        this.errors = source["errors"];
-	        this.durationMs = source["durationMs"];
-	        this.duplicateGroups = source["duplicateGroups"];
+	        this.takenAt = source["takenAt"];
+	        this.cameraMake = source["cameraMake"];
+	        this.cameraModel = source["cameraModel"];
+	        this.timeOffsetMinutes = source["timeOffsetMinutes"];
 	    }
 	}
-	export class TidySummary {
+	export class Page {
+	    limit: number;
+	    offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Page(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.limit = source["limit"];
+	        this.offset = source["offset"];
+	    }
+	}
+	export class Person {
+	    id: number;
+	    name: string;
+	    faces: number;
+	    media: number;
+	    coverMediaId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Person(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.faces = source["faces"];
+	        this.media = source["media"];
+	        this.coverMediaId = source["coverMediaId"];
+	    }
+	}
+	export class ScanError {
+	    id: number;
+	    sessionId: number;
+	    stage: string;
+	    path?: string;
+	    message: string;
+	    createdAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sessionId = source["sessionId"];
+	        this.stage = source["stage"];
+	        this.path = source["path"];
+	        this.message = source["message"];
+	        this.createdAt = source["createdAt"];
+	    }
+	}
+	export class ScanSession {
+	    id: number;
+	    jobId: string;
+	    sources: string[];
+	    status: string;
+	    summary?: number[];
+	    startedAt: string;
+	    updatedAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.jobId = source["jobId"];
+	        this.sources = source["sources"];
+	        this.status = source["status"];
+	        this.summary = source["summary"];
+	        this.startedAt = source["startedAt"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class SearchPage {
+	    media: MediaFile[];
 	    total: number;
-	    moved: number;
-	    skipped: number;
-	    failed: number;
-	    durationMs: number;
-	    dryRun: boolean;
-	    targetBase: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new TidySummary(source);
+	        return new SearchPage(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.media = this.convertValues(source["media"], MediaFile);
 	        this.total = source["total"];
-	        this.moved = source["moved"];
-	        this.skipped = source["skipped"];
-	        this.failed = source["failed"];
-	        this.durationMs = source["durationMs"];
-	        this.dryRun = source["dryRun"];
-	        this.targetBase = source["targetBase"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-
-}
-
-export namespace sql {
+	export class StagingCommit {
+	    files: number;
+	    known: number;
+	    junk: number;
 	
-	export class NullString {
-	    String: string;
-	    Valid: boolean;
+	    static createFrom(source: any = {}) {
+	        return new StagingCommit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.known = source["known"];
+	        this.junk = source["junk"];
+	    }
+	}
+	export class StagingSummary {
+	    files: number;
+	    sizeBytes: number;
+	    known: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new NullString(source);
+	        return new StagingSummary(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.String = source["String"];
-	        this.Valid = source["Valid"];
+	        this.files = source["files"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.known = source["known"];
 	    }
 	}
-	export class NullTime {
-	    // Go type: time
-	    Time: any;
-	    Valid: boolean;
+	export class TimelineBucket {
+	    period: string;
+	    count: number;
+	    representatives: MediaFile[];
 	
 	    static createFrom(source: any = {}) {
-	        return new NullTime(source);
+	        return new TimelineBucket(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Time = this.convertValues(source["Time"], null);
-	        this.Valid = source["Valid"];
+	        this.period = source["period"];
+	        this.count = source["count"];
+	        this.representatives = this.convertValues(source["representatives"], MediaFile);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -207,38 +2567,20 @@ export namespace sql {
 		    return a;
 		}
 	}
-
-}
-
-export namespace storage {
-	
-	export class MediaFile {
-	    ID: number;
-	    Path: string;
-	    HashMD5: string;
-	    SizeBytes: number;
-	    // Go type: time
-	    ModTime: any;
-	    TakenAt: sql.NullTime;
-	    CameraMake: sql.NullString;
-	    CameraModel: sql.NullString;
-	    MimeType: sql.NullString;
+	export class TimelinePage {
+	    granularity: string;
+	    buckets: TimelineBucket[];
+	    total: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new MediaFile(source);
+	        return new TimelinePage(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.ID = source["ID"];
-	        this.Path = source["Path"];
-	        this.HashMD5 = source["HashMD5"];
-	        this.SizeBytes = source["SizeBytes"];
-	        this.ModTime = this.convertValues(source["ModTime"], null);
-	        this.TakenAt = this.convertValues(source["TakenAt"], sql.NullTime);
-	        this.CameraMake = this.convertValues(source["CameraMake"], sql.NullString);
-	        this.CameraModel = this.convertValues(source["CameraModel"], sql.NullString);
-	        this.MimeType = this.convertValues(source["MimeType"], sql.NullString);
+	        this.granularity = source["granularity"];
+	        this.buckets = this.convertValues(source["buckets"], TimelineBucket);
+	        this.total = source["total"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -259,18 +2601,40 @@ export namespace storage {
 		    return a;
 		}
 	}
-	export class DuplicateGroup {
-	    Hash: string;
-	    Files: MediaFile[];
+	export class TrashedMedia {
+	    id: number;
+	    path: string;
+	    hashMd5: string;
+	    sizeBytes: number;
+	    reason: string;
+	    deletedAt: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DuplicateGroup(source);
+	        return new TrashedMedia(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Hash = source["Hash"];
-	        this.Files = this.convertValues(source["Files"], MediaFile);
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.hashMd5 = source["hashMd5"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.reason = source["reason"];
+	        this.deletedAt = source["deletedAt"];
+	    }
+	}
+	export class TrashPage {
+	    media: TrashedMedia[];
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.media = this.convertValues(source["media"], TrashedMedia);
+	        this.total = source["total"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -291,6 +2655,92 @@ export namespace storage {
 		    return a;
 		}
 	}
+	export class TrashRestore {
+	    restored: number;
+	    conflicts: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashRestore(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.restored = source["restored"];
+	        this.conflicts = source["conflicts"];
+	    }
+	}
+	
+	export class VerificationResult {
+	    mediaId: number;
+	    path: string;
+	    expectedHash: string;
+	    actualHash?: string;
+	    status: string;
+	    error?: string;
+	    verifiedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VerificationResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mediaId = source["mediaId"];
+	        this.path = source["path"];
+	        this.expectedHash = source["expectedHash"];
+	        this.actualHash = source["actualHash"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.verifiedAt = source["verifiedAt"];
+	    }
+	}
+	export class Volume {
+	    id: string;
+	    label: string;
+	    mountPoint: string;
+	    removable: boolean;
+	    online: boolean;
+	    lastSeen: string;
+	    files: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Volume(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.mountPoint = source["mountPoint"];
+	        this.removable = source["removable"];
+	        this.online = source["online"];
+	        this.lastSeen = source["lastSeen"];
+	        this.files = source["files"];
+	    }
+	}
+
+}
+
+export namespace volume {
+	
+	export class Info {
+	    id: string;
+	    label: string;
+	    mountPoint: string;
+	    removable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Info(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.mountPoint = source["mountPoint"];
+	        this.removable = source["removable"];
+	    }
+	}
 
 }
 
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/rpc"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// rpcEventBuffer is how many events a gRPC stream holds while its client
+// reads slowly; progress beyond that is dropped, not the job held up.
+const rpcEventBuffer = 256
+
+// serveGRPC starts, restarts or stops the gRPC service to match the
+// settings. Calls share jobMu with the window, so while a scan started over
+// gRPC runs the window reports busy, and the other way round.
+func (a *App) serveGRPC() {
+	a.rpcMu.Lock()
+	defer a.rpcMu.Unlock()
+
+	cfg := a.settings.GRPC
+	if a.rpcServer != nil && cfg == a.rpcConfig {
+		return
+	}
+	if a.rpcServer != nil {
+		_ = a.rpcServer.Close()
+		a.rpcServer = nil
+		a.logger.Info("grpc stopped")
+	}
+	a.rpcConfig = cfg
+	if !cfg.Enabled {
+		return
+	}
+
+	addr := cfg.ListenAddress()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		a.logger.Error("grpc listen", "address", addr, "error", err)
+		return
+	}
+	srv := rpc.NewServer(cfg.Token)
+	a.registerRPC(srv)
+	a.rpcServer = srv
+	go func() {
+		if err := srv.Serve(l); err != nil {
+			a.logger.Error("grpc stopped", "error", err)
+		}
+	}()
+	a.logger.Info("grpc listening", "address", l.Addr().String(), "token", cfg.Token != "")
+}
+
+// stopGRPC closes the gRPC service, ending the calls in progress.
+func (a *App) stopGRPC() {
+	a.rpcMu.Lock()
+	defer a.rpcMu.Unlock()
+	if a.rpcServer != nil {
+		_ = a.rpcServer.Close()
+		a.rpcServer = nil
+	}
+}
+
+// registerRPC binds the methods of phototidy.proto to the app.
+func (a *App) registerRPC(srv *rpc.Server) {
+	srv.Handle("Scan", func(ctx context.Context, b []byte, send func([]byte) error) error {
+		var req rpc.ScanRequest
+		if err := req.Unmarshal(b); err != nil {
+			return rpc.Errorf(rpc.InvalidArgument, "decode request: %v", err)
+		}
+		return a.streamJob(ctx, "scan", "RunScan", events.ScanSummaryVersion, send, func() (interface{}, error) {
+			if len(req.Sources) == 0 {
+				return a.scan(req.Incremental)
+			}
+			return a.ImportFolders(req.Sources, "")
+		})
+	})
+
+	srv.Handle("Tidy", func(ctx context.Context, b []byte, send func([]byte) error) error {
+		var req rpc.TidyRequest
+		if err := req.Unmarshal(b); err != nil {
+			return rpc.Errorf(rpc.InvalidArgument, "decode request: %v", err)
+		}
+		requests, err := a.rpcMoveRequests(ctx, req.MediaIDs)
+		if err != nil {
+			return err
+		}
+		return a.streamJob(ctx, "tidy", "ExecuteTidy", events.TidySummaryVersion, send, func() (interface{}, error) {
+			return a.runTidy(requests, req.DryRun, media.SafetyLevel(strings.ToLower(req.Safety)), false, nil)
+		})
+	})
+
+	srv.Handle("CancelScan", func(ctx context.Context, b []byte, send func([]byte) error) error {
+		if err := rpc.Empty(b); err != nil {
+			return rpc.Errorf(rpc.InvalidArgument, "%v", err)
+		}
+		return send(rpc.CancelScanResponse{Cancelled: a.CancelScan()}.Marshal())
+	})
+
+	srv.Handle("Search", func(ctx context.Context, b []byte, send func([]byte) error) error {
+		var req rpc.SearchRequest
+		if err := req.Unmarshal(b); err != nil {
+			return rpc.Errorf(rpc.InvalidArgument, "decode request: %v", err)
+		}
+		if req.Limit < 0 || req.Offset < 0 {
+			return rpc.Errorf(rpc.InvalidArgument, "limit and offset must not be negative")
+		}
+		if a.store == nil {
+			return rpc.Errorf(rpc.FailedPrecondition, "store not initialised")
+		}
+		page, err := a.store.SearchMedia(ctx, req.Query, storage.Page{Limit: int(req.Limit), Offset: int(req.Offset)})
+		if err != nil {
+			return err
+		}
+		resp := rpc.SearchResponse{Total: int32(page.Total)}
+		for _, file := range page.Media {
+			resp.Media = append(resp.Media, rpcMedia(file))
+		}
+		return send(resp.Marshal())
+	})
+
+	srv.Handle("GetStatus", func(ctx context.Context, b []byte, send func([]byte) error) error {
+		if err := rpc.Empty(b); err != nil {
+			return rpc.Errorf(rpc.InvalidArgument, "%v", err)
+		}
+		status := rpc.Status{Busy: !a.jobMu.TryLock(), PauseReasons: a.gate.Reasons()}
+		if !status.Busy {
+			a.jobMu.Unlock()
+		}
+		return send(status.Marshal())
+	})
+}
+
+// rpcMoveRequests turns the media IDs of a tidy call into move requests;
+// none means every file outside the target folder, as an automatic tidy
+// picks them.
+func (a *App) rpcMoveRequests(ctx context.Context, ids []int64) ([]media.MoveRequest, error) {
+	if len(ids) == 0 {
+		if a.store == nil || a.settings == nil {
+			return nil, rpc.Errorf(rpc.FailedPrecondition, "store not initialised")
+		}
+		pending, err := a.store.ListMediaOutside(ctx, a.settings.Target.BaseFolder)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range pending {
+			ids = append(ids, file.ID)
+		}
+	}
+	requests := make([]media.MoveRequest, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, media.MoveRequest{MediaID: id})
+	}
+	return requests, nil
+}
+
+// streamJob runs job and sends every event it emits, those whose job ID is
+// of the given kind, followed by its summary typed summaryName. The job
+// belongs to the app rather than the call: a client hanging up stops the
+// stream, not the job, which CancelScan stops as in the window.
+func (a *App) streamJob(ctx context.Context, kind, summaryName string, version int, send func([]byte) error, job func() (interface{}, error)) error {
+	sub, unsubscribe := a.hub.Subscribe(rpcEventBuffer)
+	defer unsubscribe()
+
+	type result struct {
+		summary interface{}
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		summary, err := job()
+		done <- result{summary, err}
+	}()
+
+	var jobID string
+	forward := func(env events.Envelope) error {
+		if !strings.HasPrefix(env.JobID, kind+"-") {
+			return nil
+		}
+		jobID = env.JobID
+		return sendEnvelope(send, env)
+	}
+	for {
+		select {
+		case env := <-sub:
+			if err := forward(env); err != nil {
+				return err
+			}
+		case res := <-done:
+			if res.err != nil {
+				if errors.Is(res.err, errBusy) {
+					return rpc.Errorf(rpc.Unavailable, "%v", res.err)
+				}
+				if errors.Is(res.err, errReadOnly) {
+					return rpc.Errorf(rpc.FailedPrecondition, "%v", res.err)
+				}
+				return res.err
+			}
+			// The job emitted everything before returning; pass on what
+			// is still buffered.
+			for drained := false; !drained; {
+				select {
+				case env := <-sub:
+					if err := forward(env); err != nil {
+						return err
+					}
+				default:
+					drained = true
+				}
+			}
+			env := events.Wrap(jobID, summaryName, res.summary)
+			env.Version = version
+			return sendEnvelope(send, env)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendEnvelope sends env as an Event with its payload as JSON.
+func sendEnvelope(send func([]byte) error, env events.Envelope) error {
+	payload, err := json.Marshal(env.Payload)
+	if err != nil {
+		return err
+	}
+	return send(rpc.Event{
+		Type:     env.Type,
+		Version:  int32(env.Version),
+		JobID:    env.JobID,
+		Sequence: env.Sequence,
+		Payload:  payload,
+	}.Marshal())
+}
+
+// rpcMedia converts a library file to its message.
+func rpcMedia(file storage.MediaFile) rpc.Media {
+	m := rpc.Media{
+		ID:          file.ID,
+		Path:        file.Path,
+		HashMD5:     file.HashMD5,
+		SizeBytes:   file.SizeBytes,
+		CameraMake:  file.CameraMake.String,
+		CameraModel: file.CameraModel.String,
+		MimeType:    file.MimeType.String,
+		Category:    file.Category,
+		Tags:        file.Tags,
+	}
+	if file.TakenAt.Valid {
+		m.TakenAt = file.TakenAt.Time.Format("2006-01-02T15:04:05")
+	}
+	return m
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// InboxSummary reports every stage of an inbox import.
+type InboxSummary struct {
+	Scan       media.Summary        `json:"scan"`
+	Duplicates media.RemovalSummary `json:"duplicates"`
+	Tidy       media.TidySummary    `json:"tidy"`
+	Cleanup    media.RemovalSummary `json:"cleanup"`
+}
+
+// ImportInbox scans the inbox folder, deletes files whose content the
+// library already holds on disk, tidies the rest into the target structure
+// and removes the folders left behind.
+func (a *App) ImportInbox(dryRun bool) (InboxSummary, error) {
+	var summary InboxSummary
+	if a.scanner == nil || a.remover == nil || a.settings == nil {
+		return summary, errors.New("scanner not initialised")
+	}
+	inbox := a.settings.Scan.InboxFolder
+	if strings.TrimSpace(inbox) == "" {
+		return summary, errors.New("inbox folder is not configured")
+	}
+	if !dryRun {
+		if err := a.checkWritable(); err != nil {
+			return summary, err
+		}
+	}
+	if !a.jobMu.TryLock() {
+		return summary, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	var err error
+	if summary.Scan, err = a.scanSources(media.Options{Sources: []string{inbox}}); err != nil || summary.Scan.Cancelled {
+		return summary, err
+	}
+
+	files, err := a.store.ListMediaUnder(a.ctx, inbox)
+	if err != nil {
+		return summary, err
+	}
+
+	// The first copy of each hash is imported; later inbox copies and
+	// copies the library verifiably holds are removed.
+	seen := make(map[string]bool, len(files))
+	var duplicates []int64
+	requests := make([]media.MoveRequest, 0, len(files))
+	for _, file := range files {
+		if file.HashMD5 != "" {
+			exists := seen[file.HashMD5]
+			if !exists {
+				if exists, err = media.HeldInLibrary(a.ctx, a.store, file.HashMD5, file.SizeBytes, inbox, a.throttle); err != nil {
+					return summary, err
+				}
+			}
+			seen[file.HashMD5] = true
+			if exists {
+				duplicates = append(duplicates, file.ID)
+				continue
+			}
+		}
+		requests = append(requests, media.MoveRequest{MediaID: file.ID})
+	}
+
+	if summary.Duplicates, err = a.remover.DeleteMedia(a.ctx, duplicates, nil, dryRun); err != nil {
+		return summary, err
+	}
+	if !dryRun && summary.Duplicates.Removed > 0 {
+		a.recordSnapshot(storage.SnapshotRemove, summary.Duplicates.BytesReclaimed)
+	}
+	if summary.Tidy, err = a.tidyFiles(requests, dryRun, media.SafetyStandard, false, nil); err != nil {
+		return summary, err
+	}
+	if summary.Cleanup, err = a.remover.CleanEmptyDirs(a.ctx, []string{inbox}, dryRun); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+)
+
+// OpenFolders is emitted with folders given on the command line, either at
+// launch or by a second launch handing them to the running window.
+type OpenFolders struct {
+	Folders []string `json:"folders"`
+	// Forwarded is set when a second launch passed them on.
+	Forwarded bool `json:"forwarded"`
+}
+
+// instanceID names the single-instance lock after the settings file, so a
+// portable copy with a library of its own can run beside the installed
+// app while two windows on the same database cannot. Only letters, digits
+// and underscores are used, as D-Bus names and paths require on Linux.
+func instanceID(settingsPath string) string {
+	if abs, err := filepath.Abs(settingsPath); err == nil {
+		settingsPath = abs
+	}
+	sum := sha256.Sum256([]byte(settingsPath))
+	return "photoTidyGo_" + hex.EncodeToString(sum[:8])
+}
+
+// folderArgs returns the arguments naming existing folders, relative ones
+// resolved against dir; files and flags are ignored.
+func folderArgs(args []string, dir string) []string {
+	var folders []string
+	for _, arg := range args {
+		if arg == "" || arg[0] == '-' {
+			continue
+		}
+		if !filepath.IsAbs(arg) {
+			arg = filepath.Join(dir, arg)
+		}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			folders = append(folders, filepath.Clean(arg))
+		}
+	}
+	return folders
+}
+
+// onSecondInstance runs when the app is launched again while this window is
+// open. The second process exits after handing over its arguments, so the
+// window is brought forward and told about any folders it was given.
+func (a *App) onSecondInstance(data options.SecondInstanceData) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.WindowUnminimise(a.ctx)
+	runtime.Show(a.ctx)
+
+	folders := folderArgs(data.Args, data.WorkingDirectory)
+	a.logger.Info("second launch handed over", "args", data.Args, "folders", folders)
+	if len(folders) > 0 {
+		// Emitting from the callback can block the UI thread it runs on.
+		go a.emit("", events.OpenFolders, OpenFolders{Folders: folders, Forwarded: true})
+	}
+}
+
+// GetLaunchFolders returns the folders given on the command line of this
+// launch, for a window that loads after open:folders was emitted.
+func (a *App) GetLaunchFolders() []string {
+	return a.launchFolders
+}
@@ -0,0 +1,210 @@
+// Package applog writes structured JSON logs to size-rotated files next to
+// the database and reads them back for the UI.
+package applog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fileName = "phototidy.log"
+	maxBytes = 5 << 20
+	keep     = 3
+)
+
+// Logger is a slog.Logger backed by a rotating file.
+type Logger struct {
+	*slog.Logger
+	dir string
+	w   *rotatingWriter
+}
+
+// Open starts logging to dir, creating it when needed.
+func Open(dir string) (*Logger, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create log folder: %w", err)
+	}
+	w := &rotatingWriter{path: filepath.Join(dir, fileName)}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return &Logger{
+		Logger: slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		dir:    dir,
+		w:      w,
+	}, nil
+}
+
+// Discard returns a Logger that drops everything, used until settings load.
+func Discard() *Logger {
+	return &Logger{Logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+}
+
+// Dir returns the folder holding the log files.
+func (l *Logger) Dir() string {
+	return l.dir
+}
+
+// Close flushes and closes the log file.
+func (l *Logger) Close() error {
+	if l.w == nil {
+		return nil
+	}
+	return l.w.Close()
+}
+
+// rotatingWriter renames the log to .1, .2, ... once it exceeds maxBytes.
+type rotatingWriter struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log: %w", err)
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size+int64(len(p)) > maxBytes && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	for i := keep - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return fmt.Errorf("rotate log: %w", err)
+	}
+	return w.open()
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Entry is one parsed log line.
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Recent returns up to n entries at or above level ("debug", "info", "warn",
+// "error"; empty means info), newest first.
+func Recent(dir string, n int, level string) ([]Entry, error) {
+	var min slog.Level
+	if level != "" {
+		if err := min.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("unknown log level %q", level)
+		}
+	}
+	if n <= 0 {
+		n = 100
+	}
+
+	entries := []Entry{}
+	paths := []string{filepath.Join(dir, fileName)}
+	for i := 1; i <= keep; i++ {
+		paths = append(paths, fmt.Sprintf("%s.%d", paths[0], i))
+	}
+
+	for _, path := range paths {
+		lines, err := readLines(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		for i := len(lines) - 1; i >= 0 && len(entries) < n; i-- {
+			entry, ok := parseLine(lines[i])
+			if !ok {
+				continue
+			}
+			var lvl slog.Level
+			if lvl.UnmarshalText([]byte(entry.Level)) == nil && lvl < min {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) >= n {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Entry, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return Entry{}, false
+	}
+
+	entry := Entry{}
+	if ts, ok := raw[slog.TimeKey].(string); ok {
+		entry.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	entry.Level, _ = raw[slog.LevelKey].(string)
+	entry.Message, _ = raw[slog.MessageKey].(string)
+	entry.Level = strings.ToLower(entry.Level)
+	delete(raw, slog.TimeKey)
+	delete(raw, slog.LevelKey)
+	delete(raw, slog.MessageKey)
+	if len(raw) > 0 {
+		entry.Attrs = raw
+	}
+	return entry, true
+}
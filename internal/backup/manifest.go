@@ -0,0 +1,197 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// Manifest algorithms. The checksum ones write the format of the matching
+// coreutils tool (md5sum, sha1sum, sha256sum), so `sha256sum -c` verifies a
+// copy; par2 writes one path per line to hand to `par2 create`.
+const (
+	ManifestMD5    = "md5"
+	ManifestSHA1   = "sha1"
+	ManifestSHA256 = "sha256"
+	ManifestPAR2   = "par2"
+)
+
+// ManifestOptions configures a manifest export.
+type ManifestOptions struct {
+	Algorithm string
+	// Base, when set, makes the paths of files below it relative, so the
+	// manifest is checked from inside that folder; other paths stay
+	// absolute.
+	Base string
+	// Gate, when set, can pause hashing between files.
+	Gate *media.PauseGate
+	// Throttle paces file reads; nil disables throttling.
+	Throttle *media.Throttle
+}
+
+// ManifestSummary reports an exported manifest.
+type ManifestSummary struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Files     int    `json:"files"`
+	// Skipped counts archive entries and files on remote targets, which
+	// external tools cannot open.
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// manifestLine is one listed file.
+type manifestLine struct {
+	name string
+	sum  string
+}
+
+// WriteManifest lists files in a manifest at dest. MD5 manifests reuse the
+// hashes recorded by scans; the other algorithms read every file. The
+// manifest is written to a temporary file first, so an interrupted export
+// leaves any earlier manifest intact.
+func WriteManifest(ctx context.Context, dest string, files []storage.MediaFile, opts ManifestOptions) (summary ManifestSummary, err error) {
+	start := time.Now()
+	algorithm := strings.ToLower(strings.TrimSpace(opts.Algorithm))
+	summary = ManifestSummary{Path: dest, Algorithm: algorithm}
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	var newHash func() hash.Hash
+	switch algorithm {
+	case ManifestMD5:
+		newHash = md5.New
+	case ManifestSHA1:
+		newHash = sha1.New
+	case ManifestSHA256:
+		newHash = sha256.New
+	case ManifestPAR2:
+	default:
+		return summary, fmt.Errorf("unknown manifest algorithm %q", opts.Algorithm)
+	}
+
+	lines := make([]manifestLine, 0, len(files))
+	for _, file := range files {
+		if media.IsArchivePath(file.Path) || media.IsRemote(file.Path) {
+			summary.Skipped++
+			continue
+		}
+		line := manifestLine{name: manifestName(opts.Base, file.Path)}
+		if algorithm == ManifestPAR2 {
+			// par2 reads plain lines; a name with a line break cannot be listed.
+			if strings.ContainsAny(line.name, "\r\n") {
+				summary.Skipped++
+				continue
+			}
+			if _, err := os.Stat(file.Path); err != nil {
+				summary.Failed++
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+				continue
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		if algorithm == ManifestMD5 && file.HashMD5 != "" {
+			line.sum = file.HashMD5
+		} else {
+			if err := opts.Gate.Wait(ctx); err != nil {
+				return summary, err
+			}
+			if err := opts.Throttle.Between(ctx); err != nil {
+				return summary, err
+			}
+			sum, err := hashFile(file.Path, newHash(), opts.Throttle)
+			if err != nil {
+				summary.Failed++
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+				continue
+			}
+			line.sum = sum
+		}
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].name < lines[j].name })
+
+	if err := writeManifestFile(dest, lines); err != nil {
+		return summary, err
+	}
+	summary.Files = len(lines)
+	return summary, nil
+}
+
+// manifestName is the path listed for file: relative to base when below it,
+// with forward slashes either way.
+func manifestName(base, file string) string {
+	if base != "" {
+		if rel, err := filepath.Rel(base, file); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+func hashFile(path string, h hash.Hash, throttle *media.Throttle) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, throttle.Reader(f)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeManifestFile writes lines to a temporary file beside dest and renames
+// it into place.
+func writeManifestFile(dest string, lines []manifestLine) error {
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		if line.sum == "" {
+			fmt.Fprintln(w, line.name)
+			continue
+		}
+		// Like coreutils, names with a backslash or line break are escaped
+		// and the line is marked with a leading backslash.
+		prefix, name := "", line.name
+		if strings.ContainsAny(name, "\\\n\r") {
+			prefix = `\`
+			name = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(name)
+		}
+		fmt.Fprintf(w, "%s%s  %s\n", prefix, line.sum, name)
+	}
+	err = w.Flush()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
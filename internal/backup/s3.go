@@ -0,0 +1,283 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds one request, long enough for a large part on a
+// slow uplink.
+const requestTimeout = 15 * time.Minute
+
+// Config locates a bucket on an S3-compatible service.
+type Config struct {
+	// Endpoint is the service URL; empty means AWS S3 in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKeyID and SecretAccessKey sign every request.
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as the first path segment instead of
+	// a subdomain, as MinIO and most NAS gateways need.
+	PathStyle bool
+}
+
+// Client speaks the subset of the S3 API backups need: single and
+// multipart uploads. It works with AWS S3, Backblaze B2, MinIO and other
+// services implementing Signature Version 4.
+type Client struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	http      *http.Client
+}
+
+// Part is one uploaded part of a multipart upload.
+type Part struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+	Size   int64  `xml:"Size"`
+}
+
+// APIError is an error response from the service.
+type APIError struct {
+	Status  int    `xml:"-"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// NewClient validates cfg and returns a client for its bucket.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("backup bucket is not configured")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("backup credentials are not configured")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid backup endpoint %q", endpoint)
+	}
+	return &Client{
+		endpoint:  u,
+		region:    region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		pathStyle: cfg.PathStyle,
+		http:      &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Target names the object stored under key, as recorded in upload actions.
+func (c *Client) Target(key string) string {
+	return "s3://" + c.bucket + "/" + key
+}
+
+// HeadBucket checks that the bucket exists and the credentials reach it.
+func (c *Client) HeadBucket(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodHead, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PutObject stores body under key in one request and returns its ETag.
+func (c *Client) PutObject(ctx context.Context, key string, body []byte) (string, error) {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// CreateMultipartUpload starts a multipart upload to key and returns its ID.
+func (c *Client) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := c.doXML(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: no upload ID returned")
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart sends part number n of an upload and returns its ETag.
+func (c *Client) UploadPart(ctx context.Context, key, uploadID string, n int, body []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+	resp, err := c.do(ctx, http.MethodPut, key, query, body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// ListParts returns the parts the service already holds for an upload.
+func (c *Client) ListParts(ctx context.Context, key, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := ""
+	for {
+		query := url.Values{"uploadId": {uploadID}}
+		if marker != "" {
+			query.Set("part-number-marker", marker)
+		}
+		var result struct {
+			Parts       []Part `xml:"Part"`
+			IsTruncated bool   `xml:"IsTruncated"`
+			NextMarker  string `xml:"NextPartNumberMarker"`
+		}
+		if err := c.doXML(ctx, http.MethodGet, key, query, nil, &result); err != nil {
+			return nil, err
+		}
+		parts = append(parts, result.Parts...)
+		if !result.IsTruncated || result.NextMarker == "" || result.NextMarker == marker {
+			return parts, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, in order, into the
+// object.
+func (c *Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	type completePart struct {
+		Number int    `xml:"PartNumber"`
+		ETag   string `xml:"ETag"`
+	}
+	request := struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}{}
+	for _, part := range parts {
+		request.Parts = append(request.Parts, completePart{Number: part.Number, ETag: part.ETag})
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+	// The service may report a failure with status 200 once it has started
+	// assembling, so the body is checked for an error document.
+	var result struct {
+		XMLName xml.Name
+		APIError
+	}
+	if err := c.doXML(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, &result); err != nil {
+		return err
+	}
+	if result.XMLName.Local == "Error" {
+		result.APIError.Status = http.StatusOK
+		return &result.APIError
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards an upload and the parts sent so far.
+func (c *Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// IsNoSuchUpload reports whether err says an upload ID is unknown, for
+// example because the service expired it.
+func IsNoSuchUpload(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.Code == "NoSuchUpload" || apiErr.Status == http.StatusNotFound)
+}
+
+// objectURL addresses key in the bucket, virtual-host or path style.
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	base := strings.TrimRight(u.Path, "/")
+	if c.pathStyle {
+		base += "/" + c.bucket
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path = base + "/" + key
+	u.RawPath = uriEncode(base, false) + "/" + uriEncode(key, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do sends one signed request and returns the response when it succeeded;
+// the caller closes its body.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if body != nil {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	c.sign(req, hashHex(body), time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	apiErr := &APIError{Status: resp.StatusCode}
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil {
+		xml.Unmarshal(data, apiErr)
+	}
+	return nil, apiErr
+}
+
+// doXML sends a request and decodes the XML response into out.
+func (c *Client) doXML(ctx context.Context, method, key string, query url.Values, body []byte, out interface{}) error {
+	resp, err := c.do(ctx, method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("s3 response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// Part sizes in bytes. S3 rejects parts below 5 MiB other than the last.
+const (
+	MinPartSize     = 5 << 20
+	DefaultPartSize = 16 << 20
+)
+
+// actionUpload is the file_actions type of an upload.
+const actionUpload = "upload"
+
+// Upload statuses reported per file.
+const (
+	StatusUploaded = "uploaded"
+	StatusSkipped  = "skipped"
+	StatusFailed   = "failed"
+)
+
+// Options configures one backup run.
+type Options struct {
+	// Base is the target folder; object keys mirror the paths below it, so
+	// the bucket follows the target pattern. Files outside it, and entries
+	// of archives, are skipped.
+	Base string
+	// Prefix is prepended to every key.
+	Prefix string
+	// PartSize splits files larger than it into a multipart upload.
+	PartSize int64
+	// Gate, when set, can pause the run between files and parts.
+	Gate *media.PauseGate
+	// Throttle paces file reads; nil disables throttling.
+	Throttle *media.Throttle
+}
+
+// Progress reports one file handled by a backup run.
+type Progress struct {
+	MediaID       int64  `json:"mediaId"`
+	Source        string `json:"source"`
+	Target        string `json:"target"`
+	Completed     int    `json:"completed"`
+	Total         int    `json:"total"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+	BytesUploaded int64  `json:"bytesUploaded"`
+}
+
+// Summary summarises a backup run.
+type Summary struct {
+	Total         int    `json:"total"`
+	Uploaded      int    `json:"uploaded"`
+	Skipped       int    `json:"skipped"`
+	Failed        int    `json:"failed"`
+	BytesUploaded int64  `json:"bytesUploaded"`
+	DurationMS    int64  `json:"durationMs"`
+	Destination   string `json:"destination"`
+}
+
+// Uploader copies library files to object storage, recording one upload
+// action per file.
+type Uploader struct {
+	store  *storage.Store
+	client *Client
+}
+
+// NewUploader constructs an Uploader writing through client.
+func NewUploader(store *storage.Store, client *Client) *Uploader {
+	return &Uploader{store: store, client: client}
+}
+
+// ObjectKey maps a file below base to its key: prefix joined with the
+// slash-separated relative path. ok is false for files outside base.
+func ObjectKey(base, prefix, file string) (string, bool) {
+	rel, err := filepath.Rel(base, file)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	key := filepath.ToSlash(rel)
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		key = path.Join(prefix, key)
+	}
+	return key, true
+}
+
+// Upload sends files to the bucket. Files whose current content was
+// already uploaded to the same key are skipped, and multipart uploads left
+// unfinished by an earlier run resume with the parts already sent.
+func (u *Uploader) Upload(ctx context.Context, files []storage.MediaFile, opts Options, onProgress func(Progress)) (summary Summary, err error) {
+	start := time.Now()
+	summary = Summary{Total: len(files), Destination: u.client.Target(strings.Trim(opts.Prefix, "/"))}
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	if opts.Base == "" {
+		return summary, errors.New("backup needs a target base folder")
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.PartSize < MinPartSize {
+		return summary, fmt.Errorf("backup part size must be at least %d bytes", MinPartSize)
+	}
+	uploaded, err := u.store.UploadedTargets(ctx, u.client.Target(""))
+	if err != nil {
+		return summary, err
+	}
+
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+
+		progress := Progress{MediaID: file.ID, Source: file.Path, Completed: i + 1, Total: len(files)}
+		key, ok := ObjectKey(opts.Base, opts.Prefix, file.Path)
+		switch {
+		case !ok || media.IsArchivePath(file.Path):
+			progress.Status = StatusSkipped
+			summary.Skipped++
+		case uploaded[u.client.Target(key)] == file.HashMD5:
+			progress.Target = u.client.Target(key)
+			progress.Status = StatusSkipped
+			summary.Skipped++
+		default:
+			progress.Target = u.client.Target(key)
+			err := u.uploadFile(ctx, file, key, opts)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return summary, ctxErr
+			}
+			if err != nil {
+				progress.Status = StatusFailed
+				progress.Error = err.Error()
+				summary.Failed++
+				break
+			}
+			uploaded[progress.Target] = file.HashMD5
+			progress.Status = StatusUploaded
+			summary.Uploaded++
+			summary.BytesUploaded += file.SizeBytes
+		}
+		progress.BytesUploaded = summary.BytesUploaded
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
+
+// uploadFile sends one file under key and records the attempt.
+func (u *Uploader) uploadFile(ctx context.Context, file storage.MediaFile, key string, opts Options) error {
+	target := u.client.Target(key)
+	actionID, err := u.store.CreateAction(ctx, storage.FileAction{
+		MediaID:    sql.NullInt64{Int64: file.ID, Valid: true},
+		SourcePath: file.Path,
+		TargetPath: target,
+		ActionType: actionUpload,
+		Status:     storage.ActionStatusPending,
+		HashMD5:    sql.NullString{String: file.HashMD5, Valid: file.HashMD5 != ""},
+	})
+	if err != nil {
+		return err
+	}
+
+	if file.SizeBytes <= opts.PartSize {
+		err = u.putFile(ctx, file, key, opts)
+	} else {
+		err = u.putMultipart(ctx, file, key, opts)
+	}
+
+	// The outcome is recorded even when ctx was cancelled mid-file.
+	if err != nil {
+		msg := err.Error()
+		if markErr := u.store.MarkAction(context.WithoutCancel(ctx), actionID, storage.ActionStatusFailed, &msg); markErr != nil {
+			return errors.Join(err, markErr)
+		}
+		return err
+	}
+	return u.store.MarkAction(context.WithoutCancel(ctx), actionID, storage.ActionStatusCompleted, nil)
+}
+
+// putFile uploads a file that fits in one part with a single request.
+func (u *Uploader) putFile(ctx context.Context, file storage.MediaFile, key string, opts Options) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	body, err := io.ReadAll(opts.Throttle.Reader(f))
+	if err != nil {
+		return err
+	}
+	_, err = u.client.PutObject(ctx, key, body)
+	return err
+}
+
+// putMultipart uploads a large file part by part. The upload ID is stored
+// before the first part, so a later run asks the service which parts it
+// already holds and sends only the rest.
+func (u *Uploader) putMultipart(ctx context.Context, file storage.MediaFile, key string, opts Options) error {
+	target := u.client.Target(key)
+	done := make(map[int]Part)
+
+	session, ok, err := u.store.GetUploadSession(ctx, target)
+	if err != nil {
+		return err
+	}
+	if ok && (session.HashMD5 != file.HashMD5 || session.PartSize != opts.PartSize) {
+		// The file changed or the part size did: the old parts are useless.
+		u.client.AbortMultipartUpload(ctx, key, session.UploadID)
+		ok = false
+	}
+	if ok {
+		parts, err := u.client.ListParts(ctx, key, session.UploadID)
+		switch {
+		case IsNoSuchUpload(err):
+			ok = false
+		case err != nil:
+			return err
+		default:
+			for _, part := range parts {
+				done[part.Number] = part
+			}
+		}
+	}
+	if !ok {
+		uploadID, err := u.client.CreateMultipartUpload(ctx, key)
+		if err != nil {
+			return err
+		}
+		session = storage.UploadSession{Target: target, UploadID: uploadID, HashMD5: file.HashMD5, PartSize: opts.PartSize}
+		if err := u.store.SaveUploadSession(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	buf := make([]byte, opts.PartSize)
+	var parts []Part
+	for n, offset := 1, int64(0); offset < size; n, offset = n+1, offset+opts.PartSize {
+		length := min(opts.PartSize, size-offset)
+		if part, ok := done[n]; ok && part.Size == length && part.ETag != "" {
+			parts = append(parts, part)
+			continue
+		}
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return err
+		}
+		body := buf[:length]
+		if _, err := io.ReadFull(opts.Throttle.Reader(io.NewSectionReader(f, offset, length)), body); err != nil {
+			return err
+		}
+		etag, err := u.client.UploadPart(ctx, key, session.UploadID, n, body)
+		if err != nil {
+			return fmt.Errorf("part %d: %w", n, err)
+		}
+		parts = append(parts, Part{Number: n, ETag: etag, Size: length})
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	if err := u.client.CompleteMultipartUpload(ctx, key, session.UploadID, parts); err != nil {
+		return err
+	}
+	return u.store.DeleteUploadSession(ctx, target)
+}
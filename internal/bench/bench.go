@@ -0,0 +1,185 @@
+package bench
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// Options configures a storage benchmark.
+type Options struct {
+	// TargetDir is the volume the copy test writes to.
+	TargetDir string
+	// SizeMB is the size of the generated sample file.
+	SizeMB int
+	// Rows is the number of SQLite rows inserted.
+	Rows int
+}
+
+// HashResult reports the throughput of one hash algorithm.
+type HashResult struct {
+	Algorithm string  `json:"algorithm"`
+	MBPerSec  float64 `json:"mbPerSec"`
+}
+
+// Result summarises a benchmark run.
+type Result struct {
+	SampleMB      int          `json:"sampleMb"`
+	Hashes        []HashResult `json:"hashes"`
+	InsertsPerSec float64      `json:"insertsPerSec"`
+	CopyMBPerSec  float64      `json:"copyMbPerSec"`
+	TargetDir     string       `json:"targetDir"`
+	DurationMS    int64        `json:"durationMs"`
+}
+
+// Run measures hash throughput, SQLite insert rate and copy speed to the
+// target volume using throwaway files that are removed afterwards.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if opts.SizeMB <= 0 {
+		opts.SizeMB = 64
+	}
+	if opts.Rows <= 0 {
+		opts.Rows = 2000
+	}
+	if opts.TargetDir == "" {
+		opts.TargetDir = os.TempDir()
+	}
+
+	start := time.Now()
+	result := Result{SampleMB: opts.SizeMB, TargetDir: opts.TargetDir}
+
+	work, err := os.MkdirTemp("", "phototidy-bench-*")
+	if err != nil {
+		return result, fmt.Errorf("create bench dir: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	sample := filepath.Join(work, "sample.bin")
+	if err := writeSample(sample, opts.SizeMB); err != nil {
+		return result, err
+	}
+
+	algorithms := []struct {
+		name string
+		new  func() hash.Hash
+	}{
+		{"md5", md5.New},
+		{"sha1", sha1.New},
+		{"sha256", sha256.New},
+		{"crc32", func() hash.Hash { return crc32.NewIEEE() }},
+	}
+	for _, alg := range algorithms {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		rate, err := hashRate(sample, opts.SizeMB, alg.new())
+		if err != nil {
+			return result, err
+		}
+		result.Hashes = append(result.Hashes, HashResult{Algorithm: alg.name, MBPerSec: rate})
+	}
+
+	if result.InsertsPerSec, err = insertRate(ctx, filepath.Join(work, "bench.db"), opts.Rows); err != nil {
+		return result, err
+	}
+	if result.CopyMBPerSec, err = copyRate(sample, opts.TargetDir, opts.SizeMB); err != nil {
+		return result, err
+	}
+
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+func writeSample(path string, sizeMB int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create sample: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, rand.Reader, int64(sizeMB)<<20); err != nil {
+		return fmt.Errorf("write sample: %w", err)
+	}
+	return f.Sync()
+}
+
+func hashRate(path string, sizeMB int, h hash.Hash) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, fmt.Errorf("hash sample: %w", err)
+	}
+	return perSecond(float64(sizeMB), time.Since(start)), nil
+}
+
+func insertRate(ctx context.Context, dbPath string, rows int) (float64, error) {
+	store, err := storage.New(dbPath)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+
+	start := time.Now()
+	for i := 0; i < rows; i++ {
+		_, err := store.UpsertMediaFile(ctx, storage.MediaFile{
+			Path:      fmt.Sprintf("/bench/%06d.jpg", i),
+			HashMD5:   fmt.Sprintf("%032x", i),
+			SizeBytes: int64(i),
+			ModTime:   start,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return perSecond(float64(rows), time.Since(start)), nil
+}
+
+func copyRate(sample, targetDir string, sizeMB int) (float64, error) {
+	if err := os.MkdirAll(targetDir, 0o755); err != nil {
+		return 0, fmt.Errorf("create target dir: %w", err)
+	}
+	dest, err := os.CreateTemp(targetDir, ".phototidy-bench-*")
+	if err != nil {
+		return 0, fmt.Errorf("create copy target: %w", err)
+	}
+	defer os.Remove(dest.Name())
+	defer dest.Close()
+
+	src, err := os.Open(sample)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	start := time.Now()
+	if _, err := io.Copy(dest, src); err != nil {
+		return 0, fmt.Errorf("copy sample: %w", err)
+	}
+	if err := dest.Sync(); err != nil {
+		return 0, fmt.Errorf("sync copy: %w", err)
+	}
+	return perSecond(float64(sizeMB), time.Since(start)), nil
+}
+
+func perSecond(amount float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return amount / elapsed.Seconds()
+}
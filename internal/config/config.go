@@ -3,25 +3,149 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
+
+	"github.com/deadlyedge/wails-tabs/internal/schedule"
 )
 
 // Settings models the TOML configuration for the application.
 type Settings struct {
-	Database DatabaseConfig `toml:"database"`
-	History  HistoryConfig  `toml:"history"`
-	Scan     ScanConfig     `toml:"scan"`
-	Target   TargetConfig   `toml:"target"`
+	Backup     BackupConfig     `toml:"backup"`
+	Classifier ClassifierConfig `toml:"classifier"`
+	Database   DatabaseConfig   `toml:"database"`
+	Faces      FacesConfig      `toml:"faces"`
+	GRPC       GRPCConfig       `toml:"grpc"`
+	History    HistoryConfig    `toml:"history"`
+	OCR        OCRConfig        `toml:"ocr"`
+	Power      PowerConfig      `toml:"power"`
+	Retention  RetentionConfig  `toml:"retention"`
+	Schedule   ScheduleConfig   `toml:"schedule"`
+	Scan       ScanConfig       `toml:"scan"`
+	Target     TargetConfig     `toml:"target"`
+	Throttle   ThrottleConfig   `toml:"throttle"`
+	Tools      ToolsConfig      `toml:"tools"`
+	// Features toggles experimental subsystems; see FeatureFlags.
+	Features map[string]bool `toml:"features,omitempty"`
+	// Notifications toggles desktop notifications per job type; see
+	// NotificationSettings.
+	Notifications map[string]bool `toml:"notifications,omitempty"`
+	// ReadOnly rejects every job that would move, copy, delete or rewrite
+	// media files, or replace the library's records of them, so others can
+	// browse the library without risk. It guards against accidents, not
+	// against someone editing the settings.
+	ReadOnly bool `toml:"readOnly,omitempty"`
+	// ActiveProfile selects one of Profiles whose tables override the base
+	// settings; empty means the base settings alone.
+	ActiveProfile string             `toml:"activeProfile,omitempty"`
+	Profiles      map[string]Profile `toml:"profiles,omitempty"`
+
+	// base holds the tables as written, before the profile overlay.
+	base Profile
+}
+
+// BackupConfig pushes a copy of the organised library to an S3-compatible
+// bucket (AWS S3, Backblaze B2, MinIO). Object keys mirror the paths below
+// the target base, so the bucket follows the target pattern.
+type BackupConfig struct {
+	// Endpoint is the service URL; empty means AWS S3 in Region.
+	Endpoint string `toml:"endpoint"`
+	Region   string `toml:"region"`
+	// Bucket receives the copies; empty disables backups.
+	Bucket string `toml:"bucket"`
+	// Prefix is prepended to every object key.
+	Prefix string `toml:"prefix"`
+	// AccessKeyID and SecretAccessKey fall back to the AWS_ACCESS_KEY_ID
+	// and AWS_SECRET_ACCESS_KEY environment variables.
+	AccessKeyID     string `toml:"accessKeyId"`
+	SecretAccessKey string `toml:"secretAccessKey"`
+	// PathStyle puts the bucket in the URL path, as MinIO and most NAS
+	// gateways expect.
+	PathStyle bool `toml:"pathStyle"`
+	// PartSizeMB splits larger files into resumable multipart uploads.
+	PartSizeMB int `toml:"partSizeMb"`
+	// AfterTidy uploads the files each non dry-run tidy moved.
+	AfterTidy bool `toml:"afterTidy"`
+}
+
+// ClassifierConfig sets up the image labelling stage, which the
+// imageLabels feature flag switches on.
+type ClassifierConfig struct {
+	// Model is the ONNX model file.
+	Model string `toml:"model"`
+	// Command runs the model: "{model}" is replaced by Model, the image path
+	// is appended, and the program prints
+	// {"labels": [{"label": "beach", "score": 0.92}]}.
+	Command []string `toml:"command"`
+	// MinScore is the lowest confidence a label needs to be kept (default
+	// 0.5).
+	MinScore float64 `toml:"minScore"`
+}
+
+// Configured reports whether a model and a command to run it are set.
+func (c ClassifierConfig) Configured() bool {
+	return strings.TrimSpace(c.Model) != "" && len(c.Command) > 0 && strings.TrimSpace(c.Command[0]) != ""
+}
+
+// FacesConfig selects the backend of the face analysis stage, which the
+// faceDetection feature flag switches on. Endpoint takes precedence.
+type FacesConfig struct {
+	// Endpoint is an HTTP service that receives each image as a POST body
+	// and answers {"faces": [{"box": [x, y, w, h], "embedding": [...]}]}.
+	Endpoint string `toml:"endpoint"`
+	// Command is a program, such as a script running an ONNX model, that
+	// gets the image path as its last argument and prints the same JSON.
+	Command []string `toml:"command"`
+	// Threshold is the cosine distance below which faces count as the same
+	// person (default 0.4).
+	Threshold float64 `toml:"threshold"`
+}
+
+// Configured reports whether a face backend is set.
+func (f FacesConfig) Configured() bool {
+	return strings.TrimSpace(f.Endpoint) != "" || (len(f.Command) > 0 && strings.TrimSpace(f.Command[0]) != "")
+}
+
+// DefaultGRPCAddress is where the gRPC service listens when enabled without
+// an address: localhost only, so other machines cannot reach it.
+const DefaultGRPCAddress = "127.0.0.1:50051"
+
+// GRPCConfig exposes scans, tidy runs and search over the gRPC service in
+// internal/rpc/phototidy.proto, for scripts and home automation.
+type GRPCConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Address is the host:port to listen on (default DefaultGRPCAddress).
+	Address string `toml:"address"`
+	// Token, when set, must accompany every call as a bearer token.
+	Token string `toml:"token"`
+}
+
+// ListenAddress returns Address, or DefaultGRPCAddress when it is empty.
+func (g GRPCConfig) ListenAddress() string {
+	if strings.TrimSpace(g.Address) == "" {
+		return DefaultGRPCAddress
+	}
+	return strings.TrimSpace(g.Address)
+}
+
+// OCRConfig sets up text recognition, which the ocr feature flag switches
+// on and the tesseract tool performs.
+type OCRConfig struct {
+	// Languages are the tesseract languages to read, e.g. "eng+deu"
+	// (default "eng").
+	Languages string `toml:"languages"`
 }
 
 // DatabaseConfig controls file persistence.
 type DatabaseConfig struct {
 	BaseFolder string `toml:"baseFolder"`
 	FileName   string `toml:"fileName"`
+	// BackupBeforeTidy snapshots the database before every non dry-run tidy.
+	BackupBeforeTidy bool `toml:"backupBeforeTidy"`
 }
 
 // HistoryConfig stores previous UI selections so the user can resume quickly.
@@ -29,17 +153,136 @@ type HistoryConfig struct {
 	LastSourceFolder []string `toml:"lastSourceFolder"`
 }
 
+// PowerConfig controls how long jobs react to the machine's power state.
+// On Windows jobs also pause while the OS suspends; Wails exposes suspend
+// and resume notifications on no other platform.
+type PowerConfig struct {
+	PauseOnBattery bool `toml:"pauseOnBattery"`
+}
+
+// RetentionConfig controls how long completed action rows stay in SQLite.
+// Expired rows are exported to compressed JSONL archives before deletion.
+// TrashDays purges media rows that have been in the trash that long; zero
+// keeps them until purged by hand. Maintenance vacuums the database once
+// free pages make up VacuumFreePercent of it (default 20).
+type RetentionConfig struct {
+	ActionDays        int    `toml:"actionDays"`
+	ArchiveFolder     string `toml:"archiveFolder"`
+	TrashDays         int    `toml:"trashDays"`
+	VacuumFreePercent int    `toml:"vacuumFreePercent"`
+}
+
+// ScheduleConfig controls automatic background jobs while the app is open.
+// Scan accepts a duration ("6h") or a daily time ("03:30"); empty disables it.
+type ScheduleConfig struct {
+	Scan string `toml:"scan"`
+	// AutoTidy moves newly scanned files into the target after each
+	// scheduled scan. It also requires the autoTidy feature flag.
+	AutoTidy bool `toml:"autoTidy"`
+	// Verify re-hashes library files on the same kind of schedule as Scan to
+	// catch bit rot; VerifySamplePercent is the share of the library each
+	// run checks, least recently verified first (default 10).
+	Verify              string `toml:"verify"`
+	VerifySamplePercent int    `toml:"verifySamplePercent"`
+	// Maintenance prunes expired rows, refreshes the query planner's
+	// statistics and compacts the database on the same kind of schedule.
+	Maintenance string `toml:"maintenance"`
+}
+
 // ScanConfig describes how media scanning should behave.
 type ScanConfig struct {
-	SourceFolders     []string `toml:"sourceFolders"`
-	IncludeExtensions []string `toml:"includeExtensions"`
-	FollowSymlinks    bool     `toml:"followSymlinks"`
+	// SourceFolders are local folders or rclone "remote:path" sources, which
+	// are pulled into a local mirror before each scan. Each may override the
+	// extensions, excludes and symlink handling below.
+	SourceFolders     []SourceFolder `toml:"sourceFolders"`
+	IncludeExtensions []string       `toml:"includeExtensions"`
+	FollowSymlinks    bool           `toml:"followSymlinks"`
+	// BurstWindowSeconds is the maximum gap between shots of one burst.
+	BurstWindowSeconds int `toml:"burstWindowSeconds"`
+	// PreCount enumerates sources before scanning to report a percentage.
+	PreCount bool `toml:"preCount"`
+	// InboxFolder receives files to import; it is emptied after each import.
+	InboxFolder string `toml:"inboxFolder"`
+	// Archives scans inside ZIP and TAR files; tidy extracts their entries.
+	Archives bool `toml:"archives"`
+	// TimeZone is the zone camera clocks are assumed to show when a file
+	// does not say: an IANA name, "local" (the default) or an offset such as
+	// "+02:00". CameraTimeZones overrides it per camera ("Make Model" or
+	// just the model).
+	TimeZone        string            `toml:"timeZone"`
+	CameraTimeZones map[string]string `toml:"cameraTimeZones,omitempty"`
+	// Validate decodes JPEG, PNG and GIF images during scans to flag corrupt
+	// ones: "off" (the default), "headers" or "full", which decodes every
+	// pixel and so also finds truncated files.
+	Validate string `toml:"validate"`
+	// JunkPatterns name leftovers such as "*.tmp" or "~$*" that scans list
+	// for cleanup instead of recording; empty files are always junk. Unset
+	// uses the built-in patterns and an empty list turns them off.
+	JunkPatterns []string `toml:"junkPatterns"`
+	// SkipFolders name folders scans never enter, such as recycle bins and
+	// system folders. Unset uses the defaults for this platform and an empty
+	// list walks everything.
+	SkipFolders []string `toml:"skipFolders"`
+	// MemoryBudgetMB is the memory scans try to stay under; 0 leaves it to
+	// the Go runtime. A scan overrunning it writes a heap profile next to
+	// the logs.
+	MemoryBudgetMB int `toml:"memoryBudgetMb"`
+	// Parallel is how many sources scans walk at once; 0 or 1 walks them
+	// one after the other.
+	Parallel int `toml:"parallel"`
+	// DropAction is what folders dropped onto the window do: "add" (the
+	// default) appends them to SourceFolders and "scan" scans just them
+	// without changing the settings.
+	DropAction string `toml:"dropAction"`
+}
+
+// ThrottleConfig caps the IO of scans and tidy runs, for example to keep a
+// NAS link usable. Zero disables a limit.
+type ThrottleConfig struct {
+	// MBPerSec caps hashing and copy throughput across all workers.
+	MBPerSec float64 `toml:"mbPerSec"`
+	// FileDelayMS pauses between files.
+	FileDelayMS int `toml:"fileDelayMs"`
+	// Priority is "normal" (the default) or "low", which runs the app at
+	// background CPU and IO priority and slows jobs down while the machine
+	// is busy.
+	Priority string `toml:"priority"`
+}
+
+// ToolsConfig locates optional external programs. Each entry is empty to
+// look the tool up on PATH, "off" to never use it, or the executable's path.
+type ToolsConfig struct {
+	// Exiftool reads metadata from formats the built-in decoders miss.
+	Exiftool string `toml:"exiftool"`
+	// FFprobe reads video properties; the ffmpeg beside it renders posters.
+	FFprobe string `toml:"ffprobe"`
+	// Rclone reaches "remote:path" sources and targets configured in rclone.
+	Rclone string `toml:"rclone"`
+	// Tesseract reads the text in images for search.
+	Tesseract string `toml:"tesseract"`
+	// Gphoto2 imports from cameras and phones attached over USB.
+	Gphoto2 string `toml:"gphoto2"`
 }
 
 // TargetConfig describes how tidy actions should organise files.
 type TargetConfig struct {
+	// BaseFolder is a local folder, a mounted share, an http(s) WebDAV URL
+	// such as a Nextcloud files endpoint or an rclone "remote:path".
 	BaseFolder string `toml:"baseFolder"`
-	Pattern    string `toml:"pattern"`
+	// WebDAVUser and WebDAVPassword sign in to a WebDAV BaseFolder.
+	WebDAVUser       string `toml:"webdavUser"`
+	WebDAVPassword   string `toml:"webdavPassword"`
+	Pattern          string `toml:"pattern"`
+	QuarantineFolder string `toml:"quarantineFolder"`
+	Workers          int    `toml:"workers"`
+	// Normalization is the Unicode form of generated names: "nfc" (default),
+	// "nfd" or "none".
+	Normalization string `toml:"normalization"`
+	// CaseMode is "auto" (probe the volume), "sensitive" or "insensitive".
+	CaseMode string `toml:"caseMode"`
+	// RemoveDuplicateSource deletes a source file when an identical copy
+	// already sits at its target.
+	RemoveDuplicateSource bool `toml:"removeDuplicateSource"`
 }
 
 // Load reads settings from the provided TOML file.
@@ -49,8 +292,29 @@ func Load(path string) (*Settings, error) {
 		return nil, fmt.Errorf("read settings: %w", err)
 	}
 
+	raw := make(map[string]interface{})
+	if err := toml.Unmarshal(bytes, &raw); err != nil {
+		return nil, fmt.Errorf("parse settings: %w", err)
+	}
+	normaliseSourceFolders(raw)
+	// The profile is applied in place, so the base settings are kept first.
+	original, err := toml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse settings: %w", err)
+	}
+	if err := applyProfile(raw); err != nil {
+		return nil, err
+	}
+	merged, err := toml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("merge profile: %w", err)
+	}
+
 	var cfg Settings
-	if err := toml.Unmarshal(bytes, &cfg); err != nil {
+	if err := toml.Unmarshal(merged, &cfg); err != nil {
+		return nil, fmt.Errorf("parse settings: %w", err)
+	}
+	if err := toml.Unmarshal(original, &cfg.base); err != nil {
 		return nil, fmt.Errorf("parse settings: %w", err)
 	}
 
@@ -70,13 +334,98 @@ func (s *Settings) Validate() error {
 	if s.Database.FileName == "" {
 		return errors.New("database fileName is required")
 	}
+	if _, err := schedule.Parse(s.Schedule.Scan); err != nil {
+		return err
+	}
+	if _, err := schedule.Parse(s.Schedule.Verify); err != nil {
+		return err
+	}
+	if _, err := schedule.Parse(s.Schedule.Maintenance); err != nil {
+		return err
+	}
+	if s.Faces.Threshold < 0 || s.Faces.Threshold > 2 {
+		return errors.New("faces threshold must be between 0 and 2")
+	}
+	if strings.TrimSpace(s.GRPC.Address) != "" {
+		if _, _, err := net.SplitHostPort(strings.TrimSpace(s.GRPC.Address)); err != nil {
+			return fmt.Errorf("grpc address: %w", err)
+		}
+	}
+	if s.Classifier.MinScore < 0 || s.Classifier.MinScore > 1 {
+		return errors.New("classifier minScore must be between 0 and 1")
+	}
+	if s.Schedule.VerifySamplePercent < 1 || s.Schedule.VerifySamplePercent > 100 {
+		return errors.New("schedule verifySamplePercent must be between 1 and 100")
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Scan.Validate)) {
+	case "", "off", "headers", "full":
+	default:
+		return fmt.Errorf("unknown scan validate level %q", s.Scan.Validate)
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Scan.DropAction)) {
+	case "", "add", "scan":
+	default:
+		return fmt.Errorf("unknown scan dropAction %q", s.Scan.DropAction)
+	}
+	for _, pattern := range s.Scan.JunkPatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("scan junkPatterns %q: %w", pattern, err)
+		}
+	}
+	if s.Scan.MemoryBudgetMB < 0 {
+		return errors.New("scan memoryBudgetMb must not be negative")
+	}
+	if s.Scan.Parallel < 0 {
+		return errors.New("scan parallel must not be negative")
+	}
+	if err := validateSourceFolders(s.Scan.SourceFolders); err != nil {
+		return err
+	}
+	for _, pattern := range s.Scan.SkipFolders {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("scan skipFolders %q: %w", pattern, err)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Throttle.Priority)) {
+	case "", "normal", "low":
+	default:
+		return fmt.Errorf("unknown throttle priority %q", s.Throttle.Priority)
+	}
+	switch strings.ToLower(s.Target.Normalization) {
+	case "", "nfc", "nfd", "none":
+	default:
+		return fmt.Errorf("unknown target normalization %q", s.Target.Normalization)
+	}
+	switch strings.ToLower(s.Target.CaseMode) {
+	case "", "auto", "sensitive", "insensitive":
+	default:
+		return fmt.Errorf("unknown target caseMode %q", s.Target.CaseMode)
+	}
+	if _, _, err := s.TimeZones(); err != nil {
+		return err
+	}
+	if s.Throttle.MBPerSec < 0 || s.Throttle.FileDelayMS < 0 {
+		return errors.New("throttle limits must not be negative")
+	}
+	if s.Backup.PartSizeMB < 5 {
+		return errors.New("backup partSizeMb must be at least 5")
+	}
+	if s.Retention.ActionDays < 0 {
+		return errors.New("retention actionDays must not be negative")
+	}
+	if s.Retention.TrashDays < 0 {
+		return errors.New("retention trashDays must not be negative")
+	}
+	if s.Retention.VacuumFreePercent < 1 || s.Retention.VacuumFreePercent > 100 {
+		return errors.New("retention vacuumFreePercent must be between 1 and 100")
+	}
 	if len(s.Scan.SourceFolders) == 0 && len(s.History.LastSourceFolder) == 0 {
 		return errors.New("at least one source folder must be configured")
 	}
 	return nil
 }
 
-// DatabasePath resolves the absolute SQLite file path relative to the config file.
+// DatabasePath resolves the absolute SQLite file path relative to the data root.
 func (s *Settings) DatabasePath(root string) string {
 	base := s.Database.BaseFolder
 	if !filepath.IsAbs(base) {
@@ -85,10 +434,73 @@ func (s *Settings) DatabasePath(root string) string {
 	return filepath.Join(base, s.Database.FileName)
 }
 
+// BackupDir resolves the folder receiving automatic database backups.
+func (s *Settings) BackupDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "backups")
+}
+
+// ThumbnailDir resolves the folder caching generated preview thumbnails.
+func (s *Settings) ThumbnailDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "thumbs")
+}
+
+// RcloneDir resolves the folder mirroring files pulled from rclone remote
+// sources for scanning.
+func (s *Settings) RcloneDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "rclone")
+}
+
+// PhotosDir resolves the folder originals are copied into when an Apple
+// Photos library is imported.
+func (s *Settings) PhotosDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "photos")
+}
+
+// DevicesDir resolves the folder files are copied into when a camera or
+// phone is imported.
+func (s *Settings) DevicesDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "devices")
+}
+
+// StagingPath resolves the database staging scans write to, kept apart
+// from the library until they are discarded.
+func (s *Settings) StagingPath(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "staging", s.Database.FileName)
+}
+
+// LogDir resolves the folder holding application log files.
+func (s *Settings) LogDir(root string) string {
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "logs")
+}
+
+// ArchivePath resolves the folder receiving expired action archives.
+func (s *Settings) ArchivePath(root string) string {
+	if s.Retention.ArchiveFolder != "" {
+		if filepath.IsAbs(s.Retention.ArchiveFolder) {
+			return s.Retention.ArchiveFolder
+		}
+		return filepath.Join(root, s.Retention.ArchiveFolder)
+	}
+	return filepath.Join(filepath.Dir(s.DatabasePath(root)), "archive")
+}
+
+// BackupCredentials returns the backup access key pair, taking each half
+// from the environment when settings leave it empty.
+func (s *Settings) BackupCredentials() (accessKeyID, secretAccessKey string) {
+	accessKeyID, secretAccessKey = s.Backup.AccessKeyID, s.Backup.SecretAccessKey
+	if accessKeyID == "" {
+		accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if secretAccessKey == "" {
+		secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	return accessKeyID, secretAccessKey
+}
+
 // EffectiveSources returns the ordered list of folders to scan.
 func (s *Settings) EffectiveSources() []string {
 	if len(s.Scan.SourceFolders) > 0 {
-		return s.Scan.SourceFolders
+		return SourcePaths(s.Scan.SourceFolders)
 	}
 	return s.History.LastSourceFolder
 }
@@ -126,14 +538,38 @@ func (s *Settings) applyDefaults(root string) {
 	if len(s.Scan.IncludeExtensions) == 0 {
 		s.Scan.IncludeExtensions = defaultExtensions()
 	}
+	if s.Scan.BurstWindowSeconds <= 0 {
+		s.Scan.BurstWindowSeconds = 2
+	}
+	if s.Target.Workers <= 0 {
+		s.Target.Workers = 1
+	}
 	if s.Target.Pattern == "" {
-		s.Target.Pattern = "{{.Date}}/{{.OriginalName}}"
+		s.Target.Pattern = defaultPattern
+	}
+	if s.Schedule.VerifySamplePercent == 0 {
+		s.Schedule.VerifySamplePercent = 10
+	}
+	if s.Retention.VacuumFreePercent == 0 {
+		s.Retention.VacuumFreePercent = 20
+	}
+	if s.Backup.PartSizeMB == 0 {
+		s.Backup.PartSizeMB = 16
 	}
 
 	// Expand tilde paths so Windows users can rely on them.
 	s.Database.BaseFolder = expandPath(s.Database.BaseFolder)
 	s.Target.BaseFolder = expandPath(s.Target.BaseFolder)
-	s.Scan.SourceFolders = expandSlicePaths(s.Scan.SourceFolders)
+	s.Target.QuarantineFolder = expandPath(s.Target.QuarantineFolder)
+	s.Retention.ArchiveFolder = expandPath(s.Retention.ArchiveFolder)
+	s.Scan.InboxFolder = expandPath(s.Scan.InboxFolder)
+	s.Tools.Exiftool = expandPath(s.Tools.Exiftool)
+	s.Tools.FFprobe = expandPath(s.Tools.FFprobe)
+	s.Tools.Rclone = expandPath(s.Tools.Rclone)
+	s.Tools.Tesseract = expandPath(s.Tools.Tesseract)
+	s.Tools.Gphoto2 = expandPath(s.Tools.Gphoto2)
+	s.Classifier.Model = expandPath(s.Classifier.Model)
+	s.Scan.SourceFolders = expandSourceFolders(s.Scan.SourceFolders)
 	s.History.LastSourceFolder = expandSlicePaths(s.History.LastSourceFolder)
 }
 
@@ -161,6 +597,33 @@ func expandPath(path string) string {
 	return path
 }
 
+const defaultPattern = "{{.Date}}/{{.OriginalName}}"
+
+// EffectiveJunkPatterns returns the configured junk patterns, or the
+// built-in ones when none are set.
+func (s *Settings) EffectiveJunkPatterns() []string {
+	if s.Scan.JunkPatterns == nil {
+		return defaultJunkPatterns()
+	}
+	return s.Scan.JunkPatterns
+}
+
+// EffectiveSkipFolders returns the configured folders to skip, or the
+// platform defaults when none are set.
+func (s *Settings) EffectiveSkipFolders() []string {
+	if s.Scan.SkipFolders == nil {
+		return defaultSkipFolders()
+	}
+	return s.Scan.SkipFolders
+}
+
+func defaultJunkPatterns() []string {
+	return []string{
+		"*.tmp", "~$*", ".~lock.*#", "*.part", "*.partial", "*.crdownload", "*.download",
+		"._*", ".ds_store", "thumbs.db", "desktop.ini",
+	}
+}
+
 func defaultExtensions() []string {
 	return []string{".jpg", ".jpeg", ".png", ".heic", ".mp4", ".mov"}
 }
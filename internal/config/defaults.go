@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// AppDirName is the folder created below the OS configuration directory.
+const AppDirName = "photoTidyGo"
+
+// ConfigDir returns the OS-appropriate folder for settings.toml, e.g.
+// %AppData%\photoTidyGo on Windows or ~/.config/photoTidyGo on Linux.
+func ConfigDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config folder: %w", err)
+	}
+	return filepath.Join(base, AppDirName), nil
+}
+
+// DataDir returns the OS-appropriate folder for the database, logs and
+// caches: %LocalAppData% on Windows, ~/Library/Application Support on macOS
+// and $XDG_DATA_HOME (~/.local/share) elsewhere.
+func DataDir() (string, error) {
+	var base string
+	switch runtime.GOOS {
+	case "windows":
+		base = os.Getenv("LocalAppData")
+	case "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("locate data folder: %w", err)
+		}
+		base = dir
+	default:
+		base = os.Getenv("XDG_DATA_HOME")
+		if base == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("locate data folder: %w", err)
+			}
+			base = filepath.Join(home, ".local", "share")
+		}
+	}
+	if base == "" {
+		return "", errors.New("locate data folder: no data directory for this platform")
+	}
+	return filepath.Join(base, AppDirName), nil
+}
+
+// MigrateSettings copies a settings file from an older location to dest.
+// Relative database and archive folders are rewritten against the old
+// folder so the existing library keeps being used.
+func MigrateSettings(src, dest string) error {
+	oldRoot := filepath.Dir(src)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create settings folder: %w", err)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("read settings: %w", err)
+	}
+	if err := os.WriteFile(dest, data, 0o644); err != nil {
+		return fmt.Errorf("write settings: %w", err)
+	}
+
+	return Update(dest, func(raw map[string]interface{}) {
+		absolutise(raw, oldRoot, "database", "baseFolder", "db")
+		absolutise(raw, oldRoot, "retention", "archiveFolder", "")
+	})
+}
+
+// absolutise makes raw[table][key] absolute against root. fallback is the
+// value implied when the key is missing.
+func absolutise(raw map[string]interface{}, root, table, key, fallback string) {
+	section, _ := raw[table].(map[string]interface{})
+	value, _ := section[key].(string)
+	if value == "" {
+		value = fallback
+	}
+	if value == "" || filepath.IsAbs(value) || strings.HasPrefix(value, "~") {
+		return
+	}
+	if section == nil {
+		section = make(map[string]interface{})
+		raw[table] = section
+	}
+	section[key] = filepath.Join(root, value)
+}
+
+// Defaults returns starter settings for a first run, pointing at the
+// platform's pictures folder.
+func Defaults() Settings {
+	pictures := picturesDir()
+	return Settings{
+		Backup:   BackupConfig{PartSizeMB: 16},
+		Database: DatabaseConfig{BaseFolder: "db", FileName: "media.db"},
+		Scan: ScanConfig{
+			SourceFolders:      []SourceFolder{{Path: pictures}},
+			IncludeExtensions:  defaultExtensions(),
+			BurstWindowSeconds: 2,
+		},
+		Retention: RetentionConfig{VacuumFreePercent: 20},
+		Schedule:  ScheduleConfig{VerifySamplePercent: 10},
+		Target: TargetConfig{
+			BaseFolder: filepath.Join(pictures, "Tidy"),
+			Pattern:    defaultPattern,
+			Workers:    1,
+		},
+	}
+}
+
+// FillDefaults completes s with Defaults for every required value left empty.
+func (s *Settings) FillDefaults() {
+	defaults := Defaults()
+	if s.Backup.PartSizeMB == 0 {
+		s.Backup.PartSizeMB = defaults.Backup.PartSizeMB
+	}
+	if s.Database.BaseFolder == "" {
+		s.Database.BaseFolder = defaults.Database.BaseFolder
+	}
+	if s.Database.FileName == "" {
+		s.Database.FileName = defaults.Database.FileName
+	}
+	if len(s.Scan.SourceFolders) == 0 && len(s.History.LastSourceFolder) == 0 {
+		s.Scan.SourceFolders = defaults.Scan.SourceFolders
+	}
+	if len(s.Scan.IncludeExtensions) == 0 {
+		s.Scan.IncludeExtensions = defaults.Scan.IncludeExtensions
+	}
+	if s.Scan.BurstWindowSeconds <= 0 {
+		s.Scan.BurstWindowSeconds = defaults.Scan.BurstWindowSeconds
+	}
+	if s.Retention.VacuumFreePercent == 0 {
+		s.Retention.VacuumFreePercent = defaults.Retention.VacuumFreePercent
+	}
+	if s.Schedule.VerifySamplePercent == 0 {
+		s.Schedule.VerifySamplePercent = defaults.Schedule.VerifySamplePercent
+	}
+	if s.Target.BaseFolder == "" {
+		s.Target.BaseFolder = defaults.Target.BaseFolder
+	}
+	if s.Target.Pattern == "" {
+		s.Target.Pattern = defaults.Target.Pattern
+	}
+	if s.Target.Workers <= 0 {
+		s.Target.Workers = defaults.Target.Workers
+	}
+}
+
+// Write validates s and saves it as a new settings file at path.
+func Write(path string, s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	out, err := encodeSettings(s)
+	if err != nil {
+		return fmt.Errorf("encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create settings folder: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return fmt.Errorf("write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace settings: %w", err)
+	}
+	return nil
+}
+
+// encodeSettings marshals s, leaving out the lists whose unset value means
+// the built-in defaults. TOML has no null and an empty list turns them off,
+// so writing nil as [] would change what they mean on the next load.
+func encodeSettings(s Settings) ([]byte, error) {
+	out, err := toml.Marshal(s)
+	if err != nil || (s.Scan.JunkPatterns != nil && s.Scan.SkipFolders != nil) {
+		return out, err
+	}
+	raw := make(map[string]interface{})
+	if err := toml.Unmarshal(out, &raw); err != nil {
+		return nil, err
+	}
+	if scan, ok := raw["scan"].(map[string]interface{}); ok {
+		if s.Scan.JunkPatterns == nil {
+			delete(scan, "junkPatterns")
+		}
+		if s.Scan.SkipFolders == nil {
+			delete(scan, "skipFolders")
+		}
+	}
+	return toml.Marshal(raw)
+}
+
+// defaultSkipFolders lists the system folders scans leave alone. Drives are
+// carried between machines, so the trash folders of every platform are
+// skipped, plus the system folders of this one.
+func defaultSkipFolders() []string {
+	folders := []string{
+		"$RECYCLE.BIN", "System Volume Information", ".Trash", ".Trashes", ".Trash-*",
+		".Spotlight-V100", ".fseventsd", "@eaDir", "#recycle",
+	}
+	switch runtime.GOOS {
+	case "windows":
+		folders = append(folders, "$WinREAgent", "Config.Msi", "RECYCLER")
+	case "darwin":
+		folders = append(folders, ".DocumentRevisions-V100", ".TemporaryItems", ".MobileBackups")
+	default:
+		folders = append(folders, "lost+found", ".thumbnails", ".cache")
+	}
+	return folders
+}
+
+// picturesDir guesses the user's pictures folder, honouring the XDG user
+// dirs file on Linux.
+func picturesDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	if runtime.GOOS == "linux" {
+		if dir := xdgPicturesDir(home); dir != "" {
+			return dir
+		}
+	}
+	return filepath.Join(home, "Pictures")
+}
+
+func xdgPicturesDir(home string) string {
+	if dir := os.Getenv("XDG_PICTURES_DIR"); dir != "" {
+		return dir
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	data, err := os.ReadFile(filepath.Join(configHome, "user-dirs.dirs"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "XDG_PICTURES_DIR=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		return strings.Replace(value, "$HOME", home, 1)
+	}
+	return ""
+}
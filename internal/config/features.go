@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Feature flags gating experimental subsystems.
+const (
+	FeaturePerceptualHash = "perceptualHash"
+	FeatureFaceDetection  = "faceDetection"
+	FeatureAutoTidy       = "autoTidy"
+	FeatureImageLabels    = "imageLabels"
+	FeatureOCR            = "ocr"
+)
+
+// FeatureFlag describes one registered flag and its effective state.
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// knownFeatures is the registry of flags; unknown names are rejected.
+var knownFeatures = map[string]FeatureFlag{
+	FeaturePerceptualHash: {Name: FeaturePerceptualHash, Description: "Perceptual hashing for similar images and videos"},
+	FeatureFaceDetection:  {Name: FeatureFaceDetection, Description: "Face and subject detection"},
+	FeatureAutoTidy:       {Name: FeatureAutoTidy, Description: "Automatic tidy after scans"},
+	FeatureImageLabels:    {Name: FeatureImageLabels, Description: "Image labelling with an ONNX classifier"},
+	FeatureOCR:            {Name: FeatureOCR, Description: "Text recognition in images for search"},
+}
+
+// FeatureEnabled reports whether the named flag is switched on.
+func (s *Settings) FeatureEnabled(name string) bool {
+	if enabled, ok := s.Features[name]; ok {
+		return enabled
+	}
+	return knownFeatures[name].Default
+}
+
+// FeatureFlags lists every registered flag with its effective state.
+func (s *Settings) FeatureFlags() []FeatureFlag {
+	flags := make([]FeatureFlag, 0, len(knownFeatures))
+	for name, flag := range knownFeatures {
+		flag.Enabled = s.FeatureEnabled(name)
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// SetFeature persists a flag into the [features] table of the settings file.
+func SetFeature(path, name string, enabled bool) error {
+	if _, ok := knownFeatures[name]; !ok {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		table, _ := raw["features"].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+		}
+		table[name] = enabled
+		raw["features"] = table
+	})
+}
+
+// Update rewrites the settings file after applying mutate to its raw TOML
+// tree. Values are kept as written, so relative and tilde paths survive.
+func Update(path string, mutate func(raw map[string]interface{})) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read settings: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	if err := toml.Unmarshal(bytes, &raw); err != nil {
+		return fmt.Errorf("parse settings: %w", err)
+	}
+
+	mutate(raw)
+
+	out, err := toml.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("encode settings: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return fmt.Errorf("write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Job types that can show a desktop notification when they finish or fail.
+const (
+	NotifyScan     = "scan"
+	NotifyTidy     = "tidy"
+	NotifyVerify   = "verify"
+	NotifyBackup   = "backup"
+	NotifyAnalysis = "analysis"
+)
+
+// Notification describes one job type and whether it notifies.
+type Notification struct {
+	Job         string `json:"job"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// knownNotifications is the registry of job types; unknown names are
+// rejected.
+var knownNotifications = map[string]Notification{
+	NotifyScan:     {Job: NotifyScan, Description: "Scans and imports", Default: true},
+	NotifyTidy:     {Job: NotifyTidy, Description: "Tidy runs", Default: true},
+	NotifyVerify:   {Job: NotifyVerify, Description: "Library verification", Default: true},
+	NotifyBackup:   {Job: NotifyBackup, Description: "Library backups", Default: true},
+	NotifyAnalysis: {Job: NotifyAnalysis, Description: "Face, label, text and similarity analysis"},
+}
+
+// NotifyEnabled reports whether the named job type shows notifications.
+func (s *Settings) NotifyEnabled(job string) bool {
+	if enabled, ok := s.Notifications[job]; ok {
+		return enabled
+	}
+	return knownNotifications[job].Default
+}
+
+// NotificationSettings lists every job type with its effective state.
+func (s *Settings) NotificationSettings() []Notification {
+	list := make([]Notification, 0, len(knownNotifications))
+	for job, n := range knownNotifications {
+		n.Enabled = s.NotifyEnabled(job)
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Job < list[j].Job })
+	return list
+}
+
+// SetNotification persists a job type into the [notifications] table of
+// the settings file.
+func SetNotification(path, job string, enabled bool) error {
+	if _, ok := knownNotifications[job]; !ok {
+		return fmt.Errorf("unknown notification job %q", job)
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		table, _ := raw["notifications"].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+		}
+		table[job] = enabled
+		raw["notifications"] = table
+	})
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultProfile names the base settings used when no profile is active.
+const DefaultProfile = "default"
+
+// profileTables lists the settings tables a profile may override. History
+// is included so every profile remembers its own recent folders.
+var profileTables = []string{"scan", "target", "history"}
+
+// Profile is a named set of overrides stored under [profiles.<name>].
+type Profile struct {
+	Scan    ScanConfig    `toml:"scan"`
+	Target  TargetConfig  `toml:"target"`
+	History HistoryConfig `toml:"history"`
+}
+
+// ProfileInfo summarises a profile for the UI.
+type ProfileInfo struct {
+	Name          string   `json:"name"`
+	Active        bool     `json:"active"`
+	SourceFolders []string `json:"sourceFolders"`
+	TargetFolder  string   `json:"targetFolder"`
+	Pattern       string   `json:"pattern"`
+}
+
+// ProfileName returns the active profile, or DefaultProfile.
+func (s *Settings) ProfileName() string {
+	if s.ActiveProfile == "" {
+		return DefaultProfile
+	}
+	return s.ActiveProfile
+}
+
+// ListProfiles returns the default profile followed by the named ones, each
+// with the values it would apply on top of the base settings.
+func (s *Settings) ListProfiles() []ProfileInfo {
+	names := make([]string, 0, len(s.Profiles))
+	for name := range s.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	active := s.ProfileName()
+	infos := []ProfileInfo{s.base.info(DefaultProfile, Profile{}, active)}
+	for _, name := range names {
+		infos = append(infos, s.base.info(name, s.Profiles[name], active))
+	}
+	return infos
+}
+
+// info describes override applied on top of base.
+func (base Profile) info(name string, override Profile, active string) ProfileInfo {
+	info := ProfileInfo{Name: name, Active: name == active}
+
+	switch {
+	case len(override.Scan.SourceFolders) > 0:
+		info.SourceFolders = SourcePaths(override.Scan.SourceFolders)
+	case len(override.History.LastSourceFolder) > 0:
+		info.SourceFolders = override.History.LastSourceFolder
+	case len(base.Scan.SourceFolders) > 0:
+		info.SourceFolders = SourcePaths(base.Scan.SourceFolders)
+	case name == DefaultProfile:
+		info.SourceFolders = base.History.LastSourceFolder
+	}
+
+	info.TargetFolder = firstNonEmpty(override.Target.BaseFolder, base.Target.BaseFolder)
+	info.Pattern = firstNonEmpty(override.Target.Pattern, base.Target.Pattern, defaultPattern)
+	return info
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// SetActiveProfile persists the active profile; DefaultProfile or an empty
+// name switches back to the base settings.
+func SetActiveProfile(path, name string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		name = ""
+	}
+	if _, ok := cfg.Profiles[name]; name != "" && !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		if name == "" {
+			delete(raw, "activeProfile")
+			return
+		}
+		raw["activeProfile"] = name
+	})
+}
+
+// applyProfile merges the active profile's tables over the base tables of the
+// raw settings tree before it is decoded.
+func applyProfile(raw map[string]interface{}) error {
+	name, _ := raw["activeProfile"].(string)
+	if name == "" || name == DefaultProfile {
+		return nil
+	}
+	profiles, _ := raw["profiles"].(map[string]interface{})
+	profile, ok := profiles[name].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("active profile %q is not defined", name)
+	}
+
+	// History is scoped to the profile and never inherits the base folders.
+	raw["history"] = map[string]interface{}{}
+
+	for _, table := range profileTables {
+		override, ok := profile[table].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		base, _ := raw[table].(map[string]interface{})
+		if base == nil {
+			base = make(map[string]interface{})
+		}
+		for key, value := range override {
+			base[key] = value
+		}
+		raw[table] = base
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package config
+
+// SetReadOnly persists the read-only switch into the settings file.
+func SetReadOnly(path string, enabled bool) error {
+	return Update(path, func(raw map[string]interface{}) {
+		if enabled {
+			raw["readOnly"] = true
+		} else {
+			delete(raw, "readOnly")
+		}
+	})
+}
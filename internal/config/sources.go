@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SourceFolder is one entry of scan.sourceFolders. Entries are plain paths
+// or tables with a path and overrides for that source:
+//
+//	sourceFolders = [
+//	  "~/Pictures",
+//	  { path = "~/GoPro", extensions = [".mp4"], exclude = ["proxy"] },
+//	]
+type SourceFolder struct {
+	Path string `toml:"path"`
+	// Extensions, when set, replaces includeExtensions for this source.
+	Extensions []string `toml:"extensions,omitempty"`
+	// Exclude holds patterns of files and folders left out of this source,
+	// matched against their name or their slash-separated path below it.
+	Exclude []string `toml:"exclude,omitempty"`
+	// FollowSymlinks, when set, replaces scan.followSymlinks.
+	FollowSymlinks *bool `toml:"followSymlinks,omitempty"`
+}
+
+// overrides reports whether the entry changes anything for its source.
+func (f SourceFolder) overrides() bool {
+	return len(f.Extensions) > 0 || len(f.Exclude) > 0 || f.FollowSymlinks != nil
+}
+
+// SourcePaths returns the paths of folders.
+func SourcePaths(folders []SourceFolder) []string {
+	if folders == nil {
+		return nil
+	}
+	out := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		out = append(out, folder.Path)
+	}
+	return out
+}
+
+// SourceOverrides returns the configured sources that override scan
+// settings, keyed by path.
+func (s *Settings) SourceOverrides() map[string]SourceFolder {
+	out := make(map[string]SourceFolder)
+	for _, folder := range s.Scan.SourceFolders {
+		if folder.overrides() {
+			out[folder.Path] = folder
+		}
+	}
+	return out
+}
+
+// validateSourceFolders checks the exclude patterns of folders.
+func validateSourceFolders(folders []SourceFolder) error {
+	for _, folder := range folders {
+		for _, pattern := range folder.Exclude {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("scan sourceFolders %q exclude %q: %w", folder.Path, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandSourceFolders expands the paths of folders like expandSlicePaths,
+// dropping entries without one.
+func expandSourceFolders(folders []SourceFolder) []SourceFolder {
+	out := make([]SourceFolder, 0, len(folders))
+	for _, folder := range folders {
+		folder.Path = strings.TrimSpace(folder.Path)
+		if folder.Path == "" {
+			continue
+		}
+		folder.Path = expandPath(folder.Path)
+		out = append(out, folder)
+	}
+	return out
+}
+
+// normaliseSourceFolders rewrites plain paths in the sourceFolders of the
+// scan table and of every profile as tables, so older settings files decode
+// into SourceFolder.
+func normaliseSourceFolders(raw map[string]interface{}) {
+	tables := []interface{}{raw["scan"]}
+	if profiles, ok := raw["profiles"].(map[string]interface{}); ok {
+		for _, profile := range profiles {
+			if profile, ok := profile.(map[string]interface{}); ok {
+				tables = append(tables, profile["scan"])
+			}
+		}
+	}
+	for _, table := range tables {
+		scan, ok := table.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		folders, ok := scan["sourceFolders"].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, folder := range folders {
+			if p, ok := folder.(string); ok {
+				folders[i] = map[string]interface{}{"path": p}
+			}
+		}
+	}
+}
+
+// AddSourceFolders appends folders to scan.sourceFolders in the settings
+// file, or to the active profile's list when the profile has its own, and
+// returns the ones added; folders already listed are skipped.
+func AddSourceFolders(path string, folders []string) ([]string, error) {
+	var added []string
+	err := Update(path, func(raw map[string]interface{}) {
+		scan := sourcesTable(raw)
+		list, _ := scan["sourceFolders"].([]interface{})
+		listed := make(map[string]bool, len(list))
+		for _, entry := range list {
+			switch entry := entry.(type) {
+			case string:
+				listed[filepath.Clean(expandPath(entry))] = true
+			case map[string]interface{}:
+				if p, ok := entry["path"].(string); ok {
+					listed[filepath.Clean(expandPath(p))] = true
+				}
+			}
+		}
+		for _, folder := range folders {
+			if listed[folder] {
+				continue
+			}
+			listed[folder] = true
+			list = append(list, map[string]interface{}{"path": folder})
+			added = append(added, folder)
+		}
+		scan["sourceFolders"] = list
+	})
+	return added, err
+}
+
+// sourcesTable returns the scan table whose sourceFolders are in effect:
+// the active profile's when it sets them, otherwise the base one.
+func sourcesTable(raw map[string]interface{}) map[string]interface{} {
+	if name, _ := raw["activeProfile"].(string); name != "" && name != DefaultProfile {
+		profiles, _ := raw["profiles"].(map[string]interface{})
+		profile, _ := profiles[name].(map[string]interface{})
+		if scan, ok := profile["scan"].(map[string]interface{}); ok {
+			if _, ok := scan["sourceFolders"]; ok {
+				return scan
+			}
+		}
+	}
+	scan, _ := raw["scan"].(map[string]interface{})
+	if scan == nil {
+		scan = make(map[string]interface{})
+		raw["scan"] = scan
+	}
+	return scan
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SetThrottle persists the IO limits to the [throttle] table.
+func SetThrottle(path string, mbPerSec float64, fileDelayMS int) error {
+	if mbPerSec < 0 || fileDelayMS < 0 {
+		return errors.New("throttle limits must not be negative")
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		table, _ := raw["throttle"].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+		}
+		table["mbPerSec"] = mbPerSec
+		table["fileDelayMs"] = int64(fileDelayMS)
+		raw["throttle"] = table
+	})
+}
+
+// SetPriority persists the job priority, "normal" or "low", to the
+// [throttle] table.
+func SetPriority(path, priority string) error {
+	switch priority {
+	case "normal", "low":
+	default:
+		return fmt.Errorf("unknown priority %q", priority)
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		table, _ := raw["throttle"].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+		}
+		table["priority"] = priority
+		raw["throttle"] = table
+	})
+}
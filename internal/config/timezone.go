@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Windows has no system zone database.
+	_ "time/tzdata"
+)
+
+// fixedOffset matches "+02:00", "-0530", "UTC+2" and similar.
+var fixedOffset = regexp.MustCompile(`^(?i:utc|gmt)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// ParseTimeZone reads an IANA zone name ("Europe/Berlin"), "local", "UTC" or
+// a fixed offset ("+02:00"). An empty name returns nil.
+func ParseTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "local":
+		return time.Local, nil
+	}
+
+	if m := fixedOffset.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("time zone offset %q is out of range", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(name, offset), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// TimeZones resolves the scan's default zone and the per-camera zones, keyed
+// as written in the settings.
+func (s *Settings) TimeZones() (*time.Location, map[string]*time.Location, error) {
+	def, err := ParseTimeZone(s.Scan.TimeZone)
+	if err != nil {
+		return nil, nil, err
+	}
+	cameras := make(map[string]*time.Location, len(s.Scan.CameraTimeZones))
+	for camera, zone := range s.Scan.CameraTimeZones {
+		loc, err := ParseTimeZone(zone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("camera %q: %w", camera, err)
+		}
+		if loc != nil {
+			cameras[camera] = loc
+		}
+	}
+	return def, cameras, nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "fmt"
+
+// SetTool persists the location of an external tool to the [tools] table.
+// value is empty to look the tool up on PATH, "off" or an executable path.
+func SetTool(path, name, value string) error {
+	switch name {
+	case "exiftool", "ffprobe", "rclone", "tesseract", "gphoto2":
+	default:
+		return fmt.Errorf("unknown tool %q", name)
+	}
+
+	return Update(path, func(raw map[string]interface{}) {
+		table, _ := raw["tools"].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+		}
+		table[name] = value
+		raw["tools"] = table
+	})
+}
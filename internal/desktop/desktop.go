@@ -0,0 +1,44 @@
+// Package desktop hands files to the platform file manager and default apps,
+// and shows notifications through the platform's notification centre.
+package desktop
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Reveal shows path selected in the platform file manager.
+func Reveal(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("reveal %s: %w", path, err)
+	}
+	return start(revealCommand(path))
+}
+
+// Open opens path with its default application.
+func Open(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	return start(openCommand(path))
+}
+
+// Notify shows a notification with title and body. It waits for the
+// platform helper, which returns once the notification is queued.
+func Notify(title, body string) error {
+	cmd := notifyCommand(title, body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify with %s: %w: %s", cmd.Path, err, out)
+	}
+	return nil
+}
+
+// start launches cmd without waiting; file managers often keep running.
+func start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("launch %s: %w", cmd.Path, err)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,21 @@
+package desktop
+
+import "os/exec"
+
+func revealCommand(path string) *exec.Cmd {
+	return exec.Command("open", "-R", path)
+}
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("open", path)
+}
+
+// notifyCommand passes title and body as arguments rather than splicing them
+// into the script, so they need no AppleScript quoting.
+func notifyCommand(title, body string) *exec.Cmd {
+	return exec.Command("osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+		title, body)
+}
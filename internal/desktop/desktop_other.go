@@ -0,0 +1,24 @@
+//go:build !windows && !darwin
+
+package desktop
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// revealCommand opens the containing folder; xdg-open has no way to select
+// the file itself.
+func revealCommand(path string) *exec.Cmd {
+	return exec.Command("xdg-open", filepath.Dir(path))
+}
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("xdg-open", path)
+}
+
+// notifyCommand uses notify-send, which talks to whichever notification
+// daemon the desktop runs.
+func notifyCommand(title, body string) *exec.Cmd {
+	return exec.Command("notify-send", "--app-name=photoTidyGo", title, body)
+}
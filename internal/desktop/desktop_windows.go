@@ -0,0 +1,37 @@
+package desktop
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func revealCommand(path string) *exec.Cmd {
+	// explorer ignores standard argument quoting, so build the command line by hand.
+	cmd := exec.Command("explorer.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `explorer.exe /select,"` + path + `"`}
+	return cmd
+}
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("rundll32.exe", "url.dll,FileProtocolHandler", path)
+}
+
+// toastScript shows a toast through the WinRT notification API. Title and
+// body arrive in environment variables, which spares quoting them.
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:PHOTOTIDY_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:PHOTOTIDY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('photoTidyGo').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+
+// createNoWindow keeps PowerShell from flashing a console window.
+const createNoWindow = 0x08000000
+
+func notifyCommand(title, body string) *exec.Cmd {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(), "PHOTOTIDY_TITLE="+title, "PHOTOTIDY_BODY="+body)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+	return cmd
+}
@@ -0,0 +1,158 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// maxLogFiles bounds how many of the newest log files end up in a bundle.
+const maxLogFiles = 5
+
+// Bundle lists everything collected into a diagnostics archive.
+type Bundle struct {
+	SettingsPath string
+	RedactPaths  bool
+	LogDir       string
+	Environment  interface{}
+	JobHistory   interface{}
+}
+
+// quotedPath matches TOML string literals that look like filesystem paths.
+var quotedPath = regexp.MustCompile(`(['"])([A-Za-z]:[\\/]|[\\/~]|\\\\)[^'"]*(['"])`)
+
+// secretValue matches credential assignments, which are always redacted.
+var secretValue = regexp.MustCompile(`(?m)^(\s*(?:secretAccessKey|webdavPassword)\s*=\s*)(['"]).*(['"])`)
+
+// Write stores the bundle as a zip archive at dest.
+func Write(dest string, b Bundle) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create bundle directory: %w", err)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("create bundle: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	if err := writeJSON(zw, "environment.json", b.Environment); err != nil {
+		zw.Close()
+		return err
+	}
+	if err := writeJSON(zw, "job-history.json", b.JobHistory); err != nil {
+		zw.Close()
+		return err
+	}
+	if err := writeSettings(zw, b.SettingsPath, b.RedactPaths); err != nil {
+		zw.Close()
+		return err
+	}
+	if err := writeLogs(zw, b.LogDir); err != nil {
+		zw.Close()
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finalise bundle: %w", err)
+	}
+	return f.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, value interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(value); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeSettings(zw *zip.Writer, path string, redact bool) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// A missing settings file is itself useful information.
+		data = []byte(fmt.Sprintf("# unable to read %s: %v\n", path, err))
+	} else {
+		data = secretValue.ReplaceAll(data, []byte("${1}${2}<redacted>${3}"))
+		if redact {
+			data = quotedPath.ReplaceAll(data, []byte("${1}<redacted>${3}"))
+		}
+	}
+
+	w, err := zw.Create("settings.toml")
+	if err != nil {
+		return fmt.Errorf("add settings: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeLogs(zw *zip.Writer, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	type logFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{name: entry.Name(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	if len(files) > maxLogFiles {
+		files = files[:maxLogFiles]
+	}
+
+	for _, lf := range files {
+		if err := copyInto(zw, "logs/"+lf.name, filepath.Join(dir, lf.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyInto(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer src.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Envelope wraps every payload emitted to the frontend. JobID correlates
+// events of one scan or tidy run; Sequence is strictly increasing across all
+// events of a session so the UI can drop stale updates.
+type Envelope struct {
+	JobID    string      `json:"jobId,omitempty"`
+	Type     string      `json:"type"`
+	Version  int         `json:"version"`
+	Sequence uint64      `json:"sequence"`
+	Payload  interface{} `json:"payload"`
+}
+
+var (
+	sequence atomic.Uint64
+	jobs     atomic.Uint64
+)
+
+// versions maps every event name to its current payload version.
+var versions = map[string]int{
+	ScanProgress:     ScanProgressVersion,
+	TidyProgress:     TidyProgressVersion,
+	JobStats:         JobStatsVersion,
+	PowerState:       PowerStateVersion,
+	ScheduleActivity: ScheduleActivityVersion,
+	StartupRecovery:  StartupRecoveryVersion,
+	SettingsChanged:  SettingsChangedVersion,
+	NetworkState:     NetworkStateVersion,
+	BackfillProgress: BackfillProgressVersion,
+	BackupProgress:   BackupProgressVersion,
+	RcloneProgress:   RcloneProgressVersion,
+	VerifyProgress:   VerifyProgressVersion,
+	FacesProgress:    FacesProgressVersion,
+	LabelsProgress:   LabelsProgressVersion,
+	OCRProgress:      OCRProgressVersion,
+	PHashProgress:    PHashProgressVersion,
+	VolumeState:      VolumeStateVersion,
+	DeviceProgress:   DeviceProgressVersion,
+	CardInserted:     CardInsertedVersion,
+	OpenFolders:      OpenFoldersVersion,
+	SourcesDropped:   SourcesDroppedVersion,
+}
+
+// Wrap builds the envelope for one emitted event.
+func Wrap(jobID, name string, payload interface{}) Envelope {
+	return Envelope{
+		JobID:    jobID,
+		Type:     name,
+		Version:  versions[name],
+		Sequence: sequence.Add(1),
+		Payload:  payload,
+	}
+}
+
+// NewJobID returns a session-unique identifier such as "scan-3".
+func NewJobID(kind string) string {
+	return fmt.Sprintf("%s-%d", kind, jobs.Add(1))
+}
@@ -0,0 +1,46 @@
+package events
+
+import "sync"
+
+// Hub hands emitted envelopes to listeners outside the Wails runtime, such
+// as the gRPC service. A listener that falls behind misses envelopes rather
+// than holding up the job emitting them. The zero value is ready to use.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[chan Envelope]struct{}
+}
+
+// Subscribe returns a channel receiving every envelope published from now
+// on, buffering up to buffer of them, and a function ending the
+// subscription, which closes the channel.
+func (h *Hub) Subscribe(buffer int) (<-chan Envelope, func()) {
+	ch := make(chan Envelope, buffer)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan Envelope]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish passes env to every subscriber with room for it.
+func (h *Hub) Publish(env Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- env:
+		default:
+		}
+	}
+}
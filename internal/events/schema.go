@@ -0,0 +1,199 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Event names emitted to the frontend through the Wails runtime. Every
+// event carries an Envelope; frontend/src/lib/events.ts mirrors these names.
+const (
+	ScanProgress     = "scan:progress"
+	TidyProgress     = "tidy:progress"
+	JobStats         = "job:stats"
+	PowerState       = "power:state"
+	ScheduleActivity = "schedule:activity"
+	StartupRecovery  = "startup:recovery"
+	SettingsChanged  = "settings:changed"
+	NetworkState     = "network:state"
+	BackfillProgress = "backfill:progress"
+	BackupProgress   = "backup:progress"
+	RcloneProgress   = "rclone:progress"
+	VerifyProgress   = "verify:progress"
+	FacesProgress    = "faces:progress"
+	LabelsProgress   = "labels:progress"
+	OCRProgress      = "ocr:progress"
+	PHashProgress    = "phash:progress"
+	VolumeState      = "volume:state"
+	DeviceProgress   = "device:progress"
+	CardInserted     = "card:inserted"
+	OpenFolders      = "open:folders"
+	SourcesDropped   = "sources:dropped"
+	LibraryLocked    = "library:locked"
+)
+
+// Schema versions for every payload crossing the Go/JS boundary.
+const (
+	ScanProgressVersion     = 1
+	TidyProgressVersion     = 1
+	ScanSummaryVersion      = 1
+	TidySummaryVersion      = 1
+	DuplicateGroupsVersion  = 1
+	DuplicatePageVersion    = 1
+	DuplicateSummaryVersion = 1
+	RemovalSummaryVersion   = 1
+	JobStatsVersion         = 1
+	ScheduleActivityVersion = 1
+	PowerStateVersion       = 1
+	StartupRecoveryVersion  = 1
+	SettingsChangedVersion  = 1
+	NetworkStateVersion     = 1
+	BackfillProgressVersion = 1
+	BackupProgressVersion   = 1
+	BackupSummaryVersion    = 1
+	RcloneProgressVersion   = 1
+	ManifestSummaryVersion  = 1
+	VerifyProgressVersion   = 1
+	VerifySummaryVersion    = 1
+	PhotosImportVersion     = 1
+	LightroomImportVersion  = 1
+	FacesProgressVersion    = 1
+	FacesSummaryVersion     = 1
+	LabelsProgressVersion   = 1
+	LabelsSummaryVersion    = 1
+	OCRProgressVersion      = 1
+	OCRSummaryVersion       = 1
+	PHashProgressVersion    = 1
+	PHashSummaryVersion     = 1
+	SimilarImagesVersion    = 1
+	SimilarVideosVersion    = 1
+	VolumeStateVersion      = 1
+	DeviceProgressVersion   = 1
+	DeviceImportVersion     = 1
+	CardInsertedVersion     = 1
+	CardImportVersion       = 1
+	OpenFoldersVersion      = 1
+	SourcesDroppedVersion   = 1
+	StagingInfoVersion      = 1
+	StagingCommitVersion    = 1
+	MaintenanceVersion      = 1
+	LibraryLockedVersion    = 1
+)
+
+// Schema describes the JSON layout of an emitted event or returned summary.
+type Schema struct {
+	Name    string  `json:"name"`
+	Kind    string  `json:"kind"`
+	Version int     `json:"version"`
+	Fields  []Field `json:"fields"`
+}
+
+// Field describes a single JSON property within a schema.
+type Field struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Optional bool    `json:"optional,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+}
+
+// Schema kinds distinguish pushed events from binding return values.
+const (
+	KindEvent   = "event"
+	KindSummary = "summary"
+)
+
+// Describe derives a schema from the JSON tags of the sample value.
+// Versions must be bumped by hand whenever a field is renamed, removed or
+// changes type; purely additive changes may keep the version.
+func Describe(name, kind string, version int, sample interface{}) Schema {
+	return Schema{
+		Name:    name,
+		Kind:    kind,
+		Version: version,
+		Fields:  describeFields(reflect.TypeOf(sample)),
+	}
+}
+
+func describeFields(t reflect.Type) []Field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make([]Field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Name
+		optional := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					optional = true
+				}
+			}
+		}
+
+		field := Field{Name: name, Type: typeName(sf.Type), Optional: optional}
+		if nested := elemStruct(sf.Type); nested != nil && !isOpaque(nested) {
+			field.Fields = describeFields(nested)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isOpaque(t) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return typeName(t.Elem()) + "[]"
+	case reflect.Map:
+		return "map<" + typeName(t.Key()) + "," + typeName(t.Elem()) + ">"
+	case reflect.Struct:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+func elemStruct(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// isOpaque reports types that marshal to scalars despite being structs.
+func isOpaque(t reflect.Type) bool {
+	return t.PkgPath() == "time" && t.Name() == "Time"
+}
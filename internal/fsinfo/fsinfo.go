@@ -0,0 +1,82 @@
+// Package fsinfo reports whether a folder can be used by the application.
+package fsinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathStatus describes a folder chosen in a settings form. When the path
+// does not exist yet, Writable and FreeBytes refer to the closest existing
+// parent, which is where the folder would be created.
+type PathStatus struct {
+	Path      string `json:"path"`
+	Exists    bool   `json:"exists"`
+	IsDir     bool   `json:"isDir"`
+	Writable  bool   `json:"writable"`
+	FreeBytes uint64 `json:"freeBytes"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Inspect checks existence, writability and free space of path.
+func Inspect(path string) PathStatus {
+	status := PathStatus{Path: path}
+	if strings.TrimSpace(path) == "" {
+		status.Error = "path is empty"
+		return status
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Path = abs
+
+	info, err := os.Stat(abs)
+	switch {
+	case err == nil:
+		status.Exists = true
+		status.IsDir = info.IsDir()
+		if !status.IsDir {
+			status.Error = "path is not a folder"
+			return status
+		}
+	case !os.IsNotExist(err):
+		status.Error = err.Error()
+		return status
+	}
+
+	dir := existingAncestor(abs)
+	status.Writable = writable(dir)
+	if free, err := freeBytes(dir); err == nil {
+		status.FreeBytes = free
+	}
+	return status
+}
+
+func existingAncestor(path string) string {
+	for {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// writable probes dir with a temporary file, since permission bits do not
+// reflect ACLs or read-only mounts.
+func writable(dir string) bool {
+	probe, err := os.CreateTemp(dir, ".phototidy-probe-*")
+	if err != nil {
+		return false
+	}
+	name := probe.Name()
+	probe.Close()
+	return os.Remove(name) == nil
+}
@@ -0,0 +1,71 @@
+// Package keychain keeps secrets in the platform credential store: the
+// login keychain on macOS, the Credential Locker on Windows and the Secret
+// Service, through secret-tool, elsewhere. Secrets are stored under the
+// photoTidyGo service, one per account.
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// service names the entries the app owns in the credential store.
+const service = "photoTidyGo"
+
+// ErrNotFound is returned by Get when no secret is stored for the account.
+var ErrNotFound = errors.New("no secret stored in the keychain")
+
+// Get returns the secret stored for account.
+func Get(account string) (string, error) {
+	secret, err := get(account)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("read keychain: %w", err)
+	}
+	return secret, err
+}
+
+// Set stores secret for account, replacing any stored before.
+func Set(account, secret string) error {
+	if err := set(account, secret); err != nil {
+		return fmt.Errorf("write keychain: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the secret stored for account. A missing one is not an
+// error.
+func Delete(account string) error {
+	if err := remove(account); err != nil {
+		return fmt.Errorf("delete from keychain: %w", err)
+	}
+	return nil
+}
+
+// run runs cmd with stdin and returns its trimmed output, folding stderr
+// into the error.
+func run(cmd *exec.Cmd, stdin string) (string, error) {
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", cmd.Path, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// exitCode returns the exit status of a finished helper, or -1.
+func exitCode(err error) int {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	return -1
+}
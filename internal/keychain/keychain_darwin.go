@@ -0,0 +1,33 @@
+package keychain
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// errItemNotFound is the exit status of security(1) for a missing item.
+const errItemNotFound = 44
+
+func get(account string) (string, error) {
+	secret, err := run(exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w"), "")
+	if exitCode(err) == errItemNotFound {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+// set feeds the command to security's interactive mode, which keeps the
+// secret off the command line other processes can read.
+func set(account, secret string) error {
+	cmd := fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", service, account, secret)
+	_, err := run(exec.Command("security", "-i"), cmd)
+	return err
+}
+
+func remove(account string) error {
+	_, err := run(exec.Command("security", "delete-generic-password", "-s", service, "-a", account), "")
+	if exitCode(err) == errItemNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+//go:build !windows && !darwin
+
+package keychain
+
+import "os/exec"
+
+// get uses secret-tool, which talks to whichever Secret Service the
+// desktop runs (GNOME Keyring, KWallet). It exits 1 with no output when
+// nothing matches.
+func get(account string) (string, error) {
+	secret, err := run(exec.Command("secret-tool", "lookup", "service", service, "account", account), "")
+	if exitCode(err) == 1 || (err == nil && secret == "") {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+// set passes the secret on stdin, which keeps it off the command line.
+func set(account, secret string) error {
+	_, err := run(exec.Command("secret-tool", "store", "--label=photoTidyGo library key", "service", service, "account", account), secret)
+	return err
+}
+
+func remove(account string) error {
+	_, err := run(exec.Command("secret-tool", "clear", "service", service, "account", account), "")
+	return err
+}
@@ -0,0 +1,54 @@
+package keychain
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// vaultScript loads the WinRT PasswordVault, which stores the credentials
+// of the Credential Locker. Account and secret arrive in environment
+// variables, which spares quoting them and keeps them off the command line.
+const vaultScript = `[Windows.Security.Credentials.PasswordVault, Windows.Security.Credentials, ContentType = WindowsRuntime] > $null
+$vault = New-Object Windows.Security.Credentials.PasswordVault
+`
+
+// notFoundExit is the exit status the scripts use for a missing credential.
+const notFoundExit = 3
+
+const getScript = vaultScript + `try { $cred = $vault.Retrieve('` + service + `', $env:PHOTOTIDY_ACCOUNT) } catch { exit 3 }
+$cred.RetrievePassword()
+[Console]::Out.Write($cred.Password)`
+
+const setScript = vaultScript + `$vault.Add((New-Object Windows.Security.Credentials.PasswordCredential('` + service + `', $env:PHOTOTIDY_ACCOUNT, $env:PHOTOTIDY_SECRET)))`
+
+const removeScript = vaultScript + `try { $cred = $vault.Retrieve('` + service + `', $env:PHOTOTIDY_ACCOUNT) } catch { exit 0 }
+$vault.Remove($cred)`
+
+// createNoWindow keeps PowerShell from flashing a console window.
+const createNoWindow = 0x08000000
+
+func powershell(script string, env ...string) *exec.Cmd {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+	return cmd
+}
+
+func get(account string) (string, error) {
+	secret, err := run(powershell(getScript, "PHOTOTIDY_ACCOUNT="+account), "")
+	if exitCode(err) == notFoundExit {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func set(account, secret string) error {
+	_, err := run(powershell(setScript, "PHOTOTIDY_ACCOUNT="+account, "PHOTOTIDY_SECRET="+secret), "")
+	return err
+}
+
+func remove(account string) error {
+	_, err := run(powershell(removeScript, "PHOTOTIDY_ACCOUNT="+account), "")
+	return err
+}
@@ -0,0 +1,407 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// coreDataEpoch is the Unix time of 2001-01-01 UTC, from which Photos counts
+// its timestamps.
+const coreDataEpoch = 978307200
+
+// PhotosAsset is one original in an Apple Photos library.
+type PhotosAsset struct {
+	UUID string
+	// Path is the original's location inside the bundle, slash-separated.
+	Path string
+	// OriginalName is the name the file was imported with; Photos keeps
+	// originals under their UUID.
+	OriginalName string
+	// TakenAt is the capture instant as adjusted in Photos. UTCOffsetSeconds
+	// is the offset of the zone it was taken in, when HasOffset is set.
+	TakenAt          time.Time
+	UTCOffsetSeconds int
+	HasOffset        bool
+	Favorite         bool
+}
+
+// WallClock is TakenAt on the clock of the zone it was taken in, in the
+// wall-clock-as-UTC form media rows keep.
+func (a PhotosAsset) WallClock() time.Time {
+	if !a.HasOffset {
+		return a.TakenAt
+	}
+	return a.TakenAt.Add(time.Duration(a.UTCOffsetSeconds) * time.Second)
+}
+
+// PhotosAlbum is a user album of an Apple Photos library.
+type PhotosAlbum struct {
+	UUID  string
+	Title string
+	// Assets lists the UUIDs of the album's assets.
+	Assets []string
+}
+
+// PhotosLibrary is what ReadPhotosLibrary found in a bundle.
+type PhotosLibrary struct {
+	Path   string
+	Assets []PhotosAsset
+	Albums []PhotosAlbum
+}
+
+// ReadPhotosLibrary reads the assets and user albums of a .photoslibrary
+// bundle (Photos 5, macOS 10.15, and later). The database is read from a
+// temporary copy, so the bundle is never written to, even while Photos has
+// it open. Trashed assets and albums are left out.
+func ReadPhotosLibrary(ctx context.Context, bundle string) (*PhotosLibrary, error) {
+	dbPath := filepath.Join(bundle, "database", "Photos.sqlite")
+	if _, err := os.Stat(dbPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s is not a Photos library, or predates Photos 5", bundle)
+		}
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "phototidy-photos-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	// The write-ahead log holds recent changes not yet in the main file.
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := copyPlain(dbPath+suffix, filepath.Join(tmp, "Photos.sqlite"+suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("copy photos database: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(tmp, "Photos.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("open photos database: %w", err)
+	}
+	defer db.Close()
+
+	lib := &PhotosLibrary{Path: bundle}
+	if lib.Assets, err = readPhotosAssets(ctx, db, bundle); err != nil {
+		return nil, err
+	}
+	if lib.Albums, err = readPhotosAlbums(ctx, db); err != nil {
+		return nil, err
+	}
+	return lib, nil
+}
+
+func copyPlain(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// photosAssetTable returns the asset table, which Photos 5 called
+// ZGENERICASSET and later versions ZASSET.
+func photosAssetTable(ctx context.Context, db *sql.DB) (string, error) {
+	for _, table := range []string{"ZASSET", "ZGENERICASSET"} {
+		var n int
+		if err := db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+			return "", fmt.Errorf("read photos schema: %w", err)
+		}
+		if n > 0 {
+			return table, nil
+		}
+	}
+	return "", errors.New("unsupported Photos library: no asset table")
+}
+
+func readPhotosAssets(ctx context.Context, db *sql.DB, bundle string) ([]PhotosAsset, error) {
+	table, err := photosAssetTable(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT a.ZUUID, COALESCE(a.ZDIRECTORY, ''), a.ZFILENAME, a.ZDATECREATED, COALESCE(a.ZFAVORITE, 0),
+    COALESCE(x.ZORIGINALFILENAME, ''), x.ZTIMEZONEOFFSET
+FROM `+table+` a
+LEFT JOIN ZADDITIONALASSETATTRIBUTES x ON x.ZASSET = a.Z_PK
+WHERE COALESCE(a.ZTRASHEDSTATE, 0) = 0 AND a.ZFILENAME IS NOT NULL
+ORDER BY a.Z_PK`)
+	if err != nil {
+		return nil, fmt.Errorf("query photos assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []PhotosAsset
+	for rows.Next() {
+		var (
+			asset   PhotosAsset
+			dir     string
+			created sql.NullFloat64
+			offset  sql.NullInt64
+		)
+		if err := rows.Scan(&asset.UUID, &dir, &asset.Path, &created, &asset.Favorite, &asset.OriginalName, &offset); err != nil {
+			return nil, fmt.Errorf("scan photos asset: %w", err)
+		}
+		asset.Path = photosOriginal(bundle, dir, asset.Path)
+		if asset.OriginalName == "" {
+			asset.OriginalName = path.Base(asset.Path)
+		}
+		if created.Valid {
+			seconds := int64(created.Float64)
+			asset.TakenAt = time.Unix(coreDataEpoch+seconds, 0).UTC()
+		}
+		if offset.Valid {
+			asset.UTCOffsetSeconds, asset.HasOffset = int(offset.Int64), true
+		}
+		assets = append(assets, asset)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate photos assets: %w", err)
+	}
+	return assets, nil
+}
+
+// photosOriginal locates an original inside the bundle: below "originals"
+// since Photos 5, below "Masters" in libraries upgraded from older versions.
+func photosOriginal(bundle, dir, name string) string {
+	rel := path.Join("originals", dir, name)
+	if strings.HasPrefix(dir, "originals/") || strings.HasPrefix(dir, "Masters/") {
+		rel = path.Join(dir, name)
+	} else if _, err := os.Stat(filepath.Join(bundle, filepath.FromSlash(rel))); err != nil {
+		if _, err := os.Stat(filepath.Join(bundle, "Masters", filepath.FromSlash(dir), name)); err == nil {
+			rel = path.Join("Masters", dir, name)
+		}
+	}
+	return rel
+}
+
+func readPhotosAlbums(ctx context.Context, db *sql.DB) ([]PhotosAlbum, error) {
+	join, albumCol, assetCol, err := photosAlbumJoin(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	table, err := photosAssetTable(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kind 2 marks albums the user made, as opposed to folders, smart
+	// albums and the built-in ones.
+	rows, err := db.QueryContext(ctx, `
+SELECT g.ZUUID, g.ZTITLE, a.ZUUID
+FROM ZGENERICALBUM g
+JOIN `+join+` j ON j.`+albumCol+` = g.Z_PK
+JOIN `+table+` a ON a.Z_PK = j.`+assetCol+`
+WHERE g.ZKIND = 2 AND COALESCE(g.ZTRASHEDSTATE, 0) = 0 AND COALESCE(g.ZTITLE, '') <> ''
+    AND COALESCE(a.ZTRASHEDSTATE, 0) = 0
+ORDER BY g.Z_PK, a.Z_PK`)
+	if err != nil {
+		return nil, fmt.Errorf("query photos albums: %w", err)
+	}
+	defer rows.Close()
+
+	var albums []PhotosAlbum
+	for rows.Next() {
+		var uuid, title, asset string
+		if err := rows.Scan(&uuid, &title, &asset); err != nil {
+			return nil, fmt.Errorf("scan photos album: %w", err)
+		}
+		if n := len(albums); n == 0 || albums[n-1].UUID != uuid {
+			albums = append(albums, PhotosAlbum{UUID: uuid, Title: title})
+		}
+		last := &albums[len(albums)-1]
+		last.Assets = append(last.Assets, asset)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate photos albums: %w", err)
+	}
+	return albums, nil
+}
+
+// photosAlbumJoin finds the album membership table. Core Data numbers it
+// after the entities, e.g. Z_26ASSETS with columns Z_26ALBUMS and Z_3ASSETS,
+// and the numbers change between Photos versions.
+func photosAlbumJoin(ctx context.Context, db *sql.DB) (table, albumCol, assetCol string, err error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'Z\_%ASSETS' ESCAPE '\' ORDER BY name`)
+	if err != nil {
+		return "", "", "", fmt.Errorf("read photos schema: %w", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return "", "", "", fmt.Errorf("read photos schema: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	for _, name := range tables {
+		cols, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", name))
+		if err != nil {
+			return "", "", "", fmt.Errorf("read photos schema: %w", err)
+		}
+		albumCol, assetCol = "", ""
+		for cols.Next() {
+			var (
+				cid, notNull, pk int
+				col, typ         string
+				dflt             sql.NullString
+			)
+			if err := cols.Scan(&cid, &col, &typ, &notNull, &dflt, &pk); err != nil {
+				cols.Close()
+				return "", "", "", fmt.Errorf("read photos schema: %w", err)
+			}
+			switch {
+			case strings.HasPrefix(col, "Z_FOK_"):
+			case strings.HasSuffix(col, "ALBUMS"):
+				albumCol = col
+			case strings.HasSuffix(col, "ASSETS"):
+				assetCol = col
+			}
+		}
+		cols.Close()
+		if albumCol != "" && assetCol != "" {
+			return name, albumCol, assetCol, nil
+		}
+	}
+	return "", "", "", errors.New("unsupported Photos library: no album membership table")
+}
+
+// PhotosPull reports originals copied out of a Photos library.
+type PhotosPull struct {
+	Copied int `json:"copied"`
+	// Unchanged counts originals copied by an earlier import.
+	Unchanged int `json:"unchanged"`
+	// Missing counts originals not stored in the bundle, typically those
+	// kept in iCloud only.
+	Missing int      `json:"missing"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// PullPhotosLibrary copies the originals of lib with a wanted extension into
+// dest, named as they were imported into Photos, so they can be scanned and
+// tidied without touching the bundle. Like PullRemote it skips originals an
+// earlier import copied, unless they changed since. It returns the local
+// path of each asset whose original is in dest, by UUID.
+func PullPhotosLibrary(ctx context.Context, store *storage.Store, lib *PhotosLibrary, dest string, extensions []string, gate *PauseGate, throttle *Throttle) (map[string]string, PhotosPull, error) {
+	var summary PhotosPull
+	pulled, err := store.ListRemotePulls(ctx, lib.Path)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	wanted := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		wanted[ext] = true
+	}
+
+	local := make(map[string]string, len(lib.Assets))
+	used := make(map[string]bool, len(lib.Assets))
+	var pulls []storage.RemotePull
+	for _, asset := range lib.Assets {
+		if !wanted[strings.ToLower(path.Ext(asset.Path))] {
+			continue
+		}
+		if err := gate.Wait(ctx); err != nil {
+			return local, summary, err
+		}
+		// Named before the stat so names stay put when missing originals
+		// are downloaded later.
+		target := filepath.Join(dest, photosLocalName(asset, used))
+		src := filepath.Join(lib.Path, filepath.FromSlash(asset.Path))
+		info, err := os.Stat(src)
+		if err != nil {
+			summary.Missing++
+			continue
+		}
+		if prior, ok := pulled[asset.Path]; ok && prior.SizeBytes == info.Size() && prior.ModTime.Equal(info.ModTime().UTC()) {
+			summary.Unchanged++
+			local[asset.UUID] = target
+			continue
+		}
+		if err := throttle.Between(ctx); err != nil {
+			return local, summary, err
+		}
+		if err := pullOriginal(src, target, info.ModTime(), throttle); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", asset.Path, err))
+			continue
+		}
+		summary.Copied++
+		local[asset.UUID] = target
+		pulls = append(pulls, storage.RemotePull{Path: asset.Path, SizeBytes: info.Size(), ModTime: info.ModTime().UTC()})
+	}
+	if len(pulls) > 0 {
+		if err := store.RecordRemotePulls(ctx, lib.Path, pulls); err != nil {
+			return local, summary, err
+		}
+	}
+	return local, summary, nil
+}
+
+// photosLocalName places an original in a folder named like its folder in
+// the bundle, which keeps the number of files per folder down. Originals
+// imported under the same name get the start of their UUID appended.
+func photosLocalName(asset PhotosAsset, used map[string]bool) string {
+	dir := path.Base(path.Dir(asset.Path))
+	name := sanitizeSegment(asset.OriginalName)
+	rel := filepath.Join(dir, name)
+	if used[strings.ToLower(rel)] {
+		ext := filepath.Ext(name)
+		rel = filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+strings.ToLower(shortUUID(asset.UUID))+ext)
+	}
+	used[strings.ToLower(rel)] = true
+	return rel
+}
+
+func shortUUID(uuid string) string {
+	if len(uuid) > 8 {
+		return uuid[:8]
+	}
+	return uuid
+}
+
+// pullOriginal copies src to dest through a temporary file and carries the
+// modification time over, which scans fall back on for undated files.
+func pullOriginal(src, dest string, modTime time.Time, throttle *Throttle) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".partial"
+	if err := copyFile(src, tmp, false, throttle); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package media
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ArchiveSeparator joins an archive path and an entry name into the virtual
+// path stored for entries, e.g. "backup.zip!/DCIM/IMG_001.jpg".
+const ArchiveSeparator = "!/"
+
+// exifHeadBytes is how much of an entry is kept for EXIF and size decoding
+// while its content streams through the hasher.
+const exifHeadBytes = 256 << 10
+
+var errEntryFound = errors.New("archive entry found")
+
+// archiveEntry is one regular file inside an archive. open is only valid
+// during the walkArchive callback that received the entry.
+type archiveEntry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	open    func() (io.ReadCloser, error)
+}
+
+// IsArchivePath reports whether path names an entry inside an archive.
+func IsArchivePath(path string) bool {
+	_, _, ok := splitArchivePath(path)
+	return ok
+}
+
+// splitArchivePath separates a virtual path into the archive on disk and the
+// entry name within it.
+func splitArchivePath(virtual string) (archive, entry string, ok bool) {
+	offset := 0
+	for {
+		i := strings.Index(virtual[offset:], ArchiveSeparator)
+		if i < 0 {
+			return "", "", false
+		}
+		i += offset
+		if archiveKind(virtual[:i]) != "" {
+			return virtual[:i], virtual[i+len(ArchiveSeparator):], true
+		}
+		offset = i + len(ArchiveSeparator)
+	}
+}
+
+// archiveKind classifies path by extension: "zip", "tar", "tgz" or "".
+func archiveKind(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tgz"
+	default:
+		return ""
+	}
+}
+
+// walkArchive calls visit for every regular file in the archive at path,
+// stopping at the first error visit returns.
+func walkArchive(archive string, visit func(archiveEntry) error) error {
+	switch archiveKind(archive) {
+	case "zip":
+		zr, err := zip.OpenReader(longPath(archive))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			f := f
+			entry := archiveEntry{
+				Name:    cleanEntryName(f.Name),
+				Size:    int64(f.UncompressedSize64),
+				ModTime: f.Modified,
+				open:    f.Open,
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "tar", "tgz":
+		f, err := os.Open(longPath(archive))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var r io.Reader = f
+		if archiveKind(archive) == "tgz" {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			r = gz
+		}
+
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			entry := archiveEntry{
+				Name:    cleanEntryName(hdr.Name),
+				Size:    hdr.Size,
+				ModTime: hdr.ModTime,
+				open:    func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("%s is not a supported archive", archive)
+	}
+}
+
+func cleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// scanArchive records the wanted entries of archive under virtual paths,
+// hashing their content as it streams out of the archive.
+func (r *scanRun) scanArchive(ctx context.Context, w *sourceWalk, archive string) error {
+	opts := r.opts
+	return walkArchive(archive, func(entry archiveEntry) error {
+		if len(w.rules.extSet) > 0 {
+			if _, ok := w.rules.extSet[strings.ToLower(path.Ext(entry.Name))]; !ok {
+				r.inc(&w.sum.FilesSkipped)
+				return nil
+			}
+		}
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return err
+		}
+		r.inc(&w.sum.FilesDiscovered)
+
+		virtual := archive + ArchiveSeparator + entry.Name
+		if opts.Incremental {
+			size, modTime, ok, err := r.s.store.GetMediaStamp(ctx, virtual)
+			if err == nil && ok && size == entry.Size && modTime.Equal(entry.ModTime.UTC().Truncate(time.Second)) {
+				r.inc(&w.sum.FilesUnchanged)
+				opts.Stats.Record(0, false)
+				return nil
+			}
+		}
+
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return err
+		}
+		file, fields, err := buildArchiveFile(virtual, entry, opts.Throttle)
+		if err != nil {
+			opts.Stats.Record(0, true)
+			r.addError(w.sum, "metadata", virtual, err)
+			return nil
+		}
+		localise(&file, opts)
+		r.record(ctx, w, file, fields)
+		return nil
+	})
+}
+
+func buildArchiveFile(virtual string, entry archiveEntry, throttle *Throttle) (storage.MediaFile, map[string]string, error) {
+	rc, err := entry.open()
+	if err != nil {
+		return storage.MediaFile{}, nil, err
+	}
+	defer rc.Close()
+
+	hasher := md5.New()
+	head := &headBuffer{max: exifHeadBytes}
+	size, err := io.Copy(io.MultiWriter(hasher, head), throttle.Reader(rc))
+	if err != nil {
+		return storage.MediaFile{}, nil, err
+	}
+
+	meta := decodeEXIF(bytes.NewReader(head.Bytes()))
+	mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(entry.Name)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(head.Bytes())
+	}
+	hasCameraData := meta.Make != "" || meta.Model != "" || !meta.TakenAt.IsZero()
+
+	var width, height int
+	if strings.HasPrefix(mimeType, "image/") {
+		width, height = imageSizeFrom(bytes.NewReader(head.Bytes()), meta.Fields)
+	}
+
+	file := storage.MediaFile{
+		Path:        virtual,
+		HashMD5:     hex.EncodeToString(hasher.Sum(nil)),
+		SizeBytes:   size,
+		ModTime:     entry.ModTime.UTC(),
+		MimeType:    makeNullString(mimeType),
+		CameraMake:  makeNullString(meta.Make),
+		CameraModel: makeNullString(meta.Model),
+		Width:       width,
+		Height:      height,
+		Category:    classify(virtual, mimeType, hasCameraData, width, height),
+		Latitude:    meta.Latitude,
+		Longitude:   meta.Longitude,
+	}
+	if !meta.TakenAt.IsZero() {
+		file.TakenAt = sql.NullTime{Time: meta.TakenAt, Valid: true}
+		placeInZone(&file, meta.Zone)
+	}
+	return file, meta.Fields, nil
+}
+
+// extractEntry copies the archive entry behind virtual to dest and checks it
+// against the hash recorded at scan time. The archive itself is untouched.
+func extractEntry(b Backend, virtual, dest, expectedHash string, throttle *Throttle) error {
+	archive, name, ok := splitArchivePath(virtual)
+	if !ok {
+		return fmt.Errorf("%s is not an archive entry", virtual)
+	}
+
+	err := walkArchive(archive, func(entry archiveEntry) error {
+		if entry.Name != name {
+			return nil
+		}
+		if err := copyEntry(b, entry, dest, expectedHash, throttle); err != nil {
+			return err
+		}
+		return errEntryFound
+	})
+	switch {
+	case errors.Is(err, errEntryFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return fmt.Errorf("entry %s not found in %s", name, archive)
+	}
+}
+
+func copyEntry(b Backend, entry archiveEntry, dest, expectedHash string, throttle *Throttle) error {
+	rc, err := entry.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	hasher := md5.New()
+	err = b.Copy(io.TeeReader(throttle.Reader(rc), hasher), dest)
+	if errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	if err == nil && expectedHash != "" {
+		if hash := hex.EncodeToString(hasher.Sum(nil)); hash != expectedHash {
+			err = fmt.Errorf("verify target: hash mismatch (%s != %s)", hash, expectedHash)
+		}
+	}
+	if err != nil {
+		_ = b.Remove(dest)
+		return err
+	}
+	if isLocal(b) && !entry.ModTime.IsZero() {
+		_ = os.Chtimes(longPath(dest), entry.ModTime, entry.ModTime)
+	}
+	return nil
+}
+
+// headBuffer keeps the first max bytes written to it and discards the rest.
+type headBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if room := h.max - h.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		h.Buffer.Write(p[:room])
+	}
+	return len(p), nil
+}
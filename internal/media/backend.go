@@ -0,0 +1,270 @@
+package media
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backend is the file system tidy targets live on. LocalBackend serves local
+// folders and mounted shares, WebDAV serves targets given as http(s) URLs and
+// RcloneBackend serves "remote:path" targets.
+// Paths are absolute in the backend's own syntax, the way they are stored in
+// the library.
+type Backend interface {
+	// Stat describes the file or folder at path. Missing paths report an
+	// error matching fs.ErrNotExist.
+	Stat(path string) (fs.FileInfo, error)
+	// List returns the names of the entries of the folder at dir.
+	List(dir string) ([]string, error)
+	// Mkdir creates the folder at path along with any missing parents.
+	Mkdir(path string) error
+	// Copy writes src to a new file at dest; an existing dest is an error
+	// matching fs.ErrExist.
+	Copy(src io.Reader, dest string) error
+	// Rename moves a file within the backend without replacing dest.
+	Rename(src, dest string) error
+	// Open reads the file at path.
+	Open(path string) (io.ReadCloser, error)
+	// Remove deletes the file at path.
+	Remove(path string) error
+	// Join appends slash- or separator-delimited elements to dir.
+	Join(dir string, elem ...string) string
+	// Dir returns the folder holding path.
+	Dir(path string) string
+}
+
+// BackendCredentials authenticate against remote targets.
+type BackendCredentials struct {
+	Username string
+	Password string
+}
+
+// IsRemote reports whether path names a file on a WebDAV server or an rclone
+// remote.
+func IsRemote(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || IsRcloneRemote(path)
+}
+
+// NewBackend returns the backend serving base: WebDAV for http(s) URLs and
+// the local file system otherwise. rclone remotes need the rclone executable
+// and are opened with Rclone.Backend instead.
+func NewBackend(base string, creds BackendCredentials) (Backend, error) {
+	if IsRcloneRemote(base) {
+		return nil, fmt.Errorf("%s is an rclone remote, which needs rclone", base)
+	}
+	if IsRemote(base) {
+		return NewWebDAV(base, creds)
+	}
+	return LocalBackend{}, nil
+}
+
+// backendFor returns b for paths on a remote backend and the local file
+// system for the rest, such as quarantined files and prior locations.
+func backendFor(b Backend, path string) Backend {
+	if b == nil || !IsRemote(path) {
+		return LocalBackend{}
+	}
+	return b
+}
+
+// isLocal reports whether b is the local file system.
+func isLocal(b Backend) bool {
+	_, ok := b.(LocalBackend)
+	return ok
+}
+
+// LocalBackend is the local file system, including mounted network shares.
+type LocalBackend struct{}
+
+func (LocalBackend) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(longPath(path))
+}
+
+func (LocalBackend) List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(longPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+func (LocalBackend) Mkdir(path string) error {
+	return os.MkdirAll(longPath(path), 0o755)
+}
+
+func (LocalBackend) Copy(src io.Reader, dest string) error {
+	out, err := os.OpenFile(longPath(dest), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, src)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (LocalBackend) Rename(src, dest string) error {
+	if _, err := os.Lstat(longPath(dest)); err == nil {
+		return &fs.PathError{Op: "rename", Path: dest, Err: fs.ErrExist}
+	}
+	return os.Rename(longPath(src), longPath(dest))
+}
+
+func (LocalBackend) Open(path string) (io.ReadCloser, error) {
+	return os.Open(longPath(path))
+}
+
+func (LocalBackend) Remove(path string) error {
+	return os.Remove(longPath(path))
+}
+
+func (LocalBackend) Join(dir string, elem ...string) string {
+	return filepath.Join(append([]string{dir}, elem...)...)
+}
+
+func (LocalBackend) Dir(path string) string {
+	return filepath.Dir(path)
+}
+
+// fileSender is implemented by backends that transfer local files with
+// their own tooling rather than through Copy.
+type fileSender interface {
+	// Send copies the local file src to dest.
+	Send(src, dest string) error
+}
+
+// remoteHasher is implemented by backends that can hash a file without
+// reading it back.
+type remoteHasher interface {
+	HashMD5(path string) (string, error)
+}
+
+// hashOn returns the MD5 of the file at path on b.
+func hashOn(b Backend, path string, throttle *Throttle) (string, error) {
+	if hasher, ok := b.(remoteHasher); ok {
+		return hasher.HashMD5(path)
+	}
+	rc, err := b.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, throttle.Reader(rc)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// sendFile moves the local file src to dest on a remote backend. The copy
+// lands under a temporary name and is renamed into place once verified, so
+// an interrupted transfer never leaves a partial file at dest.
+func sendFile(b Backend, src, dest string, safety SafetyLevel, expectedHash string, throttle *Throttle) error {
+	f, err := os.Open(longPath(src))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	partial := b.Join(b.Dir(dest), "."+filepath.Base(dest)+".part")
+	if err := b.Remove(partial); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("clear partial upload: %w", err)
+	}
+	if sender, ok := b.(fileSender); ok {
+		err = sender.Send(src, partial)
+	} else {
+		err = b.Copy(throttle.Reader(f), partial)
+	}
+	if err != nil {
+		_ = b.Remove(partial)
+		return err
+	}
+	if safety != SafetyFast && expectedHash != "" {
+		hash, err := hashOn(b, partial, throttle)
+		if err == nil && hash != expectedHash {
+			err = fmt.Errorf("hash mismatch (%s != %s)", hash, expectedHash)
+		}
+		if err != nil {
+			_ = b.Remove(partial)
+			return fmt.Errorf("verify target: %w", err)
+		}
+	}
+	if err := b.Rename(partial, dest); err != nil {
+		_ = b.Remove(partial)
+		return err
+	}
+
+	f.Close()
+	if err := os.Remove(longPath(src)); err != nil {
+		return fmt.Errorf("remove source after copy: %w", err)
+	}
+	return nil
+}
+
+// fetchFile moves the file src on a remote backend to the local path dest,
+// as undoing a move onto the backend requires.
+func fetchFile(b Backend, src, dest string, throttle *Throttle) error {
+	rc, err := b.Open(src)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := (LocalBackend{}).Copy(throttle.Reader(rc), dest); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			_ = os.Remove(longPath(dest))
+		}
+		return err
+	}
+	rc.Close()
+	if err := b.Remove(src); err != nil {
+		return fmt.Errorf("remove remote copy: %w", err)
+	}
+	return nil
+}
+
+// joinSlash appends elements to dir below root on a backend whose paths use
+// slashes; ".." never climbs above root.
+func joinSlash(root, dir string, elem ...string) string {
+	joined := strings.TrimRight(dir, "/")
+	for _, e := range elem {
+		for _, segment := range strings.Split(filepath.ToSlash(e), "/") {
+			switch segment {
+			case "", ".":
+			case "..":
+				if joined != root {
+					joined = dirSlash(root, joined)
+				}
+			default:
+				if !strings.HasSuffix(joined, ":") {
+					joined += "/"
+				}
+				joined += segment
+			}
+		}
+	}
+	return joined
+}
+
+// dirSlash returns the folder holding p below root on a backend whose paths
+// use slashes.
+func dirSlash(root, p string) string {
+	if i := strings.LastIndex(p, "/"); i > len(root)-1 && strings.HasPrefix(p, root) {
+		return p[:i]
+	}
+	return root
+}
@@ -0,0 +1,182 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// backfillBatch is how many rows a backfill processes before yielding to
+// other jobs and saving its progress.
+const backfillBatch = 50
+
+// Backfiller fills in data that rows scanned by older versions lack, such
+// as dimensions, GPS positions and EXIF tags, without a full rescan.
+type Backfiller struct {
+	store *storage.Store
+}
+
+// NewBackfiller constructs a Backfiller.
+func NewBackfiller(store *storage.Store) *Backfiller {
+	return &Backfiller{store: store}
+}
+
+// BackfillOptions configures a backfill run.
+type BackfillOptions struct {
+	// Gate, when set, can pause the backfill between files.
+	Gate *PauseGate
+	// Throttle paces file reads; nil disables throttling.
+	Throttle *Throttle
+	// Acquire is held while a batch runs and released between batches, so
+	// scans and tidy runs take precedence; nil runs batches back to back.
+	Acquire func(ctx context.Context) (release func(), err error)
+	// ThumbnailDir and ThumbnailSize say where the thumbnails job caches
+	// thumbnails and how large they are.
+	ThumbnailDir  string
+	ThumbnailSize int
+}
+
+// Run processes the rows job has yet to visit in batches, saving progress
+// after each one so an interrupted run resumes where it stopped.
+func (b *Backfiller) Run(ctx context.Context, job string, opts BackfillOptions, onProgress func(storage.BackfillState)) (storage.BackfillState, error) {
+	if job == storage.BackfillThumbnails && (opts.ThumbnailDir == "" || opts.ThumbnailSize <= 0) {
+		return storage.BackfillState{Job: job}, errors.New("thumbnail backfill needs a cache folder and size")
+	}
+	state, err := b.store.StartBackfill(ctx, job)
+	if err != nil {
+		return state, err
+	}
+	acquire := opts.Acquire
+	if acquire == nil {
+		acquire = func(context.Context) (func(), error) { return func() {}, nil }
+	}
+	// Progress is saved even when ctx is cancelled, so a restart resumes.
+	save := func() error {
+		return b.store.SaveBackfill(context.WithoutCancel(ctx), state)
+	}
+
+	for {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return state, err
+		}
+		release, err := acquire(ctx)
+		if err != nil {
+			return state, err
+		}
+		files, err := b.store.NextBackfillBatch(ctx, job, state.LastID, backfillBatch)
+		if err != nil {
+			release()
+			return state, err
+		}
+		if len(files) == 0 {
+			release()
+			state.Status = storage.BackfillDone
+			state.Remaining = 0
+			if err := save(); err != nil {
+				return state, err
+			}
+			if onProgress != nil {
+				onProgress(state)
+			}
+			return state, nil
+		}
+
+		runErr := b.runBatch(ctx, job, files, opts, &state)
+		release()
+		if err := save(); err != nil {
+			return state, err
+		}
+		if runErr != nil {
+			return state, runErr
+		}
+		if onProgress != nil {
+			onProgress(state)
+		}
+	}
+}
+
+func (b *Backfiller) runBatch(ctx context.Context, job string, files []storage.MediaFile, opts BackfillOptions, state *storage.BackfillState) error {
+	for _, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return err
+		}
+
+		updated, err := b.fill(ctx, job, file, opts)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		state.LastID = file.ID
+		state.Processed++
+		state.Remaining = max(state.Remaining-1, 0)
+		switch {
+		case err != nil:
+			state.Failed++
+		case updated:
+			state.Updated++
+		}
+	}
+	return nil
+}
+
+// fill derives the data job adds for one file and stores it. It reports
+// whether anything was found to store.
+func (b *Backfiller) fill(ctx context.Context, job string, file storage.MediaFile, opts BackfillOptions) (bool, error) {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return false, err
+	}
+
+	switch job {
+	case storage.BackfillDimensions:
+		meta := extractEXIF(file.Path)
+		width, height := imageSize(file.Path, meta.Fields)
+		if width == 0 || height == 0 {
+			return false, nil
+		}
+		file.Width, file.Height = width, height
+		hasCameraData := file.CameraMake.Valid || file.CameraModel.Valid || file.TakenAt.Valid
+		file.Category = classify(file.Path, file.MimeType.String, hasCameraData, width, height)
+		return true, b.store.ApplyBackfill(ctx, file)
+
+	case storage.BackfillPosition:
+		meta := extractEXIF(file.Path)
+		if !meta.Latitude.Valid {
+			return false, nil
+		}
+		file.Latitude, file.Longitude = meta.Latitude, meta.Longitude
+		return true, b.store.ApplyBackfill(ctx, file)
+
+	case storage.BackfillIdentity:
+		device, inode, ok := fileIdentity(file.Path, info)
+		if !ok {
+			return false, nil
+		}
+		file.Device = sql.NullInt64{Int64: int64(device), Valid: true}
+		file.Inode = sql.NullInt64{Int64: int64(inode), Valid: true}
+		return true, b.store.ApplyBackfill(ctx, file)
+
+	case storage.BackfillExif:
+		meta := extractEXIF(file.Path)
+		if len(meta.Fields) == 0 {
+			return false, nil
+		}
+		return true, b.store.ReplaceMediaExif(ctx, file.ID, meta.Fields)
+
+	case storage.BackfillThumbnails:
+		dest := ThumbnailPath(opts.ThumbnailDir, file.HashMD5, opts.ThumbnailSize)
+		if _, err := os.Stat(dest); err == nil {
+			return false, nil
+		}
+		return true, Thumbnail(file.Path, dest, opts.ThumbnailSize)
+
+	default:
+		return false, fmt.Errorf("unknown backfill job %q", job)
+	}
+}
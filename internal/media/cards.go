@@ -0,0 +1,204 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// CardFolder is where cameras keep photos and videos on a memory card, as
+// the DCF standard lays cards out.
+const CardFolder = "DCIM"
+
+// HasCardFolder reports whether the volume mounted at root holds a DCIM
+// folder.
+func HasCardFolder(root string) bool {
+	info, err := os.Stat(filepath.Join(root, CardFolder))
+	return err == nil && info.IsDir()
+}
+
+// CardStaging names the staging folder of a card's imports after its label,
+// or else its volume ID.
+func CardStaging(label, id string) string {
+	if name := sanitizeSegment(label); name != "" {
+		return name
+	}
+	if name := sanitizeSegment(id); name != "" {
+		return name
+	}
+	return "card"
+}
+
+// cardFile is a file in the DCIM folder of a card.
+type cardFile struct {
+	// rel is relative to the card's root, slash-separated.
+	rel  string
+	info fs.FileInfo
+}
+
+// cardFiles lists the files in the DCIM folder of root with a wanted
+// extension.
+func cardFiles(ctx context.Context, root string, extensions []string) ([]cardFile, error) {
+	wanted := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		wanted[ext] = true
+	}
+	var files []cardFile
+	err := filepath.WalkDir(filepath.Join(root, CardFolder), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != filepath.Join(root, CardFolder) && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !wanted[strings.ToLower(filepath.Ext(p))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, cardFile{rel: filepath.ToSlash(rel), info: info})
+		return nil
+	})
+	return files, err
+}
+
+// PullCard copies the DCIM files with a wanted extension of the memory card
+// mounted at root into dest, in folders named like theirs on the card. It
+// works like PullDevice: files an earlier import handled, recorded under
+// source, are skipped, and copies whose content the library already holds
+// are removed again once hashed.
+func PullCard(ctx context.Context, store *storage.Store, root, source, dest string, extensions []string, gate *PauseGate, throttle *Throttle, onProgress func(DeviceProgress)) (summary DevicePull, err error) {
+	pulled, err := store.ListRemotePulls(ctx, source)
+	if err != nil {
+		return summary, err
+	}
+	files, err := cardFiles(ctx, root, extensions)
+	if err != nil {
+		return summary, err
+	}
+	summary.Listed = len(files)
+
+	seen := make(map[string]bool)
+	var pulls []storage.RemotePull
+	defer func() {
+		// Recorded even when the import stops, so a retry resumes.
+		if len(pulls) > 0 {
+			if recErr := store.RecordRemotePulls(context.WithoutCancel(ctx), source, pulls); recErr != nil && err == nil {
+				err = recErr
+			}
+		}
+	}()
+	for i, file := range files {
+		if err := gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		progress := DeviceProgress{Device: root, Path: file.rel, Completed: i + 1, Total: len(files)}
+		modTime := file.info.ModTime().UTC()
+		if prior, ok := pulled[file.rel]; ok && prior.SizeBytes == file.info.Size() && prior.ModTime.Equal(modTime) {
+			summary.Unchanged++
+		} else if err := throttle.Between(ctx); err != nil {
+			return summary, err
+		} else if known, err := pullCardFile(ctx, store, root, file, dest, seen, throttle); err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.rel, err))
+			progress.Error = err.Error()
+		} else {
+			if known {
+				summary.Known++
+			} else {
+				summary.Copied++
+			}
+			pulls = append(pulls, storage.RemotePull{Path: file.rel, SizeBytes: file.info.Size(), ModTime: modTime})
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
+
+// pullCardFile copies one file through a temporary name and reports whether
+// it was dropped as already known.
+func pullCardFile(ctx context.Context, store *storage.Store, root string, file cardFile, dest string, seen map[string]bool, throttle *Throttle) (bool, error) {
+	dir := filepath.Join(dest, sanitizeSegment(path.Base(path.Dir(file.rel))))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, err
+	}
+	name := sanitizeSegment(path.Base(file.rel))
+	tmp := filepath.Join(dir, name+".partial")
+	if err := copyFile(filepath.Join(root, filepath.FromSlash(file.rel)), tmp, false, throttle); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return keepPulled(ctx, store, tmp, filepath.Join(dir, name), file.info.ModTime(), seen, throttle)
+}
+
+// CardCleanup reports files deleted from a memory card after an import.
+type CardCleanup struct {
+	Deleted int `json:"deleted"`
+	// Kept counts files whose content the library does not hold, such as
+	// those an import failed to copy.
+	Kept   int      `json:"kept"`
+	Failed int      `json:"failed"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ClearCard deletes the DCIM files with a wanted extension from the card
+// mounted at root once their content is verified to be in the library: each
+// file is read again and must match a library copy still on disk anywhere
+// but on the card. Folders are left for the camera to reuse.
+func ClearCard(ctx context.Context, store *storage.Store, root string, extensions []string, gate *PauseGate, throttle *Throttle) (CardCleanup, error) {
+	var summary CardCleanup
+	files, err := cardFiles(ctx, root, extensions)
+	if err != nil {
+		return summary, err
+	}
+	for _, file := range files {
+		if err := gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		src := filepath.Join(root, filepath.FromSlash(file.rel))
+		hash, err := hashFile(src, throttle)
+		if err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.rel, err))
+			continue
+		}
+		held, err := HeldInLibrary(ctx, store, hash, file.info.Size(), root, throttle)
+		if err != nil {
+			return summary, err
+		}
+		if !held {
+			summary.Kept++
+			continue
+		}
+		if err := os.Remove(src); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.rel, err))
+			continue
+		}
+		summary.Deleted++
+	}
+	return summary, nil
+}
@@ -0,0 +1,114 @@
+package media
+
+import (
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Media categories stored alongside each file.
+const (
+	CategoryPhoto      = "photo"
+	CategoryVideo      = "video"
+	CategoryScreenshot = "screenshot"
+	CategoryMessaging  = "messaging"
+	CategoryDownload   = "download"
+)
+
+var (
+	screenshotName = regexp.MustCompile(`(?i)(screenshot|screen shot|screen_shot|scrnshot|截屏|截图|屏幕截图)`)
+	messagingName  = regexp.MustCompile(`(?i)(^IMG-\d{8}-WA\d+|^VID-\d{8}-WA\d+|whatsapp|telegram|wechat|mmexport|signal-\d{4})`)
+)
+
+// commonScreens lists portrait and landscape resolutions of popular displays.
+var commonScreens = map[[2]int]struct{}{}
+
+func init() {
+	for _, size := range [][2]int{
+		{1170, 2532}, {1179, 2556}, {1284, 2778}, {1290, 2796}, {1125, 2436},
+		{1242, 2688}, {828, 1792}, {750, 1334}, {1242, 2208}, {640, 1136},
+		{1080, 1920}, {1080, 2340}, {1080, 2400}, {1440, 2560}, {1440, 3200},
+		{1366, 768}, {1920, 1080}, {2560, 1440}, {3840, 2160}, {2880, 1800},
+		{2560, 1600}, {1440, 900}, {1536, 2048}, {1668, 2388}, {2048, 2732},
+	} {
+		commonScreens[size] = struct{}{}
+		commonScreens[[2]int{size[1], size[0]}] = struct{}{}
+	}
+}
+
+// classify guesses whether a file is a camera photo, a screenshot, a
+// messaging forward or a downloaded image using cheap heuristics.
+func classify(path, mimeType string, hasCameraData bool, width, height int) string {
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if strings.HasPrefix(mimeType, "video/") {
+		if messagingName.MatchString(name) {
+			return CategoryMessaging
+		}
+		return CategoryVideo
+	}
+
+	if screenshotName.MatchString(name) {
+		return CategoryScreenshot
+	}
+	if messagingName.MatchString(name) {
+		return CategoryMessaging
+	}
+	if hasCameraData {
+		return CategoryPhoto
+	}
+
+	if _, screen := commonScreens[[2]int{width, height}]; screen {
+		return CategoryScreenshot
+	}
+	if ext == ".png" {
+		return CategoryScreenshot
+	}
+	return CategoryDownload
+}
+
+// imageSize returns the pixel dimensions as displayed, swapping width and
+// height when the EXIF orientation rotates the image by 90 degrees.
+func imageSize(path string, exifFields map[string]string) (int, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return imageSizeFrom(nil, exifFields)
+	}
+	defer f.Close()
+	return imageSizeFrom(f, exifFields)
+}
+
+// imageSizeFrom decodes the dimensions from r, falling back to EXIF when r
+// is nil or cannot be decoded.
+func imageSizeFrom(r io.Reader, exifFields map[string]string) (int, int) {
+	width, height, ok := decodedSize(r)
+	if !ok {
+		width, _ = strconv.Atoi(exifFields["PixelXDimension"])
+		height, _ = strconv.Atoi(exifFields["PixelYDimension"])
+	}
+
+	switch exifFields["Orientation"] {
+	case "5", "6", "7", "8":
+		width, height = height, width
+	}
+	return width, height
+}
+
+func decodedSize(r io.Reader) (int, int, bool) {
+	if r == nil {
+		return 0, 0, false
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
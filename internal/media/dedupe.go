@@ -0,0 +1,116 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// identicalAt reports whether target on b already holds the same bytes as
+// file.
+func identicalAt(b Backend, target string, file storage.MediaFile, throttle *Throttle) bool {
+	if file.HashMD5 == "" || target == file.Path {
+		return false
+	}
+	b = backendFor(b, target)
+	info, err := b.Stat(target)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != file.SizeBytes {
+		return false
+	}
+	hash, err := hashOn(b, target, throttle)
+	return err == nil && hash == file.HashMD5
+}
+
+// HeldInLibrary reports whether the library keeps a copy of a file with the
+// given hash and size outside base. Rows are not trusted on their own: the
+// copy has to be a local file that is still on disk with the same bytes, so
+// stale, offline, remote and archived copies do not count.
+func HeldInLibrary(ctx context.Context, store *storage.Store, hash string, size int64, base string, throttle *Throttle) (bool, error) {
+	copies, err := store.ListCopiesOutside(ctx, hash, base)
+	if err != nil {
+		return false, err
+	}
+	want := storage.MediaFile{HashMD5: hash, SizeBytes: size}
+	for _, other := range copies {
+		if IsRemote(other.Path) {
+			continue
+		}
+		if identicalAt(LocalBackend{}, other.Path, want, throttle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deduplicate short-circuits a move whose target already holds an identical
+// file instead of creating a "-1" copy. It returns false when the move should
+// proceed normally.
+func (r *tidyRun) deduplicate(ctx context.Context, file storage.MediaFile, target string) bool {
+	if !identicalAt(r.opts.Backend, target, file, r.opts.Throttle) {
+		return false
+	}
+
+	t := r.executor
+	progress := TidyProgress{MediaID: file.ID, Source: file.Path, Target: target, Status: "deduplicated"}
+	if r.opts.DryRun {
+		r.report(deduplicated, progress, 0)
+		return true
+	}
+
+	remove := r.opts.RemoveDuplicateSource && !r.opts.Transactional && !IsArchivePath(file.Path)
+	actionType := "deduplicate"
+	if remove {
+		actionType = "delete"
+	}
+	actionID, err := t.store.CreateAction(ctx, storage.FileAction{
+		MediaID:    sql.NullInt64{Int64: file.ID, Valid: true},
+		SourcePath: file.Path,
+		TargetPath: target,
+		ActionType: actionType,
+		Status:     storage.ActionStatusPending,
+		HashMD5:    sql.NullString{String: file.HashMD5, Valid: true},
+		RunID:      sql.NullInt64{Int64: r.summary.RunID, Valid: r.summary.RunID != 0},
+		RetryOf:    retryLink(r.opts.RetryOf, file.ID),
+	})
+	if err != nil {
+		progress.Status, progress.Error = "failed", fmt.Sprintf("record action: %v", err)
+		r.report(failed, progress, 0)
+		return true
+	}
+
+	if remove {
+		if err := r.dropSource(ctx, file, target); err != nil {
+			errMsg := truncateError(err)
+			_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
+			progress.Status, progress.Error = "failed", errMsg
+			r.report(failed, progress, 0)
+			return true
+		}
+	}
+
+	_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusDeduplicated, nil)
+	r.report(deduplicated, progress, 0)
+	return true
+}
+
+// dropSource deletes the duplicate source and points the library at the
+// target copy, merging with the target's own row when it was scanned.
+func (r *tidyRun) dropSource(ctx context.Context, file storage.MediaFile, target string) error {
+	store := r.executor.store
+	if err := os.Remove(longPath(file.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	_, found, err := store.FindMediaByPath(ctx, target)
+	if err != nil {
+		return err
+	}
+	if found {
+		return store.DeleteMediaFile(ctx, file.ID)
+	}
+	return relocate(ctx, store, file.ID, target)
+}
@@ -0,0 +1,412 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// deviceTimeout bounds detection and listing; downloads run until they
+// finish.
+const deviceTimeout = 2 * time.Minute
+
+// LocateGphoto2 detects gphoto2 from its setting: empty looks it up on
+// PATH, "off" disables it and anything else is the executable's path.
+func LocateGphoto2(setting string) ToolInfo {
+	return locateTool("gphoto2", setting, "--version")
+}
+
+// Gphoto2 reaches cameras and phones attached over USB through the gphoto2
+// executable, which speaks PTP and MTP. iPhones show their camera roll to it
+// as a PTP camera once the computer is trusted.
+type Gphoto2 struct {
+	path string
+}
+
+// NewGphoto2 wraps the gphoto2 at path.
+func NewGphoto2(path string) *Gphoto2 {
+	return &Gphoto2{path: path}
+}
+
+// Device is an attached camera or phone.
+type Device struct {
+	Model string `json:"model"`
+	// Port is where gphoto2 reaches the device, e.g. "usb:001,005". It
+	// changes whenever the device is plugged in again.
+	Port string `json:"port"`
+}
+
+// Folder names the staging folder of the device's imports.
+func (d Device) Folder() string {
+	if name := sanitizeSegment(d.Model); name != "" {
+		return name
+	}
+	return "device"
+}
+
+// DeviceFile is a file listed on a device.
+type DeviceFile struct {
+	// Folder is the absolute folder on the device, e.g.
+	// "/store_00010001/DCIM/100APPLE".
+	Folder string `json:"folder"`
+	Name   string `json:"name"`
+	// Number is the file's position within Folder, as gphoto2 addresses it.
+	Number int `json:"number"`
+	// SizeBytes is only as precise as gphoto2 reports it, in kilobytes.
+	SizeBytes int64     `json:"sizeBytes"`
+	ModTime   time.Time `json:"modTime"`
+}
+
+// Path is the file's path on the device.
+func (f DeviceFile) Path() string {
+	return path.Join(f.Folder, f.Name)
+}
+
+// run executes one gphoto2 command and returns its standard output.
+func (g *Gphoto2) run(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, g.path, append([]string{"--quiet"}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndex(msg, "\n"); i >= 0 {
+			msg = strings.TrimSpace(msg[i+1:])
+		}
+		if msg == "" {
+			return nil, fmt.Errorf("gphoto2: %w", err)
+		}
+		return nil, fmt.Errorf("gphoto2: %s", msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Devices lists the attached cameras and phones.
+func (g *Gphoto2) Devices(ctx context.Context) ([]Device, error) {
+	ctx, cancel := context.WithTimeout(ctx, deviceTimeout)
+	defer cancel()
+	out, err := g.run(ctx, "--auto-detect")
+	if err != nil {
+		return nil, err
+	}
+	return parseDevices(out), nil
+}
+
+// parseDevices reads the table --auto-detect prints: a header, a rule of
+// dashes and a model and port per line, the model padded with spaces.
+func parseDevices(out []byte) []Device {
+	var devices []Device
+	body := false
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !body {
+			body = strings.HasPrefix(line, "---")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		port := fields[len(fields)-1]
+		model := strings.TrimSpace(strings.TrimSuffix(line, port))
+		devices = append(devices, Device{Model: model, Port: port})
+	}
+	return devices
+}
+
+// Serial returns the serial number the device reports, or "" when it has
+// none.
+func (g *Gphoto2) Serial(ctx context.Context, port string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deviceTimeout)
+	defer cancel()
+	out, err := g.run(ctx, "--port", port, "--summary")
+	if err != nil {
+		return "", err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "Serial Number") {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
+
+// ListFiles lists the files on the device at port inside DCIM folders,
+// where cameras and phones keep their photos and videos.
+func (g *Gphoto2) ListFiles(ctx context.Context, port string) ([]DeviceFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, deviceTimeout)
+	defer cancel()
+	out, err := g.run(ctx, "--port", port, "--list-files")
+	if err != nil {
+		return nil, err
+	}
+	var files []DeviceFile
+	for _, file := range parseDeviceFiles(out) {
+		if inDCIM(file.Folder) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// parseDeviceFiles reads the listing --list-files prints: a line naming
+// each folder, followed by a line per file such as
+//
+//	#1     IMG_0001.JPG               rd  2345 KB image/jpeg 1600000000
+//
+// giving its number, name, permissions, size, MIME type and, when the
+// device knows it, its modification time.
+func parseDeviceFiles(out []byte) []DeviceFile {
+	var files []DeviceFile
+	folder := ""
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "There ") {
+			folder = ""
+			if start, end := strings.Index(line, "'"), strings.LastIndex(line, "'"); start >= 0 && end > start {
+				folder = line[start+1 : end]
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if folder == "" || len(fields) < 5 || !strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		number, err := strconv.Atoi(fields[0][1:])
+		if err != nil {
+			continue
+		}
+		unit := -1
+		for i := 3; i < len(fields); i++ {
+			if fields[i] == "KB" {
+				unit = i
+				break
+			}
+		}
+		if unit < 0 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[unit-1], 10, 64)
+		file := DeviceFile{
+			Folder: folder,
+			// Names with spaces are split like the rest of the line.
+			Name:      strings.Join(fields[1:unit-2], " "),
+			Number:    number,
+			SizeBytes: size * 1024,
+		}
+		if len(fields) > unit+2 {
+			if sec, err := strconv.ParseInt(fields[unit+2], 10, 64); err == nil && sec > 0 {
+				file.ModTime = time.Unix(sec, 0).UTC()
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func inDCIM(folder string) bool {
+	for _, segment := range strings.Split(folder, "/") {
+		if strings.EqualFold(segment, "DCIM") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFile downloads file from the device at port to dest.
+func (g *Gphoto2) GetFile(ctx context.Context, port string, file DeviceFile, dest string) error {
+	// gphoto2 expands % patterns in the file name.
+	name := strings.ReplaceAll(dest, "%", "%%")
+	_, err := g.run(ctx, "--port", port, "--folder", file.Folder, "--filename", name, "--force-overwrite",
+		"--get-file", strconv.Itoa(file.Number))
+	return err
+}
+
+// DeviceProgress reports one file handled by a device import.
+type DeviceProgress struct {
+	// Device is the model of a camera or phone, or the mount point of a
+	// memory card.
+	Device    string `json:"device"`
+	Path      string `json:"path"`
+	Error     string `json:"error,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+// DevicePull reports files copied off a camera or phone.
+type DevicePull struct {
+	Listed int `json:"listed"`
+	Copied int `json:"copied"`
+	// Known counts files copied down but dropped because the library
+	// already holds their content.
+	Known int `json:"known"`
+	// Unchanged counts files an earlier import already handled.
+	Unchanged int      `json:"unchanged"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// DeviceSource is the key files pulled from a device are recorded under:
+// its serial number when it reports one, so imports survive the device
+// being plugged in again, else its model.
+func DeviceSource(device Device, serial string) string {
+	if serial != "" {
+		return "device:" + serial
+	}
+	return "device:" + device.Model
+}
+
+// PullDevice copies the DCIM files of device with a wanted extension into
+// dest, in folders named like theirs on the device. Like PullPhotosLibrary
+// it skips files an earlier import handled; files whose content the
+// library already holds, or that an earlier file of this import had, are
+// removed again once hashed.
+func PullDevice(ctx context.Context, store *storage.Store, g *Gphoto2, device Device, dest string, extensions []string, gate *PauseGate, throttle *Throttle, onProgress func(DeviceProgress)) (summary DevicePull, err error) {
+	serial, err := g.Serial(ctx, device.Port)
+	if err != nil {
+		return summary, err
+	}
+	source := DeviceSource(device, serial)
+	pulled, err := store.ListRemotePulls(ctx, source)
+	if err != nil {
+		return summary, err
+	}
+	listed, err := g.ListFiles(ctx, device.Port)
+	if err != nil {
+		return summary, err
+	}
+
+	wanted := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		wanted[ext] = true
+	}
+	var files []DeviceFile
+	for _, file := range listed {
+		if wanted[strings.ToLower(path.Ext(file.Name))] {
+			files = append(files, file)
+		}
+	}
+	summary.Listed = len(files)
+
+	seen := make(map[string]bool)
+	var pulls []storage.RemotePull
+	defer func() {
+		// Recorded even when the import stops, so a retry resumes.
+		if len(pulls) > 0 {
+			if recErr := store.RecordRemotePulls(context.WithoutCancel(ctx), source, pulls); recErr != nil && err == nil {
+				err = recErr
+			}
+		}
+	}()
+	for i, file := range files {
+		if err := gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		progress := DeviceProgress{Device: device.Model, Path: file.Path(), Completed: i + 1, Total: len(files)}
+		report := func() {
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}
+		if prior, ok := pulled[file.Path()]; ok && prior.SizeBytes == file.SizeBytes && prior.ModTime.Equal(file.ModTime) {
+			summary.Unchanged++
+			report()
+			continue
+		}
+		if err := throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		known, err := pullDeviceFile(ctx, store, g, device, file, dest, seen, throttle)
+		if err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path(), err))
+			progress.Error = err.Error()
+			report()
+			continue
+		}
+		if known {
+			summary.Known++
+		} else {
+			summary.Copied++
+		}
+		pulls = append(pulls, storage.RemotePull{Path: file.Path(), SizeBytes: file.SizeBytes, ModTime: file.ModTime})
+		report()
+	}
+	return summary, nil
+}
+
+// pullDeviceFile downloads one file through a temporary name and reports
+// whether it was dropped as already known.
+func pullDeviceFile(ctx context.Context, store *storage.Store, g *Gphoto2, device Device, file DeviceFile, dest string, seen map[string]bool, throttle *Throttle) (bool, error) {
+	dir := filepath.Join(dest, sanitizeSegment(path.Base(file.Folder)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, err
+	}
+	name := sanitizeSegment(file.Name)
+	tmp := filepath.Join(dir, name+".partial")
+	if err := g.GetFile(ctx, device.Port, file, tmp); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return keepPulled(ctx, store, tmp, filepath.Join(dir, name), file.ModTime, seen, throttle)
+}
+
+// keepPulled hashes a file copied down to tmp and drops it when the library
+// or an earlier file of the same import, whose hashes seen collects, holds
+// its content; otherwise it is renamed to target or a free name beside it.
+// It reports whether the file was dropped.
+func keepPulled(ctx context.Context, store *storage.Store, tmp, target string, modTime time.Time, seen map[string]bool, throttle *Throttle) (bool, error) {
+	hash, err := hashFile(tmp, throttle)
+	if err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	_, found, err := store.FindMediaByHash(ctx, hash, "")
+	if err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	if found || seen[hash] {
+		return true, os.Remove(tmp)
+	}
+	seen[hash] = true
+
+	if !modTime.IsZero() {
+		// Scans fall back on the modification time for undated files.
+		if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+			os.Remove(tmp)
+			return false, err
+		}
+	}
+	// Cameras and phones restart their numbering, so a name may already be
+	// taken by an earlier import that has not been tidied yet.
+	dir, name := filepath.Split(target)
+	ext := filepath.Ext(name)
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			break
+		}
+		target = filepath.Join(dir, fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n, ext))
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,215 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// exiftoolReady is the line exiftool prints after each command's output in
+// -stay_open mode.
+const exiftoolReady = "{ready}"
+
+// exiftoolDateLayout is how exiftool prints dates.
+const exiftoolDateLayout = "2006:01:02 15:04:05"
+
+// Exiftool keeps one exiftool process open in -stay_open mode, so files can
+// be read one after another without paying its start-up cost for each.
+// It is safe for concurrent use; requests are serialised.
+type Exiftool struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// LocateExiftool detects exiftool from its setting: empty looks it up on
+// PATH, "off" disables it and anything else is the executable's path.
+func LocateExiftool(setting string) ToolInfo {
+	return locateTool("exiftool", setting, "-ver")
+}
+
+// StartExiftool launches the exiftool at path in batch mode. Close must be
+// called to stop it.
+func StartExiftool(path string) (*Exiftool, error) {
+	cmd := exec.Command(path, "-stay_open", "True", "-@", "-")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("exiftool stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("exiftool stdout: %w", err)
+	}
+	// Warnings about individual files go to stderr and are not needed.
+	cmd.Stderr = io.Discard
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start exiftool: %w", err)
+	}
+	return &Exiftool{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Read returns every tag exiftool finds in the file at path, with numeric
+// values unformatted. Binary blobs and file system details are left out.
+func (e *Exiftool) Read(path string) (map[string]string, error) {
+	if strings.ContainsAny(path, "\r\n") {
+		return nil, fmt.Errorf("exiftool cannot read %q: line break in path", path)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	args := []string{"-json", "-n", "-charset", "filename=utf8", "--System:all", "--ExifTool:all", path, "-execute"}
+	if _, err := io.WriteString(e.stdin, strings.Join(args, "\n")+"\n"); err != nil {
+		return nil, fmt.Errorf("exiftool: %w", err)
+	}
+
+	var out bytes.Buffer
+	for {
+		line, err := e.stdout.ReadString('\n')
+		if strings.TrimSpace(line) == exiftoolReady {
+			break
+		}
+		out.WriteString(line)
+		if err != nil {
+			return nil, fmt.Errorf("exiftool: %w", err)
+		}
+	}
+
+	var records []map[string]interface{}
+	if out.Len() == 0 {
+		return nil, fmt.Errorf("exiftool could not read %s", path)
+	}
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil || len(records) == 0 {
+		return nil, fmt.Errorf("exiftool output for %s: %v", path, err)
+	}
+
+	fields := make(map[string]string)
+	for tag, value := range records[0] {
+		if tag == "SourceFile" {
+			continue
+		}
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = strings.TrimSpace(v)
+			if strings.HasPrefix(text, "(Binary data") {
+				continue
+			}
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			continue
+		}
+		if text != "" {
+			fields[tag] = text
+		}
+	}
+	return fields, nil
+}
+
+// Close stops the exiftool process.
+func (e *Exiftool) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, writeErr := io.WriteString(e.stdin, "-stay_open\nFalse\n")
+	closeErr := e.stdin.Close()
+	waitErr := e.cmd.Wait()
+	return errors.Join(writeErr, closeErr, waitErr)
+}
+
+// mergeExiftool fills in what the built-in decoders could not read from
+// exiftool's view of the file. Values the scan already found win. It returns
+// the merged tag set.
+func mergeExiftool(et *Exiftool, file *storage.MediaFile, fields map[string]string) (map[string]string, error) {
+	tags, err := et.Read(file.Path)
+	if err != nil {
+		return fields, err
+	}
+	if fields == nil {
+		fields = make(map[string]string, len(tags))
+	}
+	for tag, value := range tags {
+		if fields[tag] == "" {
+			fields[tag] = value
+		}
+	}
+
+	if !file.TakenAt.Valid {
+		for _, tag := range []string{"DateTimeOriginal", "CreateDate", "MediaCreateDate"} {
+			if taken, ok := parseExiftoolDate(tags[tag]); ok {
+				file.TakenAt = sql.NullTime{Time: taken, Valid: true}
+				placeInZone(file, parseOffset(tags["OffsetTimeOriginal"]))
+				break
+			}
+		}
+	}
+	if !file.CameraMake.Valid {
+		file.CameraMake = makeNullString(tags["Make"])
+	}
+	if !file.CameraModel.Valid {
+		file.CameraModel = makeNullString(tags["Model"])
+	}
+	if !file.Latitude.Valid {
+		// Composite:GPSPosition is "lat lon" in signed decimal degrees with -n.
+		if parts := strings.Fields(tags["GPSPosition"]); len(parts) == 2 {
+			lat, latErr := strconv.ParseFloat(parts[0], 64)
+			lon, lonErr := strconv.ParseFloat(parts[1], 64)
+			if latErr == nil && lonErr == nil && !(lat == 0 && lon == 0) {
+				file.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+				file.Longitude = sql.NullFloat64{Float64: lon, Valid: true}
+			}
+		}
+	}
+	if file.Width == 0 || file.Height == 0 {
+		width, _ := strconv.Atoi(tags["ImageWidth"])
+		height, _ := strconv.Atoi(tags["ImageHeight"])
+		switch tags["Orientation"] {
+		case "5", "6", "7", "8":
+			width, height = height, width
+		}
+		file.Width, file.Height = width, height
+	}
+
+	hasCameraData := file.CameraMake.Valid || file.CameraModel.Valid || file.TakenAt.Valid
+	file.Category = classify(file.Path, file.MimeType.String, hasCameraData, file.Width, file.Height)
+	return fields, nil
+}
+
+// parseExiftoolDate reads "2006:01:02 15:04:05" as a wall clock reading,
+// ignoring sub-seconds and zone suffixes. Zeroed dates are rejected.
+func parseExiftoolDate(value string) (time.Time, bool) {
+	if len(value) < len(exiftoolDateLayout) || strings.HasPrefix(value, "0000") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(exiftoolDateLayout, value[:len(exiftoolDateLayout)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseOffset turns an EXIF offset such as "+02:00" into a fixed zone, or
+// nil when value is not one.
+func parseOffset(value string) *time.Location {
+	t, err := time.Parse("-07:00", strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	_, offset := t.Zone()
+	return time.FixedZone(value, offset)
+}
@@ -0,0 +1,326 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// faceTimeout bounds the analysis of one image by a backend.
+const faceTimeout = 2 * time.Minute
+
+// DefaultFaceThreshold is the cosine distance below which two faces are
+// taken to show the same person.
+const DefaultFaceThreshold = 0.4
+
+// FaceEmbedder is a pluggable face analysis backend: it finds the faces in
+// an image and returns an embedding for each, such that faces of one person
+// lie close together.
+type FaceEmbedder interface {
+	// Name identifies the backend; images are analysed again when it
+	// changes, since embeddings of different models do not compare.
+	Name() string
+	Embed(ctx context.Context, path string) ([]storage.Face, error)
+}
+
+// faceResponse is what backends answer with: a box of left, top, width and
+// height as fractions of the image size and an embedding per face.
+type faceResponse struct {
+	Faces []struct {
+		Box       [4]float64 `json:"box"`
+		Embedding []float32  `json:"embedding"`
+	} `json:"faces"`
+}
+
+func (r faceResponse) faces() ([]storage.Face, error) {
+	faces := make([]storage.Face, 0, len(r.Faces))
+	for _, f := range r.Faces {
+		if len(f.Embedding) == 0 {
+			return nil, errors.New("face without embedding")
+		}
+		faces = append(faces, storage.Face{Box: f.Box, Embedding: f.Embedding})
+	}
+	return faces, nil
+}
+
+// NewFaceEmbedder picks the backend configured in the settings: an HTTP
+// service at endpoint, or else command, a program such as a script running
+// an ONNX model.
+func NewFaceEmbedder(endpoint string, command []string) (FaceEmbedder, error) {
+	switch {
+	case strings.TrimSpace(endpoint) != "":
+		return &ServiceEmbedder{endpoint: strings.TrimSpace(endpoint), client: &http.Client{Timeout: faceTimeout}}, nil
+	case len(command) > 0 && strings.TrimSpace(command[0]) != "":
+		return &CommandEmbedder{args: command}, nil
+	default:
+		return nil, errors.New("no face backend configured; set faces.endpoint or faces.command")
+	}
+}
+
+// ServiceEmbedder posts each image to an HTTP service, which answers with
+// the faces as JSON.
+type ServiceEmbedder struct {
+	endpoint string
+	client   *http.Client
+}
+
+// Name implements FaceEmbedder.
+func (e *ServiceEmbedder) Name() string { return "service:" + e.endpoint }
+
+// Embed implements FaceEmbedder.
+func (e *ServiceEmbedder) Embed(ctx context.Context, path string) ([]storage.Face, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, f)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", detectMime(path))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("face service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("face service: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var out faceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("face service: %w", err)
+	}
+	return out.faces()
+}
+
+// CommandEmbedder runs a program with the image path as its last argument
+// and reads the faces as JSON from its output.
+type CommandEmbedder struct {
+	args []string
+}
+
+// Name implements FaceEmbedder.
+func (e *CommandEmbedder) Name() string { return "command:" + strings.Join(e.args, " ") }
+
+// Embed implements FaceEmbedder.
+func (e *CommandEmbedder) Embed(ctx context.Context, path string) ([]storage.Face, error) {
+	ctx, cancel := context.WithTimeout(ctx, faceTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.args[0], append(e.args[1:], path)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("face command: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var out faceResponse
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("face command: %w", err)
+	}
+	return out.faces()
+}
+
+// FaceOptions configures a face analysis run.
+type FaceOptions struct {
+	// Threshold is the cosine distance below which faces join a person;
+	// 0 means DefaultFaceThreshold.
+	Threshold float64
+	// Limit caps how many images are analysed; 0 analyses all pending.
+	Limit int
+	// Gate, when set, can pause the run between images.
+	Gate *PauseGate
+	// Throttle paces the images sent to the backend.
+	Throttle *Throttle
+}
+
+// FaceProgress reports one analysed image.
+type FaceProgress struct {
+	MediaID   int64  `json:"mediaId"`
+	Path      string `json:"path"`
+	Faces     int    `json:"faces"`
+	Error     string `json:"error,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+// FaceSummary summarises a face analysis run.
+type FaceSummary struct {
+	Analysed int `json:"analysed"`
+	Faces    int `json:"faces"`
+	Failed   int `json:"failed"`
+	// Skipped counts files on remote targets and inside archives, which
+	// backends cannot read.
+	Skipped int `json:"skipped"`
+	// Clustered counts faces assigned to a person in this run and
+	// NewPeople the people created for them.
+	Clustered  int      `json:"clustered"`
+	NewPeople  int      `json:"newPeople"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// AnalyseFaces runs images not yet seen by embedder through it, stores the
+// faces found and clusters new faces into people.
+func AnalyseFaces(ctx context.Context, store *storage.Store, embedder FaceEmbedder, opts FaceOptions, onProgress func(FaceProgress)) (summary FaceSummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	files, err := store.ListFaceCandidates(ctx, embedder.Name(), opts.Limit)
+	if err != nil {
+		return summary, err
+	}
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		progress := FaceProgress{MediaID: file.ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if IsRemote(file.Path) || IsArchivePath(file.Path) {
+			// Backends read local files only.
+			summary.Skipped++
+			progress.Error = "not a local file"
+		} else if faces, err := embedder.Embed(ctx, file.Path); err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+			progress.Error = err.Error()
+		} else {
+			if err := store.SaveFaces(ctx, file.ID, file.HashMD5, embedder.Name(), faces); err != nil {
+				return summary, err
+			}
+			summary.Analysed++
+			summary.Faces += len(faces)
+			progress.Faces = len(faces)
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	summary.Clustered, summary.NewPeople, err = ClusterFaces(ctx, store, opts.Threshold)
+	return summary, err
+}
+
+// ClusterFaces assigns faces without a person to the person whose faces
+// they are closest to on average, or to new people grown from the
+// unassigned faces themselves. Existing assignments are kept, so names and
+// corrections survive later runs. It returns how many faces were assigned
+// and how many people created.
+func ClusterFaces(ctx context.Context, store *storage.Store, threshold float64) (int, int, error) {
+	if threshold <= 0 {
+		threshold = DefaultFaceThreshold
+	}
+	faces, err := store.ListFaces(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type cluster struct {
+		id  int64
+		sum []float64
+		n   int
+	}
+	var clusters []*cluster
+	byPerson := make(map[int64]*cluster)
+	add := func(c *cluster, v []float32) {
+		if c.sum == nil {
+			c.sum = make([]float64, len(v))
+		}
+		for i := range v {
+			if i < len(c.sum) {
+				c.sum[i] += float64(v[i])
+			}
+		}
+		c.n++
+	}
+	var pending []storage.Face
+	for _, face := range faces {
+		if face.PersonID == 0 {
+			pending = append(pending, face)
+			continue
+		}
+		c := byPerson[face.PersonID]
+		if c == nil {
+			c = &cluster{id: face.PersonID}
+			byPerson[face.PersonID] = c
+			clusters = append(clusters, c)
+		}
+		add(c, normalise(face.Embedding))
+	}
+
+	assignments := make(map[int64]int64, len(pending))
+	next := int64(-1)
+	for _, face := range pending {
+		v := normalise(face.Embedding)
+		var best *cluster
+		bestDist := threshold
+		for _, c := range clusters {
+			if d := cosineDistance(v, c.sum); d < bestDist {
+				best, bestDist = c, d
+			}
+		}
+		if best == nil {
+			// Negative IDs stand for people AssignFaces creates.
+			best = &cluster{id: next}
+			next--
+			clusters = append(clusters, best)
+		}
+		add(best, v)
+		assignments[face.ID] = best.id
+	}
+	if len(assignments) == 0 {
+		return 0, 0, nil
+	}
+	created, err := store.AssignFaces(ctx, assignments)
+	return len(assignments), created, err
+}
+
+func normalise(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// cosineDistance compares a unit vector with the sum of a cluster's unit
+// vectors, i.e. with the direction of their mean.
+func cosineDistance(v []float32, sum []float64) float64 {
+	if len(v) != len(sum) {
+		return math.Inf(1)
+	}
+	var dot, norm float64
+	for i := range v {
+		dot += float64(v[i]) * sum[i]
+		norm += sum[i] * sum[i]
+	}
+	if norm == 0 {
+		return math.Inf(1)
+	}
+	return 1 - dot/math.Sqrt(norm)
+}
@@ -0,0 +1,231 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ffprobeTimeout bounds a single ffprobe or ffmpeg invocation.
+const ffprobeTimeout = time.Minute
+
+// FFprobe reads video properties with ffprobe and renders poster frames with
+// the ffmpeg found beside it.
+type FFprobe struct {
+	path   string
+	ffmpeg string
+}
+
+// VideoInfo holds the properties ffprobe reports for a video.
+type VideoInfo struct {
+	DurationSeconds float64
+	VideoCodec      string
+	AudioCodec      string
+	BitRate         int64
+	// Rotation is the display rotation in degrees, clockwise.
+	Rotation int
+	// Width and Height are the stored frame size, before rotation.
+	Width  int
+	Height int
+	// CreationTime is the recording time, always in UTC; zero when unknown.
+	CreationTime time.Time
+}
+
+// LocateFFprobe detects ffprobe from its setting: empty looks it up on PATH,
+// "off" disables it and anything else is the executable's path.
+func LocateFFprobe(setting string) ToolInfo {
+	return locateTool("ffprobe", setting, "-version")
+}
+
+// NewFFprobe wraps the ffprobe at path. Posters need ffmpeg, looked for in
+// the same folder first and then on PATH.
+func NewFFprobe(path string) *FFprobe {
+	probe := &FFprobe{path: path}
+	sibling := filepath.Join(filepath.Dir(path), "ffmpeg"+filepath.Ext(path))
+	if ffmpeg, err := exec.LookPath(sibling); err == nil {
+		probe.ffmpeg = ffmpeg
+	} else if ffmpeg, err := exec.LookPath("ffmpeg"); err == nil {
+		probe.ffmpeg = ffmpeg
+	}
+	return probe
+}
+
+// CanRenderPosters reports whether ffmpeg was found for Poster.
+func (f *FFprobe) CanRenderPosters() bool {
+	return f != nil && f.ffmpeg != ""
+}
+
+// ffprobeOutput is the subset of `ffprobe -print_format json` read here.
+type ffprobeOutput struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		BitRate  string            `json:"bit_rate"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+}
+
+// Probe reads the container and stream properties of the video at path.
+func (f *FFprobe) Probe(ctx context.Context, path string) (VideoInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, ffprobeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, f.path,
+		"-v", "error", "-print_format", "json", "-show_format", "-show_streams", path,
+	).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return VideoInfo{}, fmt.Errorf("ffprobe: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return VideoInfo{}, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	var parsed ffprobeOutput
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return VideoInfo{}, fmt.Errorf("ffprobe output: %w", err)
+	}
+
+	var info VideoInfo
+	info.DurationSeconds, _ = strconv.ParseFloat(parsed.Format.Duration, 64)
+	info.BitRate, _ = strconv.ParseInt(parsed.Format.BitRate, 10, 64)
+	info.CreationTime = parseCreationTime(parsed.Format.Tags["creation_time"])
+	for _, stream := range parsed.Streams {
+		switch stream.CodecType {
+		case "video":
+			if info.VideoCodec != "" {
+				continue
+			}
+			info.VideoCodec = stream.CodecName
+			info.Width, info.Height = stream.Width, stream.Height
+			if rotate, err := strconv.Atoi(stream.Tags["rotate"]); err == nil {
+				info.Rotation = rotate
+			}
+			// Newer ffmpeg reports a display matrix instead, counter-clockwise.
+			for _, side := range stream.SideDataList {
+				if side.Rotation != 0 {
+					info.Rotation = -int(math.Round(side.Rotation))
+				}
+			}
+			info.Rotation = ((info.Rotation % 360) + 360) % 360
+			if info.CreationTime.IsZero() {
+				info.CreationTime = parseCreationTime(stream.Tags["creation_time"])
+			}
+		case "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = stream.CodecName
+			}
+		}
+	}
+	return info, nil
+}
+
+// parseCreationTime reads the RFC 3339 creation_time tag. Cameras without a
+// clock write the QuickTime epoch, which counts as unknown.
+func parseCreationTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Year() <= 1904 {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// Poster writes a JPEG frame of the video at src, its longest edge at most
+// size pixels, to dest. Rotation is applied by ffmpeg. An existing dest is
+// reused.
+func (f *FFprobe) Poster(ctx context.Context, src, dest string, size int) error {
+	if !f.CanRenderPosters() {
+		return errors.New("video posters need ffmpeg")
+	}
+	if size <= 0 || size > MaxThumbnailSize {
+		return fmt.Errorf("thumbnail size must be between 1 and %d", MaxThumbnailSize)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+
+	// Skip the first second, often black, unless the clip is shorter.
+	seek := "1"
+	if info, err := f.Probe(ctx, src); err == nil && info.DurationSeconds < 2 {
+		seek = "0"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".poster-*.jpg")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, ffprobeTimeout)
+	defer cancel()
+	scale := fmt.Sprintf("scale='if(gte(iw,ih),min(%d,iw),-2)':'if(gte(iw,ih),-2,min(%d,ih))'", size, size)
+	out, err := exec.CommandContext(ctx, f.ffmpeg,
+		"-v", "error", "-y", "-ss", seek, "-i", src, "-frames:v", "1", "-vf", scale, "-q:v", "4", tmp.Name(),
+	).CombinedOutput()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	if info, err := os.Stat(tmp.Name()); err != nil || info.Size() == 0 {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ffmpeg rendered no frame of %s", src)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// mergeVideo adds the properties ffprobe reports for a video to file and its
+// tags. Values the scan already found win. The recording time is UTC, so
+// the wall clock is derived from the zone the camera is assumed to be in.
+func mergeVideo(info VideoInfo, file *storage.MediaFile, fields map[string]string, opts Options) {
+	set := func(tag, value string) {
+		if value != "" && fields[tag] == "" {
+			fields[tag] = value
+		}
+	}
+	if info.DurationSeconds > 0 {
+		set("Duration", strconv.FormatFloat(info.DurationSeconds, 'f', 3, 64))
+	}
+	set("VideoCodec", info.VideoCodec)
+	set("AudioCodec", info.AudioCodec)
+	if info.BitRate > 0 {
+		set("BitRate", strconv.FormatInt(info.BitRate, 10))
+	}
+	if info.Rotation != 0 {
+		set("Rotation", strconv.Itoa(info.Rotation))
+	}
+
+	if file.Width == 0 || file.Height == 0 {
+		file.Width, file.Height = info.Width, info.Height
+		if info.Rotation == 90 || info.Rotation == 270 {
+			file.Width, file.Height = info.Height, info.Width
+		}
+	}
+	if !file.TakenAt.Valid && !info.CreationTime.IsZero() {
+		loc := opts.zoneFor(file.CameraMake.String, file.CameraModel.String)
+		file.TakenAt = sql.NullTime{Time: wallClock(info.CreationTime.In(loc)), Valid: true}
+		placeInZone(file, loc)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package media
+
+import "os"
+
+// fileIdentity is unavailable on this platform; hardlinks count as copies.
+func fileIdentity(string, os.FileInfo) (device, inode uint64, ok bool) {
+	return 0, 0, false
+}
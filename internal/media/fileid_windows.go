@@ -0,0 +1,22 @@
+package media
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the volume serial number and file index of a file,
+// which hardlinks share. Windows does not expose them through Stat.
+func fileIdentity(path string, _ os.FileInfo) (device, inode uint64, ok bool) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(syscall.Handle(f.Fd()), &data); err != nil {
+		return 0, 0, false
+	}
+	return uint64(data.VolumeSerialNumber), uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow), true
+}
@@ -0,0 +1,59 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ZeroByteReason is the junk reason of empty files.
+const ZeroByteReason = "zero-byte file"
+
+// JunkMatcher recognises leftovers that are not media: temporary and
+// partial files by name, and zero-byte files from interrupted copies.
+type JunkMatcher struct {
+	patterns []string
+}
+
+// NewJunkMatcher compiles name patterns in filepath.Match syntax, such as
+// "*.tmp" or "~$*". Matching ignores case.
+func NewJunkMatcher(patterns []string) (*JunkMatcher, error) {
+	m := &JunkMatcher{}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("junk pattern %q: %w", pattern, err)
+		}
+		m.patterns = append(m.patterns, pattern)
+	}
+	return m, nil
+}
+
+// Reason says why the regular file d is junk, or returns "" when it is not.
+// The size is only looked at when checkSize is set, sparing a stat for
+// files the scan ignores anyway.
+func (m *JunkMatcher) Reason(d os.DirEntry, checkSize bool) (string, int64) {
+	if m == nil || !d.Type().IsRegular() {
+		return "", 0
+	}
+	name := strings.ToLower(d.Name())
+	for _, pattern := range m.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			var size int64
+			if info, err := d.Info(); err == nil {
+				size = info.Size()
+			}
+			return "matches " + pattern, size
+		}
+	}
+	if checkSize {
+		if info, err := d.Info(); err == nil && info.Size() == 0 {
+			return ZeroByteReason, 0
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// LabelSource is recorded as the source of tags given by the classifier.
+const LabelSource = "classifier"
+
+// DefaultLabelScore is the lowest confidence a label needs to be kept.
+const DefaultLabelScore = 0.5
+
+// modelPlaceholder is replaced by the model path in classifier commands.
+const modelPlaceholder = "{model}"
+
+// Classifier labels images with scenes and objects ("beach", "document",
+// "food") using an ONNX model. The model runs in an external program, for
+// example a short script around onnxruntime, so the app needs no native
+// runtime of its own.
+type Classifier struct {
+	model    string
+	args     []string
+	minScore float64
+}
+
+// NewClassifier prepares command to run model. "{model}" in command is
+// replaced by the model path and the image path is appended; the program
+// prints {"labels": [{"label": "beach", "score": 0.92}]}. Labels scoring
+// below minScore are dropped; 0 means DefaultLabelScore.
+func NewClassifier(model string, command []string, minScore float64) (*Classifier, error) {
+	if strings.TrimSpace(model) == "" {
+		return nil, errors.New("no classifier model configured; set classifier.model")
+	}
+	if _, err := os.Stat(model); err != nil {
+		return nil, fmt.Errorf("classifier model: %w", err)
+	}
+	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
+		return nil, errors.New("no classifier command configured; set classifier.command")
+	}
+	if minScore <= 0 {
+		minScore = DefaultLabelScore
+	}
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = strings.ReplaceAll(arg, modelPlaceholder, model)
+	}
+	return &Classifier{model: model, args: args, minScore: minScore}, nil
+}
+
+// Model identifies the classifier; images are labelled again when it
+// changes.
+func (c *Classifier) Model() string { return c.model }
+
+// Classify returns the labels of the image at path, most confident first.
+func (c *Classifier) Classify(ctx context.Context, path string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, faceTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.args[0], append(c.args[1:], path)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("classifier: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var out struct {
+		Labels []struct {
+			Label string  `json:"label"`
+			Score float64 `json:"score"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("classifier: %w", err)
+	}
+	sort.SliceStable(out.Labels, func(i, j int) bool { return out.Labels[i].Score > out.Labels[j].Score })
+
+	var labels []string
+	seen := make(map[string]bool)
+	for _, l := range out.Labels {
+		label := strings.ToLower(strings.TrimSpace(l.Label))
+		if label == "" || l.Score < c.minScore || seen[label] {
+			continue
+		}
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// LabelOptions configures a labelling run.
+type LabelOptions struct {
+	// Limit caps how many images are labelled; 0 labels all pending.
+	Limit int
+	// Gate, when set, can pause the run between images.
+	Gate *PauseGate
+	// Throttle paces the images handed to the classifier.
+	Throttle *Throttle
+}
+
+// LabelProgress reports one labelled image.
+type LabelProgress struct {
+	MediaID   int64    `json:"mediaId"`
+	Path      string   `json:"path"`
+	Labels    []string `json:"labels"`
+	Error     string   `json:"error,omitempty"`
+	Completed int      `json:"completed"`
+	Total     int      `json:"total"`
+}
+
+// LabelSummary summarises a labelling run.
+type LabelSummary struct {
+	Labelled int `json:"labelled"`
+	Labels   int `json:"labels"`
+	Failed   int `json:"failed"`
+	// Skipped counts files on remote targets and inside archives, which
+	// the classifier cannot read.
+	Skipped    int      `json:"skipped"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// LabelImages classifies images not yet labelled by c and stores the labels
+// as tags, replacing those of an earlier model.
+func LabelImages(ctx context.Context, store *storage.Store, c *Classifier, opts LabelOptions, onProgress func(LabelProgress)) (summary LabelSummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	files, err := store.ListLabelCandidates(ctx, c.Model(), opts.Limit)
+	if err != nil {
+		return summary, err
+	}
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		progress := LabelProgress{MediaID: file.ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if IsRemote(file.Path) || IsArchivePath(file.Path) {
+			summary.Skipped++
+			progress.Error = "not a local file"
+		} else if labels, err := c.Classify(ctx, file.Path); err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+			progress.Error = err.Error()
+		} else {
+			if err := store.SaveLabels(ctx, file.ID, file.HashMD5, c.Model(), LabelSource, labels); err != nil {
+				return summary, err
+			}
+			summary.Labelled++
+			summary.Labels += len(labels)
+			progress.Labels = labels
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,267 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// LightroomSource is recorded as the source of ratings and keywords
+// imported from Lightroom catalogs.
+const LightroomSource = "lightroom"
+
+// LightroomImage is the curation a Lightroom catalog holds for one file.
+type LightroomImage struct {
+	Path string
+	// Rating is 0 to 5 stars.
+	Rating int
+	// Flag is "pick", "reject" or empty.
+	Flag       string
+	ColorLabel string
+	// Developed reports adjustments made in the Develop module.
+	Developed bool
+	Keywords  []string
+}
+
+// curated reports whether the image carries anything worth importing.
+func (i LightroomImage) curated() bool {
+	return i.Rating > 0 || i.Flag != "" || i.ColorLabel != "" || i.Developed || len(i.Keywords) > 0
+}
+
+// ReadLightroomCatalog reads the master images of a Lightroom Classic
+// catalog (.lrcat); virtual copies are left out. Like ReadPhotosLibrary it
+// reads a temporary copy, so a catalog Lightroom has open is not disturbed.
+func ReadLightroomCatalog(ctx context.Context, catalog string) ([]LightroomImage, error) {
+	if _, err := os.Stat(catalog); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "phototidy-lrcat-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := copyPlain(catalog+suffix, filepath.Join(tmp, "catalog.lrcat"+suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("copy catalog: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(tmp, "catalog.lrcat"))
+	if err != nil {
+		return nil, fmt.Errorf("open catalog: %w", err)
+	}
+	defer db.Close()
+
+	developed, err := lightroomDevelopedExpr(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT i.id_local, r.absolutePath || COALESCE(f.pathFromRoot, '') || lf.idx_filename,
+    COALESCE(i.rating, 0), COALESCE(i.pick, 0), COALESCE(i.colorLabels, ''), `+developed+`
+FROM Adobe_images i
+JOIN AgLibraryFile lf ON lf.id_local = i.rootFile
+JOIN AgLibraryFolder f ON f.id_local = lf.folder
+JOIN AgLibraryRootFolder r ON r.id_local = f.rootFolder
+WHERE i.masterImage IS NULL
+ORDER BY i.id_local`)
+	if err != nil {
+		return nil, fmt.Errorf("query catalog images: %w", err)
+	}
+	defer rows.Close()
+
+	var images []LightroomImage
+	index := make(map[int64]int)
+	for rows.Next() {
+		var (
+			id     int64
+			image  LightroomImage
+			rating float64
+			pick   float64
+		)
+		if err := rows.Scan(&id, &image.Path, &rating, &pick, &image.ColorLabel, &image.Developed); err != nil {
+			return nil, fmt.Errorf("scan catalog image: %w", err)
+		}
+		// Catalogs keep forward slashes on every platform.
+		image.Path = filepath.Clean(filepath.FromSlash(image.Path))
+		image.Rating = min(max(int(rating), 0), 5)
+		switch {
+		case pick > 0:
+			image.Flag = "pick"
+		case pick < 0:
+			image.Flag = "reject"
+		}
+		index[id] = len(images)
+		images = append(images, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog images: %w", err)
+	}
+
+	keywords, err := db.QueryContext(ctx, `
+SELECT ki.image, k.name
+FROM AgLibraryKeywordImage ki
+JOIN AgLibraryKeyword k ON k.id_local = ki.tag
+WHERE COALESCE(k.name, '') <> ''
+ORDER BY ki.image, k.name`)
+	if err != nil {
+		return nil, fmt.Errorf("query catalog keywords: %w", err)
+	}
+	defer keywords.Close()
+	for keywords.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := keywords.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scan catalog keyword: %w", err)
+		}
+		if i, ok := index[id]; ok {
+			images[i].Keywords = append(images[i].Keywords, name)
+		}
+	}
+	if err := keywords.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog keywords: %w", err)
+	}
+	return images, nil
+}
+
+// lightroomDevelopedExpr selects whether an image has develop adjustments.
+// Lightroom Classic 7 replaced hasDevelopAdjustments with
+// hasDevelopAdjustmentsEx, so the column is looked up.
+func lightroomDevelopedExpr(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `PRAGMA table_info(Adobe_imageDevelopSettings)`)
+	if err != nil {
+		return "", fmt.Errorf("read catalog schema: %w", err)
+	}
+	defer rows.Close()
+	column := ""
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return "", fmt.Errorf("read catalog schema: %w", err)
+		}
+		if name == "hasDevelopAdjustmentsEx" || (name == "hasDevelopAdjustments" && column == "") {
+			column = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("read catalog schema: %w", err)
+	}
+	if column == "" {
+		return "0", nil
+	}
+	return `EXISTS(SELECT 1 FROM Adobe_imageDevelopSettings d WHERE d.image = i.id_local AND d.` + column + ` > 0)`, nil
+}
+
+// LightroomOptions configures a catalog import.
+type LightroomOptions struct {
+	// Gate, when set, can pause the import between files.
+	Gate *PauseGate
+	// Throttle paces the reads of files hashed for matching.
+	Throttle *Throttle
+}
+
+// LightroomSummary reports a catalog import.
+type LightroomSummary struct {
+	Images int `json:"images"`
+	// ByPath counts images found at the path the catalog knows; ByHash
+	// those found by content after being moved, e.g. by a tidy run.
+	ByPath    int `json:"byPath"`
+	ByHash    int `json:"byHash"`
+	Unmatched int `json:"unmatched"`
+	// Skipped counts images without ratings, flags, labels, develop
+	// adjustments or keywords.
+	Skipped    int      `json:"skipped"`
+	Keywords   int      `json:"keywords"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// ImportLightroom stores the curation of images for the library files they
+// match: the file at the catalog path, or else a file with the same content,
+// whose hash comes from the action log when a tidy run moved the file away
+// or from the file itself when it is still there. Keywords become tags.
+func ImportLightroom(ctx context.Context, store *storage.Store, images []LightroomImage, opts LightroomOptions) (summary LightroomSummary, err error) {
+	start := time.Now()
+	summary = LightroomSummary{Images: len(images)}
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	for _, image := range images {
+		if !image.curated() {
+			summary.Skipped++
+			continue
+		}
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+
+		file, found, err := store.FindMediaByPath(ctx, image.Path)
+		if err != nil {
+			return summary, err
+		}
+		if found {
+			summary.ByPath++
+		} else {
+			hash, err := lightroomHash(ctx, store, image.Path, opts.Throttle)
+			if err != nil {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", image.Path, err))
+			}
+			if hash != "" {
+				if file, found, err = store.FindMediaByHash(ctx, hash, ""); err != nil {
+					return summary, err
+				}
+			}
+			if !found {
+				summary.Unmatched++
+				continue
+			}
+			summary.ByHash++
+		}
+
+		if err := store.SaveMediaRating(ctx, storage.MediaRating{
+			MediaID:    file.ID,
+			Rating:     image.Rating,
+			Flag:       image.Flag,
+			ColorLabel: image.ColorLabel,
+			Developed:  image.Developed,
+			Source:     LightroomSource,
+		}); err != nil {
+			return summary, err
+		}
+		for _, keyword := range image.Keywords {
+			if err := store.TagMedia(ctx, keyword, LightroomSource, []int64{file.ID}); err != nil {
+				return summary, err
+			}
+			summary.Keywords++
+		}
+	}
+	return summary, nil
+}
+
+// lightroomHash finds the content hash of the file once at path: the one
+// logged when it was moved away, or else the file's own. It returns "" when
+// neither is known.
+func lightroomHash(ctx context.Context, store *storage.Store, path string, throttle *Throttle) (string, error) {
+	hash, err := store.LastHashFrom(ctx, path)
+	if err != nil || hash != "" {
+		return hash, err
+	}
+	if _, err := os.Stat(longPath(path)); err != nil {
+		return "", nil
+	}
+	if err := throttle.Between(ctx); err != nil {
+		return "", err
+	}
+	return hashFile(path, throttle)
+}
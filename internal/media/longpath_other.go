@@ -0,0 +1,8 @@
+//go:build !windows
+
+package media
+
+// longPath is a no-op outside Windows, which has no MAX_PATH limit.
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,23 @@
+package media
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is the classic Win32 MAX_PATH limit, including the terminator.
+const maxPath = 260
+
+// longPath prefixes absolute paths that exceed MAX_PATH with \\?\ (or
+// \\?\UNC\ for shares) so Win32 APIs accept them. Paths stored in the
+// library never carry the prefix.
+func longPath(path string) string {
+	if len(path) < maxPath-12 || strings.HasPrefix(path, `\\?\`) || !filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		return `\\?\UNC\` + path[2:]
+	}
+	return `\\?\` + path
+}
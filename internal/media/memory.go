@@ -0,0 +1,32 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+// memoryMB approximates the resident memory of the Go heap and runtime: what
+// the process holds from the OS minus what it has handed back.
+func memoryMB() float64 {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return float64(mem.Sys-mem.HeapReleased) / (1 << 20)
+}
+
+// writeHeapProfile saves a heap profile at path for go tool pprof.
+func writeHeapProfile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,161 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// targetRegistry hands out unique target paths to concurrent tidy workers.
+// Claims are persisted in SQLite so a resumed run does not reuse a name that
+// an interrupted run already handed to a different file.
+type targetRegistry struct {
+	store   *storage.Store
+	backend Backend
+	dryRun  bool
+	// foldCase treats names differing only by case as the same target.
+	foldCase bool
+
+	mu     sync.Mutex
+	claims map[string]int64
+	// listings caches the keys of the names in each target directory for
+	// the run, so probing candidates does not list the directory every time.
+	// Reserved names are added as they are handed out.
+	listings map[string]map[string]bool
+}
+
+func newTargetRegistry(store *storage.Store, backend Backend, dryRun, foldCase bool) *targetRegistry {
+	return &targetRegistry{
+		store:    store,
+		backend:  backend,
+		dryRun:   dryRun,
+		foldCase: foldCase,
+		claims:   make(map[string]int64),
+		listings: make(map[string]map[string]bool),
+	}
+}
+
+// key is the identity used to compare targets: names differing only in
+// Unicode normalisation, or in case on case-insensitive volumes, refer to
+// the same target.
+func (r *targetRegistry) key(path string) string {
+	path = norm.NFC.String(path)
+	if r.foldCase {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// exists reports whether a file already occupies path, also matching
+// entries whose names have the same key.
+func (r *targetRegistry) exists(path string) (bool, error) {
+	b := backendFor(r.backend, path)
+	if _, err := b.Stat(path); err == nil {
+		return true, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	listing, err := r.listing(b, b.Dir(path))
+	if err != nil {
+		return false, err
+	}
+	return listing[r.key(filepath.Base(path))], nil
+}
+
+// listing returns the cached name keys of dir, listing it on first use. A
+// missing directory has an empty listing.
+func (r *targetRegistry) listing(b Backend, dir string) (map[string]bool, error) {
+	if listing, ok := r.listings[dir]; ok {
+		return listing, nil
+	}
+
+	names, err := b.List(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	listing := make(map[string]bool, len(names))
+	for _, name := range names {
+		listing[r.key(name)] = true
+	}
+	r.listings[dir] = listing
+	return listing, nil
+}
+
+// reserve returns the first free variant of path ("name-1.ext", "name-2.ext", ...)
+// and records it as claimed by file.
+func (r *targetRegistry) reserve(ctx context.Context, path string, file storage.MediaFile) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := backendFor(r.backend, path)
+	dir := b.Dir(path)
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	candidate := path
+	for i := 1; i < 1000; i++ {
+		ok, err := r.tryClaim(ctx, candidate, file)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+		candidate = b.Join(dir, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+	return "", fmt.Errorf("unable to find unique name for %s", path)
+}
+
+// release drops the claim once the file has reached, or failed to reach, its
+// target. Dry runs keep their claims because nothing lands on disk.
+func (r *targetRegistry) release(ctx context.Context, path string) {
+	if r.dryRun {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.key(path)
+	delete(r.claims, key)
+	_ = r.store.ReleaseTarget(ctx, key)
+}
+
+func (r *targetRegistry) tryClaim(ctx context.Context, candidate string, file storage.MediaFile) (bool, error) {
+	key := r.key(candidate)
+	if owner, taken := r.claims[key]; taken && owner != file.ID {
+		return false, nil
+	}
+
+	// The file's current location is free for itself.
+	if key != r.key(file.Path) {
+		taken, err := r.exists(candidate)
+		if err != nil || taken {
+			return false, err
+		}
+	}
+
+	if !r.dryRun {
+		ok, err := r.store.ClaimTarget(ctx, key, file.ID)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	r.claims[key] = file.ID
+	b := backendFor(r.backend, candidate)
+	if listing, ok := r.listings[b.Dir(candidate)]; ok {
+		listing[r.key(filepath.Base(candidate))] = true
+	}
+	return true, nil
+}
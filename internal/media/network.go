@@ -0,0 +1,150 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// netRetries bounds how often an operation is retried after a transient
+// network error.
+const netRetries = 3
+
+// PauseOffline is the gate reason held while a network source or target is
+// unreachable; the job resumes once the user reconnects it.
+const PauseOffline = "offline"
+
+// OfflineFunc is told which path went away when a job pauses for it.
+type OfflineFunc func(path string, err error)
+
+// IsUNC reports whether path is a Windows network path (\\server\share).
+func IsUNC(path string) bool {
+	return strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, "//")
+}
+
+// PreflightTarget checks that the target base on b is reachable and
+// writable by creating and removing a probe file. Errors are phrased for the
+// UI.
+func PreflightTarget(b Backend, base string) error {
+	if strings.TrimSpace(base) == "" {
+		return errors.New("target base folder is not configured")
+	}
+
+	if err := b.Mkdir(base); err != nil {
+		return describeTargetError(base, err)
+	}
+
+	name := b.Join(base, probeName("probe"))
+	if err := b.Copy(strings.NewReader("probe"), name); err != nil {
+		return describeTargetError(base, err)
+	}
+	if err := b.Remove(name); err != nil {
+		return describeTargetError(base, err)
+	}
+	return nil
+}
+
+// probeName returns a unique hidden file name for a short-lived probe.
+func probeName(kind string) string {
+	return fmt.Sprintf(".phototidy-%s-%d", kind, time.Now().UnixNano())
+}
+
+func describeTargetError(base string, err error) error {
+	switch {
+	case errors.Is(err, os.ErrPermission) || containsAny(err, "access is denied", "logon failure", "password", "credentials"):
+		if IsUNC(base) {
+			return fmt.Errorf("network share %s rejected the current credentials; connect it in the file manager first: %w", shareRoot(base), err)
+		}
+		return fmt.Errorf("target %s is not writable: %w", base, err)
+	case IsUNC(base) && (errors.Is(err, os.ErrNotExist) || containsAny(err, "network name", "network path", "not found")):
+		return fmt.Errorf("network share %s is unreachable: %w", shareRoot(base), err)
+	case IsRcloneRemote(base) && !errors.Is(err, os.ErrExist):
+		return fmt.Errorf("rclone remote %s is unreachable or rejected the request: %w", base, err)
+	case IsRemote(base) && !errors.Is(err, os.ErrExist):
+		return fmt.Errorf("WebDAV target %s is unreachable or rejected the request: %w", base, err)
+	default:
+		return fmt.Errorf("target %s failed pre-flight check: %w", base, err)
+	}
+}
+
+// isTransientNetError matches SMB/NFS errors that usually clear on retry.
+func isTransientNetError(err error) bool {
+	return err != nil && containsAny(err,
+		"network name is no longer available",
+		"semaphore timeout",
+		"unexpected network error",
+		"connection reset",
+		"connection timed out",
+		"i/o timeout",
+		"host is down",
+		"stale nfs file handle",
+	)
+}
+
+// moveWithRetry retries moves that fail with transient network errors.
+func moveWithRetry(b Backend, src, dest string, safety SafetyLevel, expectedHash string, throttle *Throttle) error {
+	return withNetRetry(func() error {
+		return moveFile(b, src, dest, safety, expectedHash, throttle)
+	})
+}
+
+// withNetRetry runs op, retrying with a growing backoff while it fails with
+// a transient network error.
+func withNetRetry(op func() error) error {
+	var err error
+	for attempt := 0; attempt < netRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		err = op()
+		if !isTransientNetError(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("after %d attempts: %w", netRetries, err)
+}
+
+// Reachable reports whether path currently answers a stat.
+func Reachable(path string) bool {
+	_, err := os.Stat(longPath(path))
+	return err == nil
+}
+
+// holdOffline pauses the job while root on b is unreachable, which is how
+// a disconnected share or unplugged drive shows up. It reports whether the
+// failed operation should be tried again, i.e. root was offline and the gate
+// has since been released.
+func holdOffline(ctx context.Context, gate *PauseGate, b Backend, root string, cause error, notify OfflineFunc) bool {
+	if gate == nil || root == "" {
+		return false
+	}
+	if _, err := b.Stat(root); err == nil {
+		return false
+	}
+	gate.Pause(PauseOffline)
+	if notify != nil {
+		notify(root, cause)
+	}
+	return gate.Wait(ctx) == nil
+}
+
+func shareRoot(path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' })
+	if len(parts) < 2 {
+		return path
+	}
+	return `\\` + parts[0] + `\` + parts[1]
+}
+
+func containsAny(err error, needles ...string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, needle := range needles {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
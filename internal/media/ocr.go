@@ -0,0 +1,144 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ocrTimeout bounds the reading of one image.
+const ocrTimeout = 2 * time.Minute
+
+// DefaultOCRLanguages are the tesseract languages read when none are set.
+const DefaultOCRLanguages = "eng"
+
+// ocrMimeTypes are the formats tesseract reads; RAW and HEIC files are left
+// out, screenshots and scanned documents are rarely either.
+var ocrMimeTypes = []string{
+	"image/jpeg", "image/png", "image/tiff", "image/bmp", "image/x-ms-bmp", "image/gif", "image/webp",
+}
+
+// LocateTesseract detects tesseract from its setting: empty looks it up on
+// PATH, "off" disables it and anything else is the executable's path.
+func LocateTesseract(setting string) ToolInfo {
+	return locateTool("tesseract", setting, "--version")
+}
+
+// Tesseract reads the text in images, such as receipts and screenshots,
+// with the tesseract OCR engine.
+type Tesseract struct {
+	path      string
+	languages string
+}
+
+// NewTesseract wraps the tesseract at path, reading languages, e.g.
+// "eng+deu"; empty means DefaultOCRLanguages.
+func NewTesseract(path, languages string) *Tesseract {
+	languages = strings.TrimSpace(languages)
+	if languages == "" {
+		languages = DefaultOCRLanguages
+	}
+	return &Tesseract{path: path, languages: languages}
+}
+
+// Engine identifies the engine and its languages; images are read again
+// when they change.
+func (t *Tesseract) Engine() string { return "tesseract:" + t.languages }
+
+// Read returns the text in the image at path with runs of whitespace
+// collapsed, or "" when it holds none.
+func (t *Tesseract) Read(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ocrTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path, path, "stdout", "-l", t.languages)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.Join(strings.Fields(stdout.String()), " "), nil
+}
+
+// OCROptions configures a text recognition run.
+type OCROptions struct {
+	// Limit caps how many images are read; 0 reads all pending.
+	Limit int
+	// Gate, when set, can pause the run between images.
+	Gate *PauseGate
+	// Throttle paces the images handed to tesseract.
+	Throttle *Throttle
+}
+
+// OCRProgress reports one read image.
+type OCRProgress struct {
+	MediaID int64  `json:"mediaId"`
+	Path    string `json:"path"`
+	// Chars is the length of the text found.
+	Chars     int    `json:"chars"`
+	Error     string `json:"error,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+// OCRSummary summarises a text recognition run.
+type OCRSummary struct {
+	Read int `json:"read"`
+	// WithText counts the images in which any text was found.
+	WithText int `json:"withText"`
+	Failed   int `json:"failed"`
+	// Skipped counts files on remote targets and inside archives, which
+	// tesseract cannot read.
+	Skipped    int      `json:"skipped"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// RecogniseText reads the text in images t has not yet read and stores it
+// in the search index.
+func RecogniseText(ctx context.Context, store *storage.Store, t *Tesseract, opts OCROptions, onProgress func(OCRProgress)) (summary OCRSummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	files, err := store.ListTextCandidates(ctx, t.Engine(), ocrMimeTypes, opts.Limit)
+	if err != nil {
+		return summary, err
+	}
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		progress := OCRProgress{MediaID: file.ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if IsRemote(file.Path) || IsArchivePath(file.Path) {
+			summary.Skipped++
+			progress.Error = "not a local file"
+		} else if text, err := t.Read(ctx, file.Path); err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+			progress.Error = err.Error()
+		} else {
+			if err := store.SaveText(ctx, file.ID, file.HashMD5, t.Engine(), text); err != nil {
+				return summary, err
+			}
+			summary.Read++
+			if text != "" {
+				summary.WithText++
+			}
+			progress.Chars = len(text)
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,102 @@
+package media
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeForm selects how target names are normalised. macOS historically
+// writes decomposed (NFD) names while Windows and Linux expect composed (NFC)
+// ones, so the same name can otherwise appear twice.
+type UnicodeForm string
+
+// Supported normalisation forms; empty means NFC.
+const (
+	FormNFC  UnicodeForm = "nfc"
+	FormNFD  UnicodeForm = "nfd"
+	FormNone UnicodeForm = "none"
+)
+
+// ParseUnicodeForm validates a configured normalisation form.
+func ParseUnicodeForm(name string) (UnicodeForm, error) {
+	switch form := UnicodeForm(strings.ToLower(strings.TrimSpace(name))); form {
+	case "", FormNFC:
+		return FormNFC, nil
+	case FormNFD, FormNone:
+		return form, nil
+	default:
+		return "", fmt.Errorf("unknown unicode normalisation %q", name)
+	}
+}
+
+// normalize converts s to the given form.
+func (f UnicodeForm) normalize(s string) string {
+	switch f {
+	case FormNFD:
+		return norm.NFD.String(s)
+	case FormNone:
+		return s
+	default:
+		return norm.NFC.String(s)
+	}
+}
+
+// maxSegmentBytes keeps every path segment below the 255 limit of NTFS and
+// ext4 with room for the "-N" suffix added by the target registry.
+const maxSegmentBytes = 200
+
+// reservedNames are Windows device names that cannot be used as file or
+// folder names, with or without an extension.
+var reservedNames = map[string]struct{}{}
+
+func init() {
+	for _, name := range []string{"CON", "PRN", "AUX", "NUL"} {
+		reservedNames[name] = struct{}{}
+	}
+	for i := 1; i <= 9; i++ {
+		reservedNames[fmt.Sprintf("COM%d", i)] = struct{}{}
+		reservedNames[fmt.Sprintf("LPT%d", i)] = struct{}{}
+	}
+}
+
+// checkSegments rejects relative paths containing reserved device names.
+// They are rejected on every platform because targets may be Windows shares.
+func checkSegments(relative string) error {
+	for _, segment := range strings.Split(relative, string(filepath.Separator)) {
+		stem, _, _ := strings.Cut(segment, ".")
+		if _, reserved := reservedNames[strings.ToUpper(strings.TrimSpace(stem))]; reserved {
+			return fmt.Errorf("target name %q is a reserved Windows device name", segment)
+		}
+	}
+	return nil
+}
+
+// shortenSegment truncates an overlong segment on a rune boundary, keeping
+// the extension and appending a short hash of the full name so distinct long
+// names stay distinct.
+func shortenSegment(segment string) string {
+	if len(segment) <= maxSegmentBytes {
+		return segment
+	}
+
+	ext := filepath.Ext(segment)
+	if len(ext) > 16 {
+		ext = ""
+	}
+	sum := sha1.Sum([]byte(segment))
+	suffix := "~" + hex.EncodeToString(sum[:])[:8]
+
+	stem := strings.TrimSuffix(segment, ext)
+	limit := maxSegmentBytes - len(ext) - len(suffix)
+	for len(stem) > limit {
+		_, size := utf8.DecodeLastRuneInString(stem)
+		stem = stem[:len(stem)-size]
+	}
+	return strings.TrimRight(stem, " .") + suffix + ext
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// caseInsensitiveFS reports whether the default filesystems of this OS
+// ignore case when resolving names.
+func caseInsensitiveFS() bool {
+	return runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+}
+
+// CaseMode controls how target collisions treat letter case.
+type CaseMode string
+
+// Supported case modes; empty means CaseAuto.
+const (
+	CaseAuto        CaseMode = "auto"
+	CaseSensitive   CaseMode = "sensitive"
+	CaseInsensitive CaseMode = "insensitive"
+)
+
+// foldsCase resolves the mode for the volume holding dir on b. CaseAuto
+// probes the volume and falls back to the OS default when dir cannot be
+// written.
+func (m CaseMode) foldsCase(b Backend, dir string) (bool, error) {
+	switch m {
+	case CaseSensitive:
+		return false, nil
+	case CaseInsensitive:
+		return true, nil
+	case "", CaseAuto:
+		if folds, err := probeCaseInsensitive(b, dir); err == nil {
+			return folds, nil
+		}
+		return isLocal(b) && caseInsensitiveFS(), nil
+	default:
+		return false, fmt.Errorf("unknown case mode %q", m)
+	}
+}
+
+// probeCaseInsensitive creates a lower-case probe file in dir and checks
+// whether its upper-case spelling resolves to the same file.
+func probeCaseInsensitive(b Backend, dir string) (bool, error) {
+	name := probeName("case")
+	if err := b.Copy(strings.NewReader(""), b.Join(dir, name)); err != nil {
+		return false, err
+	}
+	defer b.Remove(b.Join(dir, name))
+
+	_, err := b.Stat(b.Join(dir, strings.ToUpper(name)))
+	return err == nil, nil
+}
+
+// canonicalPath rewrites every component of an absolute path to the casing
+// stored on disk. Components that cannot be resolved are kept verbatim.
+func canonicalPath(path string) string {
+	if !caseInsensitiveFS() {
+		return path
+	}
+
+	volume := filepath.VolumeName(path)
+	rest := strings.TrimPrefix(path, volume)
+	current := strings.ToUpper(volume) + string(filepath.Separator)
+
+	for _, part := range strings.FieldsFunc(rest, func(r rune) bool { return r == '/' || r == '\\' }) {
+		current = filepath.Join(current, matchEntry(current, part))
+	}
+	return current
+}
+
+func matchEntry(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return name
+	}
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name
+		}
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return entry.Name()
+		}
+	}
+	return name
+}
+
+// RepairPathCase merges library rows whose paths differ only by case, keeping
+// the row that matches the on-disk casing. It returns the number of rows removed.
+func (s *Scanner) RepairPathCase(ctx context.Context) (int, error) {
+	groups, err := s.store.ListCaseVariantGroups(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	for _, group := range groups {
+		if err := ctx.Err(); err != nil {
+			return merged, err
+		}
+		if len(group) < 2 {
+			continue
+		}
+
+		keep := group[0]
+		canonical := canonicalPath(keep.Path)
+		for _, file := range group {
+			if file.Path == canonical {
+				keep = file
+				break
+			}
+		}
+
+		drop := make([]int64, 0, len(group)-1)
+		for _, file := range group {
+			if file.ID != keep.ID {
+				drop = append(drop, file.ID)
+			}
+		}
+
+		if err := s.store.MergeMediaRows(ctx, keep.ID, drop); err != nil {
+			return merged, fmt.Errorf("merge %s: %w", keep.Path, err)
+		}
+		if keep.Path != canonical {
+			if err := relocate(ctx, s.store, keep.ID, canonical); err != nil {
+				return merged, err
+			}
+		}
+		merged += len(drop)
+	}
+	return merged, nil
+}
@@ -0,0 +1,84 @@
+package media
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// PauseGate lets long-running jobs be paused between files. Several reasons
+// (user request, suspend, battery) can hold the gate; it opens again once
+// all of them are released. A nil *PauseGate never blocks.
+type PauseGate struct {
+	mu      sync.Mutex
+	reasons map[string]struct{}
+	resume  chan struct{}
+}
+
+// NewPauseGate returns an open gate.
+func NewPauseGate() *PauseGate {
+	return &PauseGate{reasons: make(map[string]struct{})}
+}
+
+// Pause closes the gate for the given reason.
+func (g *PauseGate) Pause(reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.reasons) == 0 {
+		g.resume = make(chan struct{})
+	}
+	g.reasons[reason] = struct{}{}
+}
+
+// Resume releases the given reason and reopens the gate when none remain.
+func (g *PauseGate) Resume(reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.reasons[reason]; !ok {
+		return
+	}
+	delete(g.reasons, reason)
+	if len(g.reasons) == 0 {
+		close(g.resume)
+		g.resume = nil
+	}
+}
+
+// Reasons lists why the gate is currently closed.
+func (g *PauseGate) Reasons() []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out := make([]string, 0, len(g.reasons))
+	for reason := range g.reasons {
+		out = append(out, reason)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Wait blocks while the gate is closed or until ctx is done.
+func (g *PauseGate) Wait(ctx context.Context) error {
+	if g == nil {
+		return ctx.Err()
+	}
+
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+
+	if resume == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,362 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// phashSize is the edge of the greyscale grid whose DCT the hash is taken
+// from; the lowest 8x8 frequencies make up the 64 bits.
+const phashSize = 32
+
+// DefaultSimilarDistance is the Hamming distance up to which FindSimilar
+// counts two images as similar: crops, re-encodes and light edits of one
+// photo usually stay below it.
+const DefaultSimilarDistance = 10
+
+// phashMimeTypes are the formats the built-in decoders read.
+var phashMimeTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// PerceptualHash computes the DCT hash of the image at path after applying
+// its EXIF orientation. Visually similar images have hashes a small
+// Hamming distance apart.
+func PerceptualHash(path string) (uint64, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return 0, err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("decode %s: %w", path, err)
+	}
+	grid := orient(resample(img, phashSize), extractEXIF(path).Fields["Orientation"])
+
+	var lum [phashSize][phashSize]float64
+	for y := 0; y < phashSize; y++ {
+		for x := 0; x < phashSize; x++ {
+			lum[y][x] = float64(color.GrayModel.Convert(grid.RGBAAt(x, y)).(color.Gray).Y)
+		}
+	}
+	return lumaHash(&lum), nil
+}
+
+// lumaHash hashes a greyscale grid by the signs of its low frequencies
+// relative to their median.
+func lumaHash(lum *[phashSize][phashSize]float64) uint64 {
+	coeffs := dct8x8(lum)
+
+	// The DC term only says how bright the image is, so it is left out of
+	// the median.
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// resample squeezes img into a size x size grid, averaging the source pixels
+// covered by each cell. Unlike downscale it ignores the aspect ratio.
+func resample(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := b.Min.Y+y*h/size, b.Min.Y+max((y+1)*h/size, y*h/size+1)
+		for x := 0; x < size; x++ {
+			x0, x1 := b.Min.X+x*w/size, b.Min.X+max((x+1)*w/size, x*w/size+1)
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := img.At(sx, sy).RGBA()
+					r, g, bl, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), n+1
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), 0xff})
+		}
+	}
+	return out
+}
+
+// dct8x8 returns the lowest 8x8 coefficients of the 2-D DCT-II of lum, row
+// by row.
+func dct8x8(lum *[phashSize][phashSize]float64) [64]float64 {
+	var cos [8][phashSize]float64
+	for u := 0; u < 8; u++ {
+		for x := 0; x < phashSize; x++ {
+			cos[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+		}
+	}
+	// Rows first, then columns, keeping only the frequencies used.
+	var rows [phashSize][8]float64
+	for y := 0; y < phashSize; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < phashSize; x++ {
+				sum += lum[y][x] * cos[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+	var out [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < phashSize; y++ {
+				sum += rows[y][u] * cos[v][y]
+			}
+			out[v*8+u] = sum
+		}
+	}
+	return out
+}
+
+// bkNode is a node of a BK-tree over Hamming distance: every child at key d
+// holds hashes exactly d bits from the node's.
+type bkNode struct {
+	hash     uint64
+	ids      []int64
+	children map[int]*bkNode
+}
+
+// BKTree indexes perceptual hashes for finding those within a distance of
+// a query without comparing against every hash.
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+// Add indexes id under hash.
+func (t *BKTree) Add(hash uint64, id int64) {
+	t.size++
+	if t.root == nil {
+		t.root = &bkNode{hash: hash, ids: []int64{id}}
+		return
+	}
+	node := t.root
+	for {
+		d := bits.OnesCount64(node.hash ^ hash)
+		if d == 0 {
+			node.ids = append(node.ids, id)
+			return
+		}
+		child := node.children[d]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{hash: hash, ids: []int64{id}}
+			return
+		}
+		node = child
+	}
+}
+
+// Len returns how many IDs the tree holds.
+func (t *BKTree) Len() int { return t.size }
+
+// BKMatch is an ID whose hash lies Distance bits from the query.
+type BKMatch struct {
+	ID       int64
+	Distance int
+}
+
+// Search returns the IDs whose hashes are at most maxDistance bits from
+// hash, nearest first.
+func (t *BKTree) Search(hash uint64, maxDistance int) []BKMatch {
+	var matches []BKMatch
+	if t.root == nil {
+		return matches
+	}
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d := bits.OnesCount64(node.hash ^ hash)
+		if d <= maxDistance {
+			for _, id := range node.ids {
+				matches = append(matches, BKMatch{ID: id, Distance: d})
+			}
+		}
+		// By the triangle inequality only children keyed within
+		// maxDistance of d can hold matches.
+		for key, child := range node.children {
+			if key >= d-maxDistance && key <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// PHashOptions configures a perceptual hashing run.
+type PHashOptions struct {
+	// Limit caps how many images are hashed; 0 hashes all pending.
+	Limit int
+	// Gate, when set, can pause the run between images.
+	Gate *PauseGate
+	// Throttle paces the images decoded.
+	Throttle *Throttle
+}
+
+// PHashProgress reports one hashed image.
+type PHashProgress struct {
+	MediaID   int64  `json:"mediaId"`
+	Path      string `json:"path"`
+	Error     string `json:"error,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+// PHashSummary summarises a perceptual hashing run.
+type PHashSummary struct {
+	Hashed int `json:"hashed"`
+	Failed int `json:"failed"`
+	// Skipped counts files on remote targets and inside archives.
+	Skipped    int      `json:"skipped"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// HashImages computes the perceptual hashes of images without one for their
+// current content.
+func HashImages(ctx context.Context, store *storage.Store, opts PHashOptions, onProgress func(PHashProgress)) (summary PHashSummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	files, err := store.ListPHashCandidates(ctx, phashMimeTypes, opts.Limit)
+	if err != nil {
+		return summary, err
+	}
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		progress := PHashProgress{MediaID: file.ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if IsRemote(file.Path) || IsArchivePath(file.Path) {
+			summary.Skipped++
+			progress.Error = "not a local file"
+		} else if hash, err := PerceptualHash(file.Path); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+			progress.Error = err.Error()
+		} else {
+			if err := store.SavePHash(ctx, file.ID, file.HashMD5, hash); err != nil {
+				return summary, err
+			}
+			summary.Hashed++
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
+
+// SimilarImage is an image or video perceptually close to the one searched
+// for.
+type SimilarImage struct {
+	File storage.MediaFile `json:"file"`
+	// Distance is the number of differing hash bits; 0 is the same picture
+	// in another encoding or size.
+	Distance int `json:"distance"`
+}
+
+// FindSimilar returns up to limit images whose perceptual hashes lie at most
+// maxDistance bits from that of mediaID, nearest first. For a video it
+// returns the videos whose fingerprints lie that close on average, such as
+// re-compressed copies. Exact copies of the file are left out, since
+// duplicate groups already show them. A file without a hash is hashed on
+// the spot; probe, which may be nil, is needed for videos.
+func FindSimilar(ctx context.Context, store *storage.Store, probe *FFprobe, mediaID int64, maxDistance, limit int) ([]SimilarImage, error) {
+	if maxDistance <= 0 {
+		maxDistance = DefaultSimilarDistance
+	}
+	files, err := store.GetMediaByIDs(ctx, []int64{mediaID})
+	if err != nil {
+		return nil, err
+	}
+	origin, found := files[mediaID]
+	if !found {
+		return nil, fmt.Errorf("media %d not found", mediaID)
+	}
+	if strings.HasPrefix(origin.MimeType.String, "video/") {
+		return findSimilarVideos(ctx, store, probe, origin, maxDistance, limit)
+	}
+
+	hash, ok, err := store.GetPHash(ctx, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if IsRemote(origin.Path) || IsArchivePath(origin.Path) {
+			return nil, errors.New("the image is not a local file and has no perceptual hash")
+		}
+		if hash, err = PerceptualHash(origin.Path); err != nil {
+			return nil, err
+		}
+		if err := store.SavePHash(ctx, mediaID, origin.HashMD5, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	hashes, err := store.ListPHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tree BKTree
+	for _, h := range hashes {
+		tree.Add(h.Hash, h.MediaID)
+	}
+	return similarFiles(ctx, store, origin, tree.Search(hash, maxDistance), limit)
+}
+
+// similarFiles loads the files of matches, in order, leaving out origin and
+// its exact copies.
+func similarFiles(ctx context.Context, store *storage.Store, origin storage.MediaFile, matches []BKMatch, limit int) ([]SimilarImage, error) {
+	ids := make([]int64, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	byID, err := store.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	similar := []SimilarImage{}
+	for _, m := range matches {
+		file, ok := byID[m.ID]
+		if !ok || m.ID == origin.ID || file.HashMD5 == origin.HashMD5 {
+			continue
+		}
+		similar = append(similar, SimilarImage{File: file, Distance: m.Distance})
+		if limit > 0 && len(similar) == limit {
+			break
+		}
+	}
+	return similar, nil
+}
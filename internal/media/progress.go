@@ -0,0 +1,51 @@
+package media
+
+import "time"
+
+// progressInterval limits progress events to at most ten per second.
+const progressInterval = 100 * time.Millisecond
+
+// progressMeter derives throughput and an ETA for progress events and
+// throttles how often they are emitted. It is not safe for concurrent use.
+type progressMeter struct {
+	start time.Time
+	last  time.Time
+	files int
+	bytes int64
+	total int
+}
+
+func newProgressMeter(total int) *progressMeter {
+	return &progressMeter{start: time.Now(), total: total}
+}
+
+// add counts one processed file of the given size.
+func (m *progressMeter) add(bytes int64) {
+	m.files++
+	m.bytes += bytes
+}
+
+// due reports whether an event should be emitted now. Forced events are
+// always emitted and restart the interval.
+func (m *progressMeter) due(force bool) bool {
+	now := time.Now()
+	if !force && now.Sub(m.last) < progressInterval {
+		return false
+	}
+	m.last = now
+	return true
+}
+
+// rate returns bytes per second and, when the file total is known, the
+// estimated seconds remaining.
+func (m *progressMeter) rate() (float64, float64) {
+	secs := time.Since(m.start).Seconds()
+	if secs <= 0 {
+		return 0, 0
+	}
+	var eta float64
+	if m.total > m.files && m.files > 0 {
+		eta = float64(m.total-m.files) / (float64(m.files) / secs)
+	}
+	return float64(m.bytes) / secs, eta
+}
@@ -0,0 +1,438 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// rcloneTimeout bounds metadata commands; transfers run until they finish.
+const rcloneTimeout = 2 * time.Minute
+
+// rclone exit codes for a missing directory or file.
+const (
+	rcloneDirNotFound  = 3
+	rcloneFileNotFound = 4
+)
+
+// rcloneRemote matches the "name:" prefix of an rclone path. Single letters
+// are left to Windows drive letters, as rclone itself does.
+var rcloneRemote = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_. @+-]+:`)
+
+// IsRcloneRemote reports whether path is an rclone "remote:path".
+func IsRcloneRemote(path string) bool {
+	return rcloneRemote.MatchString(path) && !strings.Contains(path, "://")
+}
+
+// Rclone runs the rclone executable for remotes the user configured in
+// rclone itself.
+type Rclone struct {
+	path string
+}
+
+// RcloneProgress is one transfer report parsed from rclone's JSON log.
+type RcloneProgress struct {
+	// Op is the rclone command, e.g. "copy" or "copyto".
+	Op             string  `json:"op"`
+	Source         string  `json:"source"`
+	Dest           string  `json:"dest"`
+	Bytes          int64   `json:"bytes"`
+	TotalBytes     int64   `json:"totalBytes"`
+	Speed          float64 `json:"speed"`
+	Transfers      int     `json:"transfers"`
+	TotalTransfers int     `json:"totalTransfers"`
+	Errors         int     `json:"errors"`
+}
+
+// RcloneEntry is a file listed on a remote.
+type RcloneEntry struct {
+	// Path is relative to the listed folder, slash-separated.
+	Path    string    `json:"Path"`
+	Name    string    `json:"Name"`
+	Size    int64     `json:"Size"`
+	ModTime time.Time `json:"ModTime"`
+	IsDir   bool      `json:"IsDir"`
+}
+
+// LocateRclone detects rclone from its setting: empty looks it up on PATH,
+// "off" disables it and anything else is the executable's path.
+func LocateRclone(setting string) ToolInfo {
+	return locateTool("rclone", setting, "version")
+}
+
+// NewRclone wraps the rclone at path.
+func NewRclone(path string) *Rclone {
+	return &Rclone{path: path}
+}
+
+// rcloneError carries the exit code and message of a failed command.
+type rcloneError struct {
+	args []string
+	code int
+	msg  string
+}
+
+func (e *rcloneError) Error() string {
+	msg := e.msg
+	if msg == "" {
+		msg = fmt.Sprintf("exit status %d", e.code)
+	}
+	return fmt.Sprintf("rclone %s: %s", e.args[0], msg)
+}
+
+// Is lets missing files and folders match fs.ErrNotExist.
+func (e *rcloneError) Is(target error) bool {
+	return target == fs.ErrNotExist && (e.code == rcloneDirNotFound || e.code == rcloneFileNotFound)
+}
+
+// run executes one command and returns its standard output.
+func (r *Rclone) run(ctx context.Context, stdin io.Reader, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, commandError(args, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func commandError(args []string, err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("rclone %s: %w", args[0], err)
+	}
+	// The last log line names the failure; earlier ones are retries.
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	return &rcloneError{args: args, code: exitErr.ExitCode(), msg: strings.TrimSpace(lines[len(lines)-1])}
+}
+
+// ListRemotes returns the names of the configured remotes, each with its
+// trailing colon, ready to be used as a path.
+func (r *Rclone) ListRemotes(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, rcloneTimeout)
+	defer cancel()
+	out, err := r.run(ctx, nil, "listremotes")
+	if err != nil {
+		return nil, err
+	}
+	var remotes []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			remotes = append(remotes, line)
+		}
+	}
+	return remotes, nil
+}
+
+// ListFiles returns every file below dir, recursively.
+func (r *Rclone) ListFiles(ctx context.Context, dir string) ([]RcloneEntry, error) {
+	out, err := r.run(ctx, nil, "lsjson", "--recursive", "--files-only", "--no-mimetype", dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []RcloneEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("rclone lsjson %s: %w", dir, err)
+	}
+	return entries, nil
+}
+
+// Pull copies the listed files, relative to src, into the local folder dest.
+// Progress is reported about once a second.
+func (r *Rclone) Pull(ctx context.Context, src, dest string, files []string, onProgress func(RcloneProgress)) error {
+	list, err := os.CreateTemp("", "phototidy-rclone-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(list.Name())
+	_, err = io.WriteString(list, strings.Join(files, "\n")+"\n")
+	if closeErr := list.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return r.transfer(ctx, "copy", src, dest, onProgress, "--files-from-raw", list.Name(), "--no-traverse")
+}
+
+// PullRemote mirrors the media files below the rclone remote src into the
+// local folder dest so they can be scanned. Files pulled by an earlier run
+// are only fetched again once their size or time changes on the remote,
+// so files tidied out of the mirror stay out. It returns how many files
+// were copied down.
+func PullRemote(ctx context.Context, r *Rclone, store *storage.Store, src, dest string, extensions []string, archives bool, onProgress func(RcloneProgress)) (int, error) {
+	entries, err := r.ListFiles(ctx, src)
+	if err != nil {
+		return 0, err
+	}
+	pulled, err := store.ListRemotePulls(ctx, src)
+	if err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		wanted[ext] = true
+	}
+	var files []string
+	var pulls []storage.RemotePull
+	for _, entry := range entries {
+		if !wanted[strings.ToLower(path.Ext(entry.Path))] && !(archives && archiveKind(entry.Path) != "") {
+			continue
+		}
+		if prior, ok := pulled[entry.Path]; ok && prior.SizeBytes == entry.Size && prior.ModTime.Equal(entry.ModTime) {
+			continue
+		}
+		files = append(files, entry.Path)
+		pulls = append(pulls, storage.RemotePull{Path: entry.Path, SizeBytes: entry.Size, ModTime: entry.ModTime})
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+	if err := r.Pull(ctx, src, dest, files, onProgress); err != nil {
+		return 0, err
+	}
+	return len(files), store.RecordRemotePulls(ctx, src, pulls)
+}
+
+// transfer runs a copy or move command, parsing the statistics rclone logs
+// as JSON on stderr into progress reports.
+func (r *Rclone) transfer(ctx context.Context, op, src, dest string, onProgress func(RcloneProgress), extra ...string) error {
+	args := append([]string{op, src, dest,
+		"--use-json-log", "--stats", "1s", "--stats-log-level", "NOTICE"}, extra...)
+	cmd := exec.CommandContext(ctx, r.path, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("rclone stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start rclone: %w", err)
+	}
+
+	var last string
+	lines := bufio.NewScanner(stderr)
+	lines.Buffer(make([]byte, 64<<10), 1<<20)
+	for lines.Scan() {
+		var entry struct {
+			Level string          `json:"level"`
+			Msg   string          `json:"msg"`
+			Stats *RcloneProgress `json:"stats"`
+		}
+		if json.Unmarshal(lines.Bytes(), &entry) != nil {
+			continue
+		}
+		if entry.Stats != nil {
+			if onProgress != nil {
+				stats := *entry.Stats
+				stats.Op, stats.Source, stats.Dest = op, src, dest
+				onProgress(stats)
+			}
+			continue
+		}
+		if entry.Level == "error" || entry.Level == "critical" {
+			last = strings.TrimSpace(entry.Msg)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return commandError(args, err, last)
+	}
+	return nil
+}
+
+// Backend returns a tidy target backend for root, a "remote:path".
+// onProgress, when set, receives the progress of every file sent.
+func (r *Rclone) Backend(root string, onProgress func(RcloneProgress)) *RcloneBackend {
+	if !strings.HasSuffix(root, ":") {
+		root = strings.TrimRight(root, "/")
+	}
+	return &RcloneBackend{rclone: r, root: root, onProgress: onProgress}
+}
+
+// RcloneBackend is a Backend on an rclone remote. Its paths are the root
+// followed by slash-separated names, e.g. "gdrive:Photos/2024-05-01/a.jpg".
+type RcloneBackend struct {
+	rclone     *Rclone
+	root       string
+	onProgress func(RcloneProgress)
+}
+
+func (b *RcloneBackend) call(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rcloneTimeout)
+	defer cancel()
+	return b.rclone.run(ctx, nil, args...)
+}
+
+func (b *RcloneBackend) Stat(p string) (fs.FileInfo, error) {
+	out, err := b.call("lsjson", "--stat", "--no-mimetype", p)
+	if err != nil {
+		return nil, err
+	}
+	var entry RcloneEntry
+	if err := json.Unmarshal(out, &entry); err != nil {
+		return nil, fmt.Errorf("rclone lsjson %s: %w", p, err)
+	}
+	return remoteInfo{name: path.Base(p), size: entry.Size, modTime: entry.ModTime, dir: entry.IsDir}, nil
+}
+
+func (b *RcloneBackend) List(dir string) ([]string, error) {
+	out, err := b.call("lsjson", "--no-mimetype", "--no-modtime", dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []RcloneEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("rclone lsjson %s: %w", dir, err)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return names, nil
+}
+
+func (b *RcloneBackend) Mkdir(p string) error {
+	_, err := b.call("mkdir", p)
+	return err
+}
+
+// exists fails with fs.ErrExist when dest is taken, as rclone itself would
+// silently replace it.
+func (b *RcloneBackend) exists(op, dest string) error {
+	_, err := b.Stat(dest)
+	switch {
+	case err == nil:
+		return &fs.PathError{Op: op, Path: dest, Err: fs.ErrExist}
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	default:
+		return err
+	}
+}
+
+func (b *RcloneBackend) Copy(src io.Reader, dest string) error {
+	if err := b.exists("copy", dest); err != nil {
+		return err
+	}
+	_, err := b.rclone.run(context.Background(), src, "rcat", dest)
+	return err
+}
+
+// Send copies the local file src to dest with rclone copyto, which checks
+// the transfer and reports its progress.
+func (b *RcloneBackend) Send(src, dest string) error {
+	if err := b.exists("copy", dest); err != nil {
+		return err
+	}
+	return b.rclone.transfer(context.Background(), "copyto", src, dest, b.onProgress)
+}
+
+func (b *RcloneBackend) Rename(src, dest string) error {
+	if err := b.exists("rename", dest); err != nil {
+		return err
+	}
+	_, err := b.call("moveto", src, dest)
+	return err
+}
+
+func (b *RcloneBackend) Open(p string) (io.ReadCloser, error) {
+	if _, err := b.Stat(p); err != nil {
+		return nil, err
+	}
+	args := []string{"cat", p}
+	cmd := exec.Command(b.rclone.path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("rclone stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start rclone: %w", err)
+	}
+	return &rcloneReader{cmd: cmd, args: args, stdout: stdout, stderr: &stderr}, nil
+}
+
+func (b *RcloneBackend) Remove(p string) error {
+	_, err := b.call("deletefile", p)
+	return err
+}
+
+// HashMD5 asks the remote for the file's MD5, downloading it only when the
+// remote does not store one.
+func (b *RcloneBackend) HashMD5(p string) (string, error) {
+	for _, args := range [][]string{{"md5sum", p}, {"md5sum", "--download", p}} {
+		ctx, cancel := context.WithTimeout(context.Background(), rcloneTimeout)
+		out, err := b.rclone.run(ctx, nil, args...)
+		cancel()
+		if err != nil {
+			return "", err
+		}
+		if hash, _, _ := strings.Cut(strings.TrimSpace(string(out)), " "); len(hash) == 32 {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("rclone could not hash %s", p)
+}
+
+func (b *RcloneBackend) Join(dir string, elem ...string) string {
+	return joinSlash(b.root, dir, elem...)
+}
+
+func (b *RcloneBackend) Dir(p string) string {
+	return dirSlash(b.root, p)
+}
+
+// rcloneReader streams `rclone cat`, reporting a failed command instead of
+// a clean end of file.
+type rcloneReader struct {
+	cmd    *exec.Cmd
+	args   []string
+	stdout io.ReadCloser
+	stderr *bytes.Buffer
+	done   bool
+	err    error
+}
+
+func (r *rcloneReader) Read(p []byte) (int, error) {
+	if r.done {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+	n, err := r.stdout.Read(p)
+	if err == io.EOF {
+		r.done = true
+		if waitErr := r.cmd.Wait(); waitErr != nil {
+			r.err = commandError(r.args, waitErr, r.stderr.String())
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+func (r *rcloneReader) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+	r.stdout.Close()
+	_ = r.cmd.Process.Kill()
+	_ = r.cmd.Wait()
+	return nil
+}
@@ -0,0 +1,380 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// DuplicateResolution selects which copy of a duplicate group survives.
+type DuplicateResolution struct {
+	Hash   string `json:"hash"`
+	KeepID int64  `json:"keepId"`
+}
+
+// RemovedFile describes a file that was, or in dry-run would be, removed.
+type RemovedFile struct {
+	MediaID   int64  `json:"mediaId,omitempty"`
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RemovalSummary reports the outcome of a destructive operation.
+type RemovalSummary struct {
+	DryRun         bool          `json:"dryRun"`
+	Files          []RemovedFile `json:"files"`
+	Removed        int           `json:"removed"`
+	Failed         int           `json:"failed"`
+	BytesReclaimed int64         `json:"bytesReclaimed"`
+	DurationMS     int64         `json:"durationMs"`
+}
+
+// Remover deletes media files and empty folders while recording to SQLite.
+type Remover struct {
+	store *storage.Store
+}
+
+// NewRemover constructs a Remover.
+func NewRemover(store *storage.Store) *Remover {
+	return &Remover{store: store}
+}
+
+// DeleteMedia removes the given media files from disk and from the library.
+// Files tidied onto a remote target are deleted through target, which may be
+// nil when the target is local.
+func (r *Remover) DeleteMedia(ctx context.Context, ids []int64, target Backend, dryRun bool) (RemovalSummary, error) {
+	summary := RemovalSummary{DryRun: dryRun, Files: []RemovedFile{}}
+	if len(ids) == 0 {
+		return summary, nil
+	}
+
+	mediaMap, err := r.store.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return summary, err
+	}
+
+	start := time.Now()
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		file, ok := mediaMap[id]
+		if !ok {
+			summary.Failed++
+			summary.Files = append(summary.Files, RemovedFile{MediaID: id, Error: "media metadata not found"})
+			continue
+		}
+		r.removeMedia(ctx, target, file, dryRun, &summary)
+	}
+
+	summary.DurationMS = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// ResolveDuplicates removes every copy but the keeper from the duplicate
+// groups named in resolutions. Removing needs at least one resolution; a dry
+// run without any previews every group with its suggested keeper. Remote
+// copies are deleted through target, as in DeleteMedia.
+func (r *Remover) ResolveDuplicates(ctx context.Context, resolutions []DuplicateResolution, target Backend, dryRun bool) (RemovalSummary, error) {
+	summary := RemovalSummary{DryRun: dryRun, Files: []RemovedFile{}}
+	if len(resolutions) == 0 && !dryRun {
+		return summary, errors.New("no duplicate groups selected")
+	}
+
+	groups, err := r.store.ListDuplicateGroups(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	keepers := make(map[string]int64, len(resolutions))
+	for _, res := range resolutions {
+		keepers[res.Hash] = res.KeepID
+	}
+
+	start := time.Now()
+	for _, group := range groups {
+		keepID, explicit := keepers[group.Hash]
+		if len(resolutions) > 0 && !explicit {
+			continue
+		}
+		if !explicit {
+			keepID = group.SuggestedKeeperID
+		}
+		delete(keepers, group.Hash)
+
+		if !groupContains(group, keepID) {
+			summary.Failed++
+			summary.Files = append(summary.Files, RemovedFile{
+				MediaID: keepID,
+				Error:   fmt.Sprintf("keeper %d is not part of group %s", keepID, group.Hash),
+			})
+			continue
+		}
+
+		for _, file := range group.Files {
+			if err := ctx.Err(); err != nil {
+				return summary, err
+			}
+			// Acknowledged copies are intentional and always stay, and
+			// hardlinks of the keeper share its storage so removing them
+			// reclaims nothing.
+			if file.ID == keepID || slices.Contains(group.Acknowledged, file.ID) || linkedTo(group, keepID, file.ID) {
+				continue
+			}
+			r.removeMedia(ctx, target, file, dryRun, &summary)
+		}
+	}
+
+	// Groups named but not listed have lost their copies since the caller
+	// saw them.
+	for _, res := range resolutions {
+		if _, left := keepers[res.Hash]; !left {
+			continue
+		}
+		delete(keepers, res.Hash)
+		summary.Failed++
+		summary.Files = append(summary.Files, RemovedFile{
+			MediaID: res.KeepID,
+			Error:   fmt.Sprintf("group %s is no longer a duplicate group", res.Hash),
+		})
+	}
+
+	summary.DurationMS = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// CleanEmptyDirs removes empty directories below the given roots. The roots
+// themselves are never removed.
+func (r *Remover) CleanEmptyDirs(ctx context.Context, roots []string, dryRun bool) (RemovalSummary, error) {
+	summary := RemovalSummary{DryRun: dryRun, Files: []RemovedFile{}}
+	start := time.Now()
+
+	for _, root := range roots {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			summary.Failed++
+			summary.Files = append(summary.Files, RemovedFile{Path: root, Error: err.Error()})
+			continue
+		}
+
+		var dirs []string
+		_ = filepath.WalkDir(absRoot, func(path string, d os.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return nil
+			}
+			if d.IsDir() && path != absRoot {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+
+		// Deepest first so parents emptied by the cleanup are picked up too.
+		sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+
+		removed := make(map[string]bool)
+		for _, dir := range dirs {
+			if !isEmptyDir(dir, removed) {
+				continue
+			}
+
+			entry := RemovedFile{Path: dir}
+			if !dryRun {
+				if err := os.Remove(dir); err != nil {
+					entry.Error = err.Error()
+					summary.Failed++
+					summary.Files = append(summary.Files, entry)
+					continue
+				}
+			}
+			removed[dir] = true
+			summary.Removed++
+			summary.Files = append(summary.Files, entry)
+		}
+	}
+
+	summary.DurationMS = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// CleanJunk removes the junk files scans listed. Each file is checked again
+// first, so one that was filled since, such as a copy that has finished, is
+// kept and dropped from the list.
+func (r *Remover) CleanJunk(ctx context.Context, dryRun bool) (RemovalSummary, error) {
+	summary := RemovalSummary{DryRun: dryRun, Files: []RemovedFile{}}
+	junk, err := r.store.ListJunk(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	start := time.Now()
+	for _, file := range junk {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		info, err := os.Lstat(longPath(file.Path))
+		if errors.Is(err, os.ErrNotExist) || (err == nil && (!info.Mode().IsRegular() || file.Reason == ZeroByteReason && info.Size() > 0)) {
+			if !dryRun {
+				_ = r.store.ForgetJunk(ctx, file.Path)
+			}
+			continue
+		}
+		entry := RemovedFile{Path: file.Path, SizeBytes: file.SizeBytes}
+		if err != nil {
+			entry.Error = err.Error()
+			summary.Failed++
+			summary.Files = append(summary.Files, entry)
+			continue
+		}
+		entry.SizeBytes = info.Size()
+		if dryRun {
+			summary.Removed++
+			summary.BytesReclaimed += entry.SizeBytes
+			summary.Files = append(summary.Files, entry)
+			continue
+		}
+
+		actionID, err := r.store.CreateAction(ctx, storage.FileAction{
+			SourcePath: file.Path,
+			ActionType: "delete",
+			Status:     storage.ActionStatusPending,
+		})
+		if err != nil {
+			entry.Error = fmt.Sprintf("record action: %v", err)
+			summary.Failed++
+			summary.Files = append(summary.Files, entry)
+			continue
+		}
+		if err := os.Remove(longPath(file.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errMsg := truncateError(err)
+			_ = r.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
+			entry.Error = errMsg
+			summary.Failed++
+			summary.Files = append(summary.Files, entry)
+			continue
+		}
+		_ = r.store.MarkAction(ctx, actionID, storage.ActionStatusCompleted, nil)
+		if err := r.store.ForgetJunk(ctx, file.Path); err != nil {
+			entry.Error = err.Error()
+		}
+		summary.Removed++
+		summary.BytesReclaimed += entry.SizeBytes
+		summary.Files = append(summary.Files, entry)
+	}
+
+	summary.DurationMS = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+func (r *Remover) removeMedia(ctx context.Context, target Backend, file storage.MediaFile, dryRun bool, summary *RemovalSummary) {
+	entry := RemovedFile{MediaID: file.ID, Path: file.Path, SizeBytes: file.SizeBytes}
+
+	// Entries live inside their archive, which os.Remove cannot reach; the
+	// row would go while the entry stays.
+	if IsArchivePath(file.Path) {
+		entry.Error = "archive entries cannot be removed on their own"
+		summary.Failed++
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+	// os.Remove of a URL or "remote:path" reports it missing, which would
+	// count as removed while the remote file stays.
+	b := backendFor(target, file.Path)
+	if IsRemote(file.Path) && isLocal(b) {
+		entry.Error = fmt.Sprintf("%s is not on the configured target", file.Path)
+		summary.Failed++
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+
+	if dryRun {
+		summary.Removed++
+		summary.BytesReclaimed += file.SizeBytes
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+
+	actionID, err := r.store.CreateAction(ctx, storage.FileAction{
+		MediaID:    sql.NullInt64{Int64: file.ID, Valid: true},
+		SourcePath: file.Path,
+		ActionType: "delete",
+		Status:     storage.ActionStatusPending,
+		HashMD5:    sql.NullString{String: file.HashMD5, Valid: file.HashMD5 != ""},
+	})
+	if err != nil {
+		entry.Error = fmt.Sprintf("record action: %v", err)
+		summary.Failed++
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+
+	if err := b.Remove(file.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		errMsg := truncateError(err)
+		_ = r.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
+		entry.Error = errMsg
+		summary.Failed++
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+
+	if err := r.store.DeleteMediaFile(ctx, file.ID); err != nil {
+		errMsg := fmt.Sprintf("delete media row: %v", err)
+		_ = r.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
+		entry.Error = errMsg
+		summary.Failed++
+		summary.Files = append(summary.Files, entry)
+		return
+	}
+
+	_ = r.store.MarkAction(ctx, actionID, storage.ActionStatusCompleted, nil)
+	summary.Removed++
+	summary.BytesReclaimed += file.SizeBytes
+	summary.Files = append(summary.Files, entry)
+}
+
+func groupContains(group storage.DuplicateGroup, id int64) bool {
+	for _, file := range group.Files {
+		if file.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// linkedTo reports whether id is a hardlink of keepID within group.
+func linkedTo(group storage.DuplicateGroup, keepID, id int64) bool {
+	for _, links := range group.Hardlinks {
+		if slices.Contains(links, keepID) && slices.Contains(links, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyDir reports whether dir has no entries other than already removed directories.
+func isEmptyDir(dir string, removed map[string]bool) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !removed[filepath.Join(dir, entry.Name())] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ErrScanPaused is the cancel cause that pauses a scan rather than
+// cancelling it: the scan saves where it got to in each source, and a scan
+// with Options.Resume set continues from there.
+var ErrScanPaused = errors.New("scan paused")
+
+// resumeOptions are the options of a scan kept with its session, so a
+// resumed scan treats the remaining files like the first part.
+type resumeOptions struct {
+	Incremental bool         `json:"incremental,omitempty"`
+	Known       ImportPolicy `json:"known,omitempty"`
+	Takeout     TakeoutMode  `json:"takeout,omitempty"`
+}
+
+// resume loads the session opts.Resume names into opts and summary and
+// returns its checkpoints by source.
+func (s *Scanner) resume(ctx context.Context, opts *Options, summary *Summary) (map[string]*storage.ScanCheckpoint, error) {
+	session, err := s.store.GetScanSession(ctx, opts.Resume)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != storage.ScanPaused && session.Status != storage.ScanRunning {
+		return nil, fmt.Errorf("scan %d is %s and cannot be resumed", session.ID, session.Status)
+	}
+	var saved resumeOptions
+	if len(session.Options) > 0 {
+		if err := json.Unmarshal(session.Options, &saved); err != nil {
+			return nil, fmt.Errorf("decode scan options: %w", err)
+		}
+	}
+	opts.Sources = session.Sources
+	opts.Incremental, opts.Known, opts.Takeout = saved.Incremental, saved.Known, saved.Takeout
+	if len(session.Summary) > 0 {
+		if err := json.Unmarshal(session.Summary, summary); err != nil {
+			return nil, fmt.Errorf("decode scan summary: %w", err)
+		}
+	}
+	summary.SessionID = session.ID
+	summary.Cancelled, summary.Paused = false, false
+
+	list, err := s.store.ListScanCheckpoints(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make(map[string]*storage.ScanCheckpoint, len(list))
+	for i := range list {
+		checkpoints[list[i].Source] = &list[i]
+	}
+	return checkpoints, nil
+}
+
+// startedBefore shifts start back by the time earlier runs of a resumed scan
+// took, so the duration covers the whole scan.
+func startedBefore(start time.Time, summary Summary) time.Time {
+	return start.Add(-time.Duration(summary.DurationMS) * time.Millisecond)
+}
+
+// walkedBy reports whether a walk of root visits path no later than mark.
+// WalkDir visits entries in lexical order per folder, so paths compare by
+// their elements rather than as strings.
+func walkedBy(root, path, mark string) bool {
+	p, err1 := filepath.Rel(root, path)
+	m, err2 := filepath.Rel(root, mark)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	pe := strings.Split(p, string(filepath.Separator))
+	me := strings.Split(m, string(filepath.Separator))
+	for i := 0; i < len(pe) && i < len(me); i++ {
+		if pe[i] != me[i] {
+			return pe[i] < me[i]
+		}
+	}
+	return len(pe) <= len(me)
+}
+
+// leadsTo reports whether mark lies below the folder dir.
+func leadsTo(dir, mark string) bool {
+	return strings.HasPrefix(mark, dir+string(filepath.Separator))
+}
@@ -0,0 +1,97 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// RollbackSummary reports the outcome of undoing a tidy run.
+type RollbackSummary struct {
+	RunID    int64    `json:"runId"`
+	Restored int      `json:"restored"`
+	Failed   int      `json:"failed"`
+	Errors   []string `json:"errors"`
+}
+
+// Rollback moves every file of a tidy run back to the path it had before the
+// run and restores the stored paths. It works from the persisted snapshot,
+// so runs can be undone after a restart. target serves files the run moved
+// onto a remote backend; nil means the run only touched local files.
+func (t *TidyExecutor) Rollback(ctx context.Context, runID int64, target Backend) (RollbackSummary, error) {
+	summary := RollbackSummary{RunID: runID, Errors: []string{}}
+
+	status, err := t.store.GetTidyRunStatus(ctx, runID)
+	if err != nil {
+		return summary, err
+	}
+	if status == storage.RunStatusRolledBack {
+		return summary, fmt.Errorf("tidy run %d was already rolled back", runID)
+	}
+
+	moves, err := t.store.ListRunMoves(ctx, runID)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, move := range moves {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		if err := restoreMove(target, move); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("restore %s: %v", move.PriorPath, err))
+			continue
+		}
+		if err := relocate(ctx, t.store, move.MediaID, move.PriorPath); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, err.Error())
+			continue
+		}
+		_ = t.store.MarkAction(ctx, move.ActionID, storage.ActionStatusRolledBack, nil)
+		summary.Restored++
+	}
+
+	if summary.Failed == 0 {
+		if err := t.store.SetTidyRunStatus(ctx, runID, storage.RunStatusRolledBack); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func restoreMove(target Backend, move storage.RunMove) error {
+	if move.CurrentPath == move.PriorPath {
+		return nil
+	}
+	current := backendFor(target, move.CurrentPath)
+	if IsRemote(move.CurrentPath) && isLocal(current) {
+		return fmt.Errorf("%s is not on the configured target", move.CurrentPath)
+	}
+	// Extracted entries are still in their archive; drop the copy.
+	if IsArchivePath(move.PriorPath) {
+		if err := current.Remove(move.CurrentPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if _, err := os.Stat(move.PriorPath); err == nil {
+		return errors.New("original location is occupied")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(move.PriorPath), 0o755); err != nil {
+		return err
+	}
+	if !isLocal(current) {
+		return withNetRetry(func() error {
+			return fetchFile(current, move.CurrentPath, move.PriorPath, nil)
+		})
+	}
+	return moveWithRetry(current, move.CurrentPath, move.PriorPath, SafetyStandard, "", nil)
+}
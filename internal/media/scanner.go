@@ -1,24 +1,31 @@
 package media
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/md5"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rwcarlsen/goexif/exif"
 	"github.com/rwcarlsen/goexif/tiff"
 
-	"photoTidyGo/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
 )
 
 // Scanner coordinates media discovery and persistence.
@@ -31,23 +38,189 @@ type Options struct {
 	Sources        []string
 	Extensions     []string
 	FollowSymlinks bool
+	// Parallel is how many sources are walked at once; 0 or 1 walks them
+	// one after the other. Sources on different disks gain the most.
+	Parallel int
+	// SkipFolders are name patterns, in filepath.Match syntax and ignoring
+	// case, of folders not to descend into, such as "$RECYCLE.BIN". The
+	// sources themselves are always walked.
+	SkipFolders []string
+	// Stats, when set, receives per-file throughput updates.
+	Stats *JobStats
+	// Gate, when set, can pause the scan between files.
+	Gate *PauseGate
+	// Throttle paces hashing reads; nil disables throttling.
+	Throttle *Throttle
+	// OnOffline is called when the scan pauses because a source became
+	// unreachable; Gate must be set for the scan to wait.
+	OnOffline OfflineFunc
+	// Incremental skips files whose size and modification time match the library.
+	Incremental bool
+	// Known decides what happens to files whose hash is already in the library.
+	Known ImportPolicy
+	// PreCount enumerates the sources once before scanning so progress
+	// events carry a total and an ETA.
+	PreCount bool
+	// Archives scans inside ZIP and TAR files, recording entries under
+	// virtual paths such as "backup.zip!/DCIM/IMG_001.jpg".
+	Archives bool
+	// Takeout merges Google Takeout JSON sidecars into the scanned rows.
+	Takeout TakeoutMode
+	// TimeZone is the zone camera clocks are assumed to be set to when the
+	// file does not say; nil means the zone of this machine. CameraZones
+	// overrides it per camera, keyed by CameraKey.
+	TimeZone    *time.Location
+	CameraZones map[string]*time.Location
+	// Exiftool, when set, reads the files in which the built-in decoders
+	// find no metadata, such as HEIC, RAW and video files.
+	Exiftool *Exiftool
+	// FFprobe, when set, reads duration, codecs, rotation and recording
+	// time of videos.
+	FFprobe *FFprobe
+	// Junk, when set, recognises temporary, partial and empty files, which
+	// are listed for cleanup instead of being recorded as media.
+	Junk *JunkMatcher
+	// Resume continues the paused or interrupted scan session with this ID
+	// from where it stopped, with its sources and import options; Sources,
+	// Incremental, Known and Takeout are ignored.
+	Resume int64
+	// JobID ties the scan session recorded in the store to the job's events.
+	JobID string
+	// MemoryBudgetMB, when set, is the memory the scan tries to stay under
+	// by collecting garbage more eagerly. When the heap grows past it
+	// anyway, a heap profile is written to ProfileDir, if set, once per scan.
+	MemoryBudgetMB int
+	ProfileDir     string
+	// Validate decodes JPEG, PNG and GIF images to this level and flags
+	// those that fail as corrupt; empty skips the check. Unchanged files
+	// an incremental scan skips are not checked again.
+	Validate ValidationLevel
+	// PerSource overrides the filters for some sources, keyed by their
+	// entry in Sources.
+	PerSource map[string]SourceOptions
 }
 
-// Progress is emitted for UI updates.
+// SourceOptions overrides scan options for one source.
+type SourceOptions struct {
+	// Extensions, when set, replaces Options.Extensions.
+	Extensions []string
+	// Exclude holds patterns of entries left out of the source. A pattern
+	// matches an entry's name or its slash-separated path below the source,
+	// and an excluded folder is not entered.
+	Exclude []string
+	// FollowSymlinks, when set, replaces Options.FollowSymlinks.
+	FollowSymlinks *bool
+}
+
+// ImportPolicy controls how a scan treats files already in the library.
+type ImportPolicy string
+
+// Supported import policies. The zero value records every file.
+const (
+	ImportCopyAnyway ImportPolicy = "copy"
+	ImportSkipKnown  ImportPolicy = "skip"
+)
+
+// ParseImportPolicy validates a policy name; empty means copy anyway.
+func ParseImportPolicy(name string) (ImportPolicy, error) {
+	switch ImportPolicy(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ImportCopyAnyway:
+		return ImportCopyAnyway, nil
+	case ImportSkipKnown:
+		return ImportSkipKnown, nil
+	default:
+		return "", fmt.Errorf("unknown import policy %q", name)
+	}
+}
+
+// KnownFile pairs a scanned file with the library copy sharing its hash.
+type KnownFile struct {
+	Path        string `json:"path"`
+	LibraryID   int64  `json:"libraryId"`
+	LibraryPath string `json:"libraryPath"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	Skipped     bool   `json:"skipped"`
+}
+
+// Progress is emitted for UI updates, at most ten times per second.
 type Progress struct {
-	Path           string `json:"path"`
-	FilesProcessed int    `json:"filesProcessed"`
-	FilesPersisted int    `json:"filesPersisted"`
+	Path string `json:"path"`
+	// Source is the source Path was found in.
+	Source         string  `json:"source,omitempty"`
+	FilesProcessed int     `json:"filesProcessed"`
+	FilesPersisted int     `json:"filesPersisted"`
+	BytesProcessed int64   `json:"bytesProcessed"`
+	BytesPerSec    float64 `json:"bytesPerSec"`
+	// TotalEstimated and ETASeconds are only set when PreCount is enabled.
+	TotalEstimated int     `json:"totalEstimated,omitempty"`
+	ETASeconds     float64 `json:"etaSeconds,omitempty"`
 }
 
+// Caps on the messages and known files a summary holds, so scans of
+// millions of files stay within bounds; the counters keep counting past them.
+const (
+	maxErrorSample = 100
+	maxKnownSample = 1000
+	// maxUnsavedErrors is how many errors are queued before they are
+	// written to the session's error log ahead of the next checkpoint.
+	maxUnsavedErrors = 500
+)
+
+// sessionInterval is how often a running scan saves its summary.
+const sessionInterval = 5 * time.Second
+
 // Summary captures the outcome of a scan.
 type Summary struct {
-	FilesDiscovered int      `json:"filesDiscovered"`
-	FilesPersisted  int      `json:"filesPersisted"`
-	FilesSkipped    int      `json:"filesSkipped"`
+	FilesDiscovered int `json:"filesDiscovered"`
+	FilesPersisted  int `json:"filesPersisted"`
+	FilesSkipped    int `json:"filesSkipped"`
+	FilesUnchanged  int `json:"filesUnchanged"`
+	// FilesCorrupt counts images that failed validation and FilesJunk the
+	// leftovers listed for cleanup.
+	FilesCorrupt int `json:"filesCorrupt"`
+	FilesJunk    int `json:"filesJunk"`
+	// FilesKnown counts files whose content was already in the library;
+	// Known lists the first of them.
+	FilesKnown int         `json:"filesKnown"`
+	Known      []KnownFile `json:"known,omitempty"`
+	// SymlinkLoops lists the first followed links that lead back into a
+	// folder above them, which were not entered again.
+	SymlinkLoops []string `json:"symlinkLoops,omitempty"`
+	// ErrorCount counts every error and Errors holds the first messages.
+	ErrorCount      int      `json:"errorCount"`
 	Errors          []string `json:"errors"`
 	DurationMS      int64    `json:"durationMs"`
 	DuplicateGroups int      `json:"duplicateGroups"`
+	Cancelled       bool     `json:"cancelled,omitempty"`
+	// Paused reports a scan stopped with ErrScanPaused, which can resume.
+	Paused bool `json:"paused,omitempty"`
+	// PeakMemoryMB is the most memory the process held from the OS during
+	// the scan and HeapProfile the profile written when it overran the
+	// budget.
+	PeakMemoryMB float64 `json:"peakMemoryMb"`
+	HeapProfile  string  `json:"heapProfile,omitempty"`
+	// SessionID is the scan's row in ListScanSessions.
+	SessionID int64 `json:"sessionId"`
+	// Sources breaks the counts down by source.
+	Sources []SourceSummary `json:"sources,omitempty"`
+
+	// unsaved holds the errors not yet written to the session's log.
+	unsaved []storage.ScanError
+}
+
+// addError counts an error of stage with path, which is empty for errors
+// not about a file, and queues it for the session's error log. The message
+// is kept in Errors while the sample has room.
+func (s *Summary) addError(stage, path string, err error) {
+	msg := stage + ": " + err.Error()
+	if path != "" {
+		msg = stage + " " + path + ": " + err.Error()
+	}
+	s.ErrorCount++
+	if len(s.Errors) < maxErrorSample {
+		s.Errors = append(s.Errors, msg)
+	}
+	s.unsaved = append(s.unsaved, storage.ScanError{Stage: stage, Path: path, Message: err.Error()})
 }
 
 // NewScanner constructs a Scanner.
@@ -55,143 +228,314 @@ func NewScanner(store *storage.Store) *Scanner {
 	return &Scanner{store: store}
 }
 
-// Scan walks the configured folders, storing metadata into SQLite.
+// Scan walks the configured folders, storing metadata into SQLite. Up to
+// opts.Parallel sources are walked at once.
 func (s *Scanner) Scan(ctx context.Context, opts Options, onProgress func(Progress)) (Summary, error) {
-	start := time.Now()
-	summary := Summary{}
+	r := &scanRun{
+		s:          s,
+		opts:       opts,
+		extSet:     extensionSet(opts.Extensions),
+		onProgress: onProgress,
+		start:      time.Now(),
+		previous:   make(map[string]SourceSummary),
+		lastSave:   time.Now(),
+	}
 
-	extSet := make(map[string]struct{})
-	for _, ext := range opts.Extensions {
-		ext = strings.TrimSpace(strings.ToLower(ext))
-		if ext == "" {
-			continue
+	r.checkpoints = make(map[string]*storage.ScanCheckpoint)
+	if opts.Resume != 0 {
+		var err error
+		if r.checkpoints, err = s.resume(ctx, &r.opts, &r.summary); err != nil {
+			return r.summary, err
 		}
-		if !strings.HasPrefix(ext, ".") {
-			ext = "." + ext
+		r.start = startedBefore(r.start, r.summary)
+		for _, src := range r.summary.Sources {
+			r.previous[src.Source] = src
+		}
+		if len(r.summary.Sources) == 0 {
+			// Sessions saved before the per-source breakdown carry only
+			// the totals.
+			r.carried = SourceSummary{
+				FilesDiscovered: r.summary.FilesDiscovered,
+				FilesPersisted:  r.summary.FilesPersisted,
+				FilesSkipped:    r.summary.FilesSkipped,
+				FilesUnchanged:  r.summary.FilesUnchanged,
+				FilesCorrupt:    r.summary.FilesCorrupt,
+				FilesJunk:       r.summary.FilesJunk,
+			}
 		}
-		extSet[ext] = struct{}{}
+	} else {
+		options, err := json.Marshal(resumeOptions{Incremental: opts.Incremental, Known: opts.Known, Takeout: opts.Takeout})
+		if err != nil {
+			return r.summary, err
+		}
+		if r.summary.SessionID, err = s.store.StartScanSession(ctx, opts.JobID, opts.Sources, options); err != nil {
+			return r.summary, err
+		}
+	}
+	opts = r.opts
+	if opts.MemoryBudgetMB > 0 {
+		prev := debug.SetMemoryLimit(int64(opts.MemoryBudgetMB) << 20)
+		defer debug.SetMemoryLimit(prev)
 	}
 
-	fileCounter := 0
-	persistCounter := 0
+	if opts.PreCount {
+		r.estimated = countFiles(ctx, opts, r.extSet)
+		opts.Stats.SetTotal(r.estimated)
+	}
+	r.meter = newProgressMeter(r.estimated)
+	r.sources = make([]*SourceSummary, len(opts.Sources))
+
+	// Sources start in order, so a scan without parallelism walks them
+	// one after the other as listed.
+	slots := make(chan struct{}, max(opts.Parallel, 1))
+	errs := make([]error, len(opts.Sources))
+	var wg sync.WaitGroup
+	for i, src := range opts.Sources {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = r.scanSource(ctx, i, src)
+		}()
+	}
+	wg.Wait()
 
-	for _, src := range opts.Sources {
-		if ctxErr := ctx.Err(); ctxErr != nil {
-			return summary, ctxErr
+	var cancelErr error
+	if err := ctx.Err(); err != nil {
+		cancelErr = err
+	}
+	for _, err := range errs {
+		if err != nil && cancelErr == nil {
+			cancelErr = err
 		}
+	}
 
-		absSrc, err := filepath.Abs(src)
-		if err != nil {
-			summary.Errors = append(summary.Errors, fmt.Sprintf("resolve path %s: %v", src, err))
-			continue
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Flush the last throttled update so the UI ends on the final counts.
+	if r.pending {
+		onProgress(r.latest)
+	}
+
+	// A cancelled scan still reports what it persisted so far.
+	if cancelErr != nil {
+		if errors.Is(context.Cause(ctx), ErrScanPaused) {
+			r.summary.Paused = true
+			r.checkpoint(ctx, storage.ScanPaused)
+		} else {
+			r.summary.Cancelled = true
+			r.checkpoint(ctx, storage.ScanCancelled)
 		}
+		return r.summary, cancelErr
+	}
 
-		stat, err := os.Stat(absSrc)
-		if err != nil {
-			summary.Errors = append(summary.Errors, fmt.Sprintf("stat %s: %v", absSrc, err))
+	duplicates, err := s.store.DuplicateSummary(ctx, storage.DuplicateScope{})
+	if err != nil {
+		r.summary.addError("duplicate query", "", err)
+	} else {
+		r.summary.DuplicateGroups = duplicates.Groups
+	}
+
+	r.checkpoint(ctx, storage.ScanFinished)
+	return r.summary, nil
+}
+
+// extensionSet normalises extensions to a lower-case set with leading dots.
+func extensionSet(exts []string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, ext := range exts {
+		ext = strings.TrimSpace(strings.ToLower(ext))
+		if ext == "" {
 			continue
 		}
-		if !stat.IsDir() {
-			summary.Errors = append(summary.Errors, fmt.Sprintf("%s is not a directory", absSrc))
-			continue
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
 		}
+		set[ext] = struct{}{}
+	}
+	return set
+}
 
-		walkErr := filepath.WalkDir(absSrc, func(path string, d os.DirEntry, walkErr error) error {
-			if walkErr != nil {
-				summary.Errors = append(summary.Errors, fmt.Sprintf("walk %s: %v", path, walkErr))
-				return nil
-			}
-
-			if !opts.FollowSymlinks && d.Type()&os.ModeSymlink != 0 {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				summary.FilesSkipped++
-				return nil
-			}
-
-			if d.IsDir() {
-				return nil
-			}
+// wanted reports whether a non-directory entry should be scanned.
+func wanted(d os.DirEntry, extSet map[string]struct{}, followSymlinks bool) bool {
+	if !followSymlinks && d.Type()&os.ModeSymlink != 0 {
+		return false
+	}
+	if len(extSet) == 0 {
+		return true
+	}
+	_, ok := extSet[strings.ToLower(filepath.Ext(d.Name()))]
+	return ok
+}
 
-			ext := strings.ToLower(filepath.Ext(d.Name()))
-			if len(extSet) > 0 {
-				if _, ok := extSet[ext]; !ok {
-					summary.FilesSkipped++
-					return nil
-				}
-			}
+// skipFolder reports whether a folder name matches one of patterns.
+func skipFolder(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
 
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
+// countFiles is the pre-count pass: it only reads directory entries, never
+// file contents, so it stays fast even on large trees.
+func countFiles(ctx context.Context, opts Options, extSet map[string]struct{}) int {
+	count := 0
+	for _, src := range opts.Sources {
+		rules := rulesFor(opts, extSet, src)
+		_ = filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
 			}
-
-			fileCounter++
-
-			file, err := s.buildMediaFile(path)
-			if err != nil {
-				summary.Errors = append(summary.Errors, fmt.Sprintf("metadata %s: %v", path, err))
+			if err != nil || path == src {
 				return nil
 			}
-
-			if err := s.store.UpsertMediaFile(ctx, file); err != nil {
-				summary.Errors = append(summary.Errors, fmt.Sprintf("persist %s: %v", path, err))
+			if d.IsDir() {
+				if rules.skipDir(src, path, d) {
+					return filepath.SkipDir
+				}
 				return nil
 			}
-
-			persistCounter++
-			if onProgress != nil {
-				onProgress(Progress{
-					Path:           file.Path,
-					FilesProcessed: fileCounter,
-					FilesPersisted: persistCounter,
-				})
+			if !rules.excluded(src, path) && wanted(d, rules.extSet, rules.follow) {
+				count++
 			}
-
 			return nil
 		})
+	}
+	return count
+}
 
-		if walkErr != nil {
-			if errors.Is(walkErr, context.Canceled) {
-				return summary, walkErr
-			}
-			summary.Errors = append(summary.Errors, fmt.Sprintf("walk %s: %v", absSrc, walkErr))
-		}
+// sourceRules are the filters the walk of one source applies.
+type sourceRules struct {
+	extSet      map[string]struct{}
+	follow      bool
+	skipFolders []string
+	exclude     []string
+}
+
+// rulesFor applies the overrides opts holds for src to the scan's filters.
+func rulesFor(opts Options, extSet map[string]struct{}, src string) sourceRules {
+	rules := sourceRules{extSet: extSet, follow: opts.FollowSymlinks, skipFolders: opts.SkipFolders}
+	override, ok := opts.PerSource[src]
+	if !ok {
+		return rules
+	}
+	if len(override.Extensions) > 0 {
+		rules.extSet = extensionSet(override.Extensions)
+	}
+	if override.FollowSymlinks != nil {
+		rules.follow = *override.FollowSymlinks
 	}
+	rules.exclude = override.Exclude
+	return rules
+}
 
-	summary.FilesDiscovered = fileCounter
-	summary.FilesPersisted = persistCounter
-	summary.DurationMS = time.Since(start).Milliseconds()
+// skipDir reports whether the walk of root leaves out the folder entry,
+// which is below root.
+func (rules sourceRules) skipDir(root, entry string, d os.DirEntry) bool {
+	if !rules.follow && d.Type()&os.ModeSymlink != 0 {
+		return true
+	}
+	return skipFolder(d.Name(), rules.skipFolders) || rules.excluded(root, entry)
+}
 
-	groups, err := s.store.ListDuplicateGroups(ctx)
+// excluded reports whether entry matches an exclude pattern by its name or
+// by its slash-separated path below root. Like folder names, patterns match
+// regardless of case.
+func (rules sourceRules) excluded(root, entry string) bool {
+	if len(rules.exclude) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, entry)
 	if err != nil {
-		summary.Errors = append(summary.Errors, fmt.Sprintf("duplicate query: %v", err))
-	} else {
-		summary.DuplicateGroups = len(groups)
+		return false
+	}
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	name := strings.ToLower(filepath.Base(entry))
+	for _, pattern := range rules.exclude {
+		pattern = strings.ToLower(pattern)
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unchanged reports whether the library already holds path with the same size
+// and modification time, so hashing can be skipped.
+func (s *Scanner) unchanged(ctx context.Context, path string, d os.DirEntry) bool {
+	info, err := d.Info()
+	if err != nil {
+		return false
 	}
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	size, modTime, ok, err := s.store.GetMediaStamp(ctx, absolute)
+	if err != nil || !ok {
+		return false
+	}
+	return size == info.Size() && modTime.Equal(info.ModTime().UTC().Truncate(time.Second))
+}
 
-	return summary, nil
+// readFile builds the record for path, retrying transient network errors.
+// When the source root has gone away the scan pauses until it is reconnected
+// and then tries the file again.
+func (s *Scanner) readFile(ctx context.Context, path, root string, opts Options) (storage.MediaFile, map[string]string, error) {
+	for {
+		var (
+			file   storage.MediaFile
+			fields map[string]string
+		)
+		err := withNetRetry(func() error {
+			var err error
+			file, fields, err = s.buildMediaFile(path, opts.Throttle)
+			return err
+		})
+		if err == nil || !holdOffline(ctx, opts.Gate, LocalBackend{}, root, err, opts.OnOffline) {
+			return file, fields, err
+		}
+	}
 }
 
-func (s *Scanner) buildMediaFile(path string) (storage.MediaFile, error) {
+func (s *Scanner) buildMediaFile(path string, throttle *Throttle) (storage.MediaFile, map[string]string, error) {
 	absolute, err := filepath.Abs(path)
 	if err != nil {
-		return storage.MediaFile{}, err
+		return storage.MediaFile{}, nil, err
 	}
 	info, err := os.Stat(absolute)
 	if err != nil {
-		return storage.MediaFile{}, err
+		return storage.MediaFile{}, nil, err
 	}
 
-	hash, err := computeMD5(absolute)
+	hash, err := hashFile(absolute, throttle)
 	if err != nil {
-		return storage.MediaFile{}, err
+		return storage.MediaFile{}, nil, err
 	}
 
-	takenAt, makeVal, modelVal := extractEXIF(absolute)
+	meta := extractEXIF(absolute)
+	takenAt, makeVal, modelVal := meta.TakenAt, meta.Make, meta.Model
 	mimeType := detectMime(absolute)
+	hasCameraData := makeVal != "" || modelVal != "" || !takenAt.IsZero()
+
+	var width, height int
+	if strings.HasPrefix(mimeType, "image/") {
+		width, height = imageSize(absolute, meta.Fields)
+	}
 
 	file := storage.MediaFile{
 		Path:        absolute,
@@ -201,16 +545,31 @@ func (s *Scanner) buildMediaFile(path string) (storage.MediaFile, error) {
 		MimeType:    makeNullString(mimeType),
 		CameraMake:  makeNullString(makeVal),
 		CameraModel: makeNullString(modelVal),
+		Width:       width,
+		Height:      height,
+		Category:    classify(absolute, mimeType, hasCameraData, width, height),
+		Latitude:    meta.Latitude,
+		Longitude:   meta.Longitude,
+	}
+	if device, inode, ok := fileIdentity(absolute, info); ok {
+		file.Device = sql.NullInt64{Int64: int64(device), Valid: true}
+		file.Inode = sql.NullInt64{Int64: int64(inode), Valid: true}
 	}
 
 	if !takenAt.IsZero() {
-		file.TakenAt = sql.NullTime{Time: takenAt.UTC(), Valid: true}
+		file.TakenAt = sql.NullTime{Time: takenAt, Valid: true}
+		placeInZone(&file, meta.Zone)
 	}
 
-	return file, nil
+	return file, meta.Fields, nil
 }
 
 func computeMD5(path string) (string, error) {
+	return hashFile(path, nil)
+}
+
+// hashFile computes the MD5 of path, pacing reads through throttle.
+func hashFile(path string, throttle *Throttle) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return "", err
@@ -218,7 +577,7 @@ func computeMD5(path string) (string, error) {
 	defer f.Close()
 
 	hasher := md5.New()
-	if _, err := io.Copy(hasher, f); err != nil {
+	if _, err := io.Copy(hasher, throttle.Reader(f)); err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(hasher.Sum(nil)), nil
@@ -245,27 +604,185 @@ func detectMime(path string) string {
 	return http.DetectContentType(buf[:n])
 }
 
-func extractEXIF(path string) (time.Time, string, string) {
+// exifData holds the decoded EXIF block of a file.
+type exifData struct {
+	// TakenAt is the camera's clock reading; Zone is set when the maker
+	// notes say which zone that clock was in.
+	TakenAt time.Time
+	Zone    *time.Location
+	Make    string
+	Model   string
+	// Latitude and Longitude are valid when the file carries a GPS fix.
+	Latitude  sql.NullFloat64
+	Longitude sql.NullFloat64
+	Fields    map[string]string
+}
+
+func extractEXIF(path string) exifData {
 	f, err := os.Open(path)
 	if err != nil {
-		return time.Time{}, "", ""
+		return exifData{}
 	}
 	defer f.Close()
+	return decodeEXIF(f)
+}
 
-	x, err := exif.Decode(f)
+func decodeEXIF(r io.Reader) exifData {
+	x, err := exif.Decode(r)
 	if err != nil {
-		return time.Time{}, "", ""
+		return exifData{}
 	}
 
+	// DateTime falls back to the machine zone when the file names none, so
+	// keep only the clock reading and let the scan decide the zone.
+	var zone *time.Location
 	tm, err := x.DateTime()
 	if err != nil {
 		tm = time.Time{}
+	} else {
+		zone, _ = x.TimeZone()
+		tm = wallClock(tm)
+	}
+
+	var lat, lon sql.NullFloat64
+	if la, lo, err := x.LatLong(); err == nil && !(la == 0 && lo == 0) {
+		lat = sql.NullFloat64{Float64: la, Valid: true}
+		lon = sql.NullFloat64{Float64: lo, Valid: true}
 	}
 
 	makeField, _ := x.Get(exif.Make)
 	modelField, _ := x.Get(exif.Model)
 
-	return tm, stringifyExif(makeField), stringifyExif(modelField)
+	fields := make(map[string]string)
+	_ = x.Walk(exifWalker(func(name exif.FieldName, tag *tiff.Tag) error {
+		if value := exifValue(tag); value != "" {
+			fields[string(name)] = value
+		}
+		return nil
+	}))
+
+	return exifData{
+		TakenAt:   tm,
+		Zone:      zone,
+		Make:      stringifyExif(makeField),
+		Model:     stringifyExif(modelField),
+		Latitude:  lat,
+		Longitude: lon,
+		Fields:    fields,
+	}
+}
+
+type exifWalker func(exif.FieldName, *tiff.Tag) error
+
+func (w exifWalker) Walk(name exif.FieldName, tag *tiff.Tag) error {
+	return w(name, tag)
+}
+
+// exifValue renders a tag as plain text: strings unquoted, single rationals
+// as decimals and binary blobs (thumbnails, maker notes) dropped.
+func exifValue(tag *tiff.Tag) string {
+	if tag == nil {
+		return ""
+	}
+	switch tag.Format() {
+	case tiff.StringVal:
+		v, _ := tag.StringVal()
+		return strings.TrimSpace(strings.TrimRight(v, "\x00"))
+	case tiff.UndefVal, tiff.OtherVal:
+		if tag.Count > 64 {
+			return ""
+		}
+	case tiff.RatVal:
+		if tag.Count == 1 {
+			if n, d, err := tag.Rat2(0); err == nil && d != 0 {
+				return strconv.FormatFloat(float64(n)/float64(d), 'f', -1, 64)
+			}
+		}
+	}
+	if tag.Count > 64 {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(tag.String()), `"`)
+}
+
+// brokenEXIF reports whether the JPEG at path carries an EXIF segment that
+// cannot be decoded. JPEGs without EXIF, such as screenshots, exports and
+// images passed on by messengers, are fine.
+func brokenEXIF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	segment, err := jpegEXIFSegment(bufio.NewReader(f))
+	if err != nil {
+		return true
+	}
+	if segment == nil {
+		return false
+	}
+	// Errors in sub-IFDs, such as maker notes, leave the rest usable.
+	_, err = exif.Decode(bytes.NewReader(segment))
+	return err != nil && exif.IsCriticalError(err)
+}
+
+// jpegEXIFSegment returns the APP1 segment holding EXIF, starting at its
+// "Exif" header, from the markers ahead of the image data. It returns nil
+// when there is none or r is no JPEG, and an error only when the EXIF
+// segment itself is cut short.
+func jpegEXIFSegment(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil, nil
+	}
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != 0xFF {
+			return nil, nil
+		}
+		marker := byte(0xFF)
+		for marker == 0xFF {
+			if marker, err = r.ReadByte(); err != nil {
+				return nil, nil
+			}
+		}
+		switch {
+		case marker == 0xDA || marker == 0xD9:
+			// Start of scan or end of image: no EXIF ahead of the pixels.
+			return nil, nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, nil
+		}
+		n := int(size[0])<<8 | int(size[1]) - 2
+		if n < 0 {
+			return nil, nil
+		}
+		if marker != 0xE1 {
+			if _, err := r.Discard(n); err != nil {
+				return nil, nil
+			}
+			continue
+		}
+		header, err := r.Peek(6)
+		if err != nil || len(header) < 6 || !bytes.Equal(header, []byte("Exif\x00\x00")) {
+			// XMP and other APP1 payloads.
+			if _, err := r.Discard(n); err != nil {
+				return nil, nil
+			}
+			continue
+		}
+		segment := make([]byte, n)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, fmt.Errorf("read EXIF segment: %w", err)
+		}
+		return segment, nil
+	}
 }
 
 func stringifyExif(field *tiff.Tag) string {
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// Scan error report formats.
+const (
+	ReportText = "text"
+	ReportCSV  = "csv"
+)
+
+// WriteScanErrorReport exports the errors of a scan session to dest as plain
+// text or CSV; an empty format picks CSV for a .csv file and text otherwise.
+// It returns how many errors were written.
+func WriteScanErrorReport(ctx context.Context, store *storage.Store, sessionID int64, dest, format string) (int, error) {
+	if format == "" {
+		format = ReportText
+		if strings.EqualFold(filepath.Ext(dest), ".csv") {
+			format = ReportCSV
+		}
+	}
+	if format != ReportText && format != ReportCSV {
+		return 0, fmt.Errorf("unknown report format %q", format)
+	}
+	session, err := store.GetScanSession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	n := 0
+	if format == ReportCSV {
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"time", "stage", "path", "message"})
+		err = store.EachScanError(ctx, sessionID, func(e storage.ScanError) error {
+			n++
+			return cw.Write([]string{e.CreatedAt, e.Stage, e.Path, e.Message})
+		})
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	} else {
+		fmt.Fprintf(w, "Scan %d (%s), started %s, %s\n", session.ID, session.Status, session.StartedAt, strings.Join(session.Sources, ", "))
+		err = store.EachScanError(ctx, sessionID, func(e storage.ScanError) error {
+			n++
+			if e.Path == "" {
+				_, err := fmt.Fprintf(w, "%s  %s: %s\n", e.CreatedAt, e.Stage, e.Message)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "%s  %s %s: %s\n", e.CreatedAt, e.Stage, e.Path, e.Message)
+			return err
+		})
+		if err == nil {
+			fmt.Fprintf(w, "%d errors\n", n)
+		}
+	}
+	if err != nil {
+		return n, err
+	}
+	if err := w.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
@@ -0,0 +1,453 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/volume"
+)
+
+// SourceSummary reports one source of a scan.
+type SourceSummary struct {
+	Source          string `json:"source"`
+	FilesDiscovered int    `json:"filesDiscovered"`
+	FilesPersisted  int    `json:"filesPersisted"`
+	FilesSkipped    int    `json:"filesSkipped"`
+	FilesUnchanged  int    `json:"filesUnchanged"`
+	FilesCorrupt    int    `json:"filesCorrupt"`
+	FilesJunk       int    `json:"filesJunk"`
+	SymlinkLoops    int    `json:"symlinkLoops"`
+	ErrorCount      int    `json:"errorCount"`
+	DurationMS      int64  `json:"durationMs"`
+}
+
+// scanRun is the state of one Scan, shared by the walks of its sources,
+// which may run in parallel. mu guards the fields below it, including the
+// SourceSummary and ScanCheckpoint values they point to.
+type scanRun struct {
+	s          *Scanner
+	opts       Options
+	extSet     map[string]struct{}
+	onProgress func(Progress)
+	start      time.Time
+	estimated  int
+	// previous holds the source summaries of a resumed scan's earlier runs.
+	previous map[string]SourceSummary
+	carried  SourceSummary
+
+	mu          sync.Mutex
+	summary     Summary
+	sources     []*SourceSummary
+	checkpoints map[string]*storage.ScanCheckpoint
+	meter       *progressMeter
+	latest      Progress
+	pending     bool
+	lastSave    time.Time
+}
+
+// sourceWalk is the walk of one source.
+type sourceWalk struct {
+	root  string
+	rules sourceRules
+	sum   *SourceSummary
+	cp    *storage.ScanCheckpoint
+	// mark is where an earlier run of a resumed scan stopped; inFlight is
+	// the file being processed, which only counts as done once the walk
+	// moves on from it.
+	mark     string
+	inFlight string
+	// visited holds the real paths of the folders walked when links are
+	// followed.
+	visited map[string]bool
+	// volume holds the source root; its ID is empty when unknown.
+	volume volume.Info
+}
+
+// inc adds one to a counter of the run.
+func (r *scanRun) inc(n *int) {
+	r.mu.Lock()
+	*n++
+	r.mu.Unlock()
+}
+
+// addError records an error of the scan and, when sum is set, counts it
+// against that source.
+func (r *scanRun) addError(sum *SourceSummary, stage, path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.addError(stage, path, err)
+	if sum != nil {
+		sum.ErrorCount++
+	}
+}
+
+// volumeOf finds and records the volume holding the source. Files are not
+// tied to a volume where volumes cannot be detected.
+func (r *scanRun) volumeOf(ctx context.Context, w *sourceWalk) volume.Info {
+	v, err := volume.Of(w.root)
+	if err != nil {
+		return volume.Info{}
+	}
+	if err := r.s.store.SaveVolume(ctx, storage.Volume{ID: v.ID, Label: v.Label, MountPoint: v.MountPoint, Removable: v.Removable}); err != nil {
+		r.addError(w.sum, "volume", w.root, err)
+		return volume.Info{}
+	}
+	return v
+}
+
+// relocate points the row id at path and binds it to the volume holding
+// path the way scans do, so RebindVolume can still re-root moved files.
+// Remote paths and paths on undetected volumes stay unbound.
+func relocate(ctx context.Context, store *storage.Store, id int64, path string) error {
+	var volumeID, volumePath string
+	if !IsRemote(path) {
+		if v, err := volume.Of(path); err == nil {
+			rel, err := filepath.Rel(v.MountPoint, path)
+			if err == nil {
+				err = store.SaveVolume(ctx, storage.Volume{ID: v.ID, Label: v.Label, MountPoint: v.MountPoint, Removable: v.Removable})
+			}
+			if err == nil {
+				volumeID, volumePath = v.ID, rel
+			}
+		}
+	}
+	return store.UpdateMediaPath(ctx, id, path, volumeID, volumePath)
+}
+
+// scanSource walks one source. It only fails when the scan is cancelled;
+// other problems are recorded as errors.
+func (r *scanRun) scanSource(ctx context.Context, i int, src string) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		r.addError(nil, "resolve path", src, err)
+		return nil
+	}
+	// Entries below the root inherit its casing, so fixing the root is enough.
+	absSrc = canonicalPath(absSrc)
+
+	stat, err := os.Stat(absSrc)
+	if err != nil {
+		r.addError(nil, "stat", absSrc, err)
+		return nil
+	}
+	if !stat.IsDir() {
+		r.addError(nil, "stat", absSrc, errors.New("not a directory"))
+		return nil
+	}
+
+	r.mu.Lock()
+	sum := r.previous[absSrc]
+	sum.Source = absSrc
+	w := &sourceWalk{root: absSrc, rules: rulesFor(r.opts, r.extSet, src), sum: &sum, cp: r.checkpoints[absSrc]}
+	if w.cp == nil {
+		w.cp = &storage.ScanCheckpoint{Source: absSrc}
+		r.checkpoints[absSrc] = w.cp
+	}
+	r.sources[i] = w.sum
+	w.mark = w.cp.LastPath
+	done := w.cp.Done
+	r.mu.Unlock()
+	if done {
+		return nil
+	}
+	w.volume = r.volumeOf(ctx, w)
+
+	start := time.Now()
+	real := absSrc
+	if w.rules.follow {
+		w.visited = make(map[string]bool)
+		if resolved, err := filepath.EvalSymlinks(absSrc); err == nil {
+			real = resolved
+		}
+	}
+	walkErr := r.walk(ctx, w, absSrc, real)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.sum.DurationMS += time.Since(start).Milliseconds()
+	if walkErr != nil {
+		if errors.Is(walkErr, context.Canceled) {
+			return walkErr
+		}
+		r.summary.addError("walk", absSrc, walkErr)
+		w.sum.ErrorCount++
+	}
+	if w.inFlight != "" {
+		w.cp.LastPath = w.inFlight
+	}
+	w.cp.Done = true
+	return nil
+}
+
+// visit handles one entry of a source's walk.
+func (r *scanRun) visit(ctx context.Context, w *sourceWalk, path string, d os.DirEntry, walkErr error) error {
+	opts := r.opts
+	if w.inFlight != "" {
+		r.mu.Lock()
+		w.cp.LastPath = w.inFlight
+		r.mu.Unlock()
+		w.inFlight = ""
+	}
+	if walkErr != nil {
+		// The folder is lost for this run either way, but waiting
+		// keeps the rest of the tree from failing while offline.
+		holdOffline(ctx, opts.Gate, LocalBackend{}, w.root, walkErr, opts.OnOffline)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.addError(w.sum, "walk", path, walkErr)
+		return nil
+	}
+
+	if w.mark != "" && path != w.root && walkedBy(w.root, path, w.mark) {
+		if d.IsDir() && !leadsTo(path, w.mark) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+	}
+	if d.IsDir() {
+		if path != w.root && w.rules.skipDir(w.root, path, d) {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	if w.rules.excluded(w.root, path) {
+		r.inc(&w.sum.FilesSkipped)
+		return nil
+	}
+	w.inFlight = path
+	if opts.Archives && d.Type().IsRegular() && archiveKind(path) != "" {
+		if err := r.scanArchive(ctx, w, path); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			r.addError(w.sum, "archive", path, err)
+		}
+		return nil
+	}
+	want := wanted(d, w.rules.extSet, w.rules.follow)
+	if reason, size := opts.Junk.Reason(d, want); reason != "" {
+		r.inc(&w.sum.FilesJunk)
+		if err := r.s.store.RecordJunk(ctx, path, size, reason); err != nil {
+			r.addError(w.sum, "junk", path, err)
+		}
+		return nil
+	}
+	if !want {
+		r.inc(&w.sum.FilesSkipped)
+		return nil
+	}
+
+	if err := opts.Gate.Wait(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	w.sum.FilesDiscovered++
+	if time.Since(r.lastSave) >= sessionInterval || len(r.summary.unsaved) >= maxUnsavedErrors {
+		r.checkpoint(ctx, storage.ScanRunning)
+	}
+	r.mu.Unlock()
+
+	if opts.Incremental && r.s.unchanged(ctx, path, d) {
+		r.inc(&w.sum.FilesUnchanged)
+		opts.Stats.Record(0, false)
+		return nil
+	}
+
+	if err := opts.Throttle.Between(ctx); err != nil {
+		return err
+	}
+	file, fields, err := r.s.readFile(ctx, path, w.root, opts)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		opts.Stats.Record(0, true)
+		r.addError(w.sum, "metadata", path, err)
+		return nil
+	}
+	if opts.Exiftool != nil && len(fields) == 0 {
+		if fields, err = mergeExiftool(opts.Exiftool, &file, fields); err != nil {
+			r.addError(w.sum, "exiftool", path, err)
+		}
+	}
+	if opts.FFprobe != nil && strings.HasPrefix(file.MimeType.String, "video/") {
+		if info, err := opts.FFprobe.Probe(ctx, path); err != nil {
+			r.addError(w.sum, "ffprobe", path, err)
+		} else {
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			mergeVideo(info, &file, fields, opts)
+		}
+	}
+	if opts.Takeout != "" {
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		if err := applyTakeout(path, &file, fields, opts); err != nil {
+			r.addError(w.sum, "takeout", path, err)
+		}
+	}
+	localise(&file, opts)
+	if opts.Validate != "" && validatable(file.MimeType.String) {
+		if file.Corrupt = validateImage(path, opts.Validate); file.Corrupt != "" {
+			r.inc(&w.sum.FilesCorrupt)
+		}
+	}
+
+	r.record(ctx, w, file, fields)
+	return nil
+}
+
+// record persists one scanned file and reports progress.
+func (r *scanRun) record(ctx context.Context, w *sourceWalk, file storage.MediaFile, fields map[string]string) {
+	opts := r.opts
+	if opts.Known != "" {
+		existing, found, err := r.s.store.FindMediaByHash(ctx, file.HashMD5, file.Path)
+		if err != nil {
+			r.addError(w.sum, "lookup", file.Path, err)
+		} else if found {
+			skip := opts.Known == ImportSkipKnown
+			r.mu.Lock()
+			r.summary.FilesKnown++
+			if len(r.summary.Known) < maxKnownSample {
+				r.summary.Known = append(r.summary.Known, KnownFile{
+					Path:        file.Path,
+					LibraryID:   existing.ID,
+					LibraryPath: existing.Path,
+					SizeBytes:   file.SizeBytes,
+					Skipped:     skip,
+				})
+			}
+			r.mu.Unlock()
+			if skip {
+				opts.Stats.Record(file.SizeBytes, false)
+				return
+			}
+		}
+	}
+
+	if w.volume.ID != "" {
+		if rel, err := filepath.Rel(w.volume.MountPoint, file.Path); err == nil {
+			file.VolumeID, file.VolumePath = w.volume.ID, rel
+		}
+	}
+	id, err := r.s.store.UpsertMediaFile(ctx, file)
+	if err != nil {
+		opts.Stats.Record(file.SizeBytes, true)
+		r.addError(w.sum, "persist", file.Path, err)
+		return
+	}
+	opts.Stats.Record(file.SizeBytes, false)
+	if err := r.s.store.ReplaceMediaExif(ctx, id, fields); err != nil {
+		r.addError(w.sum, "persist exif", file.Path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.sum.FilesPersisted++
+	r.meter.add(file.SizeBytes)
+	if r.onProgress != nil {
+		rate, eta := r.meter.rate()
+		discovered, persisted := r.files()
+		r.latest = Progress{
+			Path:           file.Path,
+			Source:         w.root,
+			FilesProcessed: discovered,
+			FilesPersisted: persisted,
+			BytesProcessed: r.meter.bytes,
+			BytesPerSec:    rate,
+			TotalEstimated: r.estimated,
+			ETASeconds:     eta,
+		}
+		r.pending = !r.meter.due(false)
+		if !r.pending {
+			r.onProgress(r.latest)
+		}
+	}
+}
+
+// files totals the files discovered and persisted across the sources;
+// callers hold mu.
+func (r *scanRun) files() (discovered, persisted int) {
+	discovered, persisted = r.carried.FilesDiscovered, r.carried.FilesPersisted
+	for _, sum := range r.sources {
+		if sum != nil {
+			discovered += sum.FilesDiscovered
+			persisted += sum.FilesPersisted
+		}
+	}
+	return discovered, persisted
+}
+
+// tally sums the sources into the summary; callers hold mu.
+func (r *scanRun) tally() {
+	s := &r.summary
+	c := r.carried
+	s.FilesDiscovered, s.FilesPersisted, s.FilesSkipped = c.FilesDiscovered, c.FilesPersisted, c.FilesSkipped
+	s.FilesUnchanged, s.FilesCorrupt, s.FilesJunk = c.FilesUnchanged, c.FilesCorrupt, c.FilesJunk
+	s.Sources = s.Sources[:0]
+	for _, sum := range r.sources {
+		if sum == nil {
+			continue
+		}
+		s.FilesDiscovered += sum.FilesDiscovered
+		s.FilesPersisted += sum.FilesPersisted
+		s.FilesSkipped += sum.FilesSkipped
+		s.FilesUnchanged += sum.FilesUnchanged
+		s.FilesCorrupt += sum.FilesCorrupt
+		s.FilesJunk += sum.FilesJunk
+		s.Sources = append(s.Sources, *sum)
+	}
+	s.DurationMS = time.Since(r.start).Milliseconds()
+}
+
+// checkpoint saves the summary so far with the checkpoints and errors not
+// yet saved, and watches the memory budget; callers hold mu.
+func (r *scanRun) checkpoint(ctx context.Context, status string) {
+	// The final save must land even when the scan was cancelled.
+	ctx = context.WithoutCancel(ctx)
+	opts, summary := r.opts, &r.summary
+	r.tally()
+	mb := memoryMB()
+	summary.PeakMemoryMB = max(summary.PeakMemoryMB, mb)
+	if opts.MemoryBudgetMB > 0 && mb > float64(opts.MemoryBudgetMB) && opts.ProfileDir != "" && summary.HeapProfile == "" {
+		name := fmt.Sprintf("scan-%d-heap.pprof", summary.SessionID)
+		if err := writeHeapProfile(filepath.Join(opts.ProfileDir, name)); err != nil {
+			summary.addError("heap profile", "", err)
+		} else {
+			summary.HeapProfile = filepath.Join(opts.ProfileDir, name)
+		}
+	}
+	saved := make([]storage.ScanCheckpoint, 0, len(r.checkpoints))
+	for _, cp := range r.checkpoints {
+		saved = append(saved, *cp)
+	}
+	if err := r.s.store.SaveScanCheckpoints(ctx, summary.SessionID, saved); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("save scan checkpoints: %v", err))
+	}
+	if err := r.s.store.SaveScanErrors(ctx, summary.SessionID, summary.unsaved); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("save scan errors: %v", err))
+	}
+	summary.unsaved = nil
+	data, err := json.Marshal(summary)
+	if err == nil {
+		err = r.s.store.SaveScanSession(ctx, summary.SessionID, status, data)
+	}
+	if err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("save scan session: %v", err))
+	}
+	r.lastSave = time.Now()
+}
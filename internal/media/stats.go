@@ -0,0 +1,108 @@
+package media
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StatsSnapshot is a point-in-time view of a running job's throughput.
+type StatsSnapshot struct {
+	Job         string  `json:"job"`
+	FilesDone   int     `json:"filesDone"`
+	FilesTotal  int     `json:"filesTotal,omitempty"`
+	FilesPerSec float64 `json:"filesPerSec"`
+	MBPerSec    float64 `json:"mbPerSec"`
+	ETASeconds  float64 `json:"etaSeconds,omitempty"`
+	Errors      int     `json:"errors"`
+	MemoryMB    float64 `json:"memoryMb"`
+	ElapsedMS   int64   `json:"elapsedMs"`
+}
+
+// JobStats accumulates throughput counters for a scan or tidy run. A nil
+// *JobStats is valid and ignores all updates.
+type JobStats struct {
+	job   string
+	start time.Time
+
+	mu     sync.Mutex
+	files  int
+	bytes  int64
+	errors int
+	total  int
+}
+
+// NewJobStats starts tracking a job; total may be zero when unknown.
+func NewJobStats(job string, total int) *JobStats {
+	return &JobStats{job: job, start: time.Now(), total: total}
+}
+
+// Record counts one processed file.
+func (s *JobStats) Record(bytes int64, failed bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.files++
+	s.bytes += bytes
+	if failed {
+		s.errors++
+	}
+	s.mu.Unlock()
+}
+
+// SetTotal updates the expected number of files once it is known.
+func (s *JobStats) SetTotal(total int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.total = total
+	s.mu.Unlock()
+}
+
+// Snapshot computes the current rates.
+func (s *JobStats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	files, bytes, errors, total := s.files, s.bytes, s.errors, s.total
+	s.mu.Unlock()
+
+	elapsed := time.Since(s.start)
+	snap := StatsSnapshot{
+		Job:        s.job,
+		FilesDone:  files,
+		FilesTotal: total,
+		Errors:     errors,
+		ElapsedMS:  elapsed.Milliseconds(),
+	}
+
+	if secs := elapsed.Seconds(); secs > 0 {
+		snap.FilesPerSec = float64(files) / secs
+		snap.MBPerSec = float64(bytes) / (1 << 20) / secs
+	}
+	if total > files && snap.FilesPerSec > 0 {
+		snap.ETASeconds = float64(total-files) / snap.FilesPerSec
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	snap.MemoryMB = float64(mem.HeapAlloc) / (1 << 20)
+	return snap
+}
+
+// Stream emits a snapshot every interval until ctx is done, then a final one.
+func (s *JobStats) Stream(ctx context.Context, interval time.Duration, emit func(StatsSnapshot)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			emit(s.Snapshot())
+			return
+		case <-ticker.C:
+			emit(s.Snapshot())
+		}
+	}
+}
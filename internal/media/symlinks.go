@@ -0,0 +1,73 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walk walks dir, whose real path is real, reporting entries under the path
+// they were reached by. When links are followed, folders already walked
+// through another link are skipped, so a link back into a folder above it
+// cannot send the walk round in circles.
+func (r *scanRun) walk(ctx context.Context, w *sourceWalk, dir, real string) error {
+	return filepath.WalkDir(real, func(p string, d os.DirEntry, walkErr error) error {
+		path := dir
+		if p != real {
+			rel, err := filepath.Rel(real, p)
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(dir, rel)
+		}
+		if !w.rules.follow || walkErr != nil {
+			return r.visit(ctx, w, path, d, walkErr)
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			return r.followLink(ctx, w, path, p, d)
+		}
+		if d.IsDir() && w.visited[p] {
+			return filepath.SkipDir
+		}
+		if err := r.visit(ctx, w, path, d, nil); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			w.visited[p] = true
+		}
+		return nil
+	})
+}
+
+// followLink walks the folder the link at p points to, or visits the link as
+// a file when it points to one. path is where the walk reached the link.
+func (r *scanRun) followLink(ctx context.Context, w *sourceWalk, path, p string, d os.DirEntry) error {
+	if info, err := os.Stat(p); err != nil || !info.IsDir() {
+		return r.visit(ctx, w, path, d, nil)
+	}
+	if w.rules.skipDir(w.root, path, d) {
+		return nil
+	}
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		r.addError(w.sum, "symlink", path, err)
+		return nil
+	}
+	if w.visited[target] {
+		// The walk of p's parent has already marked it and every folder
+		// above it, so only a link to one of them closes a loop; other
+		// folders seen before are just skipped.
+		if parent := filepath.Dir(p); parent == target || strings.HasPrefix(parent, target+string(filepath.Separator)) {
+			r.mu.Lock()
+			w.sum.SymlinkLoops++
+			if len(r.summary.SymlinkLoops) < maxErrorSample {
+				r.summary.SymlinkLoops = append(r.summary.SymlinkLoops, fmt.Sprintf("%s -> %s", path, target))
+			}
+			r.mu.Unlock()
+		}
+		return nil
+	}
+	return r.walk(ctx, w, path, target)
+}
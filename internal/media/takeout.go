@@ -0,0 +1,218 @@
+package media
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// TakeoutMode controls how a scan uses Google Takeout JSON sidecars.
+type TakeoutMode string
+
+// Supported Takeout modes. The zero value ignores sidecars.
+const (
+	// TakeoutMerge fills missing taken times and geodata from the sidecar.
+	TakeoutMerge TakeoutMode = "merge"
+	// TakeoutWrite also writes the merged values into the file with exiftool.
+	TakeoutWrite TakeoutMode = "write"
+)
+
+// ParseTakeoutMode validates a mode coming from the UI.
+func ParseTakeoutMode(value string) (TakeoutMode, error) {
+	switch mode := TakeoutMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", TakeoutMerge, TakeoutWrite:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown takeout mode %q", value)
+	}
+}
+
+// takeoutNameLimit is the length Takeout truncates sidecar names to,
+// excluding the ".json" suffix.
+const takeoutNameLimit = 46
+
+// editedCopy matches the "(1)" Takeout appends to duplicate names.
+var editedCopy = regexp.MustCompile(`^(.*)(\(\d+\))(\.[^.]*)$`)
+
+type takeoutSidecar struct {
+	Title          string      `json:"title"`
+	PhotoTakenTime takeoutTime `json:"photoTakenTime"`
+	GeoData        takeoutGeo  `json:"geoData"`
+	GeoDataExif    takeoutGeo  `json:"geoDataExif"`
+}
+
+type takeoutTime struct {
+	Timestamp string `json:"timestamp"`
+}
+
+type takeoutGeo struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+func (g takeoutGeo) valid() bool {
+	return g.Latitude != 0 || g.Longitude != 0
+}
+
+// findSidecar locates the Takeout JSON for path, covering the naming quirks
+// of the export: supplemental-metadata suffixes, truncated names and the
+// "(1)" of duplicates landing after the extension.
+func findSidecar(path string) string {
+	dir, name := filepath.Split(path)
+
+	candidates := []string{
+		name + ".supplemental-metadata.json",
+		name + ".json",
+		strings.TrimSuffix(name, filepath.Ext(name)) + ".json",
+	}
+	if m := editedCopy.FindStringSubmatch(name); m != nil {
+		candidates = append(candidates, m[1]+m[3]+m[2]+".json")
+	}
+	if len(name) > takeoutNameLimit {
+		candidates = append(candidates, name[:takeoutNameLimit]+".json")
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(filepath.Join(dir, candidate)); err == nil && info.Mode().IsRegular() {
+			return filepath.Join(dir, candidate)
+		}
+	}
+
+	// Newer exports truncate the supplemental suffix itself.
+	if matches, _ := filepath.Glob(filepath.Join(dir, escapeGlob(name)+".s*.json")); len(matches) > 0 {
+		return matches[0]
+	}
+	return ""
+}
+
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`[`, `\[`, `]`, `\]`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+func readSidecar(path string) (takeoutSidecar, error) {
+	var sidecar takeoutSidecar
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sidecar, err
+	}
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return sidecar, fmt.Errorf("parse sidecar: %w", err)
+	}
+	return sidecar, nil
+}
+
+// takenAt returns the sidecar's capture time.
+func (s takeoutSidecar) takenAt() (time.Time, bool) {
+	seconds, err := strconv.ParseInt(s.PhotoTakenTime.Timestamp, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// geo prefers the location Google read from the file over one added later.
+func (s takeoutSidecar) geo() (takeoutGeo, bool) {
+	if s.GeoDataExif.valid() {
+		return s.GeoDataExif, true
+	}
+	return s.GeoData, s.GeoData.valid()
+}
+
+// mergeTakeout fills the taken time and GPS fields the file itself lacks from
+// its sidecar. It reports whether anything was merged.
+func mergeTakeout(path string, file *storage.MediaFile, fields map[string]string, opts Options) (bool, error) {
+	sidecarPath := findSidecar(path)
+	if sidecarPath == "" {
+		return false, nil
+	}
+	sidecar, err := readSidecar(sidecarPath)
+	if err != nil {
+		return false, err
+	}
+
+	merged := false
+	if taken, ok := sidecar.takenAt(); ok && !file.TakenAt.Valid {
+		// The sidecar holds an instant; show it on the clock of the zone
+		// the camera is assumed to be in, like EXIF times.
+		loc := opts.zoneFor(file.CameraMake.String, file.CameraModel.String)
+		file.TakenAt = sql.NullTime{Time: wallClock(taken.In(loc)), Valid: true}
+		placeInZone(file, loc)
+		merged = true
+	}
+	if geo, ok := sidecar.geo(); ok && fields["GPSLatitude"] == "" {
+		fields["GPSLatitude"] = strconv.FormatFloat(geo.Latitude, 'f', 6, 64)
+		fields["GPSLongitude"] = strconv.FormatFloat(geo.Longitude, 'f', 6, 64)
+		if geo.Altitude != 0 {
+			fields["GPSAltitude"] = strconv.FormatFloat(geo.Altitude, 'f', 1, 64)
+		}
+		file.Latitude = sql.NullFloat64{Float64: geo.Latitude, Valid: true}
+		file.Longitude = sql.NullFloat64{Float64: geo.Longitude, Valid: true}
+		merged = true
+	}
+	return merged, nil
+}
+
+// applyTakeout merges the sidecar of path into file and, in write mode,
+// writes the result back and refreshes the hash of the rewritten file.
+func applyTakeout(path string, file *storage.MediaFile, fields map[string]string, opts Options) error {
+	merged, err := mergeTakeout(path, file, fields, opts)
+	if err != nil || !merged || opts.Takeout != TakeoutWrite {
+		return err
+	}
+	if err := writeTakeout(path, *file, fields); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hash, err := hashFile(path, opts.Throttle)
+	if err != nil {
+		return err
+	}
+	file.HashMD5 = hash
+	file.SizeBytes = info.Size()
+	file.ModTime = info.ModTime().UTC()
+	return nil
+}
+
+// writeTakeout stores the merged values in the file itself with exiftool so
+// other tools see them too.
+func writeTakeout(path string, file storage.MediaFile, fields map[string]string) error {
+	exiftool, err := exec.LookPath("exiftool")
+	if err != nil {
+		return errors.New("exiftool is required to write Takeout metadata back")
+	}
+
+	args := []string{"-overwrite_original", "-P"}
+	if file.TakenAt.Valid {
+		stamp := file.TakenAt.Time.Format("2006:01:02 15:04:05")
+		args = append(args, "-DateTimeOriginal="+stamp, "-CreateDate="+stamp)
+	}
+	if lat, lon := fields["GPSLatitude"], fields["GPSLongitude"]; lat != "" && lon != "" {
+		args = append(args,
+			"-GPSLatitude="+lat, "-GPSLatitudeRef="+lat,
+			"-GPSLongitude="+lon, "-GPSLongitudeRef="+lon,
+		)
+		if alt := fields["GPSAltitude"]; alt != "" {
+			args = append(args, "-GPSAltitude="+alt, "-GPSAltitudeRef="+alt)
+		}
+	}
+	args = append(args, path)
+
+	if out, err := exec.Command(exiftool, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("exiftool: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ThrottleLimits caps the IO of scans and tidy runs, typically to keep a
+// network share usable while a job runs. Zero values disable a limit.
+type ThrottleLimits struct {
+	// MBPerSec caps the combined hashing and copy rate of all workers.
+	MBPerSec float64 `json:"mbPerSec"`
+	// FileDelayMS pauses between files.
+	FileDelayMS int `json:"fileDelayMs"`
+}
+
+// Throttle applies ThrottleLimits to running jobs. Limits can be changed at
+// any time and take effect on the next read. A nil *Throttle never blocks.
+type Throttle struct {
+	mu     sync.Mutex
+	limits ThrottleLimits
+	// backoff lengthens the inter-file delay while the machine is busy.
+	backoff time.Duration
+	// next is when the bytes granted so far have been paid for.
+	next time.Time
+}
+
+// NewThrottle returns a throttle without limits.
+func NewThrottle() *Throttle {
+	return &Throttle{}
+}
+
+// SetLimits replaces the current limits; negative values are treated as zero.
+func (t *Throttle) SetLimits(limits ThrottleLimits) {
+	if limits.MBPerSec < 0 {
+		limits.MBPerSec = 0
+	}
+	if limits.FileDelayMS < 0 {
+		limits.FileDelayMS = 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits = limits
+	t.next = time.Time{}
+}
+
+// SetBackoff adds d to the inter-file delay, for example while the user is
+// working and jobs should give way; 0 removes it.
+func (t *Throttle) SetBackoff(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.backoff = d
+}
+
+// Backoff returns the extra inter-file delay currently in force.
+func (t *Throttle) Backoff() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.backoff
+}
+
+// Limits returns the limits currently in force.
+func (t *Throttle) Limits() ThrottleLimits {
+	if t == nil {
+		return ThrottleLimits{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limits
+}
+
+// Between waits out the inter-file delay and any backoff, or until ctx is
+// done.
+func (t *Throttle) Between(ctx context.Context) error {
+	delay := time.Duration(t.Limits().FileDelayMS)*time.Millisecond + t.Backoff()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reader wraps r so reads are paced to the byte rate limit.
+func (t *Throttle) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{r: r, t: t}
+}
+
+// take books n bytes against the rate limit and sleeps until they are due.
+func (t *Throttle) take(n int) {
+	t.mu.Lock()
+	rate := t.limits.MBPerSec * 1024 * 1024
+	if rate <= 0 || n <= 0 {
+		t.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(float64(n) / rate * float64(time.Second)))
+	wait := t.next.Sub(now)
+	t.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+type throttledReader struct {
+	r io.Reader
+	t *Throttle
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	n, err := tr.r.Read(p)
+	tr.t.take(n)
+	return n, err
+}
@@ -0,0 +1,127 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+)
+
+// MaxThumbnailSize bounds the longest edge of a generated thumbnail.
+const MaxThumbnailSize = 1024
+
+// Thumbnail writes a JPEG of src whose longest edge is at most size pixels
+// to dest, applying the EXIF orientation. An existing dest is reused.
+func Thumbnail(src, dest string, size int) error {
+	if size <= 0 || size > MaxThumbnailSize {
+		return fmt.Errorf("thumbnail size must be between 1 and %d", MaxThumbnailSize)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("decode %s: %w", src, err)
+	}
+
+	thumb := orient(downscale(img, size), extractEXIF(src).Fields["Orientation"])
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".thumb-*")
+	if err != nil {
+		return err
+	}
+	encErr := jpeg.Encode(tmp, thumb, &jpeg.Options{Quality: 82})
+	closeErr := tmp.Close()
+	if err := errors.Join(encErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// ThumbnailPath is where the thumbnail of size for content with hash is
+// cached below dir. Copies of one file share their thumbnails.
+func ThumbnailPath(dir, hash string, size int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s_%d.jpg", hash, size))
+}
+
+// downscale shrinks img so its longest edge is at most size, averaging the
+// source pixels covered by each target pixel.
+func downscale(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > size {
+		tw, th = size, max(1, h*size/w)
+	} else if h > w && h > size {
+		tw, th = max(1, w*size/h), size
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return out
+}
+
+// orient applies an EXIF orientation value (1-8) to img.
+func orient(img *image.RGBA, orientation string) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	var (
+		out *image.RGBA
+		to  func(x, y int) (int, int)
+	)
+	switch orientation {
+	case "2": // mirrored horizontally
+		out, to = image.NewRGBA(image.Rect(0, 0, w, h)), func(x, y int) (int, int) { return w - 1 - x, y }
+	case "3": // rotated 180
+		out, to = image.NewRGBA(image.Rect(0, 0, w, h)), func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case "4": // mirrored vertically
+		out, to = image.NewRGBA(image.Rect(0, 0, w, h)), func(x, y int) (int, int) { return x, h - 1 - y }
+	case "5": // transposed
+		out, to = image.NewRGBA(image.Rect(0, 0, h, w)), func(x, y int) (int, int) { return y, x }
+	case "6": // rotated 90 clockwise
+		out, to = image.NewRGBA(image.Rect(0, 0, h, w)), func(x, y int) (int, int) { return h - 1 - y, x }
+	case "7": // transversed
+		out, to = image.NewRGBA(image.Rect(0, 0, h, w)), func(x, y int) (int, int) { return h - 1 - y, w - 1 - x }
+	case "8": // rotated 90 counter-clockwise
+		out, to = image.NewRGBA(image.Rect(0, 0, h, w)), func(x, y int) (int, int) { return y, w - 1 - x }
+	default:
+		return img
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := to(x, y)
+			out.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return out
+}
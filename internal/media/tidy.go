@@ -6,13 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
-	"photoTidyGo/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
 )
 
 // MoveRequest represents a request to relocate a media file.
@@ -20,33 +22,93 @@ type MoveRequest struct {
 	MediaID int64 `json:"mediaId"`
 }
 
+// SafetyLevel trades move speed against verification guarantees.
+type SafetyLevel string
+
+const (
+	// SafetyFast trusts renames and unverified cross-device copies.
+	SafetyFast SafetyLevel = "fast"
+	// SafetyStandard verifies the hash of cross-device copies before removing the source.
+	SafetyStandard SafetyLevel = "standard"
+	// SafetyParanoid verifies every move and fsyncs the target before committing.
+	SafetyParanoid SafetyLevel = "paranoid"
+)
+
 // TidyOptions configures how tidy actions should behave.
 type TidyOptions struct {
 	TargetBase string
 	Pattern    string
-	DryRun     bool
+	// Backend holds the target; nil picks one from TargetBase, without
+	// credentials.
+	Backend Backend
+	DryRun  bool
+	// Safety selects the verification depth; empty means SafetyStandard.
+	Safety SafetyLevel
+	// Workers is the number of files moved concurrently; values below one mean one.
+	Workers int
+	// Stats, when set, receives per-file throughput updates.
+	Stats *JobStats
+	// Gate, when set, can pause the run between files.
+	Gate *PauseGate
+	// Throttle paces copies and hash checks; nil disables throttling.
+	Throttle *Throttle
+	// OnOffline is called when the run pauses because the target base
+	// became unreachable; Gate must be set for the run to wait.
+	OnOffline OfflineFunc
+	// RetryOf maps media IDs to the failed action being retried, linking the
+	// new action rows to their predecessors.
+	RetryOf map[int64]int64
+	// QuarantineDir receives corrupt or suspicious files instead of the
+	// organised library. Inspection is skipped when empty.
+	QuarantineDir string
+	// Logger, when set, records the outcome of every file.
+	Logger *slog.Logger
+	// Normalization is applied to generated target names; empty means NFC.
+	Normalization UnicodeForm
+	// CaseMode decides whether targets differing only by case collide;
+	// empty probes the target volume.
+	CaseMode CaseMode
+	// RemoveDuplicateSource deletes the source when the target already holds
+	// an identical file; otherwise the source is left in place.
+	RemoveDuplicateSource bool
+	// Transactional makes the batch all-or-nothing: the first failure stops
+	// the run and undoes the moves already performed. Duplicate sources are
+	// kept in this mode since deletions cannot be undone.
+	Transactional bool
 }
 
-// TidyProgress conveys real-time execution updates.
+// TidyProgress conveys real-time execution updates. Successful updates are
+// throttled to ten per second; failures and the final update always go out.
 type TidyProgress struct {
-	MediaID   int64  `json:"mediaId"`
-	Source    string `json:"source"`
-	Target    string `json:"target"`
-	Completed int    `json:"completed"`
-	Total     int    `json:"total"`
-	Status    string `json:"status"`
-	Error     string `json:"error,omitempty"`
+	MediaID        int64   `json:"mediaId"`
+	Source         string  `json:"source"`
+	Target         string  `json:"target"`
+	Completed      int     `json:"completed"`
+	Total          int     `json:"total"`
+	Status         string  `json:"status"`
+	Error          string  `json:"error,omitempty"`
+	BytesProcessed int64   `json:"bytesProcessed"`
+	BytesPerSec    float64 `json:"bytesPerSec"`
+	ETASeconds     float64 `json:"etaSeconds,omitempty"`
 }
 
 // TidySummary summarises the outcome of a tidy run.
 type TidySummary struct {
-	Total      int    `json:"total"`
-	Moved      int    `json:"moved"`
-	Skipped    int    `json:"skipped"`
-	Failed     int    `json:"failed"`
-	DurationMS int64  `json:"durationMs"`
-	DryRun     bool   `json:"dryRun"`
-	TargetBase string `json:"targetBase"`
+	Total       int `json:"total"`
+	Moved       int `json:"moved"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+	Quarantined int `json:"quarantined"`
+	// Deduplicated counts files whose target already held identical bytes.
+	Deduplicated int    `json:"deduplicated"`
+	RunID        int64  `json:"runId,omitempty"`
+	DurationMS   int64  `json:"durationMs"`
+	DryRun       bool   `json:"dryRun"`
+	TargetBase   string `json:"targetBase"`
+	// Aborted explains why a transactional run stopped early.
+	Aborted string `json:"aborted,omitempty"`
+	// RolledBack counts moves undone after a transactional run aborted.
+	RolledBack int `json:"rolledBack,omitempty"`
 }
 
 // TidyExecutor performs filesystem moves while recording to SQLite.
@@ -59,170 +121,478 @@ func NewTidyExecutor(store *storage.Store) *TidyExecutor {
 	return &TidyExecutor{store: store}
 }
 
+// tidyItem pairs a request with its media row; ok is false when no row
+// exists for the requested ID.
+type tidyItem struct {
+	req  MoveRequest
+	file storage.MediaFile
+	ok   bool
+}
+
+// tidyFeed hands the items of a run to visit in batches and stops when visit
+// returns an error.
+type tidyFeed func(visit func([]tidyItem) error) error
+
+// errFeedStopped ends a feed early once the run stops taking items.
+var errFeedStopped = errors.New("tidy feed stopped")
+
 // Execute applies the tidy plan to the filesystem and SQLite.
 func (t *TidyExecutor) Execute(ctx context.Context, opts TidyOptions, requests []MoveRequest, onProgress func(TidyProgress)) (TidySummary, error) {
-	summary := TidySummary{Total: len(requests), DryRun: opts.DryRun, TargetBase: opts.TargetBase}
-	if len(requests) == 0 {
+	return t.execute(ctx, opts, len(requests), func(visit func([]tidyItem) error) error {
+		ids := make([]int64, 0, len(requests))
+		for _, req := range requests {
+			ids = append(ids, req.MediaID)
+		}
+		mediaMap, err := t.store.GetMediaByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		items := make([]tidyItem, 0, len(requests))
+		for _, req := range requests {
+			file, ok := mediaMap[req.MediaID]
+			items = append(items, tidyItem{req: req, file: file, ok: ok})
+		}
+		return visit(items)
+	}, onProgress)
+}
+
+// ExecuteCursor tidies every media row the cursor walks. Rows are read and
+// dispatched one batch at a time, so arbitrarily large selections run in
+// constant memory.
+func (t *TidyExecutor) ExecuteCursor(ctx context.Context, opts TidyOptions, cursor *storage.MediaCursor, onProgress func(TidyProgress)) (TidySummary, error) {
+	return t.execute(ctx, opts, cursor.Total, func(visit func([]tidyItem) error) error {
+		for {
+			files, err := cursor.Next(ctx)
+			if err != nil || len(files) == 0 {
+				return err
+			}
+			items := make([]tidyItem, 0, len(files))
+			for _, file := range files {
+				items = append(items, tidyItem{req: MoveRequest{MediaID: file.ID}, file: file, ok: true})
+			}
+			if err := visit(items); err != nil {
+				return err
+			}
+		}
+	}, onProgress)
+}
+
+// execute runs the items produced by feed, of which there are total.
+func (t *TidyExecutor) execute(ctx context.Context, opts TidyOptions, total int, feed tidyFeed, onProgress func(TidyProgress)) (TidySummary, error) {
+	summary := TidySummary{Total: total, DryRun: opts.DryRun, TargetBase: opts.TargetBase}
+	if total == 0 {
 		return summary, nil
 	}
 	if opts.TargetBase == "" {
 		return summary, errors.New("target base folder is not configured")
 	}
+	switch opts.Safety {
+	case "":
+		opts.Safety = SafetyStandard
+	case SafetyFast, SafetyStandard, SafetyParanoid:
+	default:
+		return summary, fmt.Errorf("unknown safety level %q", opts.Safety)
+	}
+	form, err := ParseUnicodeForm(string(opts.Normalization))
+	if err != nil {
+		return summary, err
+	}
+	opts.Normalization = form
+	if opts.Backend == nil {
+		if opts.Backend, err = NewBackend(opts.TargetBase, BackendCredentials{}); err != nil {
+			return summary, err
+		}
+	}
+
+	if !opts.DryRun {
+		if err := PreflightTarget(opts.Backend, opts.TargetBase); err != nil {
+			return summary, err
+		}
+	}
 
 	pattern := opts.Pattern
 	if strings.TrimSpace(pattern) == "" {
 		pattern = "{{.Date}}/{{.OriginalName}}"
 	}
 
+	foldCase, err := opts.CaseMode.foldsCase(opts.Backend, opts.TargetBase)
+	if err != nil {
+		return summary, err
+	}
+
 	tmpl, err := template.New("target").Parse(pattern)
 	if err != nil {
 		return summary, fmt.Errorf("parse pattern: %w", err)
 	}
 
-	ids := make([]int64, 0, len(requests))
-	for _, req := range requests {
-		ids = append(ids, req.MediaID)
+	start := time.Now()
+
+	if !opts.DryRun {
+		if summary.RunID, err = t.store.CreateTidyRun(ctx, nil); err != nil {
+			return summary, err
+		}
 	}
 
-	mediaMap, err := t.store.GetMediaByIDs(ctx, ids)
-	if err != nil {
-		return summary, err
+	run := &tidyRun{
+		executor:   t,
+		opts:       opts,
+		tmpl:       tmpl,
+		registry:   newTargetRegistry(t.store, opts.Backend, opts.DryRun, foldCase),
+		onProgress: onProgress,
+		summary:    &summary,
+		meter:      newProgressMeter(total),
+		stop:       make(chan struct{}),
 	}
 
-	start := time.Now()
+	workers := opts.Workers
+	if workers < 1 || opts.Transactional {
+		workers = 1
+	}
 
-	for idx, req := range requests {
-		select {
-		case <-ctx.Done():
-			return summary, ctx.Err()
-		default:
-		}
+	jobs := make(chan tidyItem)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				if run.stopped() {
+					continue
+				}
+				run.process(ctx, item.req, item.file, item.ok)
+			}
+		}()
+	}
 
-		file, ok := mediaMap[req.MediaID]
-		if !ok {
-			summary.Failed++
-			t.emit(onProgress, TidyProgress{
-				MediaID:   req.MediaID,
-				Completed: idx,
-				Total:     summary.Total,
-				Status:    "missing",
-				Error:     "media metadata not found",
-			})
-			continue
+	var ctxErr error
+	feedErr := feed(func(items []tidyItem) error {
+		if summary.RunID != 0 {
+			if err := t.store.SnapshotTidyRun(ctx, summary.RunID, snapshotOf(items)); err != nil {
+				return err
+			}
+		}
+		for _, item := range items {
+			if ctxErr = opts.Gate.Wait(ctx); ctxErr != nil {
+				return errFeedStopped
+			}
+			if ctxErr = opts.Throttle.Between(ctx); ctxErr != nil {
+				return errFeedStopped
+			}
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+				ctxErr = ctx.Err()
+			case <-run.stop:
+			}
+			if ctxErr != nil || run.stopped() {
+				return errFeedStopped
+			}
 		}
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+	if feedErr != nil && !errors.Is(feedErr, errFeedStopped) && ctxErr == nil {
+		ctxErr = feedErr
+	}
+
+	if summary.RunID != 0 {
+		_ = t.store.SetTidyRunStatus(ctx, summary.RunID, storage.RunStatusFinished)
+	}
 
-		targetPath, err := buildTargetPath(opts.TargetBase, tmpl, file)
+	if opts.Transactional && ctxErr != nil && summary.Aborted == "" {
+		summary.Aborted = ctxErr.Error()
+	}
+	if opts.Transactional && summary.Aborted != "" && summary.RunID != 0 {
+		// Undo even when the caller cancelled; a half-applied batch is
+		// exactly what transactional mode promises to avoid.
+		undo, err := t.Rollback(context.WithoutCancel(ctx), summary.RunID, opts.Backend)
+		summary.RolledBack = undo.Restored
+		if err == nil && undo.Failed > 0 {
+			err = fmt.Errorf("rollback left %d files in place: %s", undo.Failed, strings.Join(undo.Errors, "; "))
+		}
 		if err != nil {
-			summary.Failed++
-			t.emit(onProgress, TidyProgress{
-				MediaID:   file.ID,
-				Source:    file.Path,
-				Completed: idx,
-				Total:     summary.Total,
-				Status:    "failed",
-				Error:     err.Error(),
-			})
-			continue
+			summary.DurationMS = time.Since(start).Milliseconds()
+			return summary, fmt.Errorf("undo aborted run: %w", err)
+		}
+		ctxErr = nil
+	}
+
+	summary.DurationMS = time.Since(start).Milliseconds()
+	if ctxErr != nil {
+		return summary, ctxErr
+	}
+	return summary, nil
+}
+
+// snapshotOf lists the distinct media rows among items.
+func snapshotOf(items []tidyItem) []storage.MediaFile {
+	seen := make(map[int64]bool, len(items))
+	files := make([]storage.MediaFile, 0, len(items))
+	for _, item := range items {
+		if item.ok && !seen[item.file.ID] {
+			seen[item.file.ID] = true
+			files = append(files, item.file)
 		}
+	}
+	return files
+}
+
+// tidyRun holds the state shared by the workers of a single Execute call.
+type tidyRun struct {
+	executor   *TidyExecutor
+	opts       TidyOptions
+	tmpl       *template.Template
+	registry   *targetRegistry
+	onProgress func(TidyProgress)
+
+	mu        sync.Mutex
+	summary   *TidySummary
+	completed int
+	meter     *progressMeter
+
+	// stop is closed when a transactional run hits its first failure.
+	stop chan struct{}
+}
 
-		if file.Path == targetPath {
-			summary.Skipped++
-			t.emit(onProgress, TidyProgress{
-				MediaID:   file.ID,
-				Source:    file.Path,
-				Target:    targetPath,
-				Completed: idx + 1,
-				Total:     summary.Total,
-				Status:    "skipped",
-			})
-			continue
+// stopped reports whether a transactional run has aborted.
+func (r *tidyRun) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// report applies the outcome of one request to the summary and emits progress.
+// size is the number of bytes relocated and feeds the throughput statistics.
+func (r *tidyRun) report(update func(*TidySummary), progress TidyProgress, size int64) {
+	// Skipped corrupt files and quarantines carry an Error as well, but only
+	// failures abort a transactional run.
+	failure := progress.Status == "failed" || progress.Status == "missing"
+	r.opts.Stats.Record(size, failure)
+
+	r.mu.Lock()
+	update(r.summary)
+	if r.opts.Transactional && failure && r.summary.Aborted == "" {
+		r.summary.Aborted = fmt.Sprintf("media %d: %s", progress.MediaID, progress.Error)
+		close(r.stop)
+	}
+	r.completed++
+	progress.Completed = r.completed
+	progress.Total = r.summary.Total
+	r.meter.add(size)
+	progress.BytesProcessed = r.meter.bytes
+	progress.BytesPerSec, progress.ETASeconds = r.meter.rate()
+	due := r.meter.due(progress.Error != "" || r.completed == r.summary.Total)
+	r.mu.Unlock()
+
+	if logger := r.opts.Logger; logger != nil {
+		level := slog.LevelInfo
+		if progress.Error != "" {
+			level = slog.LevelError
 		}
+		logger.Log(context.Background(), level, "tidy action",
+			"runId", r.summary.RunID,
+			"mediaId", progress.MediaID,
+			"status", progress.Status,
+			"source", progress.Source,
+			"target", progress.Target,
+			"error", progress.Error,
+		)
+	}
 
-		var actionID int64
-		if !opts.DryRun {
-			actionID, err = t.store.CreateAction(ctx, storage.FileAction{
-				MediaID:    sql.NullInt64{Int64: file.ID, Valid: true},
-				SourcePath: file.Path,
-				TargetPath: targetPath,
-				ActionType: "move",
-				Status:     storage.ActionStatusPending,
-				HashMD5:    sql.NullString{String: file.HashMD5, Valid: file.HashMD5 != ""},
-			})
-			if err != nil {
-				summary.Failed++
-				t.emit(onProgress, TidyProgress{
-					MediaID:   file.ID,
-					Source:    file.Path,
-					Target:    targetPath,
-					Completed: idx,
-					Total:     summary.Total,
-					Status:    "failed",
-					Error:     fmt.Sprintf("record action: %v", err),
+	if due {
+		r.executor.emit(r.onProgress, progress)
+	}
+}
+
+func failed(s *TidySummary)       { s.Failed++ }
+func skipped(s *TidySummary)      { s.Skipped++ }
+func moved(s *TidySummary)        { s.Moved++ }
+func quarantined(s *TidySummary)  { s.Quarantined++ }
+func deduplicated(s *TidySummary) { s.Deduplicated++ }
+
+func (r *tidyRun) process(ctx context.Context, req MoveRequest, file storage.MediaFile, ok bool) {
+	t := r.executor
+	opts := r.opts
+
+	if !ok {
+		r.report(failed, TidyProgress{
+			MediaID: req.MediaID,
+			Status:  "missing",
+			Error:   "media metadata not found",
+		}, 0)
+		return
+	}
+
+	// Archive entries are extracted; the archive itself is never modified.
+	archived := IsArchivePath(file.Path)
+	actionType := "move"
+	if archived {
+		actionType = "extract"
+	}
+	var (
+		candidate string
+		reason    string
+		err       error
+	)
+	// Files a scan found corrupt go to quarantine when there is one and
+	// stay where they are otherwise.
+	if file.Corrupt != "" {
+		if opts.QuarantineDir == "" || archived {
+			r.report(skipped, TidyProgress{
+				MediaID: file.ID,
+				Source:  file.Path,
+				Status:  "corrupt",
+				Error:   file.Corrupt,
+			}, 0)
+			return
+		}
+		reason = "corrupt: " + file.Corrupt
+	} else if opts.QuarantineDir != "" && !archived {
+		reason = inspectFile(file, opts.Throttle)
+	}
+	if reason != "" {
+		actionType = "quarantine"
+		candidate, err = buildQuarantinePath(opts.QuarantineDir, file)
+	} else {
+		candidate, err = buildTargetPath(opts.Backend, opts.TargetBase, r.tmpl, file, opts.Normalization)
+	}
+	if err == nil && reason == "" && r.deduplicate(ctx, file, candidate) {
+		return
+	}
+	var targetPath string
+	if err == nil {
+		targetPath, err = r.registry.reserve(ctx, candidate, file)
+	}
+	if err != nil {
+		r.report(failed, TidyProgress{
+			MediaID: file.ID,
+			Source:  file.Path,
+			Status:  "failed",
+			Error:   err.Error(),
+		}, 0)
+		return
+	}
+	defer r.registry.release(ctx, targetPath)
+
+	if file.Path == targetPath {
+		r.report(skipped, TidyProgress{
+			MediaID: file.ID,
+			Source:  file.Path,
+			Target:  targetPath,
+			Status:  "skipped",
+		}, 0)
+		return
+	}
+
+	var actionID int64
+	if !opts.DryRun {
+		actionID, err = t.store.CreateAction(ctx, storage.FileAction{
+			MediaID:    sql.NullInt64{Int64: file.ID, Valid: true},
+			SourcePath: file.Path,
+			TargetPath: targetPath,
+			ActionType: actionType,
+			Status:     storage.ActionStatusPending,
+			HashMD5:    sql.NullString{String: file.HashMD5, Valid: file.HashMD5 != ""},
+			RunID:      sql.NullInt64{Int64: r.summary.RunID, Valid: r.summary.RunID != 0},
+			RetryOf:    retryLink(opts.RetryOf, file.ID),
+		})
+		if err != nil {
+			r.report(failed, TidyProgress{
+				MediaID: file.ID,
+				Source:  file.Path,
+				Target:  targetPath,
+				Status:  "failed",
+				Error:   fmt.Sprintf("record action: %v", err),
+			}, 0)
+			return
+		}
+	}
+
+	moveStatus := "planned"
+	var moveErr error
+	dest := backendFor(opts.Backend, targetPath)
+
+	if !opts.DryRun {
+		moveStatus = "moved"
+		if archived {
+			moveStatus = "extracted"
+		}
+		for {
+			if archived {
+				moveErr = withNetRetry(func() error {
+					return extractEntry(dest, file.Path, targetPath, file.HashMD5, opts.Throttle)
 				})
-				continue
+			} else {
+				moveErr = moveWithRetry(dest, file.Path, targetPath, opts.Safety, file.HashMD5, opts.Throttle)
+			}
+			if moveErr == nil || !holdOffline(ctx, opts.Gate, opts.Backend, opts.TargetBase, moveErr, opts.OnOffline) {
+				break
 			}
 		}
+	}
+	if reason != "" {
+		moveStatus = "quarantined"
+	}
 
-		moveStatus := "planned"
-		var moveErr error
-
-		if !opts.DryRun {
-			moveStatus = "moved"
-			moveErr = moveFile(file.Path, targetPath)
+	if moveErr != nil {
+		errMsg := truncateError(moveErr)
+		if actionID != 0 {
+			_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
 		}
+		r.report(failed, TidyProgress{
+			MediaID: file.ID,
+			Source:  file.Path,
+			Target:  targetPath,
+			Status:  "failed",
+			Error:   errMsg,
+		}, 0)
+		return
+	}
 
-		if moveErr != nil {
-			summary.Failed++
-			errMsg := truncateError(moveErr)
+	if !opts.DryRun {
+		if err := relocate(ctx, t.store, file.ID, targetPath); err != nil {
+			errMsg := fmt.Sprintf("update media path: %v", err)
 			if actionID != 0 {
 				_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
 			}
-			t.emit(onProgress, TidyProgress{
-				MediaID:   file.ID,
-				Source:    file.Path,
-				Target:    targetPath,
-				Completed: idx + 1,
-				Total:     summary.Total,
-				Status:    "failed",
-				Error:     errMsg,
-			})
-			continue
+			r.report(failed, TidyProgress{
+				MediaID: file.ID,
+				Source:  file.Path,
+				Target:  targetPath,
+				Status:  "failed",
+				Error:   errMsg,
+			}, 0)
+			return
 		}
-
-		if !opts.DryRun {
-			if err := t.store.UpdateMediaPath(ctx, file.ID, targetPath); err != nil {
-				errMsg := fmt.Sprintf("update media path: %v", err)
-				if actionID != 0 {
-					_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusFailed, &errMsg)
-				}
-				summary.Failed++
-				t.emit(onProgress, TidyProgress{
-					MediaID:   file.ID,
-					Source:    file.Path,
-					Target:    targetPath,
-					Completed: idx + 1,
-					Total:     summary.Total,
-					Status:    "failed",
-					Error:     errMsg,
-				})
-				continue
-			}
+		if reason != "" {
+			_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusQuarantined, &reason)
+		} else {
 			_ = t.store.MarkAction(ctx, actionID, storage.ActionStatusCompleted, nil)
 		}
+	}
 
-		summary.Moved++
-		t.emit(onProgress, TidyProgress{
-			MediaID:   file.ID,
-			Source:    file.Path,
-			Target:    targetPath,
-			Completed: idx + 1,
-			Total:     summary.Total,
-			Status:    moveStatus,
-		})
+	outcome := moved
+	if reason != "" {
+		outcome = quarantined
 	}
+	r.report(outcome, TidyProgress{
+		MediaID: file.ID,
+		Source:  file.Path,
+		Target:  targetPath,
+		Status:  moveStatus,
+		Error:   reason,
+	}, file.SizeBytes)
+}
 
-	summary.DurationMS = time.Since(start).Milliseconds()
-	return summary, nil
+func retryLink(retryOf map[int64]int64, mediaID int64) sql.NullInt64 {
+	if id, ok := retryOf[mediaID]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}
+	}
+	return sql.NullInt64{}
 }
 
 func (t *TidyExecutor) emit(cb func(TidyProgress), progress TidyProgress) {
@@ -239,9 +609,26 @@ type templateData struct {
 	Hash         string
 	OriginalName string
 	Ext          string
+	Category     string
+	// Person is the named person with the largest face in the file, or
+	// "Unknown".
+	Person string
+	// Tags are the imported and classifier tags of the file; see HasTag.
+	Tags []string
+}
+
+// HasTag reports whether the file carries tag, ignoring case, so patterns
+// can branch on labels: {{if .HasTag "document"}}Documents/{{end}}.
+func (d templateData) HasTag(tag string) bool {
+	for _, t := range d.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
 }
 
-func buildTargetPath(base string, tmpl *template.Template, file storage.MediaFile) (string, error) {
+func buildTargetPath(b Backend, base string, tmpl *template.Template, file storage.MediaFile, form UnicodeForm) (string, error) {
 	timestamp := file.ModTime
 	if file.TakenAt.Valid {
 		timestamp = file.TakenAt.Time
@@ -255,6 +642,13 @@ func buildTargetPath(base string, tmpl *template.Template, file storage.MediaFil
 		Hash:         file.HashMD5,
 		OriginalName: filepath.Base(file.Path),
 		Ext:          strings.ToLower(filepath.Ext(file.Path)),
+		Category:     file.Category,
+		// Names are typed by the user, so slashes must not add folders.
+		Person: sanitizeSegment(file.Person),
+		Tags:   file.Tags,
+	}
+	if data.Person == "" {
+		data.Person = "Unknown"
 	}
 
 	var builder strings.Builder
@@ -264,36 +658,106 @@ func buildTargetPath(base string, tmpl *template.Template, file storage.MediaFil
 
 	relative := sanitizeRelative(builder.String())
 	if relative == "" {
-		relative = sanitizeSegment(data.OriginalName)
+		relative = shortenSegment(sanitizeSegment(data.OriginalName))
+	}
+	if err := checkSegments(relative); err != nil {
+		return "", err
 	}
+	relative = form.normalize(relative)
 
-	target := filepath.Join(base, relative)
-	target = filepath.Clean(target)
+	target := b.Join(base, relative)
 
-	baseClean := filepath.Clean(base)
+	baseClean := b.Join(base)
 	if !strings.HasPrefix(strings.ToLower(target), strings.ToLower(baseClean)) {
 		return "", fmt.Errorf("target path escapes base: %s", target)
 	}
 
-	dir := filepath.Dir(target)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := b.Mkdir(b.Dir(target)); err != nil {
 		return "", fmt.Errorf("create target dir: %w", err)
 	}
 
-	unique, err := ensureUnique(target)
+	return target, nil
+}
+
+// buildQuarantinePath places the file flat inside the quarantine folder.
+func buildQuarantinePath(base string, file storage.MediaFile) (string, error) {
+	name := shortenSegment(sanitizeSegment(filepath.Base(file.Path)))
+	if checkSegments(name) != nil {
+		name = "_" + name
+	}
+	if name == "" {
+		name = file.HashMD5
+	}
+
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return "", fmt.Errorf("create quarantine dir: %w", err)
+	}
+	return filepath.Join(filepath.Clean(base), name), nil
+}
+
+// inspectFile returns a non-empty reason when the file looks corrupt.
+func inspectFile(file storage.MediaFile, throttle *Throttle) string {
+	info, err := os.Stat(file.Path)
 	if err != nil {
-		return "", err
+		return fmt.Sprintf("unreadable: %v", err)
+	}
+	if info.Size() == 0 {
+		return "zero-byte file"
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Path)) {
+	case ".jpg", ".jpeg":
+		if brokenEXIF(file.Path) {
+			return "unreadable EXIF"
+		}
+	}
+
+	if file.HashMD5 != "" {
+		hash, err := hashFile(file.Path, throttle)
+		if err != nil {
+			return fmt.Sprintf("hash verify: %v", err)
+		}
+		if hash != file.HashMD5 {
+			return "hash mismatch on verify"
+		}
 	}
-	return unique, nil
+	return ""
 }
 
-func moveFile(src, dest string) error {
+// moveFile moves the local file src to dest on b. Remote backends always
+// copy; locally a rename is tried first.
+func moveFile(b Backend, src, dest string, safety SafetyLevel, expectedHash string, throttle *Throttle) error {
+	if !isLocal(b) {
+		return sendFile(b, src, dest, safety, expectedHash, throttle)
+	}
+	src, dest = longPath(src), longPath(dest)
 	if err := os.Rename(src, dest); err == nil {
+		if safety == SafetyParanoid {
+			return verifyTarget(dest, expectedHash, throttle)
+		}
 		return nil
 	} else if !isCrossDeviceError(err) {
 		return err
 	}
 
+	if err := copyFile(src, dest, safety == SafetyParanoid, throttle); err != nil {
+		return err
+	}
+
+	if safety != SafetyFast {
+		if err := verifyTarget(dest, expectedHash, throttle); err != nil {
+			_ = os.Remove(dest)
+			return err
+		}
+	}
+
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("remove source after copy: %w", err)
+	}
+	return nil
+}
+
+func copyFile(src, dest string, sync bool, throttle *Throttle) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -304,16 +768,30 @@ func moveFile(src, dest string) error {
 	if err != nil {
 		return err
 	}
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
+	if _, err := io.Copy(destFile, throttle.Reader(sourceFile)); err != nil {
 		destFile.Close()
 		return err
 	}
-	if err := destFile.Close(); err != nil {
-		return err
+	if sync {
+		if err := destFile.Sync(); err != nil {
+			destFile.Close()
+			return fmt.Errorf("fsync target: %w", err)
+		}
 	}
+	return destFile.Close()
+}
 
-	if err := os.Remove(src); err != nil {
-		return fmt.Errorf("remove source after copy: %w", err)
+// verifyTarget re-hashes dest and compares it to the hash recorded at scan time.
+func verifyTarget(dest, expectedHash string, throttle *Throttle) error {
+	if expectedHash == "" {
+		return nil
+	}
+	hash, err := hashFile(dest, throttle)
+	if err != nil {
+		return fmt.Errorf("verify target: %w", err)
+	}
+	if hash != expectedHash {
+		return fmt.Errorf("verify target: hash mismatch (%s != %s)", hash, expectedHash)
 	}
 	return nil
 }
@@ -325,29 +803,6 @@ func isCrossDeviceError(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "cross-device")
 }
 
-func ensureUnique(path string) (string, error) {
-	if _, err := os.Stat(path); err == nil {
-		// falls through to suffix strategy
-	} else if errors.Is(err, os.ErrNotExist) {
-		return path, nil
-	} else if err != nil {
-		return "", err
-	}
-
-	dir := filepath.Dir(path)
-	name := filepath.Base(path)
-	ext := filepath.Ext(name)
-	base := strings.TrimSuffix(name, ext)
-
-	for i := 1; i < 1000; i++ {
-		candidate := filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, i, ext))
-		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
-			return candidate, nil
-		}
-	}
-	return "", fmt.Errorf("unable to find unique name for %s", path)
-}
-
 func sanitizeRelative(path string) string {
 	segments := strings.FieldsFunc(path, func(r rune) bool {
 		return r == '/' || r == '\\'
@@ -355,7 +810,7 @@ func sanitizeRelative(path string) string {
 
 	sanitized := make([]string, 0, len(segments))
 	for _, segment := range segments {
-		s := sanitizeSegment(segment)
+		s := shortenSegment(sanitizeSegment(segment))
 		if s != "" {
 			sanitized = append(sanitized, s)
 		}
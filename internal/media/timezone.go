@@ -0,0 +1,55 @@
+package media
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// wallClock keeps the clock reading of t and drops its zone. Capture times
+// are stored this way so tidy files photos under the date the camera showed.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// placeInZone records the UTC instant of file's wall-clock TakenAt read in loc.
+func placeInZone(file *storage.MediaFile, loc *time.Location) {
+	if !file.TakenAt.Valid || loc == nil {
+		return
+	}
+	w := file.TakenAt.Time
+	local := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), loc)
+	_, offset := local.Zone()
+	file.TakenAtUTC = sql.NullTime{Time: local.UTC(), Valid: true}
+	file.UTCOffsetMinutes = sql.NullInt64{Int64: int64(offset / 60), Valid: true}
+}
+
+// CameraKey normalises a camera name for Options.CameraZones.
+func CameraKey(make, model string) string {
+	return strings.ToLower(strings.Join(strings.Fields(make+" "+model), " "))
+}
+
+// zoneFor picks the zone a camera's clock was set to: its own entry, matched
+// on make and model or model alone, then the scan default, then the zone of
+// this machine.
+func (o Options) zoneFor(make, model string) *time.Location {
+	for _, key := range []string{CameraKey(make, model), CameraKey("", model)} {
+		if loc, ok := o.CameraZones[key]; ok && key != "" {
+			return loc
+		}
+	}
+	if o.TimeZone != nil {
+		return o.TimeZone
+	}
+	return time.Local
+}
+
+// localise fills the UTC capture time of files whose metadata carried no zone.
+func localise(file *storage.MediaFile, opts Options) {
+	if !file.TakenAt.Valid || file.UTCOffsetMinutes.Valid {
+		return
+	}
+	placeInZone(file, opts.zoneFor(file.CameraMake.String, file.CameraModel.String))
+}
@@ -0,0 +1,57 @@
+package media
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ToolOff in a tool setting disables the tool even when it is installed.
+const ToolOff = "off"
+
+// toolProbeTimeout bounds the version query run while detecting a tool.
+const toolProbeTimeout = 5 * time.Second
+
+// ToolInfo reports whether an optional external program can be used.
+type ToolInfo struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Disabled  bool   `json:"disabled,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// locateTool resolves a tool from its setting: empty looks name up on PATH,
+// ToolOff disables it and anything else is the path of the executable. The
+// tool only counts as available once it answers versionArgs.
+func locateTool(name, setting string, versionArgs ...string) ToolInfo {
+	info := ToolInfo{Name: name}
+	setting = strings.TrimSpace(setting)
+	if strings.EqualFold(setting, ToolOff) {
+		info.Disabled = true
+		return info
+	}
+	if setting == "" {
+		setting = name
+	}
+
+	path, err := exec.LookPath(setting)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Path = path
+
+	ctx, cancel := context.WithTimeout(context.Background(), toolProbeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, versionArgs...).Output()
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Version = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	info.Available = true
+	return info
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+)
+
+// ValidationLevel selects how deeply a scan checks that images decode.
+type ValidationLevel string
+
+// Supported validation levels. The zero value checks nothing.
+const (
+	ValidateOff ValidationLevel = "off"
+	// ValidateHeaders decodes the image header: format, size and colour
+	// model, which catches files that are not images at all.
+	ValidateHeaders ValidationLevel = "headers"
+	// ValidateFull decodes every pixel, which also catches truncated files
+	// from interrupted copies.
+	ValidateFull ValidationLevel = "full"
+)
+
+// ParseValidationLevel validates a level name; empty means off.
+func ParseValidationLevel(name string) (ValidationLevel, error) {
+	switch ValidationLevel(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ValidateOff:
+		return "", nil
+	case ValidateHeaders:
+		return ValidateHeaders, nil
+	case ValidateFull:
+		return ValidateFull, nil
+	default:
+		return "", fmt.Errorf("unknown validation level %q", name)
+	}
+}
+
+// validatable lists the formats the built-in decoders can check.
+func validatable(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// validateImage decodes the image at path to the given level and returns
+// why it is corrupt, or "" when it decodes.
+func validateImage(path string, level ValidationLevel) string {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if level == ValidateFull {
+		_, _, err = image.Decode(f)
+	} else {
+		var cfg image.Config
+		cfg, _, err = image.DecodeConfig(f)
+		if err == nil && (cfg.Width == 0 || cfg.Height == 0) {
+			return "image has no pixels"
+		}
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
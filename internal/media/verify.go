@@ -0,0 +1,169 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// verifyBatch is how many files verification re-hashes before yielding to
+// other jobs.
+const verifyBatch = 50
+
+// Verifier re-hashes library files and compares them with the hashes
+// recorded by scans, to catch bit rot and files changed behind its back.
+type Verifier struct {
+	store *storage.Store
+}
+
+// NewVerifier constructs a Verifier.
+func NewVerifier(store *storage.Store) *Verifier {
+	return &Verifier{store: store}
+}
+
+// VerifyOptions configures a verification run.
+type VerifyOptions struct {
+	// Gate, when set, can pause the run between files.
+	Gate *PauseGate
+	// Throttle paces file reads; nil disables throttling.
+	Throttle *Throttle
+	// Acquire is held while a batch runs and released between batches, so
+	// scans and tidy runs, which move files, take precedence; nil runs
+	// batches back to back.
+	Acquire func(ctx context.Context) (release func(), err error)
+}
+
+// VerifyProgress reports one re-hashed file.
+type VerifyProgress struct {
+	MediaID   int64  `json:"mediaId"`
+	Path      string `json:"path"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+// VerifySummary summarises a verification run.
+type VerifySummary struct {
+	Checked    int `json:"checked"`
+	OK         int `json:"ok"`
+	Mismatched int `json:"mismatched"`
+	// Changed counts files whose content differs along with their size or
+	// time, i.e. that were edited rather than corrupted.
+	Changed int `json:"changed"`
+	Missing int `json:"missing"`
+	Failed  int `json:"failed"`
+	// Skipped counts files on remote targets, which are not read back, and
+	// files removed from the library during the run.
+	Skipped    int   `json:"skipped"`
+	DurationMS int64 `json:"durationMs"`
+}
+
+// Run re-hashes files, recording each outcome in verification_results.
+func (v *Verifier) Run(ctx context.Context, files []storage.MediaFile, opts VerifyOptions, onProgress func(VerifyProgress)) (summary VerifySummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	acquire := opts.Acquire
+	if acquire == nil {
+		acquire = func(context.Context) (func(), error) { return func() {}, nil }
+	}
+
+	for offset := 0; offset < len(files); offset += verifyBatch {
+		release, err := acquire(ctx)
+		if err != nil {
+			return summary, err
+		}
+		err = v.runBatch(ctx, files, offset, opts, &summary, onProgress)
+		release()
+		if err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func (v *Verifier) runBatch(ctx context.Context, files []storage.MediaFile, offset int, opts VerifyOptions, summary *VerifySummary, onProgress func(VerifyProgress)) error {
+	end := min(offset+verifyBatch, len(files))
+	// Rows are reloaded since a tidy run may have moved files while the
+	// lock was released.
+	ids := make([]int64, 0, end-offset)
+	for _, file := range files[offset:end] {
+		ids = append(ids, file.ID)
+	}
+	current, err := v.store.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := offset; i < end; i++ {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return err
+		}
+
+		file, ok := current[files[i].ID]
+		progress := VerifyProgress{MediaID: files[i].ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if !ok || IsRemote(file.Path) {
+			summary.Skipped++
+			progress.Status = "skipped"
+		} else {
+			result := verifyFile(file, opts.Throttle)
+			if err := v.store.RecordVerification(ctx, result); err != nil {
+				return err
+			}
+			summary.Checked++
+			switch result.Status {
+			case storage.VerifyOK:
+				summary.OK++
+			case storage.VerifyMismatch:
+				summary.Mismatched++
+			case storage.VerifyChanged:
+				summary.Changed++
+			case storage.VerifyMissing:
+				summary.Missing++
+			default:
+				summary.Failed++
+			}
+			progress.Status, progress.Error = result.Status, result.Error
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return nil
+}
+
+// verifyFile re-hashes one file against its recorded hash.
+func verifyFile(file storage.MediaFile, throttle *Throttle) storage.VerificationResult {
+	result := storage.VerificationResult{MediaID: file.ID, Path: file.Path, ExpectedHash: file.HashMD5}
+	info, err := os.Stat(longPath(file.Path))
+	var hash string
+	if err == nil {
+		hash, err = hashFile(file.Path, throttle)
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		result.Status = storage.VerifyMissing
+	case err != nil:
+		result.Status = storage.VerifyError
+		result.Error = err.Error()
+	case hash != file.HashMD5 && (info.Size() != file.SizeBytes || info.ModTime().Unix() != file.ModTime.Unix()):
+		result.Status = storage.VerifyChanged
+		result.ActualHash = hash
+	case hash != file.HashMD5:
+		// Same size and time but other bytes: the storage altered the file.
+		result.Status = storage.VerifyMismatch
+		result.ActualHash = hash
+	default:
+		result.Status = storage.VerifyOK
+		result.ActualHash = hash
+	}
+	return result
+}
@@ -0,0 +1,284 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// videoFrames is how many frames a video fingerprint samples.
+const videoFrames = 8
+
+// CanFingerprint reports whether ffmpeg was found for Fingerprint.
+func (f *FFprobe) CanFingerprint() bool {
+	return f.CanRenderPosters()
+}
+
+// Fingerprint samples videoFrames frames at even fractions of the video at
+// path and hashes each like PerceptualHash. Re-encoded, re-compressed or
+// resized copies keep their duration and frames, so they fingerprint alike
+// even though their bytes differ.
+func (f *FFprobe) Fingerprint(ctx context.Context, path string) (storage.VideoFingerprint, error) {
+	if !f.CanFingerprint() {
+		return storage.VideoFingerprint{}, errors.New("video fingerprints need ffmpeg")
+	}
+	info, err := f.Probe(ctx, path)
+	if err != nil {
+		return storage.VideoFingerprint{}, err
+	}
+	if info.DurationSeconds <= 0 {
+		return storage.VideoFingerprint{}, fmt.Errorf("%s has no duration", path)
+	}
+
+	fp := storage.VideoFingerprint{DurationSeconds: info.DurationSeconds}
+	for i := 0; i < videoFrames; i++ {
+		at := info.DurationSeconds * (float64(i) + 0.5) / videoFrames
+		frame, err := f.grayFrame(ctx, path, at)
+		if err != nil {
+			return storage.VideoFingerprint{}, err
+		}
+		var lum [phashSize][phashSize]float64
+		for y := 0; y < phashSize; y++ {
+			for x := 0; x < phashSize; x++ {
+				lum[y][x] = float64(frame[y*phashSize+x])
+			}
+		}
+		fp.Frames = append(fp.Frames, lumaHash(&lum))
+	}
+	return fp, nil
+}
+
+// grayFrame decodes the frame at seconds into the video as a phashSize
+// square of 8-bit luma, rotated as the video is displayed.
+func (f *FFprobe) grayFrame(ctx context.Context, path string, seconds float64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ffprobeTimeout)
+	defer cancel()
+	size := strconv.Itoa(phashSize)
+	cmd := exec.CommandContext(ctx, f.ffmpeg,
+		"-v", "error", "-ss", strconv.FormatFloat(seconds, 'f', 3, 64), "-i", path,
+		"-frames:v", "1", "-vf", "scale="+size+":"+size+":flags=area,format=gray", "-f", "rawvideo", "-",
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(out) < phashSize*phashSize {
+		return nil, fmt.Errorf("ffmpeg rendered no frame of %s at %.1fs", path, seconds)
+	}
+	return out, nil
+}
+
+// videoDistance compares two fingerprints: the mean Hamming distance of
+// their frames, or -1 when the durations differ by more than a second and
+// 2%, as no re-encode changes the length that much.
+func videoDistance(a, b storage.VideoFingerprint) int {
+	diff := a.DurationSeconds - b.DurationSeconds
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > max(1, 0.02*max(a.DurationSeconds, b.DurationSeconds)) {
+		return -1
+	}
+	if len(a.Frames) != len(b.Frames) || len(a.Frames) == 0 {
+		return -1
+	}
+	total := 0
+	for i := range a.Frames {
+		total += bits.OnesCount64(a.Frames[i] ^ b.Frames[i])
+	}
+	return (total + len(a.Frames)/2) / len(a.Frames)
+}
+
+// FingerprintVideos fingerprints videos without a fingerprint of their
+// current content, reporting progress like HashImages.
+func FingerprintVideos(ctx context.Context, store *storage.Store, probe *FFprobe, opts PHashOptions, onProgress func(PHashProgress)) (summary PHashSummary, err error) {
+	start := time.Now()
+	defer func() { summary.DurationMS = time.Since(start).Milliseconds() }()
+
+	files, err := store.ListVideoFingerprintCandidates(ctx, opts.Limit)
+	if err != nil {
+		return summary, err
+	}
+	for i, file := range files {
+		if err := opts.Gate.Wait(ctx); err != nil {
+			return summary, err
+		}
+		if err := opts.Throttle.Between(ctx); err != nil {
+			return summary, err
+		}
+		progress := PHashProgress{MediaID: file.ID, Path: file.Path, Completed: i + 1, Total: len(files)}
+		if IsRemote(file.Path) || IsArchivePath(file.Path) {
+			summary.Skipped++
+			progress.Error = "not a local file"
+		} else if fp, err := probe.Fingerprint(ctx, file.Path); err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", file.Path, err))
+			progress.Error = err.Error()
+		} else {
+			fp.MediaID = file.ID
+			if err := store.SaveVideoFingerprint(ctx, file.HashMD5, fp); err != nil {
+				return summary, err
+			}
+			summary.Hashed++
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	return summary, nil
+}
+
+// findSimilarVideos is FindSimilar for a video. The fingerprints come
+// sorted by duration, so the comparison stops at the first one too long to
+// match.
+func findSimilarVideos(ctx context.Context, store *storage.Store, probe *FFprobe, origin storage.MediaFile, maxDistance, limit int) ([]SimilarImage, error) {
+	prints, err := store.ListVideoFingerprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var self *storage.VideoFingerprint
+	for i := range prints {
+		if prints[i].MediaID == origin.ID {
+			self = &prints[i]
+			break
+		}
+	}
+	if self == nil {
+		if IsRemote(origin.Path) || IsArchivePath(origin.Path) {
+			return nil, errors.New("the video is not a local file and has no fingerprint")
+		}
+		if probe == nil {
+			return nil, errors.New("video fingerprints need ffprobe and ffmpeg")
+		}
+		fp, err := probe.Fingerprint(ctx, origin.Path)
+		if err != nil {
+			return nil, err
+		}
+		fp.MediaID = origin.ID
+		if err := store.SaveVideoFingerprint(ctx, origin.HashMD5, fp); err != nil {
+			return nil, err
+		}
+		self = &fp
+	}
+
+	var matches []BKMatch
+	for _, other := range prints {
+		if other.DurationSeconds > self.DurationSeconds+max(1, 0.02*other.DurationSeconds) {
+			break
+		}
+		if other.MediaID == self.MediaID {
+			continue
+		}
+		if d := videoDistance(*self, other); d >= 0 && d <= maxDistance {
+			matches = append(matches, BKMatch{ID: other.MediaID, Distance: d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return similarFiles(ctx, store, origin, matches, limit)
+}
+
+// SimilarVideoGroup is a set of videos that fingerprint alike, e.g. an
+// original and the copies a messenger re-compressed.
+type SimilarVideoGroup struct {
+	Files []storage.MediaFile `json:"files"`
+	// Distance is the largest fingerprint distance that joined the group.
+	Distance int `json:"distance"`
+}
+
+// GroupSimilarVideos groups the fingerprinted videos whose fingerprints lie
+// at most maxDistance bits apart, directly or through other members, and
+// that are not all exact copies of one another. Groups come largest first.
+func GroupSimilarVideos(ctx context.Context, store *storage.Store, maxDistance int) ([]SimilarVideoGroup, error) {
+	if maxDistance <= 0 {
+		maxDistance = DefaultSimilarDistance
+	}
+	prints, err := store.ListVideoFingerprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := make([]int, len(prints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	worst := make(map[int]int)
+	for i := range prints {
+		for j := i + 1; j < len(prints); j++ {
+			if prints[j].DurationSeconds > prints[i].DurationSeconds+max(1, 0.02*prints[j].DurationSeconds) {
+				break
+			}
+			d := videoDistance(prints[i], prints[j])
+			if d < 0 || d > maxDistance {
+				continue
+			}
+			a, b := find(i), find(j)
+			w := max(d, worst[a], worst[b])
+			if a != b {
+				parent[b] = a
+			}
+			worst[a] = w
+		}
+	}
+
+	members := make(map[int][]int64)
+	var ids []int64
+	for i, fp := range prints {
+		root := find(i)
+		members[root] = append(members[root], fp.MediaID)
+		ids = append(ids, fp.MediaID)
+	}
+	byID, err := store.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []SimilarVideoGroup{}
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		g := SimilarVideoGroup{Distance: worst[root]}
+		hashes := make(map[string]bool)
+		for _, id := range group {
+			if file, ok := byID[id]; ok {
+				g.Files = append(g.Files, file)
+				hashes[file.HashMD5] = true
+			}
+		}
+		if len(hashes) > 1 {
+			groups = append(groups, g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Files) != len(groups[j].Files) {
+			return len(groups[i].Files) > len(groups[j].Files)
+		}
+		return groups[i].Files[0].ID < groups[j].Files[0].ID
+	})
+	return groups, nil
+}
@@ -0,0 +1,347 @@
+package media
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webdavTimeout bounds metadata requests; transfers are bounded by the
+// throttle and the server instead.
+const webdavTimeout = time.Minute
+
+// propfindBody asks for the properties Stat and List read.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// WebDAV is a Backend on a WebDAV server such as Nextcloud or a NAS. Its
+// paths are the configured root URL followed by unescaped, slash-separated
+// names, e.g. "https://nas/dav/Photos/2024-05-01/IMG 1.jpg".
+type WebDAV struct {
+	root    string
+	rootURL *url.URL
+	creds   BackendCredentials
+	client  *http.Client
+
+	// made remembers folders known to exist, saving a request per file.
+	mu   sync.Mutex
+	made map[string]bool
+}
+
+// NewWebDAV returns a backend for the folder at root, an http(s) URL.
+func NewWebDAV(root string, creds BackendCredentials) (*WebDAV, error) {
+	root = strings.TrimRight(root, "/")
+	u, err := url.Parse(root)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid WebDAV URL %q", root)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("put WebDAV credentials in the target settings, not the URL")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("WebDAV URL %q must not have a query", root)
+	}
+	return &WebDAV{
+		root:    root,
+		rootURL: u,
+		creds:   creds,
+		client:  &http.Client{},
+		made:    make(map[string]bool),
+	}, nil
+}
+
+// rel returns path relative to the root, starting with a slash, or "" for
+// the root itself.
+func (w *WebDAV) rel(p string) (string, error) {
+	if p != w.root && !strings.HasPrefix(p, w.root+"/") {
+		return "", fmt.Errorf("%s is outside WebDAV root %s", p, w.root)
+	}
+	return strings.TrimPrefix(p, w.root), nil
+}
+
+// url escapes path into the URL requests are sent to.
+func (w *WebDAV) url(p string) (string, error) {
+	rel, err := w.rel(p)
+	if err != nil {
+		return "", err
+	}
+	u := *w.rootURL
+	escaped := u.EscapedPath()
+	for _, segment := range strings.Split(strings.TrimPrefix(rel, "/"), "/") {
+		if segment != "" {
+			escaped += "/" + url.PathEscape(segment)
+		}
+	}
+	u.Path, u.RawPath = w.rootURL.Path+rel, escaped
+	return u.String(), nil
+}
+
+func (w *WebDAV) do(ctx context.Context, method, p string, body io.Reader, header http.Header) (*http.Response, error) {
+	target, err := w.url(p)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if w.creds.Username != "" || w.creds.Password != "" {
+		req.SetBasicAuth(w.creds.Username, w.creds.Password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webdav %s %s: %w", method, p, err)
+	}
+	return resp, nil
+}
+
+// check turns an unexpected status into an error for op on p, closing the
+// response. 404 and 412 map onto fs.ErrNotExist and fs.ErrExist.
+func (w *WebDAV) check(resp *http.Response, op, p string, ok ...int) error {
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+	case http.StatusPreconditionFailed:
+		return &fs.PathError{Op: op, Path: p, Err: fs.ErrExist}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &fs.PathError{Op: op, Path: p, Err: fmt.Errorf("%w: %s", fs.ErrPermission, resp.Status)}
+	}
+	return &fs.PathError{Op: op, Path: p, Err: fmt.Errorf("webdav: %s", resp.Status)}
+}
+
+// davResponse is one entry of a PROPFIND multistatus reply.
+type davResponse struct {
+	Href     string `xml:"href"`
+	Propstat []struct {
+		Status string `xml:"status"`
+		Prop   struct {
+			ResourceType struct {
+				Collection *struct{} `xml:"collection"`
+			} `xml:"resourcetype"`
+			ContentLength string `xml:"getcontentlength"`
+			LastModified  string `xml:"getlastmodified"`
+		} `xml:"prop"`
+	} `xml:"propstat"`
+}
+
+func (w *WebDAV) propfind(p, depth string) ([]davResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webdavTimeout)
+	defer cancel()
+	resp, err := w.do(ctx, "PROPFIND", p, strings.NewReader(propfindBody), http.Header{
+		"Depth":        {depth},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := w.check(resp, "propfind", p, http.StatusMultiStatus); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Responses []davResponse `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("webdav propfind %s: %w", p, err)
+	}
+	return result.Responses, nil
+}
+
+// remoteInfo is the fs.FileInfo of an entry on a remote backend.
+type remoteInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i remoteInfo) Name() string       { return i.name }
+func (i remoteInfo) Size() int64        { return i.size }
+func (i remoteInfo) ModTime() time.Time { return i.modTime }
+func (i remoteInfo) IsDir() bool        { return i.dir }
+func (i remoteInfo) Sys() interface{}   { return nil }
+func (i remoteInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func infoOf(r davResponse) remoteInfo {
+	href, err := url.PathUnescape(r.Href)
+	if err != nil {
+		href = r.Href
+	}
+	if u, err := url.Parse(href); err == nil && u.Host != "" {
+		href = u.Path
+	}
+	info := remoteInfo{name: path.Base(strings.TrimRight(href, "/"))}
+	for _, ps := range r.Propstat {
+		if !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+		info.dir = ps.Prop.ResourceType.Collection != nil
+		info.size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+		info.modTime, _ = http.ParseTime(ps.Prop.LastModified)
+	}
+	return info
+}
+
+func (w *WebDAV) Stat(p string) (fs.FileInfo, error) {
+	responses, err := w.propfind(p, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+	}
+	info := infoOf(responses[0])
+	info.name = path.Base(p)
+	return info, nil
+}
+
+func (w *WebDAV) List(dir string) ([]string, error) {
+	responses, err := w.propfind(dir, "1")
+	if err != nil {
+		return nil, err
+	}
+	self := path.Base(dir)
+	names := make([]string, 0, len(responses))
+	for i, r := range responses {
+		// The folder itself comes first.
+		if i == 0 && infoOf(r).name == self {
+			continue
+		}
+		names = append(names, infoOf(r).name)
+	}
+	return names, nil
+}
+
+func (w *WebDAV) Mkdir(p string) error {
+	rel, err := w.rel(p)
+	if err != nil {
+		return err
+	}
+	// The root itself is created too, as a local target folder would be.
+	current := w.root
+	if err := w.mkcol(current); err != nil {
+		return err
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(rel, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		current += "/" + segment
+		if err := w.mkcol(current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkcol creates one folder; an existing one is fine.
+func (w *WebDAV) mkcol(p string) error {
+	w.mu.Lock()
+	made := w.made[p]
+	w.mu.Unlock()
+	if made {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webdavTimeout)
+	defer cancel()
+	resp, err := w.do(ctx, "MKCOL", p, nil, nil)
+	if err != nil {
+		return err
+	}
+	// 405 means the folder already exists.
+	if err := w.check(resp, "mkdir", p, http.StatusCreated, http.StatusMethodNotAllowed); err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	w.mu.Lock()
+	w.made[p] = true
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *WebDAV) Copy(src io.Reader, dest string) error {
+	resp, err := w.do(context.Background(), http.MethodPut, dest, src, http.Header{"If-None-Match": {"*"}})
+	if err != nil {
+		return err
+	}
+	if err := w.check(resp, "copy", dest, http.StatusCreated, http.StatusNoContent, http.StatusOK); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (w *WebDAV) Rename(src, dest string) error {
+	destURL, err := w.url(dest)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webdavTimeout)
+	defer cancel()
+	resp, err := w.do(ctx, "MOVE", src, nil, http.Header{"Destination": {destURL}, "Overwrite": {"F"}})
+	if err != nil {
+		return err
+	}
+	if err := w.check(resp, "rename", src, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (w *WebDAV) Open(p string) (io.ReadCloser, error) {
+	resp, err := w.do(context.Background(), http.MethodGet, p, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.check(resp, "open", p, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (w *WebDAV) Remove(p string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webdavTimeout)
+	defer cancel()
+	resp, err := w.do(ctx, http.MethodDelete, p, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := w.check(resp, "remove", p, http.StatusNoContent, http.StatusOK); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (w *WebDAV) Join(dir string, elem ...string) string {
+	return joinSlash(w.root, dir, elem...)
+}
+
+func (w *WebDAV) Dir(p string) string {
+	return dirSlash(w.root, p)
+}
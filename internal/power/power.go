@@ -0,0 +1,13 @@
+// Package power reports the machine's power source so long jobs can pause
+// while running on battery.
+package power
+
+import "errors"
+
+// ErrUnsupported is returned on platforms without power source detection.
+var ErrUnsupported = errors.New("power source detection is not supported on this platform")
+
+// OnBattery reports whether the machine is currently running on battery.
+func OnBattery() (bool, error) {
+	return onBattery()
+}
@@ -0,0 +1,14 @@
+package power
+
+import (
+	"os/exec"
+	"strings"
+)
+
+func onBattery() (bool, error) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(out), "'Battery Power'"), nil
+}
@@ -0,0 +1,32 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func onBattery() (bool, error) {
+	supplies, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil || len(supplies) == 0 {
+		return false, ErrUnsupported
+	}
+
+	sawMains := false
+	for _, supply := range supplies {
+		kind, err := os.ReadFile(filepath.Join(supply, "type"))
+		if err != nil || strings.TrimSpace(string(kind)) != "Mains" {
+			continue
+		}
+		sawMains = true
+		online, err := os.ReadFile(filepath.Join(supply, "online"))
+		if err == nil && strings.TrimSpace(string(online)) == "1" {
+			return false, nil
+		}
+	}
+	if !sawMains {
+		// Desktops without a mains entry are never on battery.
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package power
+
+func onBattery() (bool, error) {
+	return false, ErrUnsupported
+}
@@ -0,0 +1,28 @@
+package power
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// systemPowerStatus mirrors SYSTEM_POWER_STATUS from winbase.h.
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+var procGetSystemPowerStatus = syscall.NewLazyDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+func onBattery() (bool, error) {
+	var status systemPowerStatus
+	ret, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return false, err
+	}
+	// 0 = offline (battery), 1 = online, 255 = unknown.
+	return status.ACLineStatus == 0, nil
+}
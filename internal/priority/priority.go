@@ -0,0 +1,21 @@
+// Package priority lowers the scheduling priority of the process and samples
+// how busy the machine is, so background jobs can give way to the user.
+package priority
+
+import "errors"
+
+// ErrUnsupported is returned on platforms without priority control or load
+// sampling.
+var ErrUnsupported = errors.New("process priority is not supported on this platform")
+
+// SetBackground moves the process to background CPU and IO priority, or
+// back to normal priority.
+func SetBackground(on bool) error {
+	return setBackground(on)
+}
+
+// Load reports how busy the machine is: roughly the fraction of its CPUs in
+// use, where 1 means fully loaded and more means work is queueing.
+func Load() (float64, error) {
+	return load()
+}
@@ -0,0 +1,41 @@
+package priority
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Darwin background policy from sys/resource.h: throttled CPU and IO.
+const (
+	prioDarwinProcess = 4
+	prioDarwinBG      = 0x1000
+)
+
+func setBackground(on bool) error {
+	prio := 0
+	if on {
+		prio = prioDarwinBG
+	}
+	return syscall.Setpriority(prioDarwinProcess, 0, prio)
+}
+
+func load() (float64, error) {
+	out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+	if err != nil {
+		return 0, err
+	}
+	// The output reads "{ 1.23 1.45 1.67 }".
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(string(out)), "{}"))
+	if len(fields) == 0 {
+		return 0, ErrUnsupported
+	}
+	avg, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse load average: %w", err)
+	}
+	return avg / float64(runtime.NumCPU()), nil
+}
@@ -0,0 +1,69 @@
+package priority
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// I/O scheduling classes from linux/ioprio.h.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassIdle  = 3
+)
+
+// backgroundNice is the nice value of background mode.
+const backgroundNice = 10
+
+// Nice values and IO priorities are per thread on Linux, so every thread of
+// the process is changed; threads started later inherit from their creator.
+func setBackground(on bool) error {
+	nice, ioprio := 0, 0
+	if on {
+		nice, ioprio = backgroundNice, ioprioClassIdle<<ioprioClassShift
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil {
+			// Raising the priority again needs CAP_SYS_NICE or a suitable
+			// RLIMIT_NICE, which most desktop sessions lack.
+			if first == nil {
+				first = fmt.Errorf("set nice: %w", err)
+			}
+		}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio)); errno != 0 {
+			if first == nil {
+				first = fmt.Errorf("set io priority: %w", errno)
+			}
+		}
+	}
+	return first
+}
+
+func load() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, ErrUnsupported
+	}
+	avg, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse load average: %w", err)
+	}
+	return avg / float64(runtime.NumCPU()), nil
+}
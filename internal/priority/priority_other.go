@@ -0,0 +1,11 @@
+//go:build !linux && !windows && !darwin
+
+package priority
+
+func setBackground(on bool) error {
+	return ErrUnsupported
+}
+
+func load() (float64, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,62 @@
+package priority
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Priority classes from winbase.h. Background mode lowers CPU, IO and
+// memory priority and may only be applied to the current process.
+const (
+	processModeBackgroundBegin = 0x00100000
+	processModeBackgroundEnd   = 0x00200000
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procSetPriorityClass = kernel32.NewProc("SetPriorityClass")
+	procGetSystemTimes   = kernel32.NewProc("GetSystemTimes")
+)
+
+// The previous sample of load; Load is meant to be polled by one goroutine.
+var (
+	lastIdle, lastTotal uint64
+	lastSample          time.Time
+)
+
+func setBackground(on bool) error {
+	mode := processModeBackgroundEnd
+	if on {
+		mode = processModeBackgroundBegin
+	}
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return err
+	}
+	if ret, _, err := procSetPriorityClass.Call(uintptr(process), uintptr(mode)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// load measures CPU use since the previous call, since Windows keeps no
+// load average.
+func load() (float64, error) {
+	var idle, kernel, user syscall.Filetime
+	ret, _, err := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)), uintptr(unsafe.Pointer(&kernel)), uintptr(unsafe.Pointer(&user)))
+	if ret == 0 {
+		return 0, err
+	}
+	ticks := func(ft syscall.Filetime) uint64 { return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime) }
+	// Kernel time includes idle time.
+	nowIdle, nowTotal := ticks(idle), ticks(kernel)+ticks(user)
+	dIdle, dTotal := nowIdle-lastIdle, nowTotal-lastTotal
+	first := lastSample.IsZero()
+	lastIdle, lastTotal, lastSample = nowIdle, nowTotal, time.Now()
+	if first || dTotal == 0 {
+		return 0, nil
+	}
+	return 1 - float64(dIdle)/float64(dTotal), nil
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import "fmt"
+
+// The messages of phototidy.proto, encoded and decoded by hand since the
+// service is small; field numbers must match the .proto file.
+
+// ScanRequest asks for a scan.
+type ScanRequest struct {
+	Sources     []string
+	Incremental bool
+}
+
+// Unmarshal decodes the request from b.
+func (m *ScanRequest) Unmarshal(b []byte) error {
+	return readFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Sources = append(m.Sources, string(f.bytes))
+		case 2:
+			m.Incremental = f.varint != 0
+		}
+		return nil
+	})
+}
+
+// TidyRequest asks for a tidy run.
+type TidyRequest struct {
+	MediaIDs []int64
+	DryRun   bool
+	Safety   string
+}
+
+// Unmarshal decodes the request from b.
+func (m *TidyRequest) Unmarshal(b []byte) error {
+	return readFields(b, func(f field) (err error) {
+		switch f.num {
+		case 1:
+			m.MediaIDs, err = readVarints(m.MediaIDs, f)
+		case 2:
+			m.DryRun = f.varint != 0
+		case 3:
+			m.Safety = string(f.bytes)
+		}
+		return err
+	})
+}
+
+// Event is one event of a job, its payload as JSON.
+type Event struct {
+	Type     string
+	Version  int32
+	JobID    string
+	Sequence uint64
+	Payload  []byte
+}
+
+// Marshal encodes the event.
+func (m Event) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.Type)
+	b = appendInt(b, 2, int64(m.Version))
+	b = appendString(b, 3, m.JobID)
+	b = appendUint(b, 4, m.Sequence)
+	if len(m.Payload) > 0 {
+		b = appendBytes(b, 5, m.Payload)
+	}
+	return b
+}
+
+// CancelScanResponse reports whether a scan was stopped.
+type CancelScanResponse struct {
+	Cancelled bool
+}
+
+// Marshal encodes the response.
+func (m CancelScanResponse) Marshal() []byte {
+	return appendBool(nil, 1, m.Cancelled)
+}
+
+// SearchRequest asks for a page of search results.
+type SearchRequest struct {
+	Query  string
+	Limit  int32
+	Offset int32
+}
+
+// Unmarshal decodes the request from b.
+func (m *SearchRequest) Unmarshal(b []byte) error {
+	return readFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Query = string(f.bytes)
+		case 2:
+			m.Limit = int32(f.varint)
+		case 3:
+			m.Offset = int32(f.varint)
+		}
+		return nil
+	})
+}
+
+// SearchResponse is a page of search results.
+type SearchResponse struct {
+	Media []Media
+	Total int32
+}
+
+// Marshal encodes the response.
+func (m SearchResponse) Marshal() []byte {
+	var b []byte
+	for _, media := range m.Media {
+		b = appendBytes(b, 1, media.Marshal())
+	}
+	return appendInt(b, 2, int64(m.Total))
+}
+
+// Media is a library file.
+type Media struct {
+	ID          int64
+	Path        string
+	HashMD5     string
+	SizeBytes   int64
+	TakenAt     string
+	CameraMake  string
+	CameraModel string
+	MimeType    string
+	Category    string
+	Tags        []string
+}
+
+// Marshal encodes the file.
+func (m Media) Marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, m.ID)
+	b = appendString(b, 2, m.Path)
+	b = appendString(b, 3, m.HashMD5)
+	b = appendInt(b, 4, m.SizeBytes)
+	b = appendString(b, 5, m.TakenAt)
+	b = appendString(b, 6, m.CameraMake)
+	b = appendString(b, 7, m.CameraModel)
+	b = appendString(b, 8, m.MimeType)
+	b = appendString(b, 9, m.Category)
+	for _, tag := range m.Tags {
+		b = appendBytes(b, 10, []byte(tag))
+	}
+	return b
+}
+
+// Status reports what the app is doing.
+type Status struct {
+	Busy         bool
+	PauseReasons []string
+}
+
+// Marshal encodes the status.
+func (m Status) Marshal() []byte {
+	b := appendBool(nil, 1, m.Busy)
+	for _, reason := range m.PauseReasons {
+		b = appendBytes(b, 2, []byte(reason))
+	}
+	return b
+}
+
+// Empty decodes requests without fields, such as StatusRequest; fields a
+// newer client sends are ignored, as for every message.
+func Empty(b []byte) error {
+	if err := readFields(b, func(field) error { return nil }); err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+	return nil
+}
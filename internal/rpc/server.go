@@ -0,0 +1,217 @@
+// Package rpc serves the gRPC service described in phototidy.proto, so home
+// automation and scripts can drive scans and tidy runs. It speaks gRPC over
+// cleartext HTTP/2 with net/http and encodes the few messages by hand, which
+// keeps protoc and the gRPC runtime out of the build.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServicePath prefixes the path of every method of the service.
+const ServicePath = "/phototidy.v1.PhotoTidy/"
+
+// maxRequest bounds the size of a request message.
+const maxRequest = 4 << 20
+
+// Code is a gRPC status code.
+type Code int
+
+// The status codes the service returns.
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	FailedPrecondition Code = 9
+	Unimplemented      Code = 12
+	Unavailable        Code = 14
+	Unauthenticated    Code = 16
+)
+
+// Error is a failure reported to the client with its status code.
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf builds an Error.
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler serves one method. req is the encoded request message and send
+// writes an encoded response message; unary methods send exactly one.
+type Handler func(ctx context.Context, req []byte, send func([]byte) error) error
+
+// Server routes calls to the handlers of the service's methods.
+type Server struct {
+	token   string
+	methods map[string]Handler
+
+	mu   sync.Mutex
+	http *http.Server
+}
+
+// NewServer creates a server. When token is set, calls must carry it as
+// "authorization: Bearer <token>" metadata.
+func NewServer(token string) *Server {
+	return &Server{token: token, methods: make(map[string]Handler)}
+}
+
+// Handle registers h for the method of the service with the given name.
+func (s *Server) Handle(method string, h Handler) {
+	s.methods[ServicePath+method] = h
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := &http.Server{Handler: s, Protocols: &protocols, ReadHeaderTimeout: 10 * time.Second}
+	s.mu.Lock()
+	s.http = srv
+	s.mu.Unlock()
+	err := srv.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops the server, cancelling the calls in progress.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.http == nil {
+		return nil
+	}
+	return s.http.Close()
+}
+
+// ServeHTTP implements http.Handler for one call.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	finish(w, s.call(w, r))
+}
+
+func (s *Server) call(w http.ResponseWriter, r *http.Request) error {
+	h, ok := s.methods[r.URL.Path]
+	if !ok {
+		return Errorf(Unimplemented, "unknown method %s", r.URL.Path)
+	}
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) != 1 {
+		return Errorf(Unauthenticated, "missing or wrong token")
+	}
+	ctx := r.Context()
+	if timeout, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := readMessage(r.Body)
+	if err != nil {
+		return err
+	}
+
+	rc := http.NewResponseController(w)
+	return h(ctx, req, func(msg []byte) error {
+		frame := make([]byte, 5, 5+len(msg))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+		if _, err := w.Write(append(frame, msg...)); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+}
+
+// readMessage reads the single request message of a call.
+func readMessage(body io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		return nil, Errorf(InvalidArgument, "read request: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, Errorf(Unimplemented, "compressed requests are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxRequest {
+		return nil, Errorf(InvalidArgument, "request of %d bytes is too large", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		return nil, Errorf(InvalidArgument, "read request: %v", err)
+	}
+	return msg, nil
+}
+
+// finish ends the call with the status err maps to in the trailers.
+func finish(w http.ResponseWriter, err error) {
+	code, msg := OK, ""
+	var rpcErr *Error
+	switch {
+	case err == nil:
+	case errors.As(err, &rpcErr):
+		code, msg = rpcErr.Code, rpcErr.Message
+	case errors.Is(err, context.Canceled):
+		code, msg = Canceled, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		code, msg = DeadlineExceeded, err.Error()
+	default:
+		code, msg = Unknown, err.Error()
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(code)))
+	if msg != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", percentEncode(msg))
+	}
+}
+
+// parseTimeout reads a grpc-timeout header such as "30S" or "500m".
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// percentEncode escapes a status message as gRPC requires: bytes outside
+// printable ASCII and '%' itself.
+func percentEncode(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Protocol buffer wire types used by the service's messages.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+func appendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+func appendTag(b []byte, num, typ int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// appendUint, appendString and appendBool leave out zero values, as proto3
+// encoders do for scalar fields.
+func appendUint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendVarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendInt(b []byte, num int, v int64) []byte {
+	return appendUint(b, num, uint64(v))
+}
+
+func appendBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendUint(b, num, 1)
+}
+
+func appendString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendBytes(b, num, []byte(s))
+}
+
+// appendBytes writes a length-delimited field even when empty, which
+// repeated strings and embedded messages need.
+func appendBytes(b []byte, num int, v []byte) []byte {
+	b = appendTag(b, num, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// field is one decoded field: varint holds varint and fixed-size values,
+// bytes length-delimited ones.
+type field struct {
+	num    int
+	typ    int
+	varint uint64
+	bytes  []byte
+}
+
+// readFields calls visit for every field of the encoded message b.
+func readFields(b []byte, visit func(field) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		f := field{num: int(tag >> 3), typ: int(tag & 7)}
+		switch f.typ {
+		case wireVarint:
+			if f.varint, n = binary.Uvarint(b); n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errTruncated
+			}
+			f.varint, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errTruncated
+			}
+			f.varint, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errTruncated
+			}
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return errors.New("unsupported wire type")
+		}
+		if err := visit(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readVarints appends the values of a repeated integer field, which senders
+// may pack into one length-delimited field or write one by one.
+func readVarints(dst []int64, f field) ([]int64, error) {
+	if f.typ != wireBytes {
+		return append(dst, int64(f.varint)), nil
+	}
+	b := f.bytes
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return dst, errTruncated
+		}
+		dst, b = append(dst, int64(v)), b[n:]
+	}
+	return dst, nil
+}
@@ -0,0 +1,61 @@
+// Package schedule parses the timing rules for automatic background jobs.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Spec describes when a job runs: either every Interval, or once a day at
+// the given wall-clock time.
+type Spec struct {
+	Interval time.Duration
+	Hour     int
+	Minute   int
+	daily    bool
+}
+
+// Parse accepts a Go duration ("6h", "90m") or a daily time ("03:30",
+// "daily 03:30"). An empty string yields a zero Spec that never fires.
+func Parse(value string) (Spec, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" || value == "off" {
+		return Spec{}, nil
+	}
+
+	clock := strings.TrimSpace(strings.TrimPrefix(value, "daily"))
+	if strings.Contains(clock, ":") {
+		t, err := time.Parse("15:04", clock)
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid daily time %q: use HH:MM", value)
+		}
+		return Spec{Hour: t.Hour(), Minute: t.Minute(), daily: true}, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return Spec{}, fmt.Errorf("invalid schedule %q: use a duration like 6h or a time like 03:30", value)
+	}
+	if interval < time.Minute {
+		return Spec{}, fmt.Errorf("schedule interval %s is shorter than one minute", interval)
+	}
+	return Spec{Interval: interval}, nil
+}
+
+// Enabled reports whether the spec ever fires.
+func (s Spec) Enabled() bool {
+	return s.daily || s.Interval > 0
+}
+
+// Next returns the first firing time strictly after from.
+func (s Spec) Next(from time.Time) time.Time {
+	if s.daily {
+		next := time.Date(from.Year(), from.Month(), from.Day(), s.Hour, s.Minute, 0, 0, from.Location())
+		if !next.After(from) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+	return from.Add(s.Interval)
+}
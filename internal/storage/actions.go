@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ActionFilter narrows ListActions results. Zero values match everything.
+// From and To bound created_at and accept RFC 3339 timestamps or YYYY-MM-DD dates.
+type ActionFilter struct {
+	Status     string `json:"status"`
+	ActionType string `json:"actionType"`
+	RunID      int64  `json:"runId"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// Page selects a window of results.
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// ActionRecord is the UI-facing view of a file_actions row.
+type ActionRecord struct {
+	ID         int64  `json:"id"`
+	MediaID    int64  `json:"mediaId,omitempty"`
+	RunID      int64  `json:"runId,omitempty"`
+	RetryOf    int64  `json:"retryOf,omitempty"`
+	SourcePath string `json:"sourcePath"`
+	TargetPath string `json:"targetPath,omitempty"`
+	ActionType string `json:"actionType"`
+	Status     string `json:"status"`
+	ErrorMsg   string `json:"errorMsg,omitempty"`
+	HashMD5    string `json:"hashMd5,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	ExecutedAt string `json:"executedAt,omitempty"`
+}
+
+// ActionPage is one page of action records plus the total match count.
+type ActionPage struct {
+	Actions []ActionRecord `json:"actions"`
+	Total   int            `json:"total"`
+}
+
+// ListActions returns action records matching the filter, newest first.
+func (s *Store) ListActions(ctx context.Context, filter ActionFilter, page Page) (ActionPage, error) {
+	result := ActionPage{Actions: []ActionRecord{}}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.ActionType != "" {
+		where = append(where, "action_type = ?")
+		args = append(args, filter.ActionType)
+	}
+	if filter.RunID != 0 {
+		where = append(where, "run_id = ?")
+		args = append(args, filter.RunID)
+	}
+	if filter.From != "" {
+		from, err := parseBound(filter.From, false)
+		if err != nil {
+			return result, err
+		}
+		where = append(where, "created_at >= ?")
+		args = append(args, from)
+	}
+	if filter.To != "" {
+		to, err := parseBound(filter.To, true)
+		if err != nil {
+			return result, err
+		}
+		where = append(where, "created_at < ?")
+		args = append(args, to)
+	}
+
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM file_actions`+clause, args...).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("count actions: %w", err)
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `
+SELECT id, media_id, run_id, retry_of, source_path, target_path, action_type, status, error_msg, hash_md5, created_at, executed_at
+FROM file_actions` + clause + `
+ORDER BY id DESC
+LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, page.Offset)...)
+	if err != nil {
+		return result, fmt.Errorf("query actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec                              ActionRecord
+			mediaID, runID, retryOf          sql.NullInt64
+			target, errMsg, hash, executedAt sql.NullString
+		)
+		if err := rows.Scan(&rec.ID, &mediaID, &runID, &retryOf, &rec.SourcePath, &target, &rec.ActionType, &rec.Status, &errMsg, &hash, &rec.CreatedAt, &executedAt); err != nil {
+			return result, fmt.Errorf("scan action row: %w", err)
+		}
+		rec.MediaID = mediaID.Int64
+		rec.RunID = runID.Int64
+		rec.RetryOf = retryOf.Int64
+		rec.TargetPath = target.String
+		rec.ErrorMsg = errMsg.String
+		rec.HashMD5 = hash.String
+		rec.ExecutedAt = executedAt.String
+		result.Actions = append(result.Actions, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate actions: %w", err)
+	}
+
+	return result, nil
+}
+
+// parseBound converts a filter bound into SQLite's datetime text. Date-only
+// upper bounds are inclusive of the whole day.
+func parseBound(value string, upper bool) (string, error) {
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.UTC().Format(sqliteTimeLayout), nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	if upper {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.Format(sqliteTimeLayout), nil
+}
+
+// ListFailedActions returns failed move and quarantine actions that have not
+// been retried yet, limited to a run or to explicit action IDs.
+func (s *Store) ListFailedActions(ctx context.Context, runID int64, ids []int64) ([]FileAction, error) {
+	where := []string{
+		"a.status = ?",
+		"a.action_type IN ('move', 'quarantine')",
+		"NOT EXISTS (SELECT 1 FROM file_actions r WHERE r.retry_of = a.id)",
+	}
+	args := []interface{}{string(ActionStatusFailed)}
+
+	switch {
+	case len(ids) > 0:
+		placeholders := make([]string, len(ids))
+		for i, id := range ids {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		where = append(where, "a.id IN ("+strings.Join(placeholders, ",")+")")
+	case runID != 0:
+		where = append(where, "a.run_id = ?")
+		args = append(args, runID)
+	default:
+		return nil, fmt.Errorf("a run ID or action IDs are required")
+	}
+
+	query := `
+SELECT a.id, a.media_id, a.source_path, COALESCE(a.target_path, ''), a.action_type, a.status, a.run_id
+FROM file_actions a
+WHERE ` + strings.Join(where, " AND ") + `
+ORDER BY a.id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []FileAction
+	for rows.Next() {
+		var (
+			action FileAction
+			status string
+		)
+		if err := rows.Scan(&action.ID, &action.MediaID, &action.SourcePath, &action.TargetPath, &action.ActionType, &status, &action.RunID); err != nil {
+			return nil, fmt.Errorf("scan failed action: %w", err)
+		}
+		action.Status = FileActionStatus(status)
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate failed actions: %w", err)
+	}
+	return actions, nil
+}
+
+// LastHashFrom returns the hash logged by the latest completed action that
+// moved a file away from path, or "" when none did.
+func (s *Store) LastHashFrom(ctx context.Context, path string) (string, error) {
+	var hash string
+	err := s.db.QueryRowContext(ctx, `
+SELECT hash_md5 FROM file_actions
+WHERE source_path = ? AND status = ? AND COALESCE(hash_md5, '') <> ''
+ORDER BY id DESC LIMIT 1`, path, string(ActionStatusCompleted)).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("find logged hash: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Album is a named set of media brought in from another photo manager.
+type Album struct {
+	ID     int64  `json:"id"`
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+}
+
+// SaveAlbum creates or renames the album that externalID identifies within
+// source and adds mediaIDs to it. Members are only ever added, since files
+// tidied after an earlier import can no longer be matched to the source.
+func (s *Store) SaveAlbum(ctx context.Context, source, externalID, name string, mediaIDs []int64) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin album: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+INSERT INTO albums (source, external_id, name) VALUES (?, ?, ?)
+ON CONFLICT(source, external_id) DO UPDATE SET name = excluded.name, updated_at = datetime('now')
+RETURNING id`, source, externalID, name).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("save album: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO album_media (album_id, media_id) VALUES (?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("prepare album media: %w", err)
+	}
+	defer stmt.Close()
+	for _, mediaID := range mediaIDs {
+		if _, err := stmt.ExecContext(ctx, id, mediaID); err != nil {
+			return 0, fmt.Errorf("add album media: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit album: %w", err)
+	}
+	return id, nil
+}
+
+// ListAlbums returns every album with its number of files, by name.
+func (s *Store) ListAlbums(ctx context.Context) ([]Album, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT a.id, a.source, a.name, COUNT(m.media_id)
+FROM albums a
+LEFT JOIN album_media m ON m.album_id = a.id
+GROUP BY a.id
+ORDER BY a.name COLLATE NOCASE, a.id`)
+	if err != nil {
+		return nil, fmt.Errorf("query albums: %w", err)
+	}
+	defer rows.Close()
+
+	var albums []Album
+	for rows.Next() {
+		var album Album
+		if err := rows.Scan(&album.ID, &album.Source, &album.Name, &album.Count); err != nil {
+			return nil, fmt.Errorf("scan album: %w", err)
+		}
+		albums = append(albums, album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate albums: %w", err)
+	}
+	return albums, nil
+}
+
+// ListAlbumMedia returns the files of an album.
+func (s *Store) ListAlbumMedia(ctx context.Context, albumID int64) ([]MediaFile, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+mediaColumns+` FROM media_files
+WHERE id IN (SELECT media_id FROM album_media WHERE album_id = ?)
+ORDER BY COALESCE(user_taken_at, taken_at), id`, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("query album media: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan album media: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate album media: %w", err)
+	}
+	return files, nil
+}
+
+// TagMedia attaches tag to mediaIDs, recording source as where it came
+// from. Tags already present are kept.
+func (s *Store) TagMedia(ctx context.Context, tag, source string, mediaIDs []int64) error {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || len(mediaIDs) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tags: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO media_tags (media_id, tag, source) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare tag: %w", err)
+	}
+	defer stmt.Close()
+	for _, id := range mediaIDs {
+		if _, err := stmt.ExecContext(ctx, id, tag, source); err != nil {
+			return fmt.Errorf("tag media: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tags: %w", err)
+	}
+	return nil
+}
+
+// ListMediaTags returns the tags of a file, sorted.
+func (s *Store) ListMediaTags(ctx context.Context, mediaID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT tag FROM media_tags WHERE media_id = ? ORDER BY tag COLLATE NOCASE`, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	return tags, nil
+}
+
+// splitTags unpacks the tags mediaColumns joins with the unit separator,
+// listing a tag given by several sources once.
+func splitTags(joined string) []string {
+	if joined == "" {
+		return nil
+	}
+	tags := strings.Split(joined, "\x1f")
+	sort.Slice(tags, func(i, j int) bool {
+		if a, b := strings.ToLower(tags[i]), strings.ToLower(tags[j]); a != b {
+			return a < b
+		}
+		return tags[i] < tags[j]
+	})
+	out := tags[:0]
+	for i, tag := range tags {
+		if i == 0 || tag != tags[i-1] {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// Backfill jobs fill in data that rows scanned before a column existed lack.
+const (
+	BackfillDimensions = "dimensions"
+	BackfillPosition   = "position"
+	BackfillIdentity   = "identity"
+	BackfillExif       = "exif"
+	BackfillThumbnails = "thumbnails"
+)
+
+// Backfill statuses. A job that was never started has no status.
+const (
+	BackfillRunning = "running"
+	BackfillDone    = "done"
+)
+
+// backfillPending selects the rows each backfill job still has to visit.
+// Archive entries ("backup.zip!/...") have no inode of their own.
+var backfillPending = map[string]string{
+	BackfillDimensions: "width = 0 AND mime_type LIKE 'image/%'",
+	BackfillPosition:   "latitude IS NULL AND mime_type LIKE 'image/%'",
+	BackfillIdentity:   "inode IS NULL AND instr(path, '!/') = 0",
+	BackfillExif:       "mime_type LIKE 'image/%' AND NOT EXISTS (SELECT 1 FROM media_exif e WHERE e.media_id = media_files.id)",
+	BackfillThumbnails: "mime_type LIKE 'image/%'",
+}
+
+// BackfillState is the persisted progress of one backfill job. LastID is
+// the last row visited, so a restarted job carries on after it.
+type BackfillState struct {
+	Job       string `json:"job"`
+	Status    string `json:"status"`
+	LastID    int64  `json:"lastId"`
+	Processed int    `json:"processed"`
+	Updated   int    `json:"updated"`
+	Failed    int    `json:"failed"`
+	// Remaining counts the rows after LastID the job has yet to visit.
+	Remaining int    `json:"remaining"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// BackfillJobs lists the known backfill jobs by name.
+func BackfillJobs() []string {
+	jobs := make([]string, 0, len(backfillPending))
+	for job := range backfillPending {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+	return jobs
+}
+
+// ListBackfills returns the state of every backfill job.
+func (s *Store) ListBackfills(ctx context.Context) ([]BackfillState, error) {
+	states := make([]BackfillState, 0, len(backfillPending))
+	for _, job := range BackfillJobs() {
+		state, err := s.GetBackfill(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// GetBackfill returns the state of a backfill job.
+func (s *Store) GetBackfill(ctx context.Context, job string) (BackfillState, error) {
+	state := BackfillState{Job: job}
+	pending, ok := backfillPending[job]
+	if !ok {
+		return state, fmt.Errorf("unknown backfill job %q", job)
+	}
+
+	var status, updatedAt sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+SELECT status, last_id, processed, updated, failed, updated_at
+FROM backfill_jobs WHERE job = ?
+`, job).Scan(&status, &state.LastID, &state.Processed, &state.Updated, &state.Failed, &updatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return state, fmt.Errorf("query backfill %s: %w", job, err)
+	}
+	state.Status = status.String
+	state.UpdatedAt = updatedAt.String
+
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM media_files WHERE id > ? AND `+pending, state.LastID,
+	).Scan(&state.Remaining); err != nil {
+		return state, fmt.Errorf("count backfill %s: %w", job, err)
+	}
+	return state, nil
+}
+
+// StartBackfill marks a job as running. A running job keeps its progress so
+// it resumes; a finished or new one starts over from the first row.
+func (s *Store) StartBackfill(ctx context.Context, job string) (BackfillState, error) {
+	state, err := s.GetBackfill(ctx, job)
+	if err != nil {
+		return state, err
+	}
+	if state.Status != BackfillRunning {
+		state = BackfillState{Job: job, Status: BackfillRunning}
+	}
+	if err := s.SaveBackfill(ctx, state); err != nil {
+		return state, err
+	}
+	return s.GetBackfill(ctx, job)
+}
+
+// SaveBackfill persists the progress of a job.
+func (s *Store) SaveBackfill(ctx context.Context, state BackfillState) error {
+	query := `
+INSERT INTO backfill_jobs (job, status, last_id, processed, updated, failed, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+ON CONFLICT(job) DO UPDATE SET
+    status = excluded.status,
+    last_id = excluded.last_id,
+    processed = excluded.processed,
+    updated = excluded.updated,
+    failed = excluded.failed,
+    updated_at = excluded.updated_at
+`
+	if _, err := s.db.ExecContext(ctx, query,
+		state.Job, state.Status, state.LastID, state.Processed, state.Updated, state.Failed,
+	); err != nil {
+		return fmt.Errorf("save backfill %s: %w", state.Job, err)
+	}
+	return nil
+}
+
+// ListRunningBackfills returns the jobs that were interrupted before they
+// finished, for resuming after a restart.
+func (s *Store) ListRunningBackfills(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT job FROM backfill_jobs WHERE status = ? ORDER BY job`, BackfillRunning)
+	if err != nil {
+		return nil, fmt.Errorf("query running backfills: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []string
+	for rows.Next() {
+		var job string
+		if err := rows.Scan(&job); err != nil {
+			return nil, fmt.Errorf("scan running backfill: %w", err)
+		}
+		if _, ok := backfillPending[job]; ok {
+			jobs = append(jobs, job)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate running backfills: %w", err)
+	}
+	return jobs, nil
+}
+
+// NextBackfillBatch returns up to limit rows after afterID that job has yet
+// to visit, in id order.
+func (s *Store) NextBackfillBatch(ctx context.Context, job string, afterID int64, limit int) ([]MediaFile, error) {
+	pending, ok := backfillPending[job]
+	if !ok {
+		return nil, fmt.Errorf("unknown backfill job %q", job)
+	}
+
+	query := `SELECT ` + mediaColumns + ` FROM media_files WHERE id > ? AND ` + pending + ` ORDER BY id LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list backfill %s: %w", job, err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan media row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media rows: %w", err)
+	}
+	return files, nil
+}
+
+// ApplyBackfill writes the derived columns a backfill may fill in. Hash,
+// path and capture time are left to scans.
+func (s *Store) ApplyBackfill(ctx context.Context, file MediaFile) error {
+	query := `
+UPDATE media_files
+SET width = ?, height = ?, category = ?, latitude = ?, longitude = ?, device = ?, inode = ?
+WHERE id = ?
+`
+	if _, err := s.db.ExecContext(ctx, query,
+		file.Width, file.Height, file.Category, file.Latitude, file.Longitude, file.Device, file.Inode, file.ID,
+	); err != nil {
+		return fmt.Errorf("apply backfill to media %d: %w", file.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Backup writes a consistent copy of the database to dest using VACUUM INTO.
+func (s *Store) Backup(ctx context.Context, dest string) error {
+	if dest == "" {
+		return errors.New("backup path is empty")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup target %s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create backup directory: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
+
+// ValidateBackup checks that path is an intact SQLite database with the
+// tables this application expects.
+func ValidateBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("backup is not a readable sqlite database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed integrity check: %s", result)
+	}
+
+	for _, table := range []string{"media_files", "file_actions"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("backup is missing table %s", table)
+		}
+		if err != nil {
+			return fmt.Errorf("inspect backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// RestoreFile replaces the database file at dest with the backup at src.
+// The store using dest must be closed beforehand.
+func RestoreFile(src, dest string) error {
+	if err := ValidateBackup(src); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer in.Close()
+
+	tmp := dest + ".restore"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create restore file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("copy backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("copy backup: %w", err)
+	}
+
+	// Stale journal files would otherwise be replayed onto the restored copy.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		_ = os.Remove(dest + suffix)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace database: %w", err)
+	}
+	return nil
+}
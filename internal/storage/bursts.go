@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BurstGroup collects shots from one camera taken in quick succession.
+type BurstGroup struct {
+	CameraMake  string
+	CameraModel string
+	Start       time.Time
+	End         time.Time
+	Files       []MediaFile
+}
+
+// ListBurstGroups groups media from the same camera whose capture times are
+// no more than window apart. Only groups with at least minSize files are returned.
+func (s *Store) ListBurstGroups(ctx context.Context, window time.Duration, minSize int) ([]BurstGroup, error) {
+	if minSize < 2 {
+		minSize = 2
+	}
+
+	query := `
+SELECT ` + mediaColumns + `
+FROM media_files
+WHERE COALESCE(user_taken_at, taken_at) IS NOT NULL
+ORDER BY COALESCE(user_camera_make, camera_make, ''), COALESCE(user_camera_model, camera_model, ''),
+    datetime(COALESCE(user_taken_at, taken_at), COALESCE(time_offset_minutes, 0) || ' minutes'), id
+`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query bursts: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		groups  []BurstGroup
+		current *BurstGroup
+	)
+
+	flush := func() {
+		if current != nil && len(current.Files) >= minSize {
+			groups = append(groups, *current)
+		}
+		current = nil
+	}
+
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan burst row: %w", err)
+		}
+		if !file.TakenAt.Valid {
+			continue
+		}
+
+		makeVal, modelVal := file.CameraMake.String, file.CameraModel.String
+		taken := file.TakenAt.Time
+		if current == nil ||
+			current.CameraMake != makeVal ||
+			current.CameraModel != modelVal ||
+			taken.Sub(current.End) > window {
+			flush()
+			current = &BurstGroup{CameraMake: makeVal, CameraModel: modelVal, Start: taken}
+		}
+		current.End = taken
+		current.Files = append(current.Files, file)
+	}
+	flush()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bursts: %w", err)
+	}
+
+	return groups, nil
+}
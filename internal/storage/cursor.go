@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// cursorBatch is how many rows a MediaCursor reads per query.
+const cursorBatch = 500
+
+// MediaCursor walks the media matching a filter in id order, one batch at a
+// time. No read stays open between batches, so callers may write to the
+// store while they walk it.
+type MediaCursor struct {
+	store *Store
+	where []string
+	args  []interface{}
+	last  int64
+	bound int64
+	// Total counts the matching rows when the cursor was opened.
+	Total int
+}
+
+// OpenMediaCursor counts the media matching filter and fixes the set to walk;
+// rows added afterwards are not visited. Limit and Offset are ignored.
+func (s *Store) OpenMediaCursor(ctx context.Context, filter MediaFilter) (*MediaCursor, error) {
+	where, args := filter.conditions()
+	cursor := &MediaCursor{store: s, where: where, args: args}
+
+	query := `SELECT COUNT(*), COALESCE(MAX(id), 0) FROM media_files`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&cursor.Total, &cursor.bound); err != nil {
+		return nil, fmt.Errorf("count media: %w", err)
+	}
+	return cursor, nil
+}
+
+// Next returns the following batch, or none once the walk is complete.
+func (c *MediaCursor) Next(ctx context.Context) ([]MediaFile, error) {
+	if c.last >= c.bound {
+		return nil, nil
+	}
+	where := append([]string{"id > ?", "id <= ?"}, c.where...)
+	args := append([]interface{}{c.last, c.bound}, c.args...)
+	query := `SELECT ` + mediaColumns + ` FROM media_files WHERE ` + strings.Join(where, " AND ") + ` ORDER BY id LIMIT ?`
+
+	rows, err := c.store.db.QueryContext(ctx, query, append(args, cursorBatch)...)
+	if err != nil {
+		return nil, fmt.Errorf("list media: %w", err)
+	}
+	defer rows.Close()
+
+	files := make([]MediaFile, 0, cursorBatch)
+	for rows.Next() {
+		file, err := c.store.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan media row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media rows: %w", err)
+	}
+
+	if len(files) == 0 {
+		c.last = c.bound
+		return nil, nil
+	}
+	c.last = files[len(files)-1].ID
+	return files, nil
+}
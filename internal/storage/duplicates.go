@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Duplicate group orderings accepted by ListDuplicateGroupsPage.
+const (
+	// DuplicateSortWasted puts the groups freeing the most bytes first.
+	DuplicateSortWasted = "wasted"
+	// DuplicateSortSize puts the groups with the most copies first.
+	DuplicateSortSize = "size"
+)
+
+// DuplicateScope narrows duplicate detection. A group is in scope when at
+// least one copy lies below one of Paths and was taken between From and To;
+// its other copies may be anywhere, so an import folder can be checked
+// against the rest of the library. Zero values match everything.
+type DuplicateScope struct {
+	Paths []string `json:"paths"`
+	// From and To bound the capture time as RFC 3339 timestamps or
+	// YYYY-MM-DD dates; To is inclusive of its day.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// AcrossFolders drops groups whose copies all share one folder.
+	AcrossFolders bool `json:"acrossFolders"`
+}
+
+// storedCopies counts the copies of a hash group that occupy storage of
+// their own: hardlinks of one file count once, and so do the acknowledged
+// copies between them.
+const storedCopies = `COUNT(DISTINCT CASE
+    WHEN a.media_id IS NOT NULL THEN 'ack'
+    WHEN m.inode IS NOT NULL THEN m.device || ':' || m.inode
+    ELSE 'id:' || m.id END)`
+
+// looseFile leaves archive entries ("backup.zip!/...") out of duplicate
+// groups: they cannot be removed or served on their own.
+const looseFile = `instr(path, '!/') = 0`
+
+// duplicateHashes aggregates media_files by hash into the duplicate groups
+// in scope. All copies share one size, so every stored copy beyond the first
+// is reclaimable. Groups left with a single stored copy are not duplicates.
+func duplicateHashes(scope DuplicateScope) (string, []interface{}, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if len(scope.Paths) > 0 {
+		var prefixes []string
+		for _, base := range scope.Paths {
+			prefix := dirPrefix(base)
+			prefixes = append(prefixes, "substr(path, 1, ?) = ?")
+			args = append(args, len(prefix), prefix)
+		}
+		where = append(where, "("+strings.Join(prefixes, " OR ")+")")
+	}
+	if scope.From != "" {
+		from, err := parseBound(scope.From, false)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, takenExpr+" >= ?")
+		args = append(args, from)
+	}
+	if scope.To != "" {
+		to, err := parseBound(scope.To, true)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, takenExpr+" < ?")
+		args = append(args, to)
+	}
+
+	query := `
+SELECT m.hash_md5, COUNT(*) AS files, ` + storedCopies + ` AS copies, (` + storedCopies + ` - 1) * MAX(m.size_bytes) AS wasted
+FROM media_files m
+LEFT JOIN duplicate_acks a ON a.media_id = m.id AND a.hash_md5 = m.hash_md5
+WHERE ` + looseFile
+	if len(where) > 0 {
+		where = append(where, looseFile)
+		query += `
+  AND m.hash_md5 IN (SELECT hash_md5 FROM media_files WHERE ` + strings.Join(where, " AND ") + `)`
+	}
+	query += `
+GROUP BY m.hash_md5
+HAVING copies > 1`
+	if scope.AcrossFolders {
+		query += ` AND COUNT(DISTINCT ` + dirExpr + `) > 1`
+	}
+	return query, args, nil
+}
+
+// DuplicatePage is one page of duplicate groups plus the total group count.
+type DuplicatePage struct {
+	Groups []DuplicateGroup `json:"groups"`
+	Total  int              `json:"total"`
+}
+
+// DuplicateSummary totals the duplicates in a scope.
+type DuplicateSummary struct {
+	Groups int `json:"groups"`
+	// Files counts every copy, keepers included.
+	Files       int   `json:"files"`
+	WastedBytes int64 `json:"wastedBytes"`
+}
+
+// ListDuplicateGroupsPage returns one page of the duplicate groups in scope
+// ordered by sort, loading only the files of the groups on that page.
+func (s *Store) ListDuplicateGroupsPage(ctx context.Context, scope DuplicateScope, sort string, page Page) (DuplicatePage, error) {
+	result := DuplicatePage{Groups: []DuplicateGroup{}}
+
+	var order string
+	switch sort {
+	case "", DuplicateSortWasted:
+		order = "wasted DESC, copies DESC, m.hash_md5"
+	case DuplicateSortSize:
+		order = "copies DESC, wasted DESC, m.hash_md5"
+	default:
+		return result, fmt.Errorf("unknown duplicate sort %q", sort)
+	}
+
+	hashes, args, err := duplicateHashes(scope)
+	if err != nil {
+		return result, err
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (`+hashes+`)`, args...).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("count duplicate groups: %w", err)
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, hashes+` ORDER BY `+order+` LIMIT ? OFFSET ?`, append(args, limit, page.Offset)...)
+	if err != nil {
+		return result, fmt.Errorf("query duplicate groups: %w", err)
+	}
+	index := make(map[string]int)
+	for rows.Next() {
+		var (
+			group         DuplicateGroup
+			files, copies int
+		)
+		if err := rows.Scan(&group.Hash, &files, &copies, &group.WastedBytes); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("scan duplicate group: %w", err)
+		}
+		index[group.Hash] = len(result.Groups)
+		result.Groups = append(result.Groups, group)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate duplicate groups: %w", err)
+	}
+	if len(result.Groups) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(result.Groups))
+	groupArgs := make([]interface{}, len(result.Groups))
+	for i, group := range result.Groups {
+		placeholders[i] = "?"
+		groupArgs[i] = group.Hash
+	}
+	files, err := s.db.QueryContext(ctx, `
+SELECT `+mediaColumns+`
+FROM media_files
+WHERE hash_md5 IN (`+strings.Join(placeholders, ",")+`) AND `+looseFile+`
+ORDER BY id`, groupArgs...)
+	if err != nil {
+		return result, fmt.Errorf("query duplicates: %w", err)
+	}
+	defer files.Close()
+
+	for files.Next() {
+		file, err := s.scanMediaFile(files)
+		if err != nil {
+			return result, fmt.Errorf("scan duplicate row: %w", err)
+		}
+		group := &result.Groups[index[file.HashMD5]]
+		group.Files = append(group.Files, file)
+	}
+	if err := files.Err(); err != nil {
+		return result, fmt.Errorf("iterate duplicates: %w", err)
+	}
+	files.Close()
+
+	if err := s.annotateGroups(ctx, result.Groups); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// DuplicateSummary counts the duplicate groups in scope, their files and the
+// bytes that resolving all of them would free.
+func (s *Store) DuplicateSummary(ctx context.Context, scope DuplicateScope) (DuplicateSummary, error) {
+	var summary DuplicateSummary
+	hashes, args, err := duplicateHashes(scope)
+	if err != nil {
+		return summary, err
+	}
+	err = s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(files), 0), COALESCE(SUM(wasted), 0) FROM (`+hashes+`)`, args...,
+	).Scan(&summary.Groups, &summary.Files, &summary.WastedBytes)
+	if err != nil {
+		return summary, fmt.Errorf("summarise duplicates: %w", err)
+	}
+	return summary, nil
+}
+
+// annotateGroups records the acknowledged copies, hardlinks and reclaimable
+// bytes of each group, then suggests its keeper.
+func (s *Store) annotateGroups(ctx context.Context, groups []DuplicateGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	acked := make(map[int64]bool)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT a.media_id
+FROM duplicate_acks a
+JOIN media_files m ON m.id = a.media_id AND m.hash_md5 = a.hash_md5`)
+	if err != nil {
+		return fmt.Errorf("query duplicate acks: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan duplicate ack: %w", err)
+		}
+		acked[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate duplicate acks: %w", err)
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		group.Acknowledged = []int64{}
+		group.Hardlinks = [][]int64{}
+		var (
+			size  int64
+			units = make(map[string]bool)
+			links = make(map[string][]int64)
+			order []string
+		)
+		// Same storage units as storedCopies counts in SQL.
+		for _, file := range group.Files {
+			size = max(size, file.SizeBytes)
+			unit := fmt.Sprintf("id:%d", file.ID)
+			if file.Inode.Valid {
+				unit = fmt.Sprintf("%d:%d", file.Device.Int64, file.Inode.Int64)
+				if links[unit] == nil {
+					order = append(order, unit)
+				}
+				links[unit] = append(links[unit], file.ID)
+			}
+			if acked[file.ID] {
+				group.Acknowledged = append(group.Acknowledged, file.ID)
+				unit = "ack"
+			}
+			units[unit] = true
+		}
+		for _, unit := range order {
+			if len(links[unit]) > 1 {
+				group.Hardlinks = append(group.Hardlinks, links[unit])
+			}
+		}
+		group.WastedBytes = int64(len(units)-1) * size
+	}
+	return s.suggestKeepers(ctx, groups)
+}
+
+// AcknowledgeDuplicates marks copies of hash as intentional, for example a
+// copy kept in a "Best of" album. Empty mediaIDs marks the whole group as it
+// stands; a copy added later makes the group a duplicate again. It returns
+// how many copies were marked.
+func (s *Store) AcknowledgeDuplicates(ctx context.Context, hash string, mediaIDs []int64) (int, error) {
+	query := `
+INSERT INTO duplicate_acks (media_id, hash_md5)
+SELECT id, hash_md5 FROM media_files WHERE hash_md5 = ?`
+	args := []interface{}{hash}
+	if len(mediaIDs) > 0 {
+		placeholders := make([]string, len(mediaIDs))
+		for i, id := range mediaIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += ` AND id IN (` + strings.Join(placeholders, ",") + `)`
+	}
+	query += `
+ON CONFLICT(media_id) DO UPDATE SET hash_md5 = excluded.hash_md5, created_at = datetime('now')`
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("acknowledge duplicates: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("acknowledge duplicates: %w", err)
+	}
+	return int(n), nil
+}
+
+// ClearDuplicateAcknowledgement undoes AcknowledgeDuplicates for the listed
+// copies of hash, or for all of them when mediaIDs is empty.
+func (s *Store) ClearDuplicateAcknowledgement(ctx context.Context, hash string, mediaIDs []int64) (int, error) {
+	query := `DELETE FROM duplicate_acks WHERE hash_md5 = ?`
+	args := []interface{}{hash}
+	if len(mediaIDs) > 0 {
+		placeholders := make([]string, len(mediaIDs))
+		for i, id := range mediaIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += ` AND media_id IN (` + strings.Join(placeholders, ",") + `)`
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("clear duplicate acknowledgement: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("clear duplicate acknowledgement: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MetadataEdit carries user corrections for one media row. Nil fields are
+// left as they are; an empty string (or a zero offset) drops the correction
+// so the extracted value shows again.
+type MetadataEdit struct {
+	// TakenAt is RFC 3339 or "2006-01-02 15:04:05"; times without a zone are
+	// read as UTC, like extracted EXIF times.
+	TakenAt     *string `json:"takenAt,omitempty"`
+	CameraMake  *string `json:"cameraMake,omitempty"`
+	CameraModel *string `json:"cameraModel,omitempty"`
+	// TimeOffsetMinutes shifts the capture time, e.g. for a camera clock
+	// left on another time zone.
+	TimeOffsetMinutes *int `json:"timeOffsetMinutes,omitempty"`
+}
+
+// takenAtLayouts are accepted for edited capture times, most specific first.
+var takenAtLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// UpdateMediaMetadata stores user corrections for a media row. They are kept
+// apart from the extracted columns, so rescans do not overwrite them.
+func (s *Store) UpdateMediaMetadata(ctx context.Context, id int64, edit MetadataEdit) error {
+	var (
+		sets []string
+		args []interface{}
+	)
+
+	if edit.TakenAt != nil {
+		value, err := parseTakenAt(*edit.TakenAt)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, "user_taken_at = ?")
+		args = append(args, value)
+	}
+	if edit.CameraMake != nil {
+		sets = append(sets, "user_camera_make = ?")
+		args = append(args, emptyToNull(*edit.CameraMake))
+	}
+	if edit.CameraModel != nil {
+		sets = append(sets, "user_camera_model = ?")
+		args = append(args, emptyToNull(*edit.CameraModel))
+	}
+	if edit.TimeOffsetMinutes != nil {
+		offset := *edit.TimeOffsetMinutes
+		if offset < -24*60 || offset > 24*60 {
+			return errors.New("time offset must be within 24 hours")
+		}
+		var value interface{}
+		if offset != 0 {
+			value = offset
+		}
+		sets = append(sets, "time_offset_minutes = ?")
+		args = append(args, value)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	args = append(args, id)
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE media_files SET `+strings.Join(sets, ", ")+`, updated_at = datetime('now') WHERE id = ?`, args...)
+	if err != nil {
+		return fmt.Errorf("update media metadata: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("media %d not found", id)
+	}
+	return nil
+}
+
+func parseTakenAt(value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range takenAtLayouts {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognised capture time %q", value)
+}
+
+func emptyToNull(value string) interface{} {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	return value
+}
+
+// ShiftMediaTime moves the capture time of the given rows by minutes, on top
+// of any earlier shift, and returns how many rows changed.
+func (s *Store) ShiftMediaTime(ctx context.Context, ids []int64, minutes int) (int, error) {
+	if len(ids) == 0 || minutes == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, minutes)
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+UPDATE media_files
+SET time_offset_minutes = NULLIF(COALESCE(time_offset_minutes, 0) + ?, 0), updated_at = datetime('now')
+WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("shift media time: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("shift media time: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Encryption seals the columns that reveal most about the people in a
+// library and are only ever read back whole: face embeddings, the names
+// given to people and the text OCR read from images. Values are sealed with
+// AES-256-GCM under a key derived from a passphrase with PBKDF2-SHA256.
+// Positions, EXIF values and tags stay plain, since the map, filters and
+// search query them in SQL; sealed OCR text drops out of search.
+
+// ErrLocked is returned when an encrypted column is read or written before
+// the library is unlocked.
+var ErrLocked = errors.New("the library is encrypted; unlock it with its passphrase first")
+
+// ErrWrongKey is returned when a passphrase or key does not open the library.
+var ErrWrongKey = errors.New("wrong passphrase for this library")
+
+// keyIterations is the PBKDF2 work factor given to newly encrypted
+// libraries; each library records its own.
+const keyIterations = 600_000
+
+// Sealed columns. The name is the additional data of every value, so a
+// value cannot be moved to another column.
+const (
+	sealedCheck     = "encryption.key_check"
+	sealedEmbedding = "faces.embedding"
+	sealedName      = "people.name"
+	sealedText      = "media_text.text"
+)
+
+// keyCheck is sealed into the encryption row to tell a wrong key from a
+// damaged value.
+var keyCheck = []byte("photoTidy library key")
+
+// Encrypted reports whether the library seals its sensitive columns.
+func (s *Store) Encrypted() bool {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	return s.encrypted
+}
+
+// Locked reports whether the library is encrypted and not yet unlocked.
+func (s *Store) Locked() bool {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	return s.encrypted && s.aead == nil
+}
+
+// loadEncryption reads whether the library is encrypted. It runs once the
+// schema is in place.
+func (s *Store) loadEncryption() error {
+	var encrypted bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM encryption)`).Scan(&encrypted); err != nil {
+		return fmt.Errorf("inspect encryption: %w", err)
+	}
+	s.keyMu.Lock()
+	s.encrypted = encrypted
+	s.keyMu.Unlock()
+	return nil
+}
+
+// UnlockPassphrase derives the library key from passphrase and unlocks the
+// library with it. The key is returned so it can be kept, in the OS
+// keychain for instance, and given to Unlock without asking again.
+func (s *Store) UnlockPassphrase(ctx context.Context, passphrase string) ([]byte, error) {
+	var (
+		salt       []byte
+		iterations int
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT salt, iterations FROM encryption WHERE id = 1`).Scan(&salt, &iterations)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("the library is not encrypted")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read encryption: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	if err := s.Unlock(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Unlock opens the encrypted columns with key.
+func (s *Store) Unlock(ctx context.Context, key []byte) error {
+	var check []byte
+	err := s.db.QueryRowContext(ctx, `SELECT key_check FROM encryption WHERE id = 1`).Scan(&check)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("the library is not encrypted")
+	}
+	if err != nil {
+		return fmt.Errorf("read encryption: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	if _, err := unseal(aead, sealedCheck, check); err != nil {
+		return ErrWrongKey
+	}
+
+	s.keyMu.Lock()
+	s.aead = aead
+	s.keyMu.Unlock()
+	return nil
+}
+
+// EnableEncryption derives a new key from passphrase and seals the
+// sensitive columns under it. The search index is rebuilt and the database
+// vacuumed afterwards, so no plain copy survives in either. Writers of the
+// sealed columns must be stopped meanwhile. The library is left unlocked
+// and the key returned.
+func (s *Store) EnableEncryption(ctx context.Context, passphrase string) ([]byte, error) {
+	if s.Encrypted() {
+		return nil, errors.New("the library is already encrypted")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, keyIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin encryption: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO encryption (id, salt, iterations, key_check) VALUES (1, ?, ?, ?)`,
+		salt, keyIterations, seal(aead, sealedCheck, keyCheck)); err != nil {
+		return nil, fmt.Errorf("record encryption: %w", err)
+	}
+	err = rewriteColumn(ctx, tx, `SELECT id, embedding FROM faces`, `UPDATE faces SET embedding = ? WHERE id = ?`,
+		func(v []byte) (interface{}, error) { return seal(aead, sealedEmbedding, v), nil })
+	if err != nil {
+		return nil, err
+	}
+	err = rewriteColumn(ctx, tx, `SELECT id, name FROM people WHERE name <> ''`, `UPDATE people SET name = ? WHERE id = ?`,
+		func(v []byte) (interface{}, error) { return seal(aead, sealedName, v), nil })
+	if err != nil {
+		return nil, err
+	}
+	err = rewriteColumn(ctx, tx, `SELECT media_id, text FROM media_text WHERE text <> ''`, `UPDATE media_text SET text = ? WHERE media_id = ?`,
+		func(v []byte) (interface{}, error) { return seal(aead, sealedText, v), nil })
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit encryption: %w", err)
+	}
+
+	s.keyMu.Lock()
+	s.encrypted, s.aead = true, aead
+	s.keyMu.Unlock()
+
+	// Deleted index entries linger in the FTS segments until they are
+	// merged, and replaced rows in free pages until a vacuum.
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO media_search (media_search) VALUES ('rebuild')`); err != nil {
+		return key, fmt.Errorf("rebuild search index: %w", err)
+	}
+	if err := s.Vacuum(ctx); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+// DisableEncryption writes the sealed columns back in the clear and forgets
+// the key. The library must be unlocked, and writers of the sealed columns
+// stopped meanwhile.
+func (s *Store) DisableEncryption(ctx context.Context) error {
+	s.keyMu.RLock()
+	encrypted, aead := s.encrypted, s.aead
+	s.keyMu.RUnlock()
+	if !encrypted {
+		return errors.New("the library is not encrypted")
+	}
+	if aead == nil {
+		return ErrLocked
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin decryption: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = rewriteColumn(ctx, tx, `SELECT id, embedding FROM faces`, `UPDATE faces SET embedding = ? WHERE id = ?`,
+		func(v []byte) (interface{}, error) { return unseal(aead, sealedEmbedding, v) })
+	if err != nil {
+		return err
+	}
+	err = rewriteColumn(ctx, tx, `SELECT id, name FROM people WHERE typeof(name) = 'blob'`, `UPDATE people SET name = ? WHERE id = ?`,
+		func(v []byte) (interface{}, error) { return unsealString(aead, sealedName, v) })
+	if err != nil {
+		return err
+	}
+	err = rewriteColumn(ctx, tx, `SELECT media_id, text FROM media_text WHERE typeof(text) = 'blob'`, `UPDATE media_text SET text = ? WHERE media_id = ?`,
+		func(v []byte) (interface{}, error) { return unsealString(aead, sealedText, v) })
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM encryption`); err != nil {
+		return fmt.Errorf("drop encryption: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit decryption: %w", err)
+	}
+
+	s.keyMu.Lock()
+	s.encrypted, s.aead = false, nil
+	s.keyMu.Unlock()
+	return nil
+}
+
+// rewriteColumn replaces the value of every row selected by query, which
+// reads an id and the value, with convert's result.
+func rewriteColumn(ctx context.Context, tx *sql.Tx, query, update string, convert func([]byte) (interface{}, error)) error {
+	type row struct {
+		id    int64
+		value []byte
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("read sealed column: %w", err)
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.value); err != nil {
+			rows.Close()
+			return fmt.Errorf("read sealed column: %w", err)
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read sealed column: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, update)
+	if err != nil {
+		return fmt.Errorf("prepare sealed column: %w", err)
+	}
+	defer stmt.Close()
+	for _, r := range all {
+		value, err := convert(r.value)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, value, r.id); err != nil {
+			return fmt.Errorf("rewrite sealed column: %w", err)
+		}
+	}
+	return nil
+}
+
+// sealer returns the cipher new values of the sealed columns are sealed
+// with: nil when the library is not encrypted, or ErrLocked.
+func (s *Store) sealer() (cipher.AEAD, error) {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	if !s.encrypted {
+		return nil, nil
+	}
+	if s.aead == nil {
+		return nil, ErrLocked
+	}
+	return s.aead, nil
+}
+
+// sealBlob seals a value of a BLOB column when the library is encrypted.
+func (s *Store) sealBlob(column string, value []byte) ([]byte, error) {
+	aead, err := s.sealer()
+	if err != nil || aead == nil {
+		return value, err
+	}
+	return seal(aead, column, value), nil
+}
+
+// openBlob opens a value read from a BLOB column.
+func (s *Store) openBlob(column string, value []byte) ([]byte, error) {
+	aead, err := s.sealer()
+	if err != nil || aead == nil {
+		return value, err
+	}
+	return unseal(aead, column, value)
+}
+
+// sealString seals a value of a TEXT column when the library is encrypted.
+// Sealed values are stored as BLOBs, which keeps them apart from plain
+// text in SQL; empty values stay plain, so "not set" can still be queried.
+func (s *Store) sealString(column, value string) (interface{}, error) {
+	if value == "" {
+		return value, nil
+	}
+	aead, err := s.sealer()
+	if err != nil || aead == nil {
+		return value, err
+	}
+	return seal(aead, column, []byte(value)), nil
+}
+
+// openString turns a value read from a sealed TEXT column into a
+// string. Plain values arrive as strings and sealed ones as []byte.
+func (s *Store) openString(column string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		s.keyMu.RLock()
+		aead := s.aead
+		s.keyMu.RUnlock()
+		if aead == nil {
+			return "", ErrLocked
+		}
+		return unsealString(aead, column, v)
+	default:
+		return "", nil
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("library key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts value under a fresh nonce, which leads the result.
+func seal(aead cipher.AEAD, column string, value []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	_, _ = rand.Read(nonce)
+	return aead.Seal(nonce, nonce, value, []byte(column))
+}
+
+func unseal(aead cipher.AEAD, column string, sealed []byte) ([]byte, error) {
+	n := aead.NonceSize()
+	if len(sealed) < n {
+		return nil, fmt.Errorf("open %s: value too short", column)
+	}
+	value, err := aead.Open(nil, sealed[:n], sealed[n:], []byte(column))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", column, err)
+	}
+	return value, nil
+}
+
+func unsealString(aead cipher.AEAD, column string, sealed []byte) (string, error) {
+	value, err := unseal(aead, column, sealed)
+	return string(value), err
+}
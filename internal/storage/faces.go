@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Face is a face found in a media file. Box is the left, top, width and
+// height of the face as fractions of the image size.
+type Face struct {
+	ID       int64      `json:"id"`
+	MediaID  int64      `json:"mediaId"`
+	PersonID int64      `json:"personId"`
+	Box      [4]float64 `json:"box"`
+	// Embedding places the face in the space of the backend that found it,
+	// where faces of one person lie close together.
+	Embedding []float32 `json:"-"`
+}
+
+// Person is a cluster of faces taken to be one person.
+type Person struct {
+	ID int64 `json:"id"`
+	// Name is empty until the user names the person.
+	Name  string `json:"name"`
+	Faces int    `json:"faces"`
+	Media int    `json:"media"`
+	// CoverMediaID is the file showing the person's largest face.
+	CoverMediaID int64 `json:"coverMediaId"`
+}
+
+// ListFaceCandidates returns images not yet analysed by backend in their
+// current content, up to limit (0 for all).
+func (s *Store) ListFaceCandidates(ctx context.Context, backend string, limit int) ([]MediaFile, error) {
+	query := `
+SELECT ` + mediaColumns + ` FROM media_files
+WHERE mime_type LIKE 'image/%' AND NOT EXISTS (
+    SELECT 1 FROM face_scans f
+    WHERE f.media_id = media_files.id AND f.hash_md5 = media_files.hash_md5 AND f.backend = ?
+)
+ORDER BY id`
+	args := []interface{}{backend}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query face candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan face candidate: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate face candidates: %w", err)
+	}
+	return files, nil
+}
+
+// SaveFaces replaces the faces of a file with those backend found in the
+// content with the given hash.
+func (s *Store) SaveFaces(ctx context.Context, mediaID int64, hash, backend string, faces []Face) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin faces: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM faces WHERE media_id = ?`, mediaID); err != nil {
+		return fmt.Errorf("clear faces: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO faces (media_id, box_x, box_y, box_w, box_h, embedding) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare face: %w", err)
+	}
+	defer stmt.Close()
+	for _, face := range faces {
+		embedding, err := s.sealBlob(sealedEmbedding, encodeEmbedding(face.Embedding))
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, mediaID, face.Box[0], face.Box[1], face.Box[2], face.Box[3], embedding); err != nil {
+			return fmt.Errorf("save face: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO face_scans (media_id, hash_md5, backend, faces, scanned_at)
+VALUES (?, ?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    hash_md5 = excluded.hash_md5,
+    backend = excluded.backend,
+    faces = excluded.faces,
+    scanned_at = excluded.scanned_at
+`, mediaID, hash, backend, len(faces)); err != nil {
+		return fmt.Errorf("record face scan: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit faces: %w", err)
+	}
+	return nil
+}
+
+// ListFaces returns every stored face with its embedding.
+func (s *Store) ListFaces(ctx context.Context) ([]Face, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, media_id, COALESCE(person_id, 0), box_x, box_y, box_w, box_h, embedding FROM faces ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query faces: %w", err)
+	}
+	defer rows.Close()
+
+	var faces []Face
+	for rows.Next() {
+		var (
+			face Face
+			blob []byte
+		)
+		if err := rows.Scan(&face.ID, &face.MediaID, &face.PersonID, &face.Box[0], &face.Box[1], &face.Box[2], &face.Box[3], &blob); err != nil {
+			return nil, fmt.Errorf("scan face: %w", err)
+		}
+		if blob, err = s.openBlob(sealedEmbedding, blob); err != nil {
+			return nil, err
+		}
+		face.Embedding = decodeEmbedding(blob)
+		faces = append(faces, face)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate faces: %w", err)
+	}
+	return faces, nil
+}
+
+// AssignFaces stores the person of each face, keyed by face ID. A person
+// ID of -1 creates a new person; every face given the same negative ID
+// joins that one new person. It returns how many people were created.
+func (s *Store) AssignFaces(ctx context.Context, assignments map[int64]int64) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin face assignment: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := make(map[int64]int64)
+	for faceID, personID := range assignments {
+		if personID < 0 {
+			id, ok := created[personID]
+			if !ok {
+				res, err := tx.ExecContext(ctx, `INSERT INTO people (name) VALUES ('')`)
+				if err != nil {
+					return 0, fmt.Errorf("create person: %w", err)
+				}
+				if id, err = res.LastInsertId(); err != nil {
+					return 0, fmt.Errorf("create person: %w", err)
+				}
+				created[personID] = id
+			}
+			personID = id
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE faces SET person_id = ? WHERE id = ?`, personID, faceID); err != nil {
+			return 0, fmt.Errorf("assign face: %w", err)
+		}
+	}
+	// People whose faces all went away with their files are dropped, unless
+	// the user named them.
+	if _, err := tx.ExecContext(ctx, `
+DELETE FROM people WHERE name = '' AND NOT EXISTS (SELECT 1 FROM faces WHERE faces.person_id = people.id)`); err != nil {
+		return 0, fmt.Errorf("prune people: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit face assignment: %w", err)
+	}
+	return len(created), nil
+}
+
+// ListPeople returns the people with at least one face, most photographed
+// first.
+func (s *Store) ListPeople(ctx context.Context) ([]Person, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT p.id, p.name, COUNT(f.id), COUNT(DISTINCT f.media_id),
+    (SELECT c.media_id FROM faces c WHERE c.person_id = p.id ORDER BY c.box_w * c.box_h DESC LIMIT 1)
+FROM people p
+JOIN faces f ON f.person_id = p.id
+GROUP BY p.id
+ORDER BY COUNT(DISTINCT f.media_id) DESC, p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("query people: %w", err)
+	}
+	defer rows.Close()
+
+	var people []Person
+	for rows.Next() {
+		var (
+			person Person
+			name   interface{}
+		)
+		if err := rows.Scan(&person.ID, &name, &person.Faces, &person.Media, &person.CoverMediaID); err != nil {
+			return nil, fmt.Errorf("scan person: %w", err)
+		}
+		if person.Name, err = s.openString(sealedName, name); err != nil {
+			return nil, err
+		}
+		people = append(people, person)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate people: %w", err)
+	}
+	return people, nil
+}
+
+// ListMediaByPerson returns the files showing a person, oldest first.
+func (s *Store) ListMediaByPerson(ctx context.Context, personID int64, page Page) ([]MediaFile, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+mediaColumns+` FROM media_files
+WHERE id IN (SELECT media_id FROM faces WHERE person_id = ?)
+ORDER BY COALESCE(user_taken_at, taken_at), id
+LIMIT ? OFFSET ?`, personID, limit, page.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query person media: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan person media: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate person media: %w", err)
+	}
+	return files, nil
+}
+
+// RenamePerson names a person; tidy patterns use the name as {{.Person}}.
+func (s *Store) RenamePerson(ctx context.Context, personID int64, name string) error {
+	value, err := s.sealString(sealedName, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE people SET name = ? WHERE id = ?`, value, personID)
+	if err != nil {
+		return fmt.Errorf("rename person: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("person %d not found", personID)
+	}
+	return nil
+}
+
+func encodeEmbedding(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeEmbedding(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
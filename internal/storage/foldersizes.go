@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FolderSize is the stored media below one folder. Files and Bytes include
+// every subfolder, also those beyond the requested depth.
+type FolderSize struct {
+	Path     string       `json:"path"`
+	Name     string       `json:"name"`
+	Files    int          `json:"files"`
+	Bytes    int64        `json:"bytes"`
+	Children []FolderSize `json:"children"`
+}
+
+// FolderSizes aggregates the sizes of the media below root by folder, down
+// to depth levels of subfolders, for rendering a treemap. Children are
+// ordered by size, largest first.
+func (s *Store) FolderSizes(ctx context.Context, root string, depth int) (FolderSize, error) {
+	if strings.TrimSpace(root) == "" {
+		return FolderSize{}, errors.New("folder sizes: root is empty")
+	}
+	prefix := dirPrefix(root)
+	tree := FolderSize{Path: filepath.Clean(root), Name: filepath.Base(root), Children: []FolderSize{}}
+
+	query := `
+SELECT ` + dirExpr + ` AS dir, COUNT(*), COALESCE(SUM(size_bytes), 0)
+FROM media_files
+WHERE substr(path, 1, ?) = ?
+GROUP BY dir
+`
+	rows, err := s.db.QueryContext(ctx, query, len(prefix), prefix)
+	if err != nil {
+		return tree, fmt.Errorf("query folder sizes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			dir   string
+			files int
+			bytes int64
+		)
+		if err := rows.Scan(&dir, &files, &bytes); err != nil {
+			return tree, fmt.Errorf("scan folder size: %w", err)
+		}
+
+		node := &tree
+		node.Files += files
+		node.Bytes += bytes
+		rel := strings.Trim(filepath.ToSlash(strings.TrimPrefix(dir, prefix)), "/")
+		if rel == "" {
+			continue
+		}
+		for level, name := range strings.Split(rel, "/") {
+			if level >= depth {
+				break
+			}
+			node = childFolder(node, name)
+			node.Files += files
+			node.Bytes += bytes
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return tree, fmt.Errorf("iterate folder sizes: %w", err)
+	}
+
+	sortFolders(&tree)
+	return tree, nil
+}
+
+// childFolder returns the child of node called name, adding it if missing.
+func childFolder(node *FolderSize, name string) *FolderSize {
+	for i := range node.Children {
+		if node.Children[i].Name == name {
+			return &node.Children[i]
+		}
+	}
+	node.Children = append(node.Children, FolderSize{
+		Path:     filepath.Join(node.Path, name),
+		Name:     name,
+		Children: []FolderSize{},
+	})
+	return &node.Children[len(node.Children)-1]
+}
+
+func sortFolders(node *FolderSize) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Bytes != node.Children[j].Bytes {
+			return node.Children[i].Bytes > node.Children[j].Bytes
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for i := range node.Children {
+		sortFolders(&node.Children[i])
+	}
+}
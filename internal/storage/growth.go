@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Snapshot reasons record what prompted a library snapshot.
+const (
+	SnapshotScan   = "scan"
+	SnapshotTidy   = "tidy"
+	SnapshotRemove = "remove"
+)
+
+// GrowthPoint is the state of the library at the end of a period together
+// with the space removals freed during it. Period is "2024", "2024-05" or
+// "2024-05-17" depending on granularity.
+type GrowthPoint struct {
+	Period          string `json:"period"`
+	TotalFiles      int    `json:"totalFiles"`
+	TotalBytes      int64  `json:"totalBytes"`
+	DuplicateGroups int    `json:"duplicateGroups"`
+	WastedBytes     int64  `json:"wastedBytes"`
+	ReclaimedBytes  int64  `json:"reclaimedBytes"`
+	// Snapshots counts the snapshots recorded during the period.
+	Snapshots int `json:"snapshots"`
+}
+
+// RecordLibrarySnapshot stores the current library totals and duplicate
+// counts. reclaimed is the space the operation behind reason freed.
+func (s *Store) RecordLibrarySnapshot(ctx context.Context, reason string, reclaimed int64) error {
+	var (
+		files int
+		bytes int64
+	)
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM media_files`,
+	).Scan(&files, &bytes); err != nil {
+		return fmt.Errorf("count library: %w", err)
+	}
+	dupes, err := s.DuplicateSummary(ctx, DuplicateScope{})
+	if err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO library_snapshots (reason, total_files, total_bytes, duplicate_groups, wasted_bytes, reclaimed_bytes)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+	if _, err := s.db.ExecContext(ctx, query, reason, files, bytes, dupes.Groups, dupes.WastedBytes, reclaimed); err != nil {
+		return fmt.Errorf("insert library snapshot: %w", err)
+	}
+	return nil
+}
+
+// LibraryGrowth returns one point per "year", "month" or "day" that has
+// snapshots, oldest first. Totals come from the last snapshot of each
+// period; reclaimed bytes add up over it.
+func (s *Store) LibraryGrowth(ctx context.Context, granularity string) ([]GrowthPoint, error) {
+	var width int
+	switch granularity {
+	case GranularityYear:
+		width = 4
+	case GranularityMonth:
+		width = 7
+	case GranularityDay:
+		width = 10
+	default:
+		return nil, fmt.Errorf("unknown growth granularity %q", granularity)
+	}
+
+	query := `
+WITH periods AS (
+    SELECT substr(taken_at, 1, ?) AS period, MAX(id) AS last_id, SUM(reclaimed_bytes) AS reclaimed, COUNT(*) AS n
+    FROM library_snapshots
+    GROUP BY period
+)
+SELECT p.period, s.total_files, s.total_bytes, s.duplicate_groups, s.wasted_bytes, p.reclaimed, p.n
+FROM periods p
+JOIN library_snapshots s ON s.id = p.last_id
+ORDER BY p.period
+`
+	rows, err := s.db.QueryContext(ctx, query, width)
+	if err != nil {
+		return nil, fmt.Errorf("query library growth: %w", err)
+	}
+	defer rows.Close()
+
+	points := []GrowthPoint{}
+	for rows.Next() {
+		var point GrowthPoint
+		if err := rows.Scan(&point.Period, &point.TotalFiles, &point.TotalBytes, &point.DuplicateGroups,
+			&point.WastedBytes, &point.ReclaimedBytes, &point.Snapshots); err != nil {
+			return nil, fmt.Errorf("scan growth point: %w", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate library growth: %w", err)
+	}
+	return points, nil
+}
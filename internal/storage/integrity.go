@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the reported
+// problems; an empty result means the database is healthy.
+func (s *Store) IntegrityCheck(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("scan integrity row: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate integrity rows: %w", err)
+	}
+	return problems, nil
+}
+
+// CountPendingActions returns how many actions never left the pending state,
+// which happens when the app stops mid-operation.
+func (s *Store) CountPendingActions(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM file_actions WHERE status = ?`, string(ActionStatusPending)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count pending actions: %w", err)
+	}
+	return count, nil
+}
+
+// ListInterruptedRuns returns the IDs of tidy runs still marked running.
+func (s *Store) ListInterruptedRuns(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM tidy_runs WHERE status = ? ORDER BY id`, string(RunStatusRunning))
+	if err != nil {
+		return nil, fmt.Errorf("list interrupted runs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan run id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate runs: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// JunkFile is a leftover a scan found instead of media, such as a temporary
+// file or an empty file from an interrupted copy.
+type JunkFile struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"`
+	Reason    string `json:"reason"`
+	FoundAt   string `json:"foundAt"`
+}
+
+// RecordJunk remembers a junk file for a later cleanup.
+func (s *Store) RecordJunk(ctx context.Context, path string, size int64, reason string) error {
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO junk_files (path, size_bytes, reason, found_at)
+VALUES (?, ?, ?, datetime('now'))
+ON CONFLICT(path) DO UPDATE SET
+    size_bytes = excluded.size_bytes,
+    reason = excluded.reason,
+    found_at = excluded.found_at
+`, path, size, reason); err != nil {
+		return fmt.Errorf("record junk: %w", err)
+	}
+	return nil
+}
+
+// ListJunk returns the junk files found by scans, by path.
+func (s *Store) ListJunk(ctx context.Context) ([]JunkFile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT path, size_bytes, reason, found_at FROM junk_files ORDER BY path`)
+	if err != nil {
+		return nil, fmt.Errorf("query junk: %w", err)
+	}
+	defer rows.Close()
+
+	junk := []JunkFile{}
+	for rows.Next() {
+		var file JunkFile
+		if err := rows.Scan(&file.Path, &file.SizeBytes, &file.Reason, &file.FoundAt); err != nil {
+			return nil, fmt.Errorf("scan junk: %w", err)
+		}
+		junk = append(junk, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate junk: %w", err)
+	}
+	return junk, nil
+}
+
+// ForgetJunk drops a junk file from the list, once removed or gone.
+func (s *Store) ForgetJunk(ctx context.Context, path string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM junk_files WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("forget junk: %w", err)
+	}
+	return nil
+}
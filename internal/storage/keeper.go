@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// masterFolderNames marks path segments that indicate a curated original.
+var masterFolderNames = []string{"master", "masters", "originals", "original"}
+
+// suggestKeepers fills SuggestedKeeperID for every group using RankKeeper.
+func (s *Store) suggestKeepers(ctx context.Context, groups []DuplicateGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, len(groups))
+	for i, group := range groups {
+		hashes[i] = group.Hash
+	}
+	counts, err := s.exifCounts(ctx, hashes)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		groups[i].SuggestedKeeperID = RankKeeper(groups[i].Files, counts)
+	}
+	return nil
+}
+
+// RankKeeper picks the copy most worth keeping: the largest resolution, then
+// the earliest capture time, then a path below a "master" folder, then the
+// richest EXIF block. Remaining ties keep the lowest ID.
+func RankKeeper(files []MediaFile, exifCounts map[int64]int) int64 {
+	if len(files) == 0 {
+		return 0
+	}
+
+	best := files[0]
+	for _, candidate := range files[1:] {
+		if betterKeeper(candidate, best, exifCounts) {
+			best = candidate
+		}
+	}
+	return best.ID
+}
+
+func betterKeeper(a, b MediaFile, exifCounts map[int64]int) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if a.TakenAt.Valid != b.TakenAt.Valid {
+		return a.TakenAt.Valid
+	}
+	if a.TakenAt.Valid && !a.TakenAt.Time.Equal(b.TakenAt.Time) {
+		return a.TakenAt.Time.Before(b.TakenAt.Time)
+	}
+	if ma, mb := underMasterFolder(a.Path), underMasterFolder(b.Path); ma != mb {
+		return ma
+	}
+	if ea, eb := exifCounts[a.ID], exifCounts[b.ID]; ea != eb {
+		return ea > eb
+	}
+	return a.ID < b.ID
+}
+
+func underMasterFolder(path string) bool {
+	for _, segment := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		segment = strings.ToLower(segment)
+		for _, name := range masterFolderNames {
+			if segment == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exifCountsBatch bounds the hashes bound into one exif count query.
+const exifCountsBatch = 500
+
+// exifCounts returns the number of EXIF tags of every file with one of hashes.
+func (s *Store) exifCounts(ctx context.Context, hashes []string) (map[int64]int, error) {
+	counts := make(map[int64]int)
+	for start := 0; start < len(hashes); start += exifCountsBatch {
+		batch := hashes[start:min(start+exifCountsBatch, len(hashes))]
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, len(batch))
+		for i, hash := range batch {
+			placeholders[i] = "?"
+			args[i] = hash
+		}
+		if err := s.countExif(ctx, strings.Join(placeholders, ","), args, counts); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+func (s *Store) countExif(ctx context.Context, placeholders string, args []interface{}, counts map[int64]int) error {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.media_id, COUNT(*)
+FROM media_exif e
+JOIN media_files m ON m.id = e.media_id
+WHERE m.hash_md5 IN (`+placeholders+`)
+GROUP BY e.media_id
+`, args...)
+	if err != nil {
+		return fmt.Errorf("query exif counts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return fmt.Errorf("scan exif count: %w", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate exif counts: %w", err)
+	}
+	return nil
+}
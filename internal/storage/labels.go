@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListLabelCandidates returns images not yet labelled by model in their
+// current content, up to limit (0 for all).
+func (s *Store) ListLabelCandidates(ctx context.Context, model string, limit int) ([]MediaFile, error) {
+	query := `
+SELECT ` + mediaColumns + ` FROM media_files
+WHERE mime_type LIKE 'image/%' AND NOT EXISTS (
+    SELECT 1 FROM label_scans l
+    WHERE l.media_id = media_files.id AND l.hash_md5 = media_files.hash_md5 AND l.model = ?
+)
+ORDER BY id`
+	args := []interface{}{model}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query label candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan label candidate: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate label candidates: %w", err)
+	}
+	return files, nil
+}
+
+// SaveLabels replaces the tags source gave a file with labels, found by
+// model in the content with the given hash.
+func (s *Store) SaveLabels(ctx context.Context, mediaID int64, hash, model, source string, labels []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin labels: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM media_tags WHERE media_id = ? AND source = ?`, mediaID, source); err != nil {
+		return fmt.Errorf("clear labels: %w", err)
+	}
+	for _, label := range labels {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO media_tags (media_id, tag, source) VALUES (?, ?, ?)`, mediaID, label, source); err != nil {
+			return fmt.Errorf("save label: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO label_scans (media_id, hash_md5, model, scanned_at)
+VALUES (?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    hash_md5 = excluded.hash_md5,
+    model = excluded.model,
+    scanned_at = excluded.scanned_at
+`, mediaID, hash, model); err != nil {
+		return fmt.Errorf("record label scan: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit labels: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// DatabaseStats describes how much of the database file is in use.
+type DatabaseStats struct {
+	SizeBytes int64 `json:"sizeBytes"`
+	// FreeBytes is the space held by deleted rows, which only a VACUUM
+	// hands back to the file system.
+	FreeBytes int64 `json:"freeBytes"`
+}
+
+// FreePercent is the share of the file FreeBytes make up.
+func (d DatabaseStats) FreePercent() int {
+	if d.SizeBytes == 0 {
+		return 0
+	}
+	return int(d.FreeBytes * 100 / d.SizeBytes)
+}
+
+// Stats reports the size of the database and its free pages.
+func (s *Store) Stats(ctx context.Context) (DatabaseStats, error) {
+	var pageSize, pages, free int64
+	for pragma, dst := range map[string]*int64{"page_size": &pageSize, "page_count": &pages, "freelist_count": &free} {
+		if err := s.db.QueryRowContext(ctx, `PRAGMA `+pragma).Scan(dst); err != nil {
+			return DatabaseStats{}, fmt.Errorf("read %s: %w", pragma, err)
+		}
+	}
+	return DatabaseStats{SizeBytes: pages * pageSize, FreeBytes: free * pageSize}, nil
+}
+
+// Analyze refreshes the statistics the query planner picks indexes by.
+func (s *Store) Analyze(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `ANALYZE`); err != nil {
+		return fmt.Errorf("analyze database: %w", err)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file without its free pages. It needs room
+// for a second copy of the database and blocks writers while it runs.
+func (s *Store) Vacuum(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// mapCellsPerTile is how many cluster cells span one map tile edge, so a
+// cluster covers roughly 64 pixels of a 256 pixel tile at any zoom.
+const mapCellsPerTile = 4
+
+// maxMapZoom is the deepest zoom level clustering distinguishes.
+const maxMapZoom = 22
+
+// MapBounds is the visible map area in decimal degrees. West greater than
+// East means the view crosses the antimeridian.
+type MapBounds struct {
+	North float64 `json:"north"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	West  float64 `json:"west"`
+}
+
+// MapCluster is a group of geo-tagged files drawn as one marker. Latitude
+// and Longitude are the members' centroid and Bounds their extent, which the
+// map zooms to when the marker is clicked.
+type MapCluster struct {
+	Latitude         float64   `json:"latitude"`
+	Longitude        float64   `json:"longitude"`
+	Count            int       `json:"count"`
+	RepresentativeID int64     `json:"representativeId"`
+	Bounds           MapBounds `json:"bounds"`
+	// Thumbnail is the URL the frontend loads the representative from; the
+	// app fills it in.
+	Thumbnail string `json:"thumbnail"`
+}
+
+// MapClusters groups the geo-tagged media inside bounds on a grid whose cells
+// shrink as zoom grows, so the map receives one row per marker instead of
+// one per file. The representative is the newest image of each cell.
+func (s *Store) MapClusters(ctx context.Context, bounds MapBounds, zoom int) ([]MapCluster, error) {
+	if bounds.South > bounds.North {
+		return nil, fmt.Errorf("map bounds south %.6f is above north %.6f", bounds.South, bounds.North)
+	}
+	zoom = max(0, min(zoom, maxMapZoom))
+	cell := 360 / (math.Exp2(float64(zoom)) * mapCellsPerTile)
+
+	// Longitudes west of the view are shifted by a full turn so a view across
+	// the antimeridian is one contiguous range.
+	lonCond := `longitude BETWEEN ? AND ?`
+	if bounds.West > bounds.East {
+		lonCond = `(longitude >= ? OR longitude <= ?)`
+	}
+	query := `
+WITH p AS (
+    SELECT id, mime_type, latitude,
+        CASE WHEN longitude < ? THEN longitude + 360 ELSE longitude END AS lon
+    FROM media_files
+    WHERE latitude BETWEEN ? AND ? AND ` + lonCond + `
+)
+SELECT COUNT(*), AVG(latitude), AVG(lon), MIN(latitude), MAX(latitude), MIN(lon), MAX(lon),
+    COALESCE(MAX(CASE WHEN mime_type LIKE 'image/%' THEN id END), MAX(id))
+FROM p
+GROUP BY CAST(floor((latitude + 90) / ?) AS INTEGER), CAST(floor((lon + 180) / ?) AS INTEGER)
+ORDER BY COUNT(*) DESC
+`
+	rows, err := s.db.QueryContext(ctx, query,
+		bounds.West,
+		bounds.South, bounds.North, bounds.West, bounds.East,
+		cell, cell,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cluster media: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []MapCluster{}
+	for rows.Next() {
+		var c MapCluster
+		if err := rows.Scan(&c.Count, &c.Latitude, &c.Longitude,
+			&c.Bounds.South, &c.Bounds.North, &c.Bounds.West, &c.Bounds.East,
+			&c.RepresentativeID,
+		); err != nil {
+			return nil, fmt.Errorf("scan cluster: %w", err)
+		}
+		c.Longitude = wrapLongitude(c.Longitude)
+		c.Bounds.West = wrapLongitude(c.Bounds.West)
+		c.Bounds.East = wrapLongitude(c.Bounds.East)
+		clusters = append(clusters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate clusters: %w", err)
+	}
+	return clusters, nil
+}
+
+// wrapLongitude undoes the antimeridian shift applied by MapClusters.
+func wrapLongitude(lon float64) float64 {
+	if lon > 180 {
+		return lon - 360
+	}
+	return lon
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PHash is the perceptual hash of a file's content.
+type PHash struct {
+	MediaID int64
+	Hash    uint64
+}
+
+// ListPHashCandidates returns files of the given MIME types without a
+// perceptual hash of their current content, up to limit (0 for all).
+func (s *Store) ListPHashCandidates(ctx context.Context, mimeTypes []string, limit int) ([]MediaFile, error) {
+	if len(mimeTypes) == 0 {
+		return nil, nil
+	}
+	query := `
+SELECT ` + mediaColumns + ` FROM media_files
+WHERE mime_type IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(mimeTypes)), ", ") + `) AND NOT EXISTS (
+    SELECT 1 FROM media_phash p
+    WHERE p.media_id = media_files.id AND p.hash_md5 = media_files.hash_md5
+)
+ORDER BY id`
+	args := make([]interface{}, 0, len(mimeTypes)+1)
+	for _, mime := range mimeTypes {
+		args = append(args, mime)
+	}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query phash candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan phash candidate: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate phash candidates: %w", err)
+	}
+	return files, nil
+}
+
+// SavePHash stores the perceptual hash of a file's content with the given
+// MD5 hash.
+func (s *Store) SavePHash(ctx context.Context, mediaID int64, hash string, phash uint64) error {
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO media_phash (media_id, hash_md5, phash, computed_at)
+VALUES (?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    hash_md5 = excluded.hash_md5,
+    phash = excluded.phash,
+    computed_at = excluded.computed_at
+`, mediaID, hash, int64(phash)); err != nil {
+		return fmt.Errorf("save phash: %w", err)
+	}
+	return nil
+}
+
+// GetPHash returns the perceptual hash of a file's current content and
+// whether there is one.
+func (s *Store) GetPHash(ctx context.Context, mediaID int64) (uint64, bool, error) {
+	var phash int64
+	err := s.db.QueryRowContext(ctx, `
+SELECT p.phash FROM media_phash p
+JOIN media_files m ON m.id = p.media_id AND m.hash_md5 = p.hash_md5
+WHERE p.media_id = ?`, mediaID).Scan(&phash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("query phash: %w", err)
+	}
+	return uint64(phash), true, nil
+}
+
+// ListPHashes returns the perceptual hashes of every file whose content has
+// not changed since it was hashed.
+func (s *Store) ListPHashes(ctx context.Context) ([]PHash, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT p.media_id, p.phash FROM media_phash p
+JOIN media_files m ON m.id = p.media_id AND m.hash_md5 = p.hash_md5
+ORDER BY p.media_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query phashes: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []PHash
+	for rows.Next() {
+		var (
+			entry PHash
+			phash int64
+		)
+		if err := rows.Scan(&entry.MediaID, &phash); err != nil {
+			return nil, fmt.Errorf("scan phash: %w", err)
+		}
+		entry.Hash = uint64(phash)
+		hashes = append(hashes, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate phashes: %w", err)
+	}
+	return hashes, nil
+}
+
+// VideoFingerprint is the signature of a video's content: its duration and
+// the perceptual hashes of frames sampled at fixed fractions of it.
+type VideoFingerprint struct {
+	MediaID         int64
+	DurationSeconds float64
+	Frames          []uint64
+}
+
+// ListVideoFingerprintCandidates returns videos without a fingerprint of
+// their current content, up to limit (0 for all).
+func (s *Store) ListVideoFingerprintCandidates(ctx context.Context, limit int) ([]MediaFile, error) {
+	query := `
+SELECT ` + mediaColumns + ` FROM media_files
+WHERE mime_type LIKE 'video/%' AND NOT EXISTS (
+    SELECT 1 FROM video_fingerprints v
+    WHERE v.media_id = media_files.id AND v.hash_md5 = media_files.hash_md5
+)
+ORDER BY id`
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query fingerprint candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan fingerprint candidate: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate fingerprint candidates: %w", err)
+	}
+	return files, nil
+}
+
+// SaveVideoFingerprint stores the fingerprint of a video's content with the
+// given MD5 hash.
+func (s *Store) SaveVideoFingerprint(ctx context.Context, hash string, fp VideoFingerprint) error {
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO video_fingerprints (media_id, hash_md5, duration_seconds, frames, computed_at)
+VALUES (?, ?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    hash_md5 = excluded.hash_md5,
+    duration_seconds = excluded.duration_seconds,
+    frames = excluded.frames,
+    computed_at = excluded.computed_at
+`, fp.MediaID, hash, fp.DurationSeconds, encodeFrames(fp.Frames)); err != nil {
+		return fmt.Errorf("save video fingerprint: %w", err)
+	}
+	return nil
+}
+
+// ListVideoFingerprints returns the fingerprints of every video whose
+// content has not changed since, shortest first.
+func (s *Store) ListVideoFingerprints(ctx context.Context) ([]VideoFingerprint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT v.media_id, v.duration_seconds, v.frames FROM video_fingerprints v
+JOIN media_files m ON m.id = v.media_id AND m.hash_md5 = v.hash_md5
+ORDER BY v.duration_seconds, v.media_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query video fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	var prints []VideoFingerprint
+	for rows.Next() {
+		var (
+			fp   VideoFingerprint
+			blob []byte
+		)
+		if err := rows.Scan(&fp.MediaID, &fp.DurationSeconds, &blob); err != nil {
+			return nil, fmt.Errorf("scan video fingerprint: %w", err)
+		}
+		fp.Frames = decodeFrames(blob)
+		prints = append(prints, fp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate video fingerprints: %w", err)
+	}
+	return prints, nil
+}
+
+func encodeFrames(frames []uint64) []byte {
+	buf := make([]byte, 8*len(frames))
+	for i, f := range frames {
+		binary.LittleEndian.PutUint64(buf[8*i:], f)
+	}
+	return buf
+}
+
+func decodeFrames(buf []byte) []uint64 {
+	frames := make([]uint64, len(buf)/8)
+	for i := range frames {
+		frames[i] = binary.LittleEndian.Uint64(buf[8*i:])
+	}
+	return frames
+}
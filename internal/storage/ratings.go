@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// MediaRating is curation carried over from another photo manager.
+type MediaRating struct {
+	MediaID int64 `json:"mediaId"`
+	// Rating is 0 to 5 stars.
+	Rating int `json:"rating"`
+	// Flag is "pick", "reject" or empty.
+	Flag       string `json:"flag"`
+	ColorLabel string `json:"colorLabel"`
+	// Developed reports edits made in the source application.
+	Developed bool   `json:"developed"`
+	Source    string `json:"source"`
+}
+
+// SaveMediaRating stores the curation of a file, replacing any imported
+// earlier.
+func (s *Store) SaveMediaRating(ctx context.Context, rating MediaRating) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO media_ratings (media_id, rating, flag, color_label, developed, source, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    rating = excluded.rating,
+    flag = excluded.flag,
+    color_label = excluded.color_label,
+    developed = excluded.developed,
+    source = excluded.source,
+    updated_at = excluded.updated_at
+`, rating.MediaID, rating.Rating, rating.Flag, rating.ColorLabel, rating.Developed, rating.Source)
+	if err != nil {
+		return fmt.Errorf("save media rating: %w", err)
+	}
+	return nil
+}
+
+// GetMediaRating returns the curation stored for a file.
+func (s *Store) GetMediaRating(ctx context.Context, mediaID int64) (MediaRating, bool, error) {
+	rating := MediaRating{MediaID: mediaID}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT rating, flag, color_label, developed, source FROM media_ratings WHERE media_id = ?`, mediaID,
+	).Scan(&rating.Rating, &rating.Flag, &rating.ColorLabel, &rating.Developed, &rating.Source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MediaRating{}, false, nil
+	}
+	if err != nil {
+		return MediaRating{}, false, fmt.Errorf("get media rating: %w", err)
+	}
+	return rating, true, nil
+}
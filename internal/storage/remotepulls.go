@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RemotePull is a file copied down from an rclone remote source. It is kept
+// so files tidied out of the local mirror are not downloaded again.
+type RemotePull struct {
+	// Path is relative to the source, slash-separated.
+	Path      string
+	SizeBytes int64
+	ModTime   time.Time
+}
+
+// ListRemotePulls returns the files already pulled from source, by path.
+func (s *Store) ListRemotePulls(ctx context.Context, source string) (map[string]RemotePull, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT path, size_bytes, mod_time FROM remote_pulls WHERE source = ?`, source)
+	if err != nil {
+		return nil, fmt.Errorf("query remote pulls: %w", err)
+	}
+	defer rows.Close()
+
+	pulls := make(map[string]RemotePull)
+	for rows.Next() {
+		var pull RemotePull
+		var modTime string
+		if err := rows.Scan(&pull.Path, &pull.SizeBytes, &modTime); err != nil {
+			return nil, fmt.Errorf("scan remote pull: %w", err)
+		}
+		pull.ModTime, _ = time.Parse(time.RFC3339Nano, modTime)
+		pulls[pull.Path] = pull
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate remote pulls: %w", err)
+	}
+	return pulls, nil
+}
+
+// RecordRemotePulls remembers files pulled from source, replacing earlier
+// records of the same paths.
+func (s *Store) RecordRemotePulls(ctx context.Context, source string, pulls []RemotePull) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin remote pulls: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+INSERT INTO remote_pulls (source, path, size_bytes, mod_time, pulled_at)
+VALUES (?, ?, ?, ?, datetime('now'))
+ON CONFLICT(source, path) DO UPDATE SET
+    size_bytes = excluded.size_bytes,
+    mod_time = excluded.mod_time,
+    pulled_at = excluded.pulled_at
+`)
+	if err != nil {
+		return fmt.Errorf("prepare remote pull: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, pull := range pulls {
+		if _, err := stmt.ExecContext(ctx, source, pull.Path, pull.SizeBytes, pull.ModTime.UTC().Format(time.RFC3339Nano)); err != nil {
+			return fmt.Errorf("record remote pull: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit remote pulls: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sqliteTimeLayout matches the text produced by SQLite's datetime('now').
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// archivedAction is the JSONL record written for each expired action row.
+type archivedAction struct {
+	ID         int64  `json:"id"`
+	MediaID    *int64 `json:"mediaId,omitempty"`
+	SourcePath string `json:"sourcePath"`
+	TargetPath string `json:"targetPath,omitempty"`
+	ActionType string `json:"actionType"`
+	Status     string `json:"status"`
+	ErrorMsg   string `json:"errorMsg,omitempty"`
+	ExecutedAt string `json:"executedAt,omitempty"`
+	HashMD5    string `json:"hashMd5,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+// ArchiveExpiredActions exports completed actions created before the cutoff
+// into gzip-compressed JSONL files under dir, one file per calendar month,
+// and deletes them afterwards. It returns the number of archived rows. The
+// latest upload of each backup object stays, since backups skip objects by it.
+func (s *Store) ArchiveExpiredActions(ctx context.Context, before time.Time, dir string) (int, error) {
+	query := `
+SELECT id, media_id, source_path, target_path, action_type, status, error_msg, executed_at, hash_md5, created_at
+FROM file_actions
+WHERE status = ? AND created_at < ?
+  AND NOT (action_type = 'upload' AND id = (
+      SELECT MAX(u.id) FROM file_actions u
+      WHERE u.action_type = 'upload' AND u.status = file_actions.status AND u.target_path = file_actions.target_path))
+ORDER BY id
+`
+
+	rows, err := s.db.QueryContext(ctx, query, string(ActionStatusCompleted), before.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("query expired actions: %w", err)
+	}
+
+	byMonth := make(map[string][]archivedAction)
+	var ids []int64
+	for rows.Next() {
+		var (
+			rec                              archivedAction
+			mediaID                          sql.NullInt64
+			target, errMsg, executedAt, hash sql.NullString
+		)
+		if err := rows.Scan(&rec.ID, &mediaID, &rec.SourcePath, &target, &rec.ActionType, &rec.Status, &errMsg, &executedAt, &hash, &rec.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan expired action: %w", err)
+		}
+		if mediaID.Valid {
+			rec.MediaID = &mediaID.Int64
+		}
+		rec.TargetPath = target.String
+		rec.ErrorMsg = errMsg.String
+		rec.ExecutedAt = executedAt.String
+		rec.HashMD5 = hash.String
+
+		month := "unknown"
+		if len(rec.CreatedAt) >= 7 {
+			month = rec.CreatedAt[:7]
+		}
+		byMonth[month] = append(byMonth[month], rec)
+		ids = append(ids, rec.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate expired actions: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("create archive directory: %w", err)
+	}
+	for month, records := range byMonth {
+		if err := appendArchive(filepath.Join(dir, fmt.Sprintf("actions-%s.jsonl.gz", month)), records); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin prune actions: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM file_actions WHERE id = ?`)
+	if err != nil {
+		return 0, fmt.Errorf("prepare prune actions: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, id); err != nil {
+			return 0, fmt.Errorf("prune action %d: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit prune actions: %w", err)
+	}
+
+	return len(ids), nil
+}
+
+// appendArchive appends records as a new gzip member, so repeated exports
+// for the same month remain a single valid gzip stream.
+func appendArchive(path string, records []archivedAction) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			zw.Close()
+			return fmt.Errorf("write archive: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("flush archive: %w", err)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TidyRunStatus enumerates the lifecycle of a tidy run.
+type TidyRunStatus string
+
+const (
+	RunStatusRunning    TidyRunStatus = "running"
+	RunStatusFinished   TidyRunStatus = "finished"
+	RunStatusRolledBack TidyRunStatus = "rolled_back"
+)
+
+// RunMove is one completed move of a run together with the path the media
+// had before the run started.
+type RunMove struct {
+	ActionID    int64
+	MediaID     int64
+	PriorPath   string
+	CurrentPath string
+}
+
+// CreateTidyRun opens a run and snapshots the current paths of its media.
+func (s *Store) CreateTidyRun(ctx context.Context, files []MediaFile) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tidy run: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO tidy_runs (status) VALUES (?)`, string(RunStatusRunning))
+	if err != nil {
+		return 0, fmt.Errorf("insert tidy run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("insert tidy run: %w", err)
+	}
+
+	if err := insertRunItems(ctx, tx, runID, files); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tidy run: %w", err)
+	}
+	return runID, nil
+}
+
+// SnapshotTidyRun adds the current paths of more media to an open run, for
+// runs whose media are fed in batches.
+func (s *Store) SnapshotTidyRun(ctx context.Context, runID int64, files []MediaFile) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin run snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertRunItems(ctx, tx, runID, files); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit run snapshot: %w", err)
+	}
+	return nil
+}
+
+func insertRunItems(ctx context.Context, tx *sql.Tx, runID int64, files []MediaFile) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO tidy_run_items (run_id, media_id, prior_path) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare run snapshot: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, file := range files {
+		if _, err := stmt.ExecContext(ctx, runID, file.ID, file.Path); err != nil {
+			return fmt.Errorf("snapshot media %d: %w", file.ID, err)
+		}
+	}
+	return nil
+}
+
+// SetTidyRunStatus updates the status of a run and stamps its finish time.
+func (s *Store) SetTidyRunStatus(ctx context.Context, runID int64, status TidyRunStatus) error {
+	query := `UPDATE tidy_runs SET status = ?, finished_at = datetime('now') WHERE id = ?`
+	if _, err := s.db.ExecContext(ctx, query, string(status), runID); err != nil {
+		return fmt.Errorf("update tidy run: %w", err)
+	}
+	return nil
+}
+
+// GetTidyRunStatus returns the status of a run.
+func (s *Store) GetTidyRunStatus(ctx context.Context, runID int64) (TidyRunStatus, error) {
+	var status string
+	err := s.db.QueryRowContext(ctx, `SELECT status FROM tidy_runs WHERE id = ?`, runID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("tidy run %d not found", runID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("query tidy run: %w", err)
+	}
+	return TidyRunStatus(status), nil
+}
+
+// ListRunMoves returns the completed moves of a run, newest first, so they
+// can be undone in reverse order.
+func (s *Store) ListRunMoves(ctx context.Context, runID int64) ([]RunMove, error) {
+	query := `
+SELECT a.id, a.media_id, i.prior_path, a.target_path
+FROM file_actions a
+JOIN tidy_run_items i ON i.run_id = a.run_id AND i.media_id = a.media_id
+WHERE a.run_id = ? AND a.status IN (?, ?)
+ORDER BY a.id DESC
+`
+
+	rows, err := s.db.QueryContext(ctx, query, runID, string(ActionStatusCompleted), string(ActionStatusQuarantined))
+	if err != nil {
+		return nil, fmt.Errorf("query run moves: %w", err)
+	}
+	defer rows.Close()
+
+	var moves []RunMove
+	for rows.Next() {
+		var move RunMove
+		if err := rows.Scan(&move.ActionID, &move.MediaID, &move.PriorPath, &move.CurrentPath); err != nil {
+			return nil, fmt.Errorf("scan run move: %w", err)
+		}
+		moves = append(moves, move)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate run moves: %w", err)
+	}
+	return moves, nil
+}
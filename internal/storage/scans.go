@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Scan session states.
+const (
+	ScanRunning   = "running"
+	ScanFinished  = "finished"
+	ScanCancelled = "cancelled"
+	ScanPaused    = "paused"
+)
+
+// ScanSession is the record of one scan. A running scan saves its summary
+// every few seconds, so the row tells how far a scan got even when the app
+// stopped before it finished.
+type ScanSession struct {
+	ID      int64    `json:"id"`
+	JobID   string   `json:"jobId"`
+	Sources []string `json:"sources"`
+	Status  string   `json:"status"`
+	// Summary is the scan's summary as last saved.
+	Summary   json.RawMessage `json:"summary,omitempty"`
+	StartedAt string          `json:"startedAt"`
+	UpdatedAt string          `json:"updatedAt"`
+	// Options are the scanner's options to resume the scan with.
+	Options json.RawMessage `json:"-"`
+}
+
+// ScanCheckpoint records how far a scan got through one source: the last
+// file it processed, or that it finished the source.
+type ScanCheckpoint struct {
+	Source   string `json:"source"`
+	LastPath string `json:"lastPath"`
+	Done     bool   `json:"done"`
+}
+
+// StartScanSession records a scan of sources starting and returns its ID.
+// options is kept to resume the scan with.
+func (s *Store) StartScanSession(ctx context.Context, jobID string, sources []string, options []byte) (int64, error) {
+	encoded, err := json.Marshal(sources)
+	if err != nil {
+		return 0, fmt.Errorf("encode scan sources: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO scan_sessions (job_id, sources, status, options) VALUES (?, ?, ?, ?)`,
+		jobID, string(encoded), ScanRunning, string(options))
+	if err != nil {
+		return 0, fmt.Errorf("start scan session: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("start scan session: %w", err)
+	}
+	return id, nil
+}
+
+// SaveScanSession stores the state and JSON summary of a scan.
+func (s *Store) SaveScanSession(ctx context.Context, id int64, status string, summary []byte) error {
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE scan_sessions SET status = ?, summary = ?, updated_at = datetime('now') WHERE id = ?`,
+		status, string(summary), id); err != nil {
+		return fmt.Errorf("save scan session: %w", err)
+	}
+	return nil
+}
+
+// scanSessionColumns lists the columns scanScanSession reads.
+const scanSessionColumns = `id, job_id, sources, status, COALESCE(summary, ''), started_at, updated_at, COALESCE(options, '')`
+
+// GetScanSession returns one scan session.
+func (s *Store) GetScanSession(ctx context.Context, id int64) (ScanSession, error) {
+	session, err := scanScanSession(s.db.QueryRowContext(ctx,
+		`SELECT `+scanSessionColumns+` FROM scan_sessions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, fmt.Errorf("scan session %d not found", id)
+	}
+	return session, err
+}
+
+// ListScanSessions returns the latest scans, newest first, up to limit (0
+// for 50).
+func (s *Store) ListScanSessions(ctx context.Context, limit int) ([]ScanSession, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+scanSessionColumns+` FROM scan_sessions ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query scan sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []ScanSession
+	for rows.Next() {
+		session, err := scanScanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate scan sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+func scanScanSession(row rowScanner) (ScanSession, error) {
+	var (
+		session ScanSession
+		sources string
+		summary string
+		options string
+	)
+	if err := row.Scan(&session.ID, &session.JobID, &sources, &session.Status, &summary, &session.StartedAt, &session.UpdatedAt, &options); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return session, err
+		}
+		return session, fmt.Errorf("read scan session: %w", err)
+	}
+	if err := json.Unmarshal([]byte(sources), &session.Sources); err != nil {
+		return session, fmt.Errorf("decode scan sources: %w", err)
+	}
+	if summary != "" {
+		session.Summary = json.RawMessage(summary)
+	}
+	if options != "" {
+		session.Options = json.RawMessage(options)
+	}
+	return session, nil
+}
+
+// LatestResumableScan returns the newest scan that was paused or that was
+// still running when the app stopped. Callers must make sure no scan is
+// running.
+func (s *Store) LatestResumableScan(ctx context.Context) (ScanSession, bool, error) {
+	session, err := scanScanSession(s.db.QueryRowContext(ctx,
+		`SELECT `+scanSessionColumns+` FROM scan_sessions WHERE status IN (?, ?) ORDER BY id DESC LIMIT 1`,
+		ScanPaused, ScanRunning))
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, false, nil
+	}
+	if err != nil {
+		return session, false, err
+	}
+	return session, true, nil
+}
+
+// SaveScanCheckpoints records how far a scan got through its sources.
+func (s *Store) SaveScanCheckpoints(ctx context.Context, sessionID int64, checkpoints []ScanCheckpoint) error {
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin scan checkpoints: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+INSERT INTO scan_checkpoints (session_id, source, last_path, done) VALUES (?, ?, ?, ?)
+ON CONFLICT(session_id, source) DO UPDATE SET last_path = excluded.last_path, done = excluded.done`)
+	if err != nil {
+		return fmt.Errorf("prepare scan checkpoint: %w", err)
+	}
+	defer stmt.Close()
+	for _, cp := range checkpoints {
+		if _, err := stmt.ExecContext(ctx, sessionID, cp.Source, cp.LastPath, cp.Done); err != nil {
+			return fmt.Errorf("save scan checkpoint: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit scan checkpoints: %w", err)
+	}
+	return nil
+}
+
+// ListScanCheckpoints returns how far a scan got through each source.
+func (s *Store) ListScanCheckpoints(ctx context.Context, sessionID int64) ([]ScanCheckpoint, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT source, last_path, done FROM scan_checkpoints WHERE session_id = ? ORDER BY source`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query scan checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []ScanCheckpoint
+	for rows.Next() {
+		var cp ScanCheckpoint
+		if err := rows.Scan(&cp.Source, &cp.LastPath, &cp.Done); err != nil {
+			return nil, fmt.Errorf("read scan checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate scan checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// ScanError is a problem a scan ran into, mostly with a single file.
+type ScanError struct {
+	ID        int64 `json:"id"`
+	SessionID int64 `json:"sessionId"`
+	// Stage names the step that failed, such as "metadata" or "walk".
+	Stage string `json:"stage"`
+	// Path is empty for errors not about a file.
+	Path      string `json:"path,omitempty"`
+	Message   string `json:"message"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// SaveScanErrors appends errors to the log of a scan session.
+func (s *Store) SaveScanErrors(ctx context.Context, sessionID int64, errs []ScanError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin scan errors: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO scan_errors (session_id, stage, path, message) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare scan error: %w", err)
+	}
+	defer stmt.Close()
+	for _, e := range errs {
+		if _, err := stmt.ExecContext(ctx, sessionID, e.Stage, e.Path, e.Message); err != nil {
+			return fmt.Errorf("save scan error: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit scan errors: %w", err)
+	}
+	return nil
+}
+
+// ListScanErrors returns a page of the errors of a scan session in the
+// order they happened.
+func (s *Store) ListScanErrors(ctx context.Context, sessionID int64, page Page) ([]ScanError, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	var errs []ScanError
+	err := s.eachScanError(ctx, `
+SELECT id, session_id, stage, path, message, created_at FROM scan_errors
+WHERE session_id = ? ORDER BY id LIMIT ? OFFSET ?`, []interface{}{sessionID, limit, page.Offset}, func(e ScanError) error {
+		errs = append(errs, e)
+		return nil
+	})
+	return errs, err
+}
+
+// EachScanError calls fn with every error of a scan session in the order
+// they happened, without holding them all in memory.
+func (s *Store) EachScanError(ctx context.Context, sessionID int64, fn func(ScanError) error) error {
+	return s.eachScanError(ctx, `
+SELECT id, session_id, stage, path, message, created_at FROM scan_errors
+WHERE session_id = ? ORDER BY id`, []interface{}{sessionID}, fn)
+}
+
+func (s *Store) eachScanError(ctx context.Context, query string, args []interface{}, fn func(ScanError) error) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query scan errors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e ScanError
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.Stage, &e.Path, &e.Message, &e.CreatedAt); err != nil {
+			return fmt.Errorf("read scan error: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate scan errors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// searchTags are the EXIF tags whose values are searchable alongside the
+// category and the tags imported from other photo managers. Text read from
+// images by OCR has a column of its own.
+const searchTags = `'ImageDescription', 'UserComment', 'Artist', 'LensModel'`
+
+// searchSchema keeps media_search in step with media_files and media_exif
+// through triggers, so every writer stays oblivious to the index. The
+// unicode61 tokenizer splits paths on separators, underscores and dots, and
+// the capture date on dashes, so "DSC beach 2019" matches
+// "/Photos/Beach/DSC_0042.JPG" taken that year.
+const searchSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS media_search USING fts5(
+    name, path, camera, taken, tags, text,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIEW IF NOT EXISTS media_search_source AS
+SELECT id,
+    replace(path, ` + dirExpr + `, '') AS name,
+    path,
+    trim(COALESCE(user_camera_make, camera_make, '') || ' ' || COALESCE(user_camera_model, camera_model, '')) AS camera,
+    COALESCE(substr(` + takenExpr + `, 1, 10), '') AS taken,
+    trim(category || ' ' || COALESCE((
+        SELECT group_concat(value, ' ') FROM media_exif
+        WHERE media_exif.media_id = media_files.id AND tag IN (` + searchTags + `)
+    ), '') || ' ' || COALESCE((
+        SELECT group_concat(tag, ' ') FROM media_tags
+        WHERE media_tags.media_id = media_files.id
+    ), '')) AS tags,
+    COALESCE((SELECT text FROM media_text WHERE media_text.media_id = media_files.id AND typeof(text) = 'text'), '') AS text
+FROM media_files;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_insert
+AFTER INSERT ON media_files
+BEGIN
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_update
+AFTER UPDATE OF path, camera_make, camera_model, user_camera_make, user_camera_model,
+    taken_at, user_taken_at, time_offset_minutes, category ON media_files
+BEGIN
+    DELETE FROM media_search WHERE rowid = OLD.id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_delete
+AFTER DELETE ON media_files
+BEGIN
+    DELETE FROM media_search WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_exif_insert
+AFTER INSERT ON media_exif
+WHEN NEW.tag IN (` + searchTags + `)
+BEGIN
+    DELETE FROM media_search WHERE rowid = NEW.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.media_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_tag_insert
+AFTER INSERT ON media_tags
+BEGIN
+    DELETE FROM media_search WHERE rowid = NEW.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.media_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_tag_delete
+AFTER DELETE ON media_tags
+BEGIN
+    DELETE FROM media_search WHERE rowid = OLD.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = OLD.media_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_text_insert
+AFTER INSERT ON media_text
+BEGIN
+    DELETE FROM media_search WHERE rowid = NEW.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.media_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_text_update
+AFTER UPDATE OF text ON media_text
+BEGIN
+    DELETE FROM media_search WHERE rowid = NEW.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = NEW.media_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_search_exif_delete
+AFTER DELETE ON media_exif
+WHEN OLD.tag IN (` + searchTags + `)
+BEGIN
+    DELETE FROM media_search WHERE rowid = OLD.media_id;
+    INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+    SELECT id, name, path, camera, taken, tags, text FROM media_search_source WHERE id = OLD.media_id;
+END;
+`
+
+// searchRank weighs matches in the file name above the other columns.
+const searchRank = `bm25(media_search, 4.0, 1.0, 2.0, 1.0, 2.0, 1.0)`
+
+// SearchPage is one page of search hits, best match first, plus the total
+// hit count.
+type SearchPage struct {
+	Media []MediaFile `json:"media"`
+	Total int         `json:"total"`
+}
+
+// ensureSearchIndex creates the full-text index and fills it from existing
+// rows the first time it is created.
+func (s *Store) ensureSearchIndex() error {
+	var exists bool
+	if err := s.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'media_search')`,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("inspect search index: %w", err)
+	}
+	// Schema 16 added imported tags to the indexed view, schema 19 the OCR
+	// text and schema 29 left sealed OCR text out; each rebuilds the view
+	// along with the index.
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("inspect search index: %w", err)
+	}
+	if exists && version < 29 {
+		// The triggers name the columns, so they go too.
+		if _, err := s.db.Exec(`
+DROP TRIGGER IF EXISTS trg_search_insert;
+DROP TRIGGER IF EXISTS trg_search_update;
+DROP TRIGGER IF EXISTS trg_search_exif_insert;
+DROP TRIGGER IF EXISTS trg_search_exif_delete;
+DROP TRIGGER IF EXISTS trg_search_tag_insert;
+DROP TRIGGER IF EXISTS trg_search_tag_delete;
+DROP VIEW IF EXISTS media_search_source;
+DROP TABLE media_search;`); err != nil {
+			return fmt.Errorf("rebuild search index: %w", err)
+		}
+		exists = false
+	}
+	if _, err := s.db.Exec(searchSchema); err != nil {
+		return fmt.Errorf("bootstrap search index: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := s.db.Exec(`
+INSERT INTO media_search (rowid, name, path, camera, taken, tags, text)
+SELECT id, name, path, camera, taken, tags, text FROM media_search_source`); err != nil {
+		return fmt.Errorf("fill search index: %w", err)
+	}
+	return nil
+}
+
+// SearchMedia finds media whose name, path, camera, capture date, tags or
+// OCR text match query. Every word matches as a prefix ("DSC" finds
+// "DSC_0042") and double-quoted text as an exact phrase; all of them must
+// match.
+func (s *Store) SearchMedia(ctx context.Context, query string, page Page) (SearchPage, error) {
+	result := SearchPage{Media: []MediaFile{}}
+	match := searchExpression(query)
+	if match == "" {
+		return result, nil
+	}
+
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM media_search WHERE media_search MATCH ?`, match,
+	).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("count search hits: %w", err)
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+mediaColumns+`
+FROM media_files
+JOIN (SELECT rowid AS hit, `+searchRank+` AS score FROM media_search WHERE media_search MATCH ?) ON id = hit
+ORDER BY score, id
+LIMIT ? OFFSET ?`, match, limit, page.Offset)
+	if err != nil {
+		return result, fmt.Errorf("search media: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return result, fmt.Errorf("scan media row: %w", err)
+		}
+		result.Media = append(result.Media, file)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate search hits: %w", err)
+	}
+	return result, nil
+}
+
+// searchExpression turns user input into an FTS5 query: bare words become
+// prefix terms and quoted text a phrase, each quoted so that FTS5 operators
+// and punctuation in the input are taken literally.
+func searchExpression(query string) string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, `"`+phrase+`"`)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if word = strings.TrimRight(word, "*"); word != "" {
+				terms = append(terms, `"`+word+`"*`)
+			}
+		}
+	}
+	return strings.Join(terms, " ")
+}
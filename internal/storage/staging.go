@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// StagingSummary describes what a staging database holds against the
+// library.
+type StagingSummary struct {
+	Files     int   `json:"files"`
+	SizeBytes int64 `json:"sizeBytes"`
+	// Known counts staged files whose content the library already has at
+	// another path.
+	Known int `json:"known"`
+}
+
+// StagingCommit reports a staging database merged into the library.
+type StagingCommit struct {
+	// Files counts the media rows added or, for paths the library already
+	// had, updated.
+	Files int `json:"files"`
+	Known int `json:"known"`
+	Junk  int `json:"junk"`
+}
+
+// knownStaged counts the staged files whose hash the library holds at
+// another path.
+const knownStaged = `
+SELECT COUNT(*) FROM staging.media_files sm
+WHERE EXISTS (SELECT 1 FROM media_files m WHERE m.hash_md5 = sm.hash_md5 AND m.path <> sm.path)`
+
+// StagingSummary compares the staging database at path with the library.
+func (s *Store) StagingSummary(ctx context.Context, path string) (StagingSummary, error) {
+	var summary StagingSummary
+	err := s.withStaging(ctx, path, func(conn *sql.Conn) error {
+		if err := conn.QueryRowContext(ctx,
+			`SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM staging.media_files`,
+		).Scan(&summary.Files, &summary.SizeBytes); err != nil {
+			return fmt.Errorf("count staged media: %w", err)
+		}
+		if err := conn.QueryRowContext(ctx, knownStaged).Scan(&summary.Known); err != nil {
+			return fmt.Errorf("count known staged media: %w", err)
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// CommitStaging merges the media, their EXIF fields, volumes and junk
+// recorded in the staging database at path into the library in one
+// transaction. Staged rows replace the scanned columns of rows for the same
+// path, as a rescan would; the staging database is left unchanged.
+func (s *Store) CommitStaging(ctx context.Context, path string) (StagingCommit, error) {
+	var commit StagingCommit
+	err := s.withStaging(ctx, path, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin staging commit: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := tx.QueryRowContext(ctx, knownStaged).Scan(&commit.Known); err != nil {
+			return fmt.Errorf("count known staged media: %w", err)
+		}
+		// Volumes first: media rows refer to them by id.
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO volumes (id, label, mount_point, removable, online, last_seen)
+SELECT id, label, mount_point, removable, online, last_seen FROM staging.volumes WHERE true
+ON CONFLICT(id) DO UPDATE SET
+    label = excluded.label,
+    mount_point = excluded.mount_point,
+    removable = excluded.removable,
+    online = excluded.online,
+    last_seen = MAX(volumes.last_seen, excluded.last_seen)`); err != nil {
+			return fmt.Errorf("commit staged volumes: %w", err)
+		}
+		// "WHERE true" keeps SQLite from reading ON CONFLICT as a join
+		// constraint of the SELECT.
+		res, err := tx.ExecContext(ctx, `
+INSERT INTO media_files (`+mediaUpsertColumns+`)
+SELECT `+mediaUpsertColumns+` FROM staging.media_files WHERE true`+mediaUpsertConflict)
+		if err != nil {
+			return fmt.Errorf("commit staged media: %w", err)
+		}
+		files, _ := res.RowsAffected()
+		commit.Files = int(files)
+
+		if _, err := tx.ExecContext(ctx, `
+DELETE FROM media_exif WHERE media_id IN (
+    SELECT m.id FROM media_files m JOIN staging.media_files sm ON sm.path = m.path)`); err != nil {
+			return fmt.Errorf("clear exif of staged media: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO media_exif (media_id, tag, value)
+SELECT m.id, se.tag, se.value
+FROM staging.media_exif se
+JOIN staging.media_files sm ON sm.id = se.media_id
+JOIN media_files m ON m.path = sm.path`); err != nil {
+			return fmt.Errorf("commit staged exif: %w", err)
+		}
+
+		res, err = tx.ExecContext(ctx, `
+INSERT INTO junk_files (path, size_bytes, reason, found_at)
+SELECT path, size_bytes, reason, found_at FROM staging.junk_files WHERE true
+ON CONFLICT(path) DO UPDATE SET
+    size_bytes = excluded.size_bytes,
+    reason = excluded.reason,
+    found_at = excluded.found_at`)
+		if err != nil {
+			return fmt.Errorf("commit staged junk: %w", err)
+		}
+		junk, _ := res.RowsAffected()
+		commit.Junk = int(junk)
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit staging: %w", err)
+		}
+		return nil
+	})
+	return commit, err
+}
+
+// withStaging runs fn on a connection that has the staging database at
+// path attached as "staging". The store allows one connection, so other
+// queries wait until fn returns.
+func (s *Store) withStaging(ctx context.Context, path string, fn func(*sql.Conn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS staging`, path); err != nil {
+		return fmt.Errorf("attach staging: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `DETACH DATABASE staging`)
+	return fn(conn)
+}
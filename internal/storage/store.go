@@ -2,48 +2,116 @@ package storage
 
 import (
 	"context"
+	"crypto/cipher"
 	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// SchemaVersion is written to PRAGMA user_version after migrations run.
+// Bump it whenever migrate gains a step.
+const SchemaVersion = 29
+
 // Store manages application persistence.
 type Store struct {
 	db *sql.DB
+	// keyMu guards encrypted, set when the library seals its sensitive
+	// columns, and aead, their cipher once unlocked; see encryption.go.
+	keyMu     sync.RWMutex
+	encrypted bool
+	aead      cipher.AEAD
 }
 
 // MediaFile represents one scanned file persisted to SQLite.
 type MediaFile struct {
-	ID          int64
-	Path        string
-	HashMD5     string
-	SizeBytes   int64
-	ModTime     time.Time
-	TakenAt     sql.NullTime
-	CameraMake  sql.NullString
-	CameraModel sql.NullString
-	MimeType    sql.NullString
+	ID        int64
+	Path      string
+	HashMD5   string
+	SizeBytes int64
+	ModTime   time.Time
+	// TakenAt is the capture time as the camera's clock showed it.
+	TakenAt sql.NullTime
+	// TakenAtUTC is the same moment in UTC, known when the zone the camera
+	// clock was set to is, and UTCOffsetMinutes is that zone's offset.
+	TakenAtUTC       sql.NullTime
+	UTCOffsetMinutes sql.NullInt64
+	CameraMake       sql.NullString
+	CameraModel      sql.NullString
+	MimeType         sql.NullString
+	Width            int
+	Height           int
+	Category         string
+	// Latitude and Longitude are the GPS position in decimal degrees.
+	Latitude  sql.NullFloat64
+	Longitude sql.NullFloat64
+	// Device and Inode identify the file's storage; hardlinks share them.
+	Device sql.NullInt64
+	Inode  sql.NullInt64
+	// TimeOffsetMinutes is the user's shift already applied to TakenAt.
+	TimeOffsetMinutes int
+	// Edited reports that TakenAt or the camera fields carry user corrections.
+	Edited bool
+	// Person is the name of the named person with the largest face in the
+	// file, or empty.
+	Person string
+	// Tags are the imported and classifier tags of the file, sorted.
+	Tags []string
+	// Corrupt is why the file failed validation during a scan, or empty.
+	Corrupt string
+	// VolumeID is the volume the file was scanned on and VolumePath its
+	// path below the volume's mount point; both are empty for files moved
+	// since. Offline reports that the volume is not mounted.
+	VolumeID   string
+	VolumePath string
+	Offline    bool
+}
+
+// MediaFilter narrows ListMedia results. Zero values match everything.
+type MediaFilter struct {
+	Category string `json:"category"`
+	// Orientation is one of "portrait", "landscape" or "square".
+	Orientation string `json:"orientation"`
+	Lens        string `json:"lens"`
+	ISOMin      int    `json:"isoMin"`
+	ISOMax      int    `json:"isoMax"`
+	Limit       int    `json:"limit"`
+	Offset      int    `json:"offset"`
 }
 
 // DuplicateGroup groups files that share the same hash.
 type DuplicateGroup struct {
-	Hash  string
-	Files []MediaFile
+	Hash              string
+	Files             []MediaFile
+	SuggestedKeeperID int64
+	// WastedBytes is what keeping a single copy would free.
+	WastedBytes int64
+	// Acknowledged lists the copies the user marked as intentional; they
+	// count as one copy and are never removed when the group is resolved.
+	Acknowledged []int64
+	// Hardlinks lists the sets of copies that are hardlinks of one file.
+	// Each set counts as one copy since it occupies storage once.
+	Hardlinks [][]int64
 }
 
 // FileActionStatus enumerates tidy execution states.
 type FileActionStatus string
 
 const (
-	ActionStatusPending   FileActionStatus = "pending"
-	ActionStatusCompleted FileActionStatus = "completed"
-	ActionStatusFailed    FileActionStatus = "failed"
+	ActionStatusPending     FileActionStatus = "pending"
+	ActionStatusCompleted   FileActionStatus = "completed"
+	ActionStatusFailed      FileActionStatus = "failed"
+	ActionStatusQuarantined FileActionStatus = "quarantined"
+	ActionStatusRolledBack  FileActionStatus = "rolled_back"
+	// ActionStatusDeduplicated marks a move skipped because an identical
+	// file already occupied the target.
+	ActionStatusDeduplicated FileActionStatus = "deduplicated"
 )
 
 // FileAction stores execution attempts for tidy operations.
@@ -57,6 +125,8 @@ type FileAction struct {
 	ErrorMsg   sql.NullString
 	ExecutedAt sql.NullTime
 	HashMD5    sql.NullString
+	RunID      sql.NullInt64
+	RetryOf    sql.NullInt64
 }
 
 // New initialises the SQLite store.
@@ -126,6 +196,263 @@ CREATE TABLE IF NOT EXISTS file_actions (
 );
 
 CREATE INDEX IF NOT EXISTS idx_actions_status ON file_actions(status);
+
+CREATE TABLE IF NOT EXISTS media_exif (
+    media_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (media_id, tag),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_exif_tag_value ON media_exif(tag, value);
+
+CREATE TABLE IF NOT EXISTS tidy_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL,
+    started_at TEXT NOT NULL DEFAULT (datetime('now')),
+    finished_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS tidy_run_items (
+    run_id INTEGER NOT NULL,
+    media_id INTEGER NOT NULL,
+    prior_path TEXT NOT NULL,
+    PRIMARY KEY (run_id, media_id),
+    FOREIGN KEY(run_id) REFERENCES tidy_runs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS duplicate_acks (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS library_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    taken_at TEXT NOT NULL DEFAULT (datetime('now')),
+    reason TEXT NOT NULL,
+    total_files INTEGER NOT NULL,
+    total_bytes INTEGER NOT NULL,
+    duplicate_groups INTEGER NOT NULL,
+    wasted_bytes INTEGER NOT NULL,
+    reclaimed_bytes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    job TEXT PRIMARY KEY,
+    status TEXT NOT NULL,
+    last_id INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    target TEXT PRIMARY KEY,
+    upload_id TEXT NOT NULL,
+    hash_md5 TEXT NOT NULL,
+    part_size INTEGER NOT NULL,
+    started_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS remote_pulls (
+    source TEXT NOT NULL,
+    path TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    mod_time TEXT NOT NULL,
+    pulled_at TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (source, path)
+);
+
+CREATE TABLE IF NOT EXISTS verification_results (
+    media_id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL,
+    expected_hash TEXT NOT NULL,
+    actual_hash TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    verified_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    UNIQUE (source, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS album_media (
+    album_id INTEGER NOT NULL,
+    media_id INTEGER NOT NULL,
+    PRIMARY KEY (album_id, media_id),
+    FOREIGN KEY(album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS media_tags (
+    media_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    source TEXT NOT NULL,
+    PRIMARY KEY (media_id, tag, source),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_tags_tag ON media_tags(tag);
+
+CREATE TABLE IF NOT EXISTS media_ratings (
+    media_id INTEGER PRIMARY KEY,
+    rating INTEGER NOT NULL DEFAULT 0,
+    flag TEXT NOT NULL DEFAULT '',
+    color_label TEXT NOT NULL DEFAULT '',
+    developed INTEGER NOT NULL DEFAULT 0,
+    source TEXT NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS people (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS faces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    media_id INTEGER NOT NULL,
+    person_id INTEGER,
+    box_x REAL NOT NULL,
+    box_y REAL NOT NULL,
+    box_w REAL NOT NULL,
+    box_h REAL NOT NULL,
+    embedding BLOB NOT NULL,
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE,
+    FOREIGN KEY(person_id) REFERENCES people(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_faces_media ON faces(media_id);
+CREATE INDEX IF NOT EXISTS idx_faces_person ON faces(person_id);
+
+CREATE TABLE IF NOT EXISTS face_scans (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    backend TEXT NOT NULL,
+    faces INTEGER NOT NULL,
+    scanned_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS label_scans (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    model TEXT NOT NULL,
+    scanned_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS media_text (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    engine TEXT NOT NULL,
+    text TEXT NOT NULL,
+    scanned_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS media_phash (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    phash INTEGER NOT NULL,
+    computed_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS video_fingerprints (
+    media_id INTEGER PRIMARY KEY,
+    hash_md5 TEXT NOT NULL,
+    duration_seconds REAL NOT NULL,
+    frames BLOB NOT NULL,
+    computed_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY(media_id) REFERENCES media_files(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS junk_files (
+    path TEXT PRIMARY KEY,
+    size_bytes INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    found_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS scan_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL DEFAULT '',
+    sources TEXT NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL,
+    summary TEXT,
+    started_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS scan_errors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL REFERENCES scan_sessions(id) ON DELETE CASCADE,
+    stage TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS scan_checkpoints (
+    session_id INTEGER NOT NULL REFERENCES scan_sessions(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    last_path TEXT NOT NULL DEFAULT '',
+    done INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (session_id, source)
+);
+
+CREATE TABLE IF NOT EXISTS volumes (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    mount_point TEXT NOT NULL,
+    removable INTEGER NOT NULL DEFAULT 0,
+    online INTEGER NOT NULL DEFAULT 1,
+    last_seen TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS target_claims (
+    path TEXT PRIMARY KEY,
+    media_id INTEGER NOT NULL,
+    claimed_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS media_trash (
+    id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL,
+    hash_md5 TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    row TEXT NOT NULL,
+    exif TEXT NOT NULL DEFAULT '[]',
+    tags TEXT NOT NULL DEFAULT '[]',
+    rating TEXT,
+    albums TEXT NOT NULL DEFAULT '[]',
+    reason TEXT NOT NULL,
+    deleted_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_trash_deleted ON media_trash(deleted_at);
+
+CREATE TABLE IF NOT EXISTS encryption (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    salt BLOB NOT NULL,
+    iterations INTEGER NOT NULL,
+    key_check BLOB NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
 `
 
 	if _, err := s.db.Exec(schema); err != nil {
@@ -145,14 +472,119 @@ END;
 		return fmt.Errorf("bootstrap trigger: %w", err)
 	}
 
+	if err := s.migrate(); err != nil {
+		return err
+	}
+	return s.loadEncryption()
+}
+
+// migrate adds columns introduced after the initial schema to existing databases.
+func (s *Store) migrate() error {
+	columns := []struct {
+		table, name, ddl string
+	}{
+		{"media_files", "category", "TEXT NOT NULL DEFAULT ''"},
+		{"media_files", "width", "INTEGER NOT NULL DEFAULT 0"},
+		{"media_files", "height", "INTEGER NOT NULL DEFAULT 0"},
+		{"file_actions", "run_id", "INTEGER"},
+		{"file_actions", "retry_of", "INTEGER"},
+		// User corrections live beside the extracted values so a rescan,
+		// which rewrites the latter, leaves them intact.
+		{"media_files", "user_taken_at", "TEXT"},
+		{"media_files", "user_camera_make", "TEXT"},
+		{"media_files", "user_camera_model", "TEXT"},
+		{"media_files", "time_offset_minutes", "INTEGER"},
+		{"media_files", "taken_at_utc", "TEXT"},
+		{"media_files", "utc_offset_minutes", "INTEGER"},
+		{"media_files", "latitude", "REAL"},
+		{"media_files", "longitude", "REAL"},
+		{"media_files", "device", "INTEGER"},
+		{"media_files", "inode", "INTEGER"},
+		{"media_files", "corrupt", "TEXT"},
+		{"scan_sessions", "options", "TEXT"},
+		// volume_path is the path below the volume's mount point, kept so
+		// files can be re-bound when the volume is mounted elsewhere.
+		{"media_files", "volume_id", "TEXT"},
+		{"media_files", "volume_path", "TEXT"},
+	}
+
+	for _, col := range columns {
+		if err := s.ensureColumn(col.table, col.name, col.ddl); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_media_category ON media_files(category)`); err != nil {
+		return fmt.Errorf("bootstrap category index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_actions_run ON file_actions(run_id)`); err != nil {
+		return fmt.Errorf("bootstrap run index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_media_taken_at_utc ON media_files(taken_at_utc)`); err != nil {
+		return fmt.Errorf("bootstrap utc time index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_media_position ON media_files(latitude, longitude)`); err != nil {
+		return fmt.Errorf("bootstrap position index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_media_corrupt ON media_files(id) WHERE corrupt IS NOT NULL`); err != nil {
+		return fmt.Errorf("bootstrap corrupt index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_scan_errors_session ON scan_errors(session_id)`); err != nil {
+		return fmt.Errorf("bootstrap scan error index: %w", err)
+	}
+	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_media_volume ON media_files(volume_id)`); err != nil {
+		return fmt.Errorf("bootstrap volume index: %w", err)
+	}
+	if err := s.ensureSearchIndex(); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("record schema version: %w", err)
+	}
 	return nil
 }
 
-// UpsertMediaFile inserts or updates the metadata for a media file.
-func (s *Store) UpsertMediaFile(ctx context.Context, file MediaFile) error {
-	query := `
-INSERT INTO media_files (path, hash_md5, size_bytes, mod_time, taken_at, camera_make, camera_model, mime_type)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+func (s *Store) ensureColumn(table, name, ddl string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			colName   string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &colName, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("inspect %s: %w", table, err)
+		}
+		if colName == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, ddl)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, name, err)
+	}
+	return nil
+}
+
+// mediaUpsertColumns are the scanned columns of media_files, and
+// mediaUpsertConflict updates them when a row for the path exists, as a
+// rescan does; user corrections live in other columns and survive.
+const (
+	mediaUpsertColumns  = `path, hash_md5, size_bytes, mod_time, taken_at, camera_make, camera_model, mime_type, width, height, category, taken_at_utc, utc_offset_minutes, latitude, longitude, device, inode, corrupt, volume_id, volume_path`
+	mediaUpsertConflict = `
 ON CONFLICT(path) DO UPDATE SET
     hash_md5 = excluded.hash_md5,
     size_bytes = excluded.size_bytes,
@@ -160,11 +592,37 @@ ON CONFLICT(path) DO UPDATE SET
     taken_at = excluded.taken_at,
     camera_make = excluded.camera_make,
     camera_model = excluded.camera_model,
-    mime_type = excluded.mime_type
+    mime_type = excluded.mime_type,
+    width = excluded.width,
+    height = excluded.height,
+    category = excluded.category,
+    taken_at_utc = excluded.taken_at_utc,
+    utc_offset_minutes = excluded.utc_offset_minutes,
+    latitude = excluded.latitude,
+    longitude = excluded.longitude,
+    device = excluded.device,
+    inode = excluded.inode,
+    volume_id = excluded.volume_id,
+    volume_path = excluded.volume_path,
+    -- A scan without validation keeps the verdict on unchanged content.
+    corrupt = CASE
+        WHEN excluded.corrupt IS NULL AND excluded.hash_md5 = media_files.hash_md5 THEN media_files.corrupt
+        ELSE excluded.corrupt
+    END
+`
+)
+
+// UpsertMediaFile inserts or updates the metadata for a media file and returns its ID.
+func (s *Store) UpsertMediaFile(ctx context.Context, file MediaFile) (int64, error) {
+	query := `
+INSERT INTO media_files (` + mediaUpsertColumns + `)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)` + mediaUpsertConflict + `
+RETURNING id
 `
 
 	takenAt := nullTimeToString(file.TakenAt)
-	_, err := s.db.ExecContext(ctx, query,
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
 		file.Path,
 		file.HashMD5,
 		file.SizeBytes,
@@ -173,26 +631,40 @@ ON CONFLICT(path) DO UPDATE SET
 		nullString(file.CameraMake),
 		nullString(file.CameraModel),
 		nullString(file.MimeType),
-	)
+		file.Width,
+		file.Height,
+		file.Category,
+		nullTimeToString(file.TakenAtUTC),
+		file.UTCOffsetMinutes,
+		file.Latitude,
+		file.Longitude,
+		file.Device,
+		file.Inode,
+		emptyToNull(file.Corrupt),
+		emptyToNull(file.VolumeID),
+		emptyToNull(file.VolumePath),
+	).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("upsert media file: %w", err)
+		return 0, fmt.Errorf("upsert media file: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
 // ListDuplicateGroups finds duplicate files grouped by MD5 hash.
 func (s *Store) ListDuplicateGroups(ctx context.Context) ([]DuplicateGroup, error) {
+	hashes, args, err := duplicateHashes(DuplicateScope{})
+	if err != nil {
+		return nil, err
+	}
 	query := `
-SELECT id, path, hash_md5, size_bytes, mod_time, taken_at, camera_make, camera_model, mime_type
+SELECT ` + mediaColumns + `
 FROM media_files
-WHERE hash_md5 IN (
-    SELECT hash_md5 FROM media_files GROUP BY hash_md5 HAVING COUNT(*) > 1
-)
+WHERE hash_md5 IN (SELECT hash_md5 FROM (` + hashes + `)) AND ` + looseFile + `
 ORDER BY hash_md5, id
 `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query duplicates: %w", err)
 	}
@@ -204,33 +676,11 @@ ORDER BY hash_md5, id
 	)
 
 	for rows.Next() {
-		var (
-			file    MediaFile
-			modUnix int64
-			takenAt sql.NullString
-		)
-
-		if err := rows.Scan(
-			&file.ID,
-			&file.Path,
-			&file.HashMD5,
-			&file.SizeBytes,
-			&modUnix,
-			&takenAt,
-			&file.CameraMake,
-			&file.CameraModel,
-			&file.MimeType,
-		); err != nil {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan duplicate row: %w", err)
 		}
 
-		file.ModTime = time.Unix(modUnix, 0).UTC()
-		if takenAt.Valid {
-			if ts, err := time.Parse(time.RFC3339, takenAt.String); err == nil {
-				file.TakenAt = sql.NullTime{Time: ts, Valid: true}
-			}
-		}
-
 		if current == nil || current.Hash != file.HashMD5 {
 			if current != nil {
 				groups = append(groups, *current)
@@ -247,10 +697,289 @@ ORDER BY hash_md5, id
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate duplicates: %w", err)
 	}
+	rows.Close()
+
+	if err := s.annotateGroups(ctx, groups); err != nil {
+		return nil, err
+	}
 
 	return groups, nil
 }
 
+// GetMediaStamp returns the stored size and modification time of path.
+func (s *Store) GetMediaStamp(ctx context.Context, path string) (int64, time.Time, bool, error) {
+	var size, modUnix int64
+	err := s.db.QueryRowContext(ctx, `SELECT size_bytes, mod_time FROM media_files WHERE path = ?`, path).Scan(&size, &modUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("get media stamp: %w", err)
+	}
+	return size, time.Unix(modUnix, 0).UTC(), true, nil
+}
+
+// FindMediaByPath returns the library row stored at path.
+func (s *Store) FindMediaByPath(ctx context.Context, path string) (MediaFile, bool, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+mediaColumns+` FROM media_files WHERE path = ?`, path)
+	file, err := s.scanMediaFile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MediaFile{}, false, nil
+	}
+	if err != nil {
+		return MediaFile{}, false, fmt.Errorf("find media by path: %w", err)
+	}
+	return file, true, nil
+}
+
+// FindMediaByHash returns a library file with the given hash stored at a
+// path other than excludePath.
+func (s *Store) FindMediaByHash(ctx context.Context, hash, excludePath string) (MediaFile, bool, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+mediaColumns+` FROM media_files WHERE hash_md5 = ? AND path <> ? ORDER BY id LIMIT 1`,
+		hash, excludePath,
+	)
+	file, err := s.scanMediaFile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MediaFile{}, false, nil
+	}
+	if err != nil {
+		return MediaFile{}, false, fmt.Errorf("find media by hash: %w", err)
+	}
+	return file, true, nil
+}
+
+// ListMediaOutside returns media whose path is not below base, i.e. files
+// that have not been tidied into the target structure yet.
+func (s *Store) ListMediaOutside(ctx context.Context, base string) ([]MediaFile, error) {
+	return s.listByPrefix(ctx, base, "<>")
+}
+
+// ListMediaUnder returns media whose path is below base.
+func (s *Store) ListMediaUnder(ctx context.Context, base string) ([]MediaFile, error) {
+	return s.listByPrefix(ctx, base, "=")
+}
+
+// ListCorruptMedia returns a page of the files a scan found corrupt.
+func (s *Store) ListCorruptMedia(ctx context.Context, page Page) ([]MediaFile, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+mediaColumns+` FROM media_files
+WHERE corrupt IS NOT NULL
+ORDER BY path
+LIMIT ? OFFSET ?`, limit, page.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query corrupt media: %w", err)
+	}
+	defer rows.Close()
+
+	files := []MediaFile{}
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan corrupt media: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate corrupt media: %w", err)
+	}
+	return files, nil
+}
+
+// ListCopiesOutside returns the files with the given hash stored anywhere but
+// below base. Archive entries are left out.
+func (s *Store) ListCopiesOutside(ctx context.Context, hash, base string) ([]MediaFile, error) {
+	prefix := dirPrefix(base)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+mediaColumns+` FROM media_files WHERE hash_md5 = ? AND substr(path, 1, ?) <> ? AND `+looseFile+` ORDER BY id`,
+		hash, len(prefix), prefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list copies: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan copy: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate copies: %w", err)
+	}
+	return files, nil
+}
+
+func (s *Store) listByPrefix(ctx context.Context, base, op string) ([]MediaFile, error) {
+	prefix := dirPrefix(base)
+	query := `SELECT ` + mediaColumns + ` FROM media_files WHERE substr(path, 1, ?) ` + op + ` ? ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list media by folder: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan media row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media rows: %w", err)
+	}
+	return files, nil
+}
+
+// dirPrefix returns base with exactly one trailing separator so prefix
+// matches do not catch sibling folders sharing a name prefix. WebDAV URLs
+// and rclone remotes, whose scheme or remote name ends in a colon past the
+// drive letter position, always use slashes.
+func dirPrefix(base string) string {
+	if i := strings.Index(base, ":"); i > 1 && !strings.ContainsAny(base[:i], `/\`) {
+		if strings.HasSuffix(base, ":") {
+			return base
+		}
+		return strings.TrimRight(base, "/") + "/"
+	}
+	return strings.TrimRight(filepath.Clean(base), `/\`) + string(filepath.Separator)
+}
+
+// dirExpr is the folder of path including its trailing separator: trimming
+// every character that is not a separator leaves the directory part.
+const dirExpr = `rtrim(path, replace(replace(path, '/', ''), '\', ''))`
+
+// ListMedia returns media rows matching the filter ordered by ID.
+func (s *Store) ListMedia(ctx context.Context, filter MediaFilter) ([]MediaFile, error) {
+	where, args := filter.conditions()
+
+	query := `SELECT ` + mediaColumns + ` FROM media_files`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list media: %w", err)
+	}
+	defer rows.Close()
+
+	files := []MediaFile{}
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan media row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media rows: %w", err)
+	}
+
+	return files, nil
+}
+
+// conditions translates the filter into WHERE clauses on media_files.
+func (filter MediaFilter) conditions() ([]string, []interface{}) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if filter.Category != "" {
+		where = append(where, "category = ?")
+		args = append(args, filter.Category)
+	}
+	switch filter.Orientation {
+	case "portrait":
+		where = append(where, "height > width")
+	case "landscape":
+		where = append(where, "width > height")
+	case "square":
+		where = append(where, "width = height AND width > 0")
+	}
+	if filter.Lens != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM media_exif e WHERE e.media_id = media_files.id AND e.tag = 'LensModel' AND e.value LIKE ?)")
+		args = append(args, "%"+filter.Lens+"%")
+	}
+	if filter.ISOMin > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM media_exif e WHERE e.media_id = media_files.id AND e.tag = 'ISOSpeedRatings' AND CAST(e.value AS INTEGER) >= ?)")
+		args = append(args, filter.ISOMin)
+	}
+	if filter.ISOMax > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM media_exif e WHERE e.media_id = media_files.id AND e.tag = 'ISOSpeedRatings' AND CAST(e.value AS INTEGER) <= ?)")
+		args = append(args, filter.ISOMax)
+	}
+	return where, args
+}
+
+// ReplaceMediaExif stores the full decoded EXIF set of a media file,
+// replacing whatever was recorded by a previous scan.
+func (s *Store) ReplaceMediaExif(ctx context.Context, mediaID int64, fields map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin replace exif: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM media_exif WHERE media_id = ?`, mediaID); err != nil {
+		return fmt.Errorf("clear exif: %w", err)
+	}
+
+	if len(fields) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO media_exif (media_id, tag, value) VALUES (?, ?, ?)`)
+		if err != nil {
+			return fmt.Errorf("prepare exif insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for tag, value := range fields {
+			if _, err := stmt.ExecContext(ctx, mediaID, tag, value); err != nil {
+				return fmt.Errorf("insert exif %s: %w", tag, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMediaExif returns the stored EXIF tags of a media file.
+func (s *Store) GetMediaExif(ctx context.Context, mediaID int64) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT tag, value FROM media_exif WHERE media_id = ? ORDER BY tag`, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("query exif: %w", err)
+	}
+	defer rows.Close()
+
+	fields := make(map[string]string)
+	for rows.Next() {
+		var tag, value string
+		if err := rows.Scan(&tag, &value); err != nil {
+			return nil, fmt.Errorf("scan exif row: %w", err)
+		}
+		fields[tag] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate exif rows: %w", err)
+	}
+
+	return fields, nil
+}
+
 // GetMediaByIDs returns media rows keyed by ID.
 func (s *Store) GetMediaByIDs(ctx context.Context, ids []int64) (map[int64]MediaFile, error) {
 	result := make(map[int64]MediaFile)
@@ -266,7 +995,7 @@ func (s *Store) GetMediaByIDs(ctx context.Context, ids []int64) (map[int64]Media
 	}
 
 	query := fmt.Sprintf(`
-SELECT id, path, hash_md5, size_bytes, mod_time, taken_at, camera_make, camera_model, mime_type
+SELECT `+mediaColumns+`
 FROM media_files
 WHERE id IN (%s)
 `, strings.Join(placeholders, ","))
@@ -278,33 +1007,11 @@ WHERE id IN (%s)
 	defer rows.Close()
 
 	for rows.Next() {
-		var (
-			file    MediaFile
-			modUnix int64
-			takenAt sql.NullString
-		)
-
-		if err := rows.Scan(
-			&file.ID,
-			&file.Path,
-			&file.HashMD5,
-			&file.SizeBytes,
-			&modUnix,
-			&takenAt,
-			&file.CameraMake,
-			&file.CameraModel,
-			&file.MimeType,
-		); err != nil {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan media row: %w", err)
 		}
 
-		file.ModTime = time.Unix(modUnix, 0).UTC()
-		if takenAt.Valid {
-			if ts, err := time.Parse(time.RFC3339, takenAt.String); err == nil {
-				file.TakenAt = sql.NullTime{Time: ts, Valid: true}
-			}
-		}
-
 		result[file.ID] = file
 	}
 
@@ -318,8 +1025,8 @@ WHERE id IN (%s)
 // CreateAction records a tidy action before execution so that crashes can resume.
 func (s *Store) CreateAction(ctx context.Context, action FileAction) (int64, error) {
 	query := `
-INSERT INTO file_actions (media_id, source_path, target_path, action_type, status, hash_md5)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO file_actions (media_id, source_path, target_path, action_type, status, hash_md5, run_id, retry_of)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 	res, err := s.db.ExecContext(ctx, query,
@@ -329,6 +1036,8 @@ VALUES (?, ?, ?, ?, ?, ?)
 		action.ActionType,
 		string(action.Status),
 		nullString(action.HashMD5),
+		nullInt(action.RunID),
+		nullInt(action.RetryOf),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("insert action: %w", err)
@@ -351,15 +1060,221 @@ func (s *Store) MarkAction(ctx context.Context, id int64, status FileActionStatu
 	return nil
 }
 
-// UpdateMediaPath updates the stored path of a media file when it is relocated.
-func (s *Store) UpdateMediaPath(ctx context.Context, id int64, newPath string) error {
-	query := `UPDATE media_files SET path = ? WHERE id = ?`
-	if _, err := s.db.ExecContext(ctx, query, newPath, id); err != nil {
+// UpdateMediaPath updates the stored path of a media file when it is
+// relocated, along with the volume holding the new path. Empty volume values
+// leave the file unbound.
+func (s *Store) UpdateMediaPath(ctx context.Context, id int64, newPath, volumeID, volumePath string) error {
+	query := `UPDATE media_files SET path = ?, volume_id = ?, volume_path = ? WHERE id = ?`
+	if _, err := s.db.ExecContext(ctx, query, newPath, emptyToNull(volumeID), emptyToNull(volumePath), id); err != nil {
 		return fmt.Errorf("update media path: %w", err)
 	}
 	return nil
 }
 
+// ClaimTarget reserves a tidy target path for a media file. It reports false
+// when a different media file already holds the claim; re-claiming by the
+// same file succeeds so interrupted runs can resume.
+func (s *Store) ClaimTarget(ctx context.Context, path string, mediaID int64) (bool, error) {
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO target_claims (path, media_id) VALUES (?, ?) ON CONFLICT(path) DO NOTHING`,
+		path, mediaID,
+	); err != nil {
+		return false, fmt.Errorf("claim target: %w", err)
+	}
+
+	var owner int64
+	if err := s.db.QueryRowContext(ctx, `SELECT media_id FROM target_claims WHERE path = ?`, path).Scan(&owner); err != nil {
+		return false, fmt.Errorf("read target claim: %w", err)
+	}
+	return owner == mediaID, nil
+}
+
+// ReleaseTarget removes the claim on a tidy target path.
+func (s *Store) ReleaseTarget(ctx context.Context, path string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM target_claims WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("release target: %w", err)
+	}
+	return nil
+}
+
+// ReleaseStaleClaims drops target claims made before the cutoff that no
+// pending action still relies on, such as those of a run that was killed
+// between reserving a name and moving the file. It returns how many went.
+func (s *Store) ReleaseStaleClaims(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+DELETE FROM target_claims
+WHERE claimed_at < ?
+  AND media_id NOT IN (SELECT media_id FROM file_actions WHERE status = ? AND media_id IS NOT NULL)`,
+		before.UTC().Format(sqliteTimeLayout), string(ActionStatusPending))
+	if err != nil {
+		return 0, fmt.Errorf("release stale claims: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ListCaseVariantGroups returns media rows whose paths are equal ignoring case.
+func (s *Store) ListCaseVariantGroups(ctx context.Context) ([][]MediaFile, error) {
+	query := `
+SELECT ` + mediaColumns + `
+FROM media_files
+WHERE lower(path) IN (
+    SELECT lower(path) FROM media_files GROUP BY lower(path) HAVING COUNT(*) > 1
+)
+ORDER BY lower(path), id
+`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query case variants: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		groups  [][]MediaFile
+		lastKey string
+	)
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan case variant row: %w", err)
+		}
+		key := strings.ToLower(file.Path)
+		if len(groups) == 0 || key != lastKey {
+			groups = append(groups, nil)
+			lastKey = key
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate case variants: %w", err)
+	}
+
+	return groups, nil
+}
+
+// MergeMediaRows repoints actions from the dropped rows to keepID and moves
+// them to the trash.
+func (s *Store) MergeMediaRows(ctx context.Context, keepID int64, dropIDs []int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin merge media: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range dropIDs {
+		if _, err := tx.ExecContext(ctx, `UPDATE file_actions SET media_id = ? WHERE media_id = ?`, keepID, id); err != nil {
+			return fmt.Errorf("repoint media actions: %w", err)
+		}
+		if err := trashMedia(ctx, tx, id, TrashMerged); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM media_files WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("delete merged media: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteMediaFile moves a media row to the trash, detaching any actions that
+// reference it.
+func (s *Store) DeleteMediaFile(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete media: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE file_actions SET media_id = NULL WHERE media_id = ?`, id); err != nil {
+		return fmt.Errorf("detach media actions: %w", err)
+	}
+	if err := trashMedia(ctx, tx, id, TrashDeleted); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM media_files WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete media file: %w", err)
+	}
+	return tx.Commit()
+}
+
+// mediaColumns lists the media_files columns read by scanMediaFile, in order.
+// User corrections take precedence over the extracted values.
+const mediaColumns = `id, path, hash_md5, size_bytes, mod_time,
+    COALESCE(user_taken_at, taken_at), COALESCE(user_camera_make, camera_make), COALESCE(user_camera_model, camera_model),
+    mime_type, width, height, category, COALESCE(time_offset_minutes, 0), utc_offset_minutes, latitude, longitude, device, inode,
+    (user_taken_at IS NOT NULL OR user_camera_make IS NOT NULL OR user_camera_model IS NOT NULL OR time_offset_minutes IS NOT NULL),
+    COALESCE((SELECT p.name FROM faces f JOIN people p ON p.id = f.person_id
+        WHERE f.media_id = media_files.id AND p.name <> '' ORDER BY f.box_w * f.box_h DESC LIMIT 1), ''),
+    COALESCE((SELECT group_concat(tag, char(31)) FROM media_tags WHERE media_tags.media_id = media_files.id), ''),
+    COALESCE(corrupt, ''), COALESCE(volume_id, ''), COALESCE(volume_path, ''),
+    COALESCE((SELECT NOT v.online FROM volumes v WHERE v.id = media_files.volume_id), 0)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMediaFile reads a row of mediaColumns. Names of people are sealed in
+// an encrypted library, so a row naming one fails with ErrLocked until the
+// library is unlocked rather than reading as unnamed.
+func (s *Store) scanMediaFile(row rowScanner) (MediaFile, error) {
+	var (
+		file    MediaFile
+		modUnix int64
+		takenAt sql.NullString
+		person  interface{}
+		tags    string
+	)
+
+	if err := row.Scan(
+		&file.ID,
+		&file.Path,
+		&file.HashMD5,
+		&file.SizeBytes,
+		&modUnix,
+		&takenAt,
+		&file.CameraMake,
+		&file.CameraModel,
+		&file.MimeType,
+		&file.Width,
+		&file.Height,
+		&file.Category,
+		&file.TimeOffsetMinutes,
+		&file.UTCOffsetMinutes,
+		&file.Latitude,
+		&file.Longitude,
+		&file.Device,
+		&file.Inode,
+		&file.Edited,
+		&person,
+		&tags,
+		&file.Corrupt,
+		&file.VolumeID,
+		&file.VolumePath,
+		&file.Offline,
+	); err != nil {
+		return MediaFile{}, err
+	}
+	var err error
+	if file.Person, err = s.openString(sealedName, person); err != nil {
+		return MediaFile{}, err
+	}
+	file.Tags = splitTags(tags)
+
+	file.ModTime = time.Unix(modUnix, 0).UTC()
+	if takenAt.Valid {
+		if ts, err := time.Parse(time.RFC3339, takenAt.String); err == nil {
+			ts = ts.Add(time.Duration(file.TimeOffsetMinutes) * time.Minute)
+			file.TakenAt = sql.NullTime{Time: ts, Valid: true}
+			// Derived rather than read back so corrections carry over.
+			if file.UTCOffsetMinutes.Valid {
+				utc := ts.Add(-time.Duration(file.UTCOffsetMinutes.Int64) * time.Minute)
+				file.TakenAtUTC = sql.NullTime{Time: utc, Valid: true}
+			}
+		}
+	}
+	return file, nil
+}
+
 func nullString(ns sql.NullString) interface{} {
 	if ns.Valid {
 		return ns.String
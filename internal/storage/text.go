@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ListTextCandidates returns files of the given MIME types whose text engine
+// has not yet read in their current content, up to limit (0 for all).
+func (s *Store) ListTextCandidates(ctx context.Context, engine string, mimeTypes []string, limit int) ([]MediaFile, error) {
+	if len(mimeTypes) == 0 {
+		return nil, nil
+	}
+	query := `
+SELECT ` + mediaColumns + ` FROM media_files
+WHERE mime_type IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(mimeTypes)), ", ") + `) AND NOT EXISTS (
+    SELECT 1 FROM media_text t
+    WHERE t.media_id = media_files.id AND t.hash_md5 = media_files.hash_md5 AND t.engine = ?
+)
+ORDER BY id`
+	args := make([]interface{}, 0, len(mimeTypes)+2)
+	for _, mime := range mimeTypes {
+		args = append(args, mime)
+	}
+	args = append(args, engine)
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query text candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan text candidate: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate text candidates: %w", err)
+	}
+	return files, nil
+}
+
+// SaveText stores the text engine read from a file in the content with the
+// given hash. Empty text is stored too, so the file is not read again.
+func (s *Store) SaveText(ctx context.Context, mediaID int64, hash, engine, text string) error {
+	value, err := s.sealString(sealedText, text)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO media_text (media_id, hash_md5, engine, text, scanned_at)
+VALUES (?, ?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    hash_md5 = excluded.hash_md5,
+    engine = excluded.engine,
+    text = excluded.text,
+    scanned_at = excluded.scanned_at
+`, mediaID, hash, engine, value); err != nil {
+		return fmt.Errorf("save text: %w", err)
+	}
+	return nil
+}
+
+// GetMediaText returns the text read from a file, or "" when none was.
+func (s *Store) GetMediaText(ctx context.Context, mediaID int64) (string, error) {
+	var text interface{}
+	err := s.db.QueryRowContext(ctx, `SELECT text FROM media_text WHERE media_id = ?`, mediaID).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("query text: %w", err)
+	}
+	return s.openString(sealedText, text)
+}
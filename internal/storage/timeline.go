@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Timeline granularities accepted by Timeline.
+const (
+	GranularityYear  = "year"
+	GranularityMonth = "month"
+	GranularityDay   = "day"
+)
+
+// timelineSamples is how many representative files each bucket carries.
+const timelineSamples = 4
+
+// takenExpr is the corrected capture time as "YYYY-MM-DD HH:MM:SS".
+const takenExpr = `datetime(COALESCE(user_taken_at, taken_at), COALESCE(time_offset_minutes, 0) || ' minutes')`
+
+// TimelineBucket is one period of the timeline. Period is "2024",
+// "2024-05" or "2024-05-17" depending on granularity, or empty for files
+// without a capture time, which always come last.
+type TimelineBucket struct {
+	Period          string      `json:"period"`
+	Count           int         `json:"count"`
+	Representatives []MediaFile `json:"representatives"`
+}
+
+// TimelinePage is one slice of the timeline, newest period first.
+type TimelinePage struct {
+	Granularity string           `json:"granularity"`
+	Buckets     []TimelineBucket `json:"buckets"`
+	// Total counts the buckets across all pages.
+	Total int `json:"total"`
+}
+
+// Timeline groups the media matching filter by capture period. filter.Limit
+// and filter.Offset page through buckets rather than files, so the UI can
+// load periods as it scrolls.
+func (s *Store) Timeline(ctx context.Context, granularity string, filter MediaFilter) (TimelinePage, error) {
+	page := TimelinePage{Granularity: granularity, Buckets: []TimelineBucket{}}
+
+	var width int
+	switch granularity {
+	case GranularityYear:
+		width = 4
+	case GranularityMonth:
+		width = 7
+	case GranularityDay:
+		width = 10
+	default:
+		return page, fmt.Errorf("unknown timeline granularity %q", granularity)
+	}
+
+	where, args := filter.conditions()
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+	periods := `SELECT id, substr(` + takenExpr + `, 1, ?) AS period, ` + takenExpr + ` AS taken FROM media_files` + clause
+	periodArgs := append([]interface{}{width}, args...)
+
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT COALESCE(period, '')) FROM (`+periods+`)`, periodArgs...,
+	).Scan(&page.Total); err != nil {
+		return page, fmt.Errorf("count timeline: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query := `
+WITH t AS (` + periods + `),
+buckets AS (
+    SELECT COALESCE(period, '') AS bucket, COUNT(*) AS n
+    FROM t
+    GROUP BY bucket
+    ORDER BY bucket = '', bucket DESC
+    LIMIT ? OFFSET ?
+),
+ranked AS (
+    SELECT t.id, COALESCE(t.period, '') AS period,
+        ROW_NUMBER() OVER (PARTITION BY COALESCE(t.period, '') ORDER BY t.taken DESC, t.id DESC) AS rn
+    FROM t
+)
+SELECT b.bucket, b.n, r.id
+FROM buckets b
+JOIN ranked r ON r.period = b.bucket AND r.rn <= ?
+ORDER BY b.bucket = '', b.bucket DESC, r.rn
+`
+	queryArgs := append(append(periodArgs, limit, filter.Offset), timelineSamples)
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return page, fmt.Errorf("query timeline: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	samples := make(map[string][]int64)
+	for rows.Next() {
+		var (
+			period string
+			count  int
+			id     int64
+		)
+		if err := rows.Scan(&period, &count, &id); err != nil {
+			return page, fmt.Errorf("scan timeline row: %w", err)
+		}
+		if n := len(page.Buckets); n == 0 || page.Buckets[n-1].Period != period {
+			page.Buckets = append(page.Buckets, TimelineBucket{Period: period, Count: count})
+		}
+		samples[period] = append(samples[period], id)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("iterate timeline: %w", err)
+	}
+	rows.Close()
+
+	files, err := s.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return page, err
+	}
+	for i := range page.Buckets {
+		bucket := &page.Buckets[i]
+		bucket.Representatives = make([]MediaFile, 0, len(samples[bucket.Period]))
+		for _, id := range samples[bucket.Period] {
+			if file, ok := files[id]; ok {
+				bucket.Representatives = append(bucket.Representatives, file)
+			}
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Reasons a media row went to the trash.
+const (
+	TrashDeleted = "deleted"
+	TrashMerged  = "merged"
+)
+
+// TrashedMedia is a media row removed from the library and kept in the
+// trash until it is restored or purged.
+type TrashedMedia struct {
+	// ID is the row's ID in the library, which a restore keeps.
+	ID        int64  `json:"id"`
+	Path      string `json:"path"`
+	HashMD5   string `json:"hashMd5"`
+	SizeBytes int64  `json:"sizeBytes"`
+	Reason    string `json:"reason"`
+	DeletedAt string `json:"deletedAt"`
+}
+
+// TrashPage is one page of the trash and the number of rows in it.
+type TrashPage struct {
+	Media []TrashedMedia `json:"media"`
+	Total int            `json:"total"`
+}
+
+// TrashRestore reports rows put back into the library.
+type TrashRestore struct {
+	Restored int `json:"restored"`
+	// Conflicts lists the paths the library has a row for again; those
+	// rows stay in the trash.
+	Conflicts []string `json:"conflicts"`
+}
+
+// trashColumns are the media_files columns the trash keeps; updated_at is
+// left to the trigger.
+var trashColumns = []string{
+	"id", "path", "hash_md5", "size_bytes", "mod_time", "taken_at", "camera_make", "camera_model", "mime_type",
+	"created_at", "category", "width", "height", "user_taken_at", "user_camera_make", "user_camera_model",
+	"time_offset_minutes", "taken_at_utc", "utc_offset_minutes", "latitude", "longitude", "device", "inode",
+	"corrupt", "volume_id", "volume_path",
+}
+
+// trashMedia copies the media row id with its EXIF fields, tags, rating and
+// album memberships into the trash, ahead of the row's deletion in tx.
+func trashMedia(ctx context.Context, tx *sql.Tx, id int64, reason string) error {
+	fields := make([]string, 0, 2*len(trashColumns))
+	for _, col := range trashColumns {
+		fields = append(fields, "'"+col+"'", col)
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT OR REPLACE INTO media_trash (id, path, hash_md5, size_bytes, row, exif, tags, rating, albums, reason)
+SELECT id, path, hash_md5, size_bytes, json_object(`+strings.Join(fields, ", ")+`),
+    (SELECT json_group_array(json_object('tag', tag, 'value', value)) FROM media_exif WHERE media_id = media_files.id),
+    (SELECT json_group_array(json_object('tag', tag, 'source', source)) FROM media_tags WHERE media_id = media_files.id),
+    (SELECT json_object('rating', rating, 'flag', flag, 'color_label', color_label, 'developed', developed, 'source', source)
+        FROM media_ratings WHERE media_id = media_files.id),
+    (SELECT json_group_array(album_id) FROM album_media WHERE media_id = media_files.id),
+    ?
+FROM media_files WHERE id = ?`, reason, id); err != nil {
+		return fmt.Errorf("move media to trash: %w", err)
+	}
+	return nil
+}
+
+// ListTrashedMedia returns a page of the trash, most recently removed first.
+func (s *Store) ListTrashedMedia(ctx context.Context, page Page) (TrashPage, error) {
+	result := TrashPage{Media: []TrashedMedia{}}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM media_trash`).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("count trash: %w", err)
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, path, hash_md5, size_bytes, reason, deleted_at
+FROM media_trash
+ORDER BY deleted_at DESC, id DESC
+LIMIT ? OFFSET ?`, limit, page.Offset)
+	if err != nil {
+		return result, fmt.Errorf("query trash: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m TrashedMedia
+		if err := rows.Scan(&m.ID, &m.Path, &m.HashMD5, &m.SizeBytes, &m.Reason, &m.DeletedAt); err != nil {
+			return result, fmt.Errorf("scan trash row: %w", err)
+		}
+		result.Media = append(result.Media, m)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate trash: %w", err)
+	}
+	return result, nil
+}
+
+// RestoreMedia puts the trashed rows with the given IDs back into the
+// library under their old IDs, with what the trash kept of them. Rows whose
+// path the library has again are left in the trash and reported.
+func (s *Store) RestoreMedia(ctx context.Context, ids []int64) (TrashRestore, error) {
+	result := TrashRestore{Conflicts: []string{}}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin restore media: %w", err)
+	}
+	defer tx.Rollback()
+
+	values := make([]string, 0, len(trashColumns))
+	for _, col := range trashColumns {
+		values = append(values, "json_extract(row, '$."+col+"')")
+	}
+	restore := `INSERT INTO media_files (` + strings.Join(trashColumns, ", ") + `)
+SELECT ` + strings.Join(values, ", ") + ` FROM media_trash WHERE id = ?`
+
+	for _, id := range ids {
+		var path string
+		var taken bool
+		err := tx.QueryRowContext(ctx, `
+SELECT path, EXISTS(SELECT 1 FROM media_files m WHERE m.path = media_trash.path OR m.id = media_trash.id)
+FROM media_trash WHERE id = ?`, id).Scan(&path, &taken)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("read trashed media: %w", err)
+		}
+		if taken {
+			result.Conflicts = append(result.Conflicts, path)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, restore, id); err != nil {
+			return result, fmt.Errorf("restore media: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO media_exif (media_id, tag, value)
+SELECT ?, json_extract(e.value, '$.tag'), json_extract(e.value, '$.value')
+FROM media_trash t, json_each(t.exif) e WHERE t.id = ?`, id, id); err != nil {
+			return result, fmt.Errorf("restore exif: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO media_tags (media_id, tag, source)
+SELECT ?, json_extract(e.value, '$.tag'), json_extract(e.value, '$.source')
+FROM media_trash t, json_each(t.tags) e WHERE t.id = ?`, id, id); err != nil {
+			return result, fmt.Errorf("restore tags: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO media_ratings (media_id, rating, flag, color_label, developed, source)
+SELECT id, json_extract(rating, '$.rating'), json_extract(rating, '$.flag'), json_extract(rating, '$.color_label'),
+    json_extract(rating, '$.developed'), json_extract(rating, '$.source')
+FROM media_trash WHERE id = ? AND rating IS NOT NULL`, id); err != nil {
+			return result, fmt.Errorf("restore rating: %w", err)
+		}
+		// Albums deleted in the meantime are skipped.
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO album_media (album_id, media_id)
+SELECT a.id, t.id FROM media_trash t, json_each(t.albums) e JOIN albums a ON a.id = e.value
+WHERE t.id = ?`, id); err != nil {
+			return result, fmt.Errorf("restore albums: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM media_trash WHERE id = ?`, id); err != nil {
+			return result, fmt.Errorf("remove from trash: %w", err)
+		}
+		result.Restored++
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit restore media: %w", err)
+	}
+	return result, nil
+}
+
+// PurgeTrash deletes the trashed rows removed before olderThan for good and
+// returns how many there were; the zero time purges the whole trash.
+func (s *Store) PurgeTrash(ctx context.Context, olderThan time.Time) (int, error) {
+	query, args := `DELETE FROM media_trash`, []interface{}{}
+	if !olderThan.IsZero() {
+		query += ` WHERE deleted_at < ?`
+		args = append(args, olderThan.UTC().Format(sqliteTimeLayout))
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("purge trash: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
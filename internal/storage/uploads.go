@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// UploadSession is a multipart upload that has not been completed yet. It is
+// kept so an interrupted backup resumes with the parts already sent.
+type UploadSession struct {
+	// Target is the object the upload creates, e.g. "s3://bucket/key".
+	Target   string
+	UploadID string
+	HashMD5  string
+	PartSize int64
+}
+
+// GetUploadSession returns the unfinished upload to target, if any.
+func (s *Store) GetUploadSession(ctx context.Context, target string) (UploadSession, bool, error) {
+	session := UploadSession{Target: target}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT upload_id, hash_md5, part_size FROM upload_sessions WHERE target = ?`, target,
+	).Scan(&session.UploadID, &session.HashMD5, &session.PartSize)
+	if err == sql.ErrNoRows {
+		return session, false, nil
+	}
+	if err != nil {
+		return session, false, fmt.Errorf("query upload session: %w", err)
+	}
+	return session, true, nil
+}
+
+// SaveUploadSession records an upload started for session.Target, replacing
+// any earlier one.
+func (s *Store) SaveUploadSession(ctx context.Context, session UploadSession) error {
+	query := `
+INSERT INTO upload_sessions (target, upload_id, hash_md5, part_size, started_at)
+VALUES (?, ?, ?, ?, datetime('now'))
+ON CONFLICT(target) DO UPDATE SET
+    upload_id = excluded.upload_id,
+    hash_md5 = excluded.hash_md5,
+    part_size = excluded.part_size,
+    started_at = excluded.started_at
+`
+	if _, err := s.db.ExecContext(ctx, query, session.Target, session.UploadID, session.HashMD5, session.PartSize); err != nil {
+		return fmt.Errorf("save upload session: %w", err)
+	}
+	return nil
+}
+
+// DeleteUploadSession forgets the upload to target once it completed or
+// was abandoned.
+func (s *Store) DeleteUploadSession(ctx context.Context, target string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM upload_sessions WHERE target = ?`, target); err != nil {
+		return fmt.Errorf("delete upload session: %w", err)
+	}
+	return nil
+}
+
+// UploadedTargets maps every target below prefix that a completed upload
+// action wrote to the hash of the file last uploaded there.
+func (s *Store) UploadedTargets(ctx context.Context, prefix string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT target_path, COALESCE(hash_md5, '')
+FROM file_actions
+WHERE action_type = 'upload' AND status = ? AND substr(target_path, 1, ?) = ?
+ORDER BY id
+`, string(ActionStatusCompleted), len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("query uploads: %w", err)
+	}
+	defer rows.Close()
+
+	uploaded := make(map[string]string)
+	for rows.Next() {
+		var target, hash string
+		if err := rows.Scan(&target, &hash); err != nil {
+			return nil, fmt.Errorf("scan upload: %w", err)
+		}
+		uploaded[target] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate uploads: %w", err)
+	}
+	return uploaded, nil
+}
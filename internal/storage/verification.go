@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verification statuses recorded per file.
+const (
+	VerifyOK       = "ok"
+	VerifyMismatch = "mismatch"
+	VerifyChanged  = "changed"
+	VerifyMissing  = "missing"
+	VerifyError    = "error"
+)
+
+// VerificationResult is the latest re-hash of a library file. Mismatches,
+// missing files and read errors are reported as possible corruption;
+// VerifyChanged marks files edited since the scan, whose size or time moved
+// along with their content.
+type VerificationResult struct {
+	MediaID      int64  `json:"mediaId"`
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expectedHash"`
+	ActualHash   string `json:"actualHash,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	VerifiedAt   string `json:"verifiedAt,omitempty"`
+}
+
+// verifiable selects the rows verification can re-hash: files with a hash,
+// leaving out archive entries ("backup.zip!/...").
+const verifiable = `hash_md5 <> '' AND instr(path, '!/') = 0`
+
+// CountVerifiable returns how many files verification can re-hash.
+func (s *Store) CountVerifiable(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM media_files WHERE `+verifiable).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count verifiable media: %w", err)
+	}
+	return n, nil
+}
+
+// ListVerificationCandidates returns up to limit files to re-hash, those
+// never verified first and then the longest unverified, so repeated samples
+// rotate through the whole library. limit <= 0 returns every file.
+func (s *Store) ListVerificationCandidates(ctx context.Context, limit int) ([]MediaFile, error) {
+	query := `SELECT ` + mediaColumns + ` FROM media_files WHERE ` + verifiable + `
+ORDER BY COALESCE((SELECT v.verified_at FROM verification_results v WHERE v.media_id = media_files.id), ''), id`
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list verification candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var files []MediaFile
+	for rows.Next() {
+		file, err := s.scanMediaFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan media row: %w", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate media rows: %w", err)
+	}
+	return files, nil
+}
+
+// RecordVerification stores the outcome of re-hashing a file, replacing its
+// previous result.
+func (s *Store) RecordVerification(ctx context.Context, result VerificationResult) error {
+	query := `
+INSERT INTO verification_results (media_id, path, expected_hash, actual_hash, status, error, verified_at)
+VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+ON CONFLICT(media_id) DO UPDATE SET
+    path = excluded.path,
+    expected_hash = excluded.expected_hash,
+    actual_hash = excluded.actual_hash,
+    status = excluded.status,
+    error = excluded.error,
+    verified_at = excluded.verified_at
+`
+	if _, err := s.db.ExecContext(ctx, query, result.MediaID, result.Path, result.ExpectedHash,
+		result.ActualHash, result.Status, result.Error); err != nil {
+		return fmt.Errorf("record verification: %w", err)
+	}
+	return nil
+}
+
+// ListVerificationIssues returns the files whose latest verification points
+// at possible corruption, newest first. Results for files since rescanned with a new hash or
+// removed from the library are left out.
+func (s *Store) ListVerificationIssues(ctx context.Context) ([]VerificationResult, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT v.media_id, v.path, v.expected_hash, v.actual_hash, v.status, v.error, v.verified_at
+FROM verification_results v
+JOIN media_files m ON m.id = v.media_id AND m.hash_md5 = v.expected_hash
+WHERE v.status IN (?, ?, ?)
+ORDER BY v.verified_at DESC, v.media_id
+`, VerifyMismatch, VerifyMissing, VerifyError)
+	if err != nil {
+		return nil, fmt.Errorf("query verification issues: %w", err)
+	}
+	defer rows.Close()
+
+	results := []VerificationResult{}
+	for rows.Next() {
+		var r VerificationResult
+		if err := rows.Scan(&r.MediaID, &r.Path, &r.ExpectedHash, &r.ActualHash, &r.Status, &r.Error, &r.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("scan verification result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate verification results: %w", err)
+	}
+	return results, nil
+}
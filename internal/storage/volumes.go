@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Volume is a drive or share that scanned files live on. A file is keyed by
+// its volume and its path relative to the mount point; the absolute path is
+// resolved from them whenever the volume is found under another mount point
+// or drive letter, without a rescan.
+type Volume struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	MountPoint string `json:"mountPoint"`
+	Removable  bool   `json:"removable"`
+	// Online reports whether the volume was mounted when last checked;
+	// the files of an offline volume are listed with Offline set.
+	Online   bool   `json:"online"`
+	LastSeen string `json:"lastSeen"`
+	Files    int    `json:"files"`
+}
+
+// SaveVolume records a volume seen mounted now.
+func (s *Store) SaveVolume(ctx context.Context, v Volume) error {
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO volumes (id, label, mount_point, removable, online, last_seen)
+VALUES (?, ?, ?, ?, 1, datetime('now'))
+ON CONFLICT(id) DO UPDATE SET
+    label = excluded.label,
+    mount_point = excluded.mount_point,
+    removable = excluded.removable,
+    online = 1,
+    last_seen = excluded.last_seen
+`, v.ID, v.Label, v.MountPoint, v.Removable); err != nil {
+		return fmt.Errorf("save volume: %w", err)
+	}
+	return nil
+}
+
+// ListVolumes returns the recorded volumes with their number of files.
+func (s *Store) ListVolumes(ctx context.Context) ([]Volume, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT v.id, v.label, v.mount_point, v.removable, v.online, v.last_seen,
+    (SELECT COUNT(*) FROM media_files m WHERE m.volume_id = v.id)
+FROM volumes v
+ORDER BY v.mount_point, v.id`)
+	if err != nil {
+		return nil, fmt.Errorf("query volumes: %w", err)
+	}
+	defer rows.Close()
+
+	volumes := []Volume{}
+	for rows.Next() {
+		var v Volume
+		if err := rows.Scan(&v.ID, &v.Label, &v.MountPoint, &v.Removable, &v.Online, &v.LastSeen, &v.Files); err != nil {
+			return nil, fmt.Errorf("scan volume: %w", err)
+		}
+		volumes = append(volumes, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate volumes: %w", err)
+	}
+	return volumes, nil
+}
+
+// SetVolumeOffline marks a volume as no longer mounted.
+func (s *Store) SetVolumeOffline(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE volumes SET online = 0 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("set volume offline: %w", err)
+	}
+	return nil
+}
+
+// RebindVolume moves the files of a volume to mountPoint, where it is
+// mounted now, and marks it online. Files whose new path is already taken,
+// say by a scan of the new mount point, keep their old path. It returns how
+// many files moved.
+func (s *Store) RebindVolume(ctx context.Context, id, mountPoint string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin rebind: %w", err)
+	}
+	defer tx.Rollback()
+
+	prefix := dirPrefix(mountPoint)
+	res, err := tx.ExecContext(ctx, `
+UPDATE OR IGNORE media_files SET path = ? || volume_path
+WHERE volume_id = ? AND volume_path IS NOT NULL AND path <> ? || volume_path`, prefix, id, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("rebind volume files: %w", err)
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rebind volume files: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE volumes SET mount_point = ?, online = 1, last_seen = datetime('now') WHERE id = ?`, mountPoint, id); err != nil {
+		return 0, fmt.Errorf("rebind volume: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit rebind: %w", err)
+	}
+	return moved, nil
+}
+
+// RebindRoot moves every file stored below oldRoot to the same place below
+// newRoot, for a share or folder that now lives elsewhere. Volumes mounted
+// at or below oldRoot move with it, so their files stay tied to them; other
+// files lose their volume until they are scanned again. Files whose new
+// path is already taken keep their old path. It returns how many files
+// moved.
+func (s *Store) RebindRoot(ctx context.Context, oldRoot, newRoot string) (int64, error) {
+	oldPrefix, newPrefix := dirPrefix(oldRoot), dirPrefix(newRoot)
+	// substr counts characters, not bytes.
+	n := utf8.RuneCountInString(oldPrefix)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin rebind: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, mount_point FROM volumes`)
+	if err != nil {
+		return 0, fmt.Errorf("query volumes: %w", err)
+	}
+	moved := make(map[string]string)
+	for rows.Next() {
+		var id, mountPoint string
+		if err := rows.Scan(&id, &mountPoint); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan volume: %w", err)
+		}
+		prefix := dirPrefix(mountPoint)
+		switch {
+		case prefix == oldPrefix:
+			moved[id] = filepath.Clean(newRoot)
+		case strings.HasPrefix(prefix, oldPrefix):
+			moved[id] = newPrefix + strings.TrimSuffix(prefix[len(oldPrefix):], string(filepath.Separator))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate volumes: %w", err)
+	}
+
+	ids := make([]interface{}, 0, len(moved))
+	for id, mountPoint := range moved {
+		if _, err := tx.ExecContext(ctx, `UPDATE volumes SET mount_point = ? WHERE id = ?`, mountPoint, id); err != nil {
+			return 0, fmt.Errorf("move volume: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := []interface{}{newPrefix, n + 1}
+	args = append(args, ids...)
+	args = append(args, ids...)
+	args = append(args, n, oldPrefix)
+	res, err := tx.ExecContext(ctx, `
+UPDATE OR IGNORE media_files SET
+    path = ? || substr(path, ?),
+    volume_id = CASE WHEN volume_id IN (`+placeholders+`) THEN volume_id END,
+    volume_path = CASE WHEN volume_id IN (`+placeholders+`) THEN volume_path END
+WHERE substr(path, 1, ?) = ?`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("rebind files: %w", err)
+	}
+	files, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rebind files: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit rebind: %w", err)
+	}
+	return files, nil
+}
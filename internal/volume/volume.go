@@ -0,0 +1,59 @@
+// Package volume identifies the filesystem a path lives on, so files on a
+// removable drive can be recognised when the drive comes back under another
+// mount point or drive letter.
+package volume
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrUnsupported is returned on platforms without volume detection.
+var ErrUnsupported = errors.New("volume detection is not supported on this platform")
+
+// Info describes a mounted volume. ID stays the same when the volume is
+// mounted elsewhere: it is the filesystem UUID or serial number where there
+// is one, and the mounted device or share otherwise.
+type Info struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	MountPoint string `json:"mountPoint"`
+	Removable  bool   `json:"removable"`
+}
+
+// Mounted lists the volumes mounted now.
+func Mounted() ([]Info, error) {
+	return mounted()
+}
+
+// Of returns the volume holding path, which must be absolute: the mounted
+// volume with the longest mount point containing it.
+func Of(path string) (Info, error) {
+	volumes, err := mounted()
+	if err != nil {
+		return Info{}, err
+	}
+	var best Info
+	found := false
+	for _, v := range volumes {
+		if contains(v.MountPoint, path) && (!found || len(v.MountPoint) > len(best.MountPoint)) {
+			best, found = v, true
+		}
+	}
+	if !found {
+		return Info{}, errors.New("no volume holds " + path)
+	}
+	return best, nil
+}
+
+// contains reports whether path lies at or below mountPoint. Windows and
+// macOS paths compare regardless of case.
+func contains(mountPoint, path string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		mountPoint, path = strings.ToLower(mountPoint), strings.ToLower(path)
+	}
+	mountPoint = strings.TrimSuffix(mountPoint, string(filepath.Separator))
+	return path == mountPoint || strings.HasPrefix(path, mountPoint+string(filepath.Separator))
+}
@@ -0,0 +1,83 @@
+package volume
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// described caches what diskutil reports per device and mount point, since
+// running it for every poll is slow.
+var (
+	describedMu sync.Mutex
+	described   = make(map[string]Info)
+)
+
+func mounted() ([]Info, error) {
+	n, err := syscall.Getfsstat(nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]syscall.Statfs_t, n)
+	if n, err = syscall.Getfsstat(stats, 0); err != nil {
+		return nil, err
+	}
+	volumes := make([]Info, 0, n)
+	for _, st := range stats[:n] {
+		from, on := cString(st.Mntfromname[:]), cString(st.Mntonname[:])
+		v := Info{ID: from, MountPoint: on}
+		if strings.HasPrefix(from, "/dev/") {
+			v = describe(from, on)
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// describe asks diskutil for the UUID, name and location of the volume on
+// device mounted at mountPoint.
+func describe(device, mountPoint string) Info {
+	key := device + "\x00" + mountPoint
+	describedMu.Lock()
+	defer describedMu.Unlock()
+	if v, ok := described[key]; ok {
+		return v
+	}
+	v := Info{ID: device, MountPoint: mountPoint}
+	if out, err := exec.Command("diskutil", "info", mountPoint).Output(); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "Volume UUID":
+				v.ID = value
+			case "Volume Name":
+				v.Label = value
+			case "Removable Media":
+				v.Removable = v.Removable || value == "Removable"
+			case "Device Location":
+				v.Removable = v.Removable || value == "External"
+			}
+		}
+	}
+	described[key] = v
+	return v
+}
+
+func cString(b []int8) string {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		out = append(out, byte(c))
+	}
+	return string(out)
+}
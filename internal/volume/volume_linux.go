@@ -0,0 +1,108 @@
+package volume
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func mounted() ([]Info, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	uuids := deviceNames("/dev/disk/by-uuid")
+	labels := deviceNames("/dev/disk/by-label")
+	var volumes []Info
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Fields before the "-" separator are mount ID, parent ID,
+		// major:minor, root, mount point and options; after it come the
+		// filesystem type and the source.
+		before, after, ok := strings.Cut(scanner.Text(), " - ")
+		if !ok {
+			continue
+		}
+		fields, rest := strings.Fields(before), strings.Fields(after)
+		if len(fields) < 5 || len(rest) < 2 {
+			continue
+		}
+		v := Info{MountPoint: unescape(fields[4]), ID: unescape(rest[1])}
+		if device, err := filepath.EvalSymlinks(v.ID); err == nil && strings.HasPrefix(device, "/dev/") {
+			if uuid, ok := uuids[device]; ok {
+				v.ID = uuid
+			}
+			v.Label = labels[device]
+			v.Removable = removable(device)
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, scanner.Err()
+}
+
+// deviceNames maps the devices the links in dir point to to the links'
+// names, such as filesystem UUIDs or labels.
+func deviceNames(dir string) map[string]string {
+	names := make(map[string]string)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return names
+	}
+	for _, entry := range entries {
+		if device, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name())); err == nil {
+			names[device] = unescape(entry.Name())
+		}
+	}
+	return names
+}
+
+// removable reports whether device is a partition of a removable or USB
+// disk; USB hard drives usually do not call themselves removable.
+func removable(device string) bool {
+	sys, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", filepath.Base(device)))
+	if err != nil {
+		return false
+	}
+	if strings.Contains(sys, "/usb") {
+		return true
+	}
+	disk := sys
+	if _, err := os.Stat(filepath.Join(sys, "partition")); err == nil {
+		disk = filepath.Dir(sys)
+	}
+	flag, err := os.ReadFile(filepath.Join(disk, "removable"))
+	return err == nil && strings.TrimSpace(string(flag)) == "1"
+}
+
+// unescape decodes the octal (\040) and hex (\x20) escapes of mountinfo and
+// udev link names.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			if i+3 < len(s) && s[i+1] == 'x' {
+				if n, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+					b.WriteByte(byte(n))
+					i += 3
+					continue
+				}
+			}
+			if i+3 < len(s) {
+				if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+					b.WriteByte(byte(n))
+					i += 3
+					continue
+				}
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
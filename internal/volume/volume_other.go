@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package volume
+
+func mounted() ([]Info, error) {
+	return nil, ErrUnsupported
+}
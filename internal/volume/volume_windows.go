@@ -0,0 +1,64 @@
+package volume
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// driveRemovable is DRIVE_REMOVABLE from winbase.h: card readers and USB
+// sticks. USB hard drives report themselves as fixed.
+const driveRemovable = 2
+
+var (
+	kernel32                    = syscall.NewLazyDLL("kernel32.dll")
+	procGetLogicalDriveStringsW = kernel32.NewProc("GetLogicalDriveStringsW")
+	procGetVolumeInformationW   = kernel32.NewProc("GetVolumeInformationW")
+	procGetDriveTypeW           = kernel32.NewProc("GetDriveTypeW")
+)
+
+func mounted() ([]Info, error) {
+	buf := make([]uint16, 512)
+	n, _, err := procGetLogicalDriveStringsW.Call(uintptr(len(buf)), uintptr(unsafe.Pointer(&buf[0])))
+	if n == 0 {
+		return nil, err
+	}
+	// The buffer holds NUL-terminated roots such as "C:\".
+	var volumes []Info
+	for start := 0; start < int(n); {
+		end := start
+		for end < int(n) && buf[end] != 0 {
+			end++
+		}
+		if end > start {
+			if v, ok := info(buf[start : end+1]); ok {
+				volumes = append(volumes, v)
+			}
+		}
+		start = end + 1
+	}
+	return volumes, nil
+}
+
+// info describes the volume at root, a NUL-terminated UTF-16 path. Drives
+// without media, such as empty card readers, are left out.
+func info(root []uint16) (Info, bool) {
+	var (
+		label  [syscall.MAX_PATH + 1]uint16
+		serial uint32
+	)
+	ret, _, _ := procGetVolumeInformationW.Call(
+		uintptr(unsafe.Pointer(&root[0])),
+		uintptr(unsafe.Pointer(&label[0])), uintptr(len(label)),
+		uintptr(unsafe.Pointer(&serial)), 0, 0, 0, 0)
+	if ret == 0 {
+		return Info{}, false
+	}
+	kind, _, _ := procGetDriveTypeW.Call(uintptr(unsafe.Pointer(&root[0])))
+	return Info{
+		ID:         fmt.Sprintf("%04X-%04X", serial>>16, serial&0xffff),
+		Label:      syscall.UTF16ToString(label[:]),
+		MountPoint: syscall.UTF16ToString(root),
+		Removable:  kind == driveRemovable,
+	}, true
+}
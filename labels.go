@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// ClassifyImages labels images not yet labelled by the configured model, up
+// to limit (0 for all). Labels become tags, which search matches and tidy
+// patterns test with .HasTag. It requires the imageLabels feature flag and
+// reports labels:progress events.
+func (a *App) ClassifyImages(limit int) (media.LabelSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return media.LabelSummary{}, errors.New("store not initialised")
+	}
+	if !a.settings.FeatureEnabled(config.FeatureImageLabels) {
+		return media.LabelSummary{}, errors.New("image labelling is turned off in the feature flags")
+	}
+	cfg := a.settings.Classifier
+	classifier, err := media.NewClassifier(cfg.Model, cfg.Command, cfg.MinScore)
+	if err != nil {
+		return media.LabelSummary{}, err
+	}
+
+	a.labelsMu.Lock()
+	if a.cancelLabels != nil {
+		a.labelsMu.Unlock()
+		return media.LabelSummary{}, errors.New("image labelling is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelLabels = cancel
+	a.labelsMu.Unlock()
+	defer func() {
+		a.labelsMu.Lock()
+		a.cancelLabels()
+		a.cancelLabels = nil
+		a.labelsMu.Unlock()
+	}()
+
+	jobID := events.NewJobID("labels")
+	a.logger.Info("image labelling started", "jobId", jobID, "model", classifier.Model(), "limit", limit)
+	opts := media.LabelOptions{Limit: limit, Gate: a.gate, Throttle: a.throttle}
+	summary, err := media.LabelImages(ctx, a.store, classifier, opts, func(p media.LabelProgress) {
+		a.emit(jobID, events.LabelsProgress, p)
+	})
+	if err != nil {
+		a.notifyJob(config.NotifyAnalysis, "Image labelling", err, "")
+		a.logger.Error("image labelling stopped", "jobId", jobID, "error", err, "labelled", summary.Labelled)
+		return summary, err
+	}
+	a.logger.Info("image labelling finished", "jobId", jobID,
+		"labelled", summary.Labelled,
+		"labels", summary.Labels,
+		"failed", summary.Failed,
+		"durationMs", summary.DurationMS,
+	)
+	for _, msg := range summary.Errors {
+		a.logger.Warn("image labelling error", "jobId", jobID, "error", msg)
+	}
+	a.notifyJob(config.NotifyAnalysis, "Image labelling", nil, fmt.Sprintf("%d images labelled, %d failed", summary.Labelled, summary.Failed))
+	return summary, nil
+}
+
+// CancelClassify stops the running image labelling after the image in
+// progress. It reports whether labelling was running.
+func (a *App) CancelClassify() bool {
+	a.labelsMu.Lock()
+	defer a.labelsMu.Unlock()
+	if a.cancelLabels == nil {
+		return false
+	}
+	a.cancelLabels()
+	return true
+}
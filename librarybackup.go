@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/backup"
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// BackupLibrary uploads every file below the target base that the bucket
+// does not hold yet, reporting backup:progress events. Unfinished multipart
+// uploads from an interrupted run resume.
+func (a *App) BackupLibrary() (backup.Summary, error) {
+	if a.store == nil || a.settings == nil {
+		return backup.Summary{}, errors.New("store not initialised")
+	}
+	if a.settings.Target.BaseFolder == "" {
+		return backup.Summary{}, errors.New("target baseFolder is not configured")
+	}
+	if media.IsRemote(a.settings.Target.BaseFolder) {
+		return backup.Summary{}, errors.New("library backup reads local files; the target is remote")
+	}
+	files, err := a.store.ListMediaUnder(a.ctx, a.settings.Target.BaseFolder)
+	if err != nil {
+		return backup.Summary{}, err
+	}
+	return a.backupFiles(files)
+}
+
+// CancelLibraryBackup stops the running library backup after the file or
+// part in progress. It reports whether a backup was running.
+func (a *App) CancelLibraryBackup() bool {
+	a.uploadMu.Lock()
+	defer a.uploadMu.Unlock()
+	if a.cancelUpload == nil {
+		return false
+	}
+	a.cancelUpload()
+	return true
+}
+
+// CheckBackupBucket verifies that the configured bucket is reachable with
+// the configured credentials.
+func (a *App) CheckBackupBucket() error {
+	client, err := a.backupClient()
+	if err != nil {
+		return err
+	}
+	return client.HeadBucket(a.ctx)
+}
+
+// ExportManifest writes a checksum manifest of the library to path so
+// external tools can verify backups. algorithm is "sha256", "sha1", "md5" or
+// "par2" for a plain file listing. targetOnly limits it to files tidied into
+// the target folder, whose paths are listed relative to it.
+func (a *App) ExportManifest(path, algorithm string, targetOnly bool) (backup.ManifestSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return backup.ManifestSummary{}, errors.New("store not initialised")
+	}
+	if strings.TrimSpace(path) == "" {
+		return backup.ManifestSummary{}, errors.New("manifest path is required")
+	}
+	base := a.settings.Target.BaseFolder
+	if media.IsRemote(base) {
+		base = ""
+	}
+
+	var files []storage.MediaFile
+	var err error
+	if targetOnly {
+		if base == "" {
+			return backup.ManifestSummary{}, errors.New("target baseFolder is not a local folder")
+		}
+		files, err = a.store.ListMediaUnder(a.ctx, base)
+	} else {
+		files, err = a.store.ListMedia(a.ctx, storage.MediaFilter{})
+	}
+	if err != nil {
+		return backup.ManifestSummary{}, err
+	}
+
+	summary, err := backup.WriteManifest(a.ctx, path, files, backup.ManifestOptions{
+		Algorithm: algorithm,
+		Base:      base,
+		Gate:      a.gate,
+		Throttle:  a.throttle,
+	})
+	if err != nil {
+		a.logger.Error("manifest export failed", "path", path, "error", err)
+		return summary, err
+	}
+	a.logger.Info("manifest exported", "path", path, "algorithm", summary.Algorithm,
+		"files", summary.Files, "skipped", summary.Skipped, "failed", summary.Failed, "durationMs", summary.DurationMS)
+	return summary, nil
+}
+
+// backupRun uploads the files a tidy run moved, when backups follow tidy.
+func (a *App) backupRun(runID int64) {
+	moves, err := a.store.ListRunMoves(a.ctx, runID)
+	if err != nil {
+		a.logger.Error("list moves for backup", "runId", runID, "error", err)
+		return
+	}
+	ids := make([]int64, 0, len(moves))
+	for _, move := range moves {
+		ids = append(ids, move.MediaID)
+	}
+	byID, err := a.store.GetMediaByIDs(a.ctx, ids)
+	if err != nil {
+		a.logger.Error("load moves for backup", "runId", runID, "error", err)
+		return
+	}
+	files := make([]storage.MediaFile, 0, len(byID))
+	for _, id := range ids {
+		if file, ok := byID[id]; ok {
+			files = append(files, file)
+		}
+	}
+	if _, err := a.backupFiles(files); err != nil {
+		a.logger.Warn("backup after tidy", "runId", runID, "error", err)
+	}
+}
+
+func (a *App) backupFiles(files []storage.MediaFile) (backup.Summary, error) {
+	client, err := a.backupClient()
+	if err != nil {
+		return backup.Summary{}, err
+	}
+
+	a.uploadMu.Lock()
+	if a.cancelUpload != nil {
+		a.uploadMu.Unlock()
+		return backup.Summary{}, errors.New("a library backup is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelUpload = cancel
+	a.uploadMu.Unlock()
+	defer func() {
+		a.uploadMu.Lock()
+		a.cancelUpload()
+		a.cancelUpload = nil
+		a.uploadMu.Unlock()
+	}()
+
+	jobID := events.NewJobID("backup")
+	opts := backup.Options{
+		Base:     a.settings.Target.BaseFolder,
+		Prefix:   a.settings.Backup.Prefix,
+		PartSize: int64(a.settings.Backup.PartSizeMB) << 20,
+		Gate:     a.gate,
+		Throttle: a.throttle,
+	}
+	a.logger.Info("library backup started", "jobId", jobID, "files", len(files), "bucket", a.settings.Backup.Bucket)
+	summary, err := backup.NewUploader(a.store, client).Upload(ctx, files, opts, func(p backup.Progress) {
+		a.emit(jobID, events.BackupProgress, p)
+	})
+	if err != nil {
+		a.logger.Error("library backup stopped", "jobId", jobID, "error", err, "uploaded", summary.Uploaded)
+		a.notifyJob(config.NotifyBackup, "Backup", err, "")
+		return summary, err
+	}
+	a.logger.Info("library backup finished", "jobId", jobID,
+		"uploaded", summary.Uploaded,
+		"skipped", summary.Skipped,
+		"failed", summary.Failed,
+		"bytes", summary.BytesUploaded,
+		"durationMs", summary.DurationMS,
+	)
+	a.notifyJob(config.NotifyBackup, "Backup", nil,
+		fmt.Sprintf("%d files uploaded, %d skipped, %d failed", summary.Uploaded, summary.Skipped, summary.Failed))
+	return summary, nil
+}
+
+// backupClient builds the S3 client for the configured bucket.
+func (a *App) backupClient() (*backup.Client, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings not loaded")
+	}
+	cfg := a.settings.Backup
+	accessKeyID, secretAccessKey := a.settings.BackupCredentials()
+	return backup.NewClient(backup.Config{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		Bucket:          cfg.Bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		PathStyle:       cfg.PathStyle,
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ImportLightroomCatalog carries the ratings, flags, color labels, develop
+// state and keywords of a Lightroom Classic catalog over to the library
+// files they belong to, matched by path or, for files tidied since, by
+// content. The catalog itself is only read.
+func (a *App) ImportLightroomCatalog(catalog string) (media.LightroomSummary, error) {
+	if a.store == nil {
+		return media.LightroomSummary{}, errors.New("store not initialised")
+	}
+	catalog = filepath.Clean(strings.TrimSpace(catalog))
+	if !strings.EqualFold(filepath.Ext(catalog), ".lrcat") {
+		return media.LightroomSummary{}, errors.New("choose a Lightroom catalog (.lrcat)")
+	}
+	if !a.jobMu.TryLock() {
+		return media.LightroomSummary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	images, err := media.ReadLightroomCatalog(a.ctx, catalog)
+	if err != nil {
+		return media.LightroomSummary{}, err
+	}
+	summary, err := media.ImportLightroom(a.ctx, a.store, images, media.LightroomOptions{Gate: a.gate, Throttle: a.throttle})
+	if err != nil {
+		a.logger.Error("lightroom import stopped", "catalog", catalog, "error", err)
+		return summary, err
+	}
+	a.logger.Info("lightroom catalog imported", "catalog", catalog,
+		"images", summary.Images,
+		"byPath", summary.ByPath,
+		"byHash", summary.ByHash,
+		"unmatched", summary.Unmatched,
+		"durationMs", summary.DurationMS,
+	)
+	return summary, nil
+}
+
+// GetMediaRating returns the rating, flag, color label and develop state
+// imported for a file, or nil when none was.
+func (a *App) GetMediaRating(mediaID int64) (*storage.MediaRating, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	rating, ok, err := a.store.GetMediaRating(a.ctx, mediaID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &rating, nil
+}
@@ -1,20 +1,45 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"encoding/json"
+	"flag"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 	"github.com/wailsapp/wails/v2/pkg/options/windows"
+
+	"github.com/deadlyedge/wails-tabs/internal/bench"
+	"github.com/deadlyedge/wails-tabs/internal/media"
 )
 
 //go:embed all:frontend/dist
 var assets embed.FS
 
+// safetyLevels exports media.SafetyLevel to the frontend as an enum; the tidy
+// bindings take one.
+var safetyLevels = []struct {
+	Value  media.SafetyLevel
+	TSName string
+}{
+	{media.SafetyFast, "FAST"},
+	{media.SafetyStandard, "STANDARD"},
+	{media.SafetyParanoid, "PARANOID"},
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		os.Exit(runBenchmark(os.Args[2:]))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
+	if wd, err := os.Getwd(); err == nil {
+		app.launchFolders = folderArgs(os.Args[1:], wd)
+	}
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -22,14 +47,31 @@ func main() {
 		Width:  800,
 		Height: 600,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: newMediaHandler(app),
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
 		OnShutdown:       app.shutdown,
+		// Folders dropped onto the window become scan sources; the webview
+		// itself must not navigate to them.
+		DragAndDrop: &options.DragAndDrop{
+			EnableFileDrop:     true,
+			DisableWebViewDrop: true,
+		},
+		// A second launch on the same settings hands its arguments over
+		// and exits instead of opening the database again.
+		SingleInstanceLock: &options.SingleInstanceLock{
+			UniqueId:               instanceID(app.settingsPath),
+			OnSecondInstanceLaunch: app.onSecondInstance,
+		},
 		Bind: []interface{}{
 			app,
 		},
+		EnumBind: []interface{}{
+			safetyLevels,
+		},
 
 		// xdream edit
 		DisableResize: true,
@@ -38,6 +80,8 @@ func main() {
 			ZoomFactor:           1.0,
 			IsZoomControlEnabled: false,
 			DisablePinchZoom:     true,
+			OnSuspend:            app.onSuspend,
+			OnResume:             app.onResume,
 		},
 	})
 
@@ -45,3 +89,25 @@ func main() {
 		println("Error:", err.Error())
 	}
 }
+
+// runBenchmark implements the "benchmark" command line mode.
+func runBenchmark(args []string) int {
+	fs := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+	target := fs.String("target", "", "folder on the volume to test copy speed against")
+	size := fs.Int("size", 64, "sample file size in MB")
+	rows := fs.Int("rows", 2000, "number of SQLite rows to insert")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	result, err := bench.Run(context.Background(), bench.Options{TargetDir: *target, SizeMB: *size, Rows: *rows})
+	if err != nil {
+		println("Error:", err.Error())
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(result)
+	return 0
+}
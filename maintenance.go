@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// MaintenanceSummary reports what a maintenance run pruned and how the
+// database's size changed.
+type MaintenanceSummary struct {
+	ArchivedActions int                   `json:"archivedActions"`
+	PurgedTrash     int                   `json:"purgedTrash"`
+	ReleasedClaims  int                   `json:"releasedClaims"`
+	Vacuumed        bool                  `json:"vacuumed"`
+	Before          storage.DatabaseStats `json:"before"`
+	After           storage.DatabaseStats `json:"after"`
+	DurationMs      int64                 `json:"durationMs"`
+}
+
+// RunMaintenance archives and prunes what the retention settings expire,
+// releases target names left claimed by killed tidy runs, refreshes the
+// query planner's statistics and vacuums the database when free pages make
+// up at least the configured share of it, or always with forceVacuum. It
+// holds other jobs off while it runs.
+func (a *App) RunMaintenance(forceVacuum bool) (MaintenanceSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return MaintenanceSummary{}, errors.New("store not initialised")
+	}
+	if !a.jobMu.TryLock() {
+		return MaintenanceSummary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	started := time.Now()
+	var summary MaintenanceSummary
+	var err error
+	if summary.Before, err = a.store.Stats(a.ctx); err != nil {
+		return summary, err
+	}
+	if summary.ArchivedActions, summary.PurgedTrash, err = a.pruneExpired(); err != nil {
+		return summary, err
+	}
+	// Holding jobMu, no tidy run of this process holds a claim.
+	if summary.ReleasedClaims, err = a.store.ReleaseStaleClaims(a.ctx, time.Now().Add(-claimGrace)); err != nil {
+		return summary, err
+	}
+	if err := a.store.Analyze(a.ctx); err != nil {
+		return summary, err
+	}
+
+	// Pruning frees pages, so the threshold is checked after it.
+	pruned, err := a.store.Stats(a.ctx)
+	if err != nil {
+		return summary, err
+	}
+	summary.After = pruned
+	if forceVacuum || (pruned.FreeBytes > 0 && pruned.FreePercent() >= a.settings.Retention.VacuumFreePercent) {
+		if err := a.store.Vacuum(a.ctx); err != nil {
+			return summary, err
+		}
+		summary.Vacuumed = true
+		if summary.After, err = a.store.Stats(a.ctx); err != nil {
+			return summary, err
+		}
+	}
+	summary.DurationMs = time.Since(started).Milliseconds()
+
+	a.logger.Info("maintenance finished",
+		"archivedActions", summary.ArchivedActions,
+		"purgedTrash", summary.PurgedTrash,
+		"releasedClaims", summary.ReleasedClaims,
+		"vacuumed", summary.Vacuumed,
+		"sizeBefore", summary.Before.SizeBytes,
+		"sizeAfter", summary.After.SizeBytes)
+	return summary, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// mediaHandler serves library files to the frontend under /media/{id}.
+// With ?size=N images, and videos when ffmpeg is available, are served as
+// cached JPEG thumbnails; without it the original is streamed with range
+// support so videos can seek.
+type mediaHandler struct {
+	app *App
+}
+
+func newMediaHandler(app *App) http.Handler {
+	return &mediaHandler{app: app}
+}
+
+// thumbnailURL is the address mediaHandler serves a thumbnail of id from.
+func thumbnailURL(id int64, size int) string {
+	return fmt.Sprintf("/media/%d?size=%d", id, size)
+}
+
+func (h *mediaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/media/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid media id", http.StatusBadRequest)
+		return
+	}
+	file, err := h.app.mediaFile(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	settings, probe := h.app.currentSettings(), h.app.currentProbe()
+	path := file.Path
+	contentType := file.MimeType.String
+	isImage := strings.HasPrefix(contentType, "image/")
+	isVideo := strings.HasPrefix(contentType, "video/") && probe.CanRenderPosters()
+	if sizeParam := r.URL.Query().Get("size"); sizeParam != "" && (isImage || isVideo) {
+		size, err := strconv.Atoi(sizeParam)
+		if err != nil || size <= 0 || size > media.MaxThumbnailSize {
+			http.Error(w, fmt.Sprintf("size must be between 1 and %d", media.MaxThumbnailSize), http.StatusBadRequest)
+			return
+		}
+		thumb := media.ThumbnailPath(settings.ThumbnailDir(h.app.dataRoot), file.HashMD5, size)
+		// Formats the decoder cannot read fall back to the original.
+		if isImage {
+			err = media.Thumbnail(file.Path, thumb, size)
+		} else {
+			err = probe.Poster(r.Context(), file.Path, thumb, size)
+		}
+		if err == nil {
+			path, contentType = thumb, "image/jpeg"
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, os.ErrNotExist) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
@@ -0,0 +1,7 @@
+//go:build dev
+
+package main
+
+// devBuild is set by the "dev" tag that wails dev builds with; settings are
+// then read from the working directory.
+const devBuild = true
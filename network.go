@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// NetworkState is emitted when a job pauses for an unreachable source or
+// target and again when it resumes.
+type NetworkState struct {
+	Offline bool   `json:"offline"`
+	Path    string `json:"path,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// offlineHandler returns the callback that reports a job waiting for path.
+func (a *App) offlineHandler(jobID string) media.OfflineFunc {
+	return func(path string, err error) {
+		a.offlineMu.Lock()
+		a.offlinePath = path
+		a.offlineMu.Unlock()
+
+		a.logger.Warn("job paused: path offline", "jobId", jobID, "path", path, "error", err)
+		a.emit(jobID, events.NetworkState, NetworkState{Offline: true, Path: path, Error: err.Error()})
+	}
+}
+
+// reachable reports whether path answers again, asking the WebDAV server
+// for remote targets.
+func (a *App) reachable(path string) bool {
+	if !media.IsRemote(path) {
+		return media.Reachable(path)
+	}
+	backend, err := a.targetBackend("")
+	if err != nil {
+		return false
+	}
+	_, err = backend.Stat(path)
+	return err == nil
+}
+
+// ResumeOffline resumes jobs paused for an unreachable share once it answers
+// again. It fails, leaving the jobs paused, while the share is still away.
+func (a *App) ResumeOffline() error {
+	a.offlineMu.Lock()
+	defer a.offlineMu.Unlock()
+
+	if a.offlinePath != "" && !a.reachable(a.offlinePath) {
+		return fmt.Errorf("%s is still unreachable", a.offlinePath)
+	}
+	a.offlinePath = ""
+	a.gate.Resume(media.PauseOffline)
+	a.logger.Info("jobs resumed after reconnect")
+	a.emit("", events.NetworkState, NetworkState{})
+	return nil
+}
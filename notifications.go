@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/desktop"
+)
+
+// ListNotifications returns every job type and whether it shows a desktop
+// notification when it finishes or fails.
+func (a *App) ListNotifications() []config.Notification {
+	if a.settings == nil {
+		return nil
+	}
+	return a.settings.NotificationSettings()
+}
+
+// SetNotification persists a job type's notification setting to
+// settings.toml and reloads the settings.
+func (a *App) SetNotification(job string, enabled bool) ([]config.Notification, error) {
+	if !a.jobMu.TryLock() {
+		return nil, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := config.SetNotification(a.settingsPath, job, enabled); err != nil {
+		return nil, err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return nil, err
+	}
+	return a.settings.NotificationSettings(), nil
+}
+
+// notifyJob shows a desktop notification that a job of the given type,
+// named name, finished with body as the result, or failed with err. Jobs
+// the user or shutdown cancelled do not notify. The notification is shown
+// in the background so the job returns at once.
+func (a *App) notifyJob(job, name string, err error, body string) {
+	if a.settings == nil || !a.settings.NotifyEnabled(job) || errors.Is(err, context.Canceled) {
+		return
+	}
+	title := name + " finished"
+	if err != nil {
+		title, body = name+" failed", err.Error()
+	}
+	go func() {
+		if err := desktop.Notify(title, body); err != nil {
+			a.logger.Warn("desktop notification", "job", job, "error", err)
+		}
+	}()
+}
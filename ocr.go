@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// RecogniseText reads the text in images not yet read with tesseract, up to
+// limit (0 for all), so that searches find receipts and screenshots by
+// their content. It requires the ocr feature flag and reports ocr:progress
+// events.
+func (a *App) RecogniseText(limit int) (media.OCRSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return media.OCRSummary{}, errors.New("store not initialised")
+	}
+	if !a.settings.FeatureEnabled(config.FeatureOCR) {
+		return media.OCRSummary{}, errors.New("text recognition is turned off in the feature flags")
+	}
+	if !a.tesseract.Available {
+		if a.tesseract.Disabled {
+			return media.OCRSummary{}, errors.New("tesseract is turned off in the tools settings")
+		}
+		return media.OCRSummary{}, errors.New("tesseract was not found; install it or set its path in the tools settings")
+	}
+	tesseract := media.NewTesseract(a.tesseract.Path, a.settings.OCR.Languages)
+
+	a.ocrMu.Lock()
+	if a.cancelOCR != nil {
+		a.ocrMu.Unlock()
+		return media.OCRSummary{}, errors.New("text recognition is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelOCR = cancel
+	a.ocrMu.Unlock()
+	defer func() {
+		a.ocrMu.Lock()
+		a.cancelOCR()
+		a.cancelOCR = nil
+		a.ocrMu.Unlock()
+	}()
+
+	jobID := events.NewJobID("ocr")
+	a.logger.Info("text recognition started", "jobId", jobID, "engine", tesseract.Engine(), "limit", limit)
+	opts := media.OCROptions{Limit: limit, Gate: a.gate, Throttle: a.throttle}
+	summary, err := media.RecogniseText(ctx, a.store, tesseract, opts, func(p media.OCRProgress) {
+		a.emit(jobID, events.OCRProgress, p)
+	})
+	if err != nil {
+		a.notifyJob(config.NotifyAnalysis, "Text recognition", err, "")
+		a.logger.Error("text recognition stopped", "jobId", jobID, "error", err, "read", summary.Read)
+		return summary, err
+	}
+	a.logger.Info("text recognition finished", "jobId", jobID,
+		"read", summary.Read,
+		"withText", summary.WithText,
+		"failed", summary.Failed,
+		"durationMs", summary.DurationMS,
+	)
+	for _, msg := range summary.Errors {
+		a.logger.Warn("text recognition error", "jobId", jobID, "error", msg)
+	}
+	a.notifyJob(config.NotifyAnalysis, "Text recognition", nil, fmt.Sprintf("%d images read, %d with text, %d failed", summary.Read, summary.WithText, summary.Failed))
+	return summary, nil
+}
+
+// CancelOCR stops the running text recognition after the image in progress.
+// It reports whether recognition was running.
+func (a *App) CancelOCR() bool {
+	a.ocrMu.Lock()
+	defer a.ocrMu.Unlock()
+	if a.cancelOCR == nil {
+		return false
+	}
+	a.cancelOCR()
+	return true
+}
+
+// GetMediaText returns the text recognised in a file, or "" when none was.
+func (a *App) GetMediaText(mediaID int64) (string, error) {
+	if a.store == nil {
+		return "", errors.New("store not initialised")
+	}
+	return a.store.GetMediaText(a.ctx, mediaID)
+}
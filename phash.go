@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// HashSimilarImages computes the perceptual hashes FindSimilar compares for
+// images without one, up to limit (0 for all), and then fingerprints videos
+// the same way when ffmpeg is available. It requires the perceptualHash
+// feature flag and reports phash:progress events.
+func (a *App) HashSimilarImages(limit int) (media.PHashSummary, error) {
+	if a.store == nil || a.settings == nil {
+		return media.PHashSummary{}, errors.New("store not initialised")
+	}
+	if !a.settings.FeatureEnabled(config.FeaturePerceptualHash) {
+		return media.PHashSummary{}, errors.New("perceptual hashing is turned off in the feature flags")
+	}
+
+	a.phashMu.Lock()
+	if a.cancelPHash != nil {
+		a.phashMu.Unlock()
+		return media.PHashSummary{}, errors.New("perceptual hashing is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelPHash = cancel
+	a.phashMu.Unlock()
+	defer func() {
+		a.phashMu.Lock()
+		a.cancelPHash()
+		a.cancelPHash = nil
+		a.phashMu.Unlock()
+	}()
+
+	jobID := events.NewJobID("phash")
+	a.logger.Info("perceptual hashing started", "jobId", jobID, "limit", limit)
+	opts := media.PHashOptions{Limit: limit, Gate: a.gate, Throttle: a.throttle}
+	progress := func(p media.PHashProgress) {
+		a.emit(jobID, events.PHashProgress, p)
+	}
+	summary, err := media.HashImages(ctx, a.store, opts, progress)
+	if err == nil && a.probe.CanFingerprint() {
+		var videos media.PHashSummary
+		videos, err = media.FingerprintVideos(ctx, a.store, a.probe, opts, progress)
+		summary.Hashed += videos.Hashed
+		summary.Failed += videos.Failed
+		summary.Skipped += videos.Skipped
+		summary.Errors = append(summary.Errors, videos.Errors...)
+		summary.DurationMS += videos.DurationMS
+	}
+	if err != nil {
+		a.notifyJob(config.NotifyAnalysis, "Similarity hashing", err, "")
+		a.logger.Error("perceptual hashing stopped", "jobId", jobID, "error", err, "hashed", summary.Hashed)
+		return summary, err
+	}
+	a.logger.Info("perceptual hashing finished", "jobId", jobID,
+		"hashed", summary.Hashed,
+		"failed", summary.Failed,
+		"durationMs", summary.DurationMS,
+	)
+	for _, msg := range summary.Errors {
+		a.logger.Warn("perceptual hashing error", "jobId", jobID, "error", msg)
+	}
+	a.notifyJob(config.NotifyAnalysis, "Similarity hashing", nil, fmt.Sprintf("%d files hashed, %d failed", summary.Hashed, summary.Failed))
+	return summary, nil
+}
+
+// CancelHashSimilarImages stops the running perceptual hashing after the
+// image in progress. It reports whether hashing was running.
+func (a *App) CancelHashSimilarImages() bool {
+	a.phashMu.Lock()
+	defer a.phashMu.Unlock()
+	if a.cancelPHash == nil {
+		return false
+	}
+	a.cancelPHash()
+	return true
+}
+
+// FindSimilar returns up to limit images that look like mediaID, such as
+// crops and edits of it, nearest first; for a video, its re-encoded copies.
+// threshold is the largest Hamming distance between perceptual hashes
+// counted as similar; 0 uses the default of 10 bits. Only files hashed by
+// HashSimilarImages are found.
+func (a *App) FindSimilar(mediaID int64, threshold, limit int) ([]media.SimilarImage, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	if threshold < 0 || threshold > 64 {
+		return nil, errors.New("threshold must be between 0 and 64 bits")
+	}
+	return media.FindSimilar(a.ctx, a.store, a.probe, mediaID, threshold, limit)
+}
+
+// ListSimilarVideoGroups returns the groups of videos that fingerprint
+// alike within threshold bits (0 for the default), such as the copies
+// WhatsApp re-compressed, which never share an MD5 hash.
+func (a *App) ListSimilarVideoGroups(threshold int) ([]media.SimilarVideoGroup, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	if threshold < 0 || threshold > 64 {
+		return nil, errors.New("threshold must be between 0 and 64 bits")
+	}
+	return media.GroupSimilarVideos(a.ctx, a.store, threshold)
+}
@@ -0,0 +1,120 @@
+package phototidy
+
+import (
+	"context"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// File is a media file recorded in a library.
+type File struct {
+	ID   int64
+	Path string
+	// Hash is the MD5 of the content, as hex.
+	Hash      string
+	SizeBytes int64
+	ModTime   time.Time
+	// TakenAt is the capture time as the camera's clock showed it, or zero
+	// when the file does not say.
+	TakenAt     time.Time
+	CameraMake  string
+	CameraModel string
+	MimeType    string
+	Width       int
+	Height      int
+	// Category is "photo", "video", "screenshot", "messaging" or
+	// "download".
+	Category string
+	Tags     []string
+}
+
+// DuplicateGroup is a set of files with the same content.
+type DuplicateGroup struct {
+	Hash  string
+	Files []File
+	// KeeperID is the copy suggested to keep.
+	KeeperID int64
+	// WastedBytes is what keeping a single copy would free.
+	WastedBytes int64
+}
+
+func fileOf(m storage.MediaFile) File {
+	return File{
+		ID:          m.ID,
+		Path:        m.Path,
+		Hash:        m.HashMD5,
+		SizeBytes:   m.SizeBytes,
+		ModTime:     m.ModTime,
+		TakenAt:     m.TakenAt.Time,
+		CameraMake:  m.CameraMake.String,
+		CameraModel: m.CameraModel.String,
+		MimeType:    m.MimeType.String,
+		Width:       m.Width,
+		Height:      m.Height,
+		Category:    m.Category,
+		Tags:        m.Tags,
+	}
+}
+
+func filesOf(ms []storage.MediaFile) []File {
+	files := make([]File, 0, len(ms))
+	for _, m := range ms {
+		files = append(files, fileOf(m))
+	}
+	return files
+}
+
+// FileAt implements Catalog.
+func (l *Library) FileAt(ctx context.Context, path string) (File, bool, error) {
+	m, found, err := l.store.FindMediaByPath(ctx, path)
+	if err != nil || !found {
+		return File{}, false, err
+	}
+	return fileOf(m), true, nil
+}
+
+// FileWithHash implements Catalog.
+func (l *Library) FileWithHash(ctx context.Context, hash string) (File, bool, error) {
+	m, found, err := l.store.FindMediaByHash(ctx, hash, "")
+	if err != nil || !found {
+		return File{}, false, err
+	}
+	return fileOf(m), true, nil
+}
+
+// FilesUnder implements Catalog.
+func (l *Library) FilesUnder(ctx context.Context, base string) ([]File, error) {
+	ms, err := l.store.ListMediaUnder(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	return filesOf(ms), nil
+}
+
+// FilesOutside implements Catalog.
+func (l *Library) FilesOutside(ctx context.Context, base string) ([]File, error) {
+	ms, err := l.store.ListMediaOutside(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	return filesOf(ms), nil
+}
+
+// Duplicates implements Catalog.
+func (l *Library) Duplicates(ctx context.Context) ([]DuplicateGroup, error) {
+	groups, err := l.store.ListDuplicateGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, DuplicateGroup{
+			Hash:        g.Hash,
+			Files:       filesOf(g.Files),
+			KeeperID:    g.SuggestedKeeperID,
+			WastedBytes: g.WastedBytes,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,301 @@
+// Package phototidy embeds the photoTidy engine in other Go programs: it
+// scans folders into a library database, reports the duplicate files found
+// and tidies files into a folder structure built from their metadata, as
+// the desktop app does. A library database is shared with the app, so a
+// command-line tool can scan and the app browse the result.
+//
+// The types of this package are its stable API. They are copied from the
+// engine's internal packages rather than aliased, so those can change
+// between releases without breaking programs built against this one.
+package phototidy
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ErrBusy is returned when a scan or tidy run is started on a library that
+// is already running one.
+var ErrBusy = errors.New("another scan or tidy run is in progress")
+
+// ErrLocked is returned when a library the app encrypted is read for the
+// names of people before Unlock; files showing a named person are among
+// them.
+var ErrLocked = storage.ErrLocked
+
+// Scanner records the media files below a set of folders in a library.
+type Scanner interface {
+	Scan(ctx context.Context, opts ScanOptions, onProgress func(ScanProgress)) (ScanSummary, error)
+}
+
+// Tidier moves library files into a folder structure.
+type Tidier interface {
+	Tidy(ctx context.Context, opts TidyOptions, ids []int64, onProgress func(TidyProgress)) (TidySummary, error)
+}
+
+// Catalog looks files up in a library.
+type Catalog interface {
+	// FileAt returns the file stored at path.
+	FileAt(ctx context.Context, path string) (File, bool, error)
+	// FileWithHash returns a file with the given content hash.
+	FileWithHash(ctx context.Context, hash string) (File, bool, error)
+	// FilesUnder returns the files below base and FilesOutside the rest,
+	// such as those a tidy run into base has still to move.
+	FilesUnder(ctx context.Context, base string) ([]File, error)
+	FilesOutside(ctx context.Context, base string) ([]File, error)
+	// Duplicates groups the files stored more than once by content.
+	Duplicates(ctx context.Context) ([]DuplicateGroup, error)
+}
+
+// Library is an open library database. It implements Scanner, Tidier and
+// Catalog and is safe for concurrent use, though it runs one scan or tidy
+// at a time.
+type Library struct {
+	store   *storage.Store
+	scanner *media.Scanner
+	tidy    *media.TidyExecutor
+	jobs    sync.Mutex
+}
+
+var (
+	_ Scanner = (*Library)(nil)
+	_ Tidier  = (*Library)(nil)
+	_ Catalog = (*Library)(nil)
+)
+
+// Open opens the library database at path, creating it and its folder when
+// missing and upgrading its schema when it was written by an older release.
+func Open(path string) (*Library, error) {
+	store, err := storage.New(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Library{store: store, scanner: media.NewScanner(store), tidy: media.NewTidyExecutor(store)}, nil
+}
+
+// Close closes the database.
+func (l *Library) Close() error {
+	return l.store.Close()
+}
+
+// Unlock opens a library the app encrypted with its passphrase. Libraries
+// that are not encrypted need no unlocking.
+func (l *Library) Unlock(ctx context.Context, passphrase string) error {
+	if !l.store.Locked() {
+		return nil
+	}
+	_, err := l.store.UnlockPassphrase(ctx, passphrase)
+	return err
+}
+
+// DefaultExtensions returns the file extensions scanned when ScanOptions
+// names none.
+func DefaultExtensions() []string {
+	defaults := config.Defaults()
+	return defaults.NormalisedExtensions()
+}
+
+// DefaultPattern returns the target pattern used when TidyOptions names
+// none.
+func DefaultPattern() string {
+	return config.Defaults().Target.Pattern
+}
+
+// ScanOptions configures a scan.
+type ScanOptions struct {
+	// Sources are the folders to scan.
+	Sources []string
+	// Extensions are the lower-case extensions, with their dot, of the
+	// files to record; empty means DefaultExtensions.
+	Extensions []string
+	// SkipFolders are name patterns, in filepath.Match syntax and ignoring
+	// case, of folders not to descend into.
+	SkipFolders    []string
+	FollowSymlinks bool
+	// Incremental skips files whose size and modification time match the
+	// library.
+	Incremental bool
+	// Parallel is how many sources are walked at once; 0 or 1 walks them
+	// one after the other.
+	Parallel int
+}
+
+// ScanProgress reports a file handled by a scan.
+type ScanProgress struct {
+	Path           string
+	Source         string
+	FilesProcessed int
+	FilesPersisted int
+	BytesProcessed int64
+}
+
+// ScanSummary reports the outcome of a scan.
+type ScanSummary struct {
+	FilesDiscovered int
+	FilesPersisted  int
+	FilesSkipped    int
+	FilesUnchanged  int
+	FilesCorrupt    int
+	FilesJunk       int
+	DuplicateGroups int
+	// ErrorCount counts every error and Errors holds the first messages.
+	ErrorCount int
+	Errors     []string
+	Cancelled  bool
+	DurationMS int64
+}
+
+// Scan records the media files below opts.Sources in the library, reading
+// their capture time, camera and size and hashing their content.
+func (l *Library) Scan(ctx context.Context, opts ScanOptions, onProgress func(ScanProgress)) (ScanSummary, error) {
+	if !l.jobs.TryLock() {
+		return ScanSummary{}, ErrBusy
+	}
+	defer l.jobs.Unlock()
+
+	extensions := opts.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions()
+	}
+	var progress func(media.Progress)
+	if onProgress != nil {
+		progress = func(p media.Progress) {
+			onProgress(ScanProgress{
+				Path:           p.Path,
+				Source:         p.Source,
+				FilesProcessed: p.FilesProcessed,
+				FilesPersisted: p.FilesPersisted,
+				BytesProcessed: p.BytesProcessed,
+			})
+		}
+	}
+	summary, err := l.scanner.Scan(ctx, media.Options{
+		Sources:        opts.Sources,
+		Extensions:     extensions,
+		SkipFolders:    opts.SkipFolders,
+		FollowSymlinks: opts.FollowSymlinks,
+		Incremental:    opts.Incremental,
+		Parallel:       opts.Parallel,
+	}, progress)
+	return ScanSummary{
+		FilesDiscovered: summary.FilesDiscovered,
+		FilesPersisted:  summary.FilesPersisted,
+		FilesSkipped:    summary.FilesSkipped,
+		FilesUnchanged:  summary.FilesUnchanged,
+		FilesCorrupt:    summary.FilesCorrupt,
+		FilesJunk:       summary.FilesJunk,
+		DuplicateGroups: summary.DuplicateGroups,
+		ErrorCount:      summary.ErrorCount,
+		Errors:          summary.Errors,
+		Cancelled:       summary.Cancelled,
+		DurationMS:      summary.DurationMS,
+	}, err
+}
+
+// Safety trades tidy speed against verification.
+type Safety string
+
+const (
+	// SafetyFast trusts renames and unverified copies between drives.
+	SafetyFast Safety = "fast"
+	// SafetyStandard verifies the hash of copies between drives before
+	// removing the source.
+	SafetyStandard Safety = "standard"
+	// SafetyParanoid verifies every move and syncs the target to disk.
+	SafetyParanoid Safety = "paranoid"
+)
+
+// TidyOptions configures a tidy run.
+type TidyOptions struct {
+	// Target is the folder files are moved into.
+	Target string
+	// Pattern is the text/template placing a file below Target, e.g.
+	// "{{.Year}}/{{.Month}}/{{.OriginalName}}"; empty means DefaultPattern.
+	Pattern string
+	// DryRun plans the moves without touching any file.
+	DryRun bool
+	// Safety is the verification depth; empty means SafetyStandard.
+	Safety Safety
+	// Workers is how many files are moved at once; below one means one.
+	Workers int
+}
+
+// TidyProgress reports a file handled by a tidy run.
+type TidyProgress struct {
+	ID        int64
+	Source    string
+	Target    string
+	Status    string
+	Error     string
+	Completed int
+	Total     int
+}
+
+// TidySummary reports the outcome of a tidy run.
+type TidySummary struct {
+	Total   int
+	Moved   int
+	Skipped int
+	Failed  int
+	// Deduplicated counts files whose target already held identical bytes.
+	Deduplicated int
+	DryRun       bool
+	DurationMS   int64
+}
+
+// Tidy moves the library files with the given IDs below opts.Target, to
+// the place opts.Pattern builds from their metadata, and records every move
+// in the library's action log.
+func (l *Library) Tidy(ctx context.Context, opts TidyOptions, ids []int64, onProgress func(TidyProgress)) (TidySummary, error) {
+	if opts.Target == "" {
+		return TidySummary{}, errors.New("tidy target is required")
+	}
+	if !l.jobs.TryLock() {
+		return TidySummary{}, ErrBusy
+	}
+	defer l.jobs.Unlock()
+
+	pattern := opts.Pattern
+	if pattern == "" {
+		pattern = DefaultPattern()
+	}
+	requests := make([]media.MoveRequest, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, media.MoveRequest{MediaID: id})
+	}
+	var progress func(media.TidyProgress)
+	if onProgress != nil {
+		progress = func(p media.TidyProgress) {
+			onProgress(TidyProgress{
+				ID:        p.MediaID,
+				Source:    p.Source,
+				Target:    p.Target,
+				Status:    p.Status,
+				Error:     p.Error,
+				Completed: p.Completed,
+				Total:     p.Total,
+			})
+		}
+	}
+	summary, err := l.tidy.Execute(ctx, media.TidyOptions{
+		TargetBase: opts.Target,
+		Pattern:    pattern,
+		DryRun:     opts.DryRun,
+		Safety:     media.SafetyLevel(opts.Safety),
+		Workers:    opts.Workers,
+	}, requests, progress)
+	return TidySummary{
+		Total:        summary.Total,
+		Moved:        summary.Moved,
+		Skipped:      summary.Skipped,
+		Failed:       summary.Failed,
+		Deduplicated: summary.Deduplicated,
+		DryRun:       summary.DryRun,
+		DurationMS:   summary.DurationMS,
+	}, err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/power"
+)
+
+// Pause reasons held on the shared job gate.
+const (
+	pauseSuspend = "suspend"
+	pauseBattery = "battery"
+)
+
+// batteryPollInterval controls how often the power source is sampled.
+const batteryPollInterval = 30 * time.Second
+
+// PowerState is emitted whenever power handling pauses or resumes jobs.
+type PowerState struct {
+	Paused  bool     `json:"paused"`
+	Reasons []string `json:"reasons"`
+}
+
+// onSuspend is wired to the OS suspend notification.
+func (a *App) onSuspend() {
+	a.gate.Pause(pauseSuspend)
+	a.emitPowerState()
+}
+
+// onResume is wired to the OS resume notification.
+func (a *App) onResume() {
+	a.gate.Resume(pauseSuspend)
+	a.emitPowerState()
+}
+
+// watchBattery pauses jobs while on battery when the settings ask for it.
+func (a *App) watchBattery() {
+	ticker := time.NewTicker(batteryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.checkBattery()
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) checkBattery() {
+	settings := a.currentSettings()
+	enabled := settings != nil && settings.Power.PauseOnBattery
+	onBattery, err := power.OnBattery()
+	if err != nil {
+		onBattery = false
+	}
+
+	before := len(a.gate.Reasons())
+	if enabled && onBattery {
+		a.gate.Pause(pauseBattery)
+	} else {
+		a.gate.Resume(pauseBattery)
+	}
+	if len(a.gate.Reasons()) != before {
+		a.emitPowerState()
+	}
+}
+
+func (a *App) emitPowerState() {
+	if a.ctx == nil {
+		return
+	}
+	reasons := a.gate.Reasons()
+	a.emit("", events.PowerState, PowerState{Paused: len(reasons) > 0, Reasons: reasons})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/priority"
+)
+
+// Job priorities; see config.ThrottleConfig.Priority.
+const (
+	priorityNormal = "normal"
+	priorityLow    = "low"
+)
+
+// loadPollInterval controls how often system load is sampled at low priority.
+const loadPollInterval = 10 * time.Second
+
+// At low priority jobs wait up to maxBackoff between files, scaling up from
+// nothing at loadIdle to the full wait at loadBusy.
+const (
+	loadIdle   = 0.5
+	loadBusy   = 1.5
+	maxBackoff = 2 * time.Second
+)
+
+// PriorityState describes how much jobs currently give way to the user.
+type PriorityState struct {
+	Priority string `json:"priority"`
+	// Background reports whether the OS runs the app at background CPU and
+	// IO priority.
+	Background bool `json:"background"`
+	// Load is the last sampled system load per CPU and BackoffMS the extra
+	// wait between files it caused.
+	Load      float64 `json:"load"`
+	BackoffMS int64   `json:"backoffMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// GetPriority returns the job priority and the backoff in force.
+func (a *App) GetPriority() PriorityState {
+	a.priorityMu.Lock()
+	defer a.priorityMu.Unlock()
+	return PriorityState{
+		Priority:   a.priorityLevel(),
+		Background: a.background,
+		Load:       a.load,
+		BackoffMS:  a.throttle.Backoff().Milliseconds(),
+		Error:      a.priorityErr,
+	}
+}
+
+// SetPriority switches jobs between "normal" and "low" priority, including
+// a job already running, and persists the choice to settings.toml.
+func (a *App) SetPriority(level string) (PriorityState, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if err := config.SetPriority(a.settingsPath, level); err != nil {
+		return a.GetPriority(), err
+	}
+	a.updateSettings(func(settings *config.Settings) {
+		settings.Throttle.Priority = level
+	})
+	a.applyPriority()
+	a.checkLoad()
+	a.logger.Info("priority changed", "priority", level)
+	return a.GetPriority(), nil
+}
+
+// priorityLevel returns the configured priority; callers hold priorityMu.
+func (a *App) priorityLevel() string {
+	settings := a.currentSettings()
+	if settings == nil || !strings.EqualFold(strings.TrimSpace(settings.Throttle.Priority), priorityLow) {
+		return priorityNormal
+	}
+	return priorityLow
+}
+
+// applyPriority moves the process to or from background priority to match
+// the settings.
+func (a *App) applyPriority() {
+	a.priorityMu.Lock()
+	defer a.priorityMu.Unlock()
+
+	want := a.priorityLevel() == priorityLow
+	if want == a.background {
+		return
+	}
+	if err := priority.SetBackground(want); err != nil {
+		a.priorityErr = err.Error()
+		a.logger.Warn("change process priority", "background", want, "error", err)
+		return
+	}
+	a.background = want
+	a.priorityErr = ""
+}
+
+// watchLoad slows jobs down while the machine is busy at low priority.
+func (a *App) watchLoad() {
+	ticker := time.NewTicker(loadPollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.checkLoad()
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) checkLoad() {
+	a.priorityMu.Lock()
+	defer a.priorityMu.Unlock()
+
+	if a.priorityLevel() != priorityLow {
+		a.load = 0
+		a.throttle.SetBackoff(0)
+		return
+	}
+	load, err := priority.Load()
+	if err != nil {
+		load = 0
+	}
+	a.load = load
+	a.throttle.SetBackoff(backoffFor(load))
+}
+
+// backoffFor scales the wait between files with the system load.
+func backoffFor(load float64) time.Duration {
+	switch {
+	case load <= loadIdle:
+		return 0
+	case load >= loadBusy:
+		return maxBackoff
+	default:
+		return time.Duration((load - loadIdle) / (loadBusy - loadIdle) * float64(maxBackoff))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// ListRcloneRemotes returns the remotes configured in rclone, such as
+// "gdrive:", for use as "remote:path" sources or targets.
+func (a *App) ListRcloneRemotes() ([]string, error) {
+	rclone, err := a.rcloneClient()
+	if err != nil {
+		return nil, err
+	}
+	return rclone.ListRemotes(a.ctx)
+}
+
+// rcloneClient returns the detected rclone.
+func (a *App) rcloneClient() (*media.Rclone, error) {
+	if !a.rclone.Available {
+		if a.rclone.Disabled {
+			return nil, errors.New("rclone is turned off in the tools settings")
+		}
+		return nil, errors.New("rclone was not found; install it or set its path in the tools settings")
+	}
+	return media.NewRclone(a.rclone.Path), nil
+}
+
+// rcloneMirror is the local folder a remote source is pulled into, e.g.
+// "<db folder>/rclone/gdrive/Camera" for "gdrive:Camera".
+func (a *App) rcloneMirror(source string) string {
+	remote, rest, _ := strings.Cut(source, ":")
+	return filepath.Join(a.settings.RcloneDir(a.dataRoot), remote, filepath.FromSlash(strings.Trim(rest, "/")))
+}
+
+// localSources maps rclone remotes among sources to their local mirrors.
+func (a *App) localSources(sources []string) []string {
+	local := make([]string, len(sources))
+	for i, source := range sources {
+		local[i] = source
+		if media.IsRcloneRemote(source) {
+			local[i] = a.rcloneMirror(source)
+		}
+	}
+	return local
+}
+
+// pullRemoteSources copies new media files from rclone remotes among sources
+// into their mirrors, reporting rclone:progress, and returns the sources
+// with each remote replaced by its mirror.
+func (a *App) pullRemoteSources(ctx context.Context, jobID string, sources []string) ([]string, error) {
+	for _, source := range sources {
+		if !media.IsRcloneRemote(source) {
+			continue
+		}
+		rclone, err := a.rcloneClient()
+		if err != nil {
+			return nil, err
+		}
+		mirror := a.rcloneMirror(source)
+		if err := os.MkdirAll(mirror, 0o755); err != nil {
+			return nil, err
+		}
+		pulled, err := media.PullRemote(ctx, rclone, a.store, source, mirror,
+			a.settings.NormalisedExtensions(), a.settings.Scan.Archives,
+			func(p media.RcloneProgress) { a.emit(jobID, events.RcloneProgress, p) })
+		if err != nil {
+			return nil, err
+		}
+		a.logger.Info("rclone source pulled", "jobId", jobID, "source", source, "mirror", mirror, "files", pulled)
+	}
+	return a.localSources(sources), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+)
+
+// errReadOnly is returned by jobs that would change media files while the
+// read-only switch is on.
+var errReadOnly = errors.New("the library is read-only; turn off read-only mode in settings to change files")
+
+// GetReadOnly reports whether jobs that change media files are rejected.
+func (a *App) GetReadOnly() bool {
+	return a.readOnly()
+}
+
+// SetReadOnly turns the read-only switch on or off, persists it and
+// reloads the settings. It refuses while a job runs, since reloading
+// replaces the store the job uses.
+func (a *App) SetReadOnly(enabled bool) (bool, error) {
+	if !a.jobMu.TryLock() {
+		return a.readOnly(), errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := config.SetReadOnly(a.settingsPath, enabled); err != nil {
+		return a.readOnly(), err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return a.readOnly(), err
+	}
+	a.logger.Info("read-only mode changed", "enabled", a.readOnly())
+	return a.readOnly(), nil
+}
+
+func (a *App) readOnly() bool {
+	return a.settings != nil && a.settings.ReadOnly
+}
+
+// checkWritable returns errReadOnly in read-only mode. Bindings that move,
+// copy, delete or rewrite media files, or replace what the library records
+// about them, call it first; dry runs are let through since they only
+// report.
+func (a *App) checkWritable() error {
+	if a.readOnly() {
+		return errReadOnly
+	}
+	return nil
+}
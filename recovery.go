@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+)
+
+// claimGrace is how old a target claim without a pending action must be
+// to count as stale; a live run records the action right after claiming.
+const claimGrace = time.Minute
+
+// sessionMarker is created at startup and removed on clean shutdown, so a
+// leftover marker reveals that the previous session crashed.
+const sessionMarker = ".session"
+
+// RecoveryReport summarises the startup integrity routine.
+type RecoveryReport struct {
+	CheckedAt       time.Time `json:"checkedAt"`
+	IntegrityOK     bool      `json:"integrityOk"`
+	Integrity       []string  `json:"integrity,omitempty"`
+	PendingActions  int       `json:"pendingActions"`
+	InterruptedRuns []int64   `json:"interruptedRuns,omitempty"`
+	UncleanShutdown bool      `json:"uncleanShutdown"`
+	Suggestions     []string  `json:"suggestions"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Healthy reports whether nothing needs the user's attention.
+func (r RecoveryReport) Healthy() bool {
+	return r.IntegrityOK && r.PendingActions == 0 && len(r.InterruptedRuns) == 0 && !r.UncleanShutdown && r.Error == ""
+}
+
+// checkIntegrity runs the startup checks and arms the session marker.
+func (a *App) checkIntegrity() RecoveryReport {
+	report := RecoveryReport{CheckedAt: time.Now(), Suggestions: []string{}}
+	if a.store == nil || a.settings == nil {
+		report.Error = "settings not loaded"
+		return report
+	}
+
+	a.markerPath = filepath.Join(filepath.Dir(a.settings.DatabasePath(a.dataRoot)), sessionMarker)
+	if _, err := os.Stat(a.markerPath); err == nil {
+		report.UncleanShutdown = true
+	}
+	if err := os.WriteFile(a.markerPath, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		a.logger.Warn("write session marker", "error", err)
+	}
+
+	var errs []error
+	problems, err := a.store.IntegrityCheck(a.ctx)
+	errs = append(errs, err)
+	report.Integrity = problems
+	report.IntegrityOK = err == nil && len(problems) == 0
+
+	report.PendingActions, err = a.store.CountPendingActions(a.ctx)
+	errs = append(errs, err)
+	report.InterruptedRuns, err = a.store.ListInterruptedRuns(a.ctx)
+	errs = append(errs, err)
+	released, err := a.store.ReleaseStaleClaims(a.ctx, time.Now().Add(-claimGrace))
+	errs = append(errs, err)
+	if released > 0 {
+		a.logger.Info("released stale target claims", "count", released)
+	}
+	if err := errors.Join(errs...); err != nil {
+		report.Error = err.Error()
+	}
+
+	if len(problems) > 0 {
+		report.Suggestions = append(report.Suggestions, "The database is damaged; restore it from a backup with RestoreDatabase.")
+	}
+	for _, runID := range report.InterruptedRuns {
+		report.Suggestions = append(report.Suggestions, fmt.Sprintf("Tidy run %d was interrupted; roll it back or retry its failed actions.", runID))
+	}
+	if report.PendingActions > 0 {
+		report.Suggestions = append(report.Suggestions, fmt.Sprintf("%d actions never finished; rescan the sources to resync file locations.", report.PendingActions))
+	}
+	if report.UncleanShutdown && len(report.Suggestions) == 0 {
+		report.Suggestions = append(report.Suggestions, "The previous session ended unexpectedly; an incremental scan is recommended.")
+	}
+
+	if report.Healthy() {
+		a.logger.Info("startup integrity check passed")
+	} else {
+		a.logger.Warn("startup integrity check found problems",
+			"integrity", report.Integrity,
+			"pendingActions", report.PendingActions,
+			"interruptedRuns", report.InterruptedRuns,
+			"uncleanShutdown", report.UncleanShutdown,
+			"error", report.Error,
+		)
+	}
+	return report
+}
+
+// domReady emits the startup report once the frontend can receive events.
+func (a *App) domReady(ctx context.Context) {
+	a.emit("", events.StartupRecovery, a.recovery)
+	if len(a.launchFolders) > 0 {
+		a.emit("", events.OpenFolders, OpenFolders{Folders: a.launchFolders})
+	}
+}
+
+// GetRecoveryReport returns the result of the startup integrity check.
+func (a *App) GetRecoveryReport() RecoveryReport {
+	return a.recovery
+}
+
+// clearSessionMarker records a clean shutdown.
+func (a *App) clearSessionMarker() {
+	if a.markerPath == "" {
+		return
+	}
+	if err := os.Remove(a.markerPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		a.logger.Warn("remove session marker", "error", err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/schedule"
+)
+
+// ScheduleActivity is emitted when the scheduler starts or finishes a job.
+type ScheduleActivity struct {
+	Job     string      `json:"job"`
+	Phase   string      `json:"phase"`
+	Error   string      `json:"error,omitempty"`
+	Summary interface{} `json:"summary,omitempty"`
+	NextRun string      `json:"nextRun,omitempty"`
+}
+
+// runScheduler fires scheduled scans until the app shuts down.
+func (a *App) runScheduler() {
+	a.runSchedule(func(s *config.Settings) string { return s.Schedule.Scan }, a.runScheduledJobs)
+}
+
+// runVerifyScheduler fires scheduled verification runs until the app shuts
+// down.
+func (a *App) runVerifyScheduler() {
+	a.runSchedule(func(s *config.Settings) string { return s.Schedule.Verify }, a.runScheduledVerify)
+}
+
+// runMaintenanceScheduler fires scheduled maintenance runs until the app
+// shuts down.
+func (a *App) runMaintenanceScheduler() {
+	a.runSchedule(func(s *config.Settings) string { return s.Schedule.Maintenance }, a.runScheduledMaintenance)
+}
+
+// runSchedule calls run whenever the spec read from the settings fires. The
+// spec is re-read after every wake-up so edits to settings take effect.
+func (a *App) runSchedule(specOf func(*config.Settings) string, run func(schedule.Spec)) {
+	for {
+		spec := schedule.Spec{}
+		if settings := a.currentSettings(); settings != nil {
+			spec, _ = schedule.Parse(specOf(settings))
+		}
+
+		wait := time.Minute
+		if spec.Enabled() {
+			wait = time.Until(spec.Next(time.Now()))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if spec.Enabled() {
+			run(spec)
+		}
+	}
+}
+
+func (a *App) runScheduledJobs(spec schedule.Spec) {
+	next := spec.Next(time.Now()).Format(time.RFC3339)
+
+	a.emitSchedule(ScheduleActivity{Job: "scan", Phase: "started"})
+	summary, err := a.scan(true)
+	if err != nil {
+		a.emitSchedule(ScheduleActivity{Job: "scan", Phase: "failed", Error: err.Error(), NextRun: next})
+		return
+	}
+	a.emitSchedule(ScheduleActivity{Job: "scan", Phase: "finished", Summary: summary, NextRun: next})
+
+	// Read-only mode leaves the inbox and the automatic tidy out instead of
+	// reporting them failed on every run.
+	if a.settings != nil && a.settings.Scan.InboxFolder != "" && !a.readOnly() {
+		a.emitSchedule(ScheduleActivity{Job: "inbox", Phase: "started"})
+		if inbox, err := a.ImportInbox(false); err != nil {
+			a.emitSchedule(ScheduleActivity{Job: "inbox", Phase: "failed", Error: err.Error()})
+		} else {
+			a.emitSchedule(ScheduleActivity{Job: "inbox", Phase: "finished", Summary: inbox, NextRun: next})
+		}
+	}
+
+	// Faces and labels go before the tidy so {{.Person}} and .HasTag see
+	// the new files.
+	if a.settings != nil && a.settings.FeatureEnabled(config.FeatureFaceDetection) && a.settings.Faces.Configured() {
+		a.emitSchedule(ScheduleActivity{Job: "faces", Phase: "started"})
+		if faces, err := a.AnalyseFaces(0); err != nil {
+			a.emitSchedule(ScheduleActivity{Job: "faces", Phase: "failed", Error: err.Error()})
+		} else {
+			a.emitSchedule(ScheduleActivity{Job: "faces", Phase: "finished", Summary: faces, NextRun: next})
+		}
+	}
+
+	if a.settings != nil && a.settings.FeatureEnabled(config.FeatureImageLabels) && a.settings.Classifier.Configured() {
+		a.emitSchedule(ScheduleActivity{Job: "labels", Phase: "started"})
+		if labels, err := a.ClassifyImages(0); err != nil {
+			a.emitSchedule(ScheduleActivity{Job: "labels", Phase: "failed", Error: err.Error()})
+		} else {
+			a.emitSchedule(ScheduleActivity{Job: "labels", Phase: "finished", Summary: labels, NextRun: next})
+		}
+	}
+
+	if a.settings != nil && a.settings.FeatureEnabled(config.FeaturePerceptualHash) {
+		a.emitSchedule(ScheduleActivity{Job: "phash", Phase: "started"})
+		if phash, err := a.HashSimilarImages(0); err != nil {
+			a.emitSchedule(ScheduleActivity{Job: "phash", Phase: "failed", Error: err.Error()})
+		} else {
+			a.emitSchedule(ScheduleActivity{Job: "phash", Phase: "finished", Summary: phash, NextRun: next})
+		}
+	}
+
+	if a.settings != nil && a.settings.FeatureEnabled(config.FeatureOCR) && a.tesseract.Available {
+		a.emitSchedule(ScheduleActivity{Job: "ocr", Phase: "started"})
+		if ocr, err := a.RecogniseText(0); err != nil {
+			a.emitSchedule(ScheduleActivity{Job: "ocr", Phase: "failed", Error: err.Error()})
+		} else {
+			a.emitSchedule(ScheduleActivity{Job: "ocr", Phase: "finished", Summary: ocr, NextRun: next})
+		}
+	}
+
+	if a.settings == nil || !a.settings.Schedule.AutoTidy || !a.settings.FeatureEnabled(config.FeatureAutoTidy) || a.readOnly() {
+		return
+	}
+
+	pending, err := a.store.ListMediaOutside(a.ctx, a.settings.Target.BaseFolder)
+	if err != nil {
+		a.emitSchedule(ScheduleActivity{Job: "tidy", Phase: "failed", Error: err.Error()})
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	requests := make([]media.MoveRequest, 0, len(pending))
+	for _, file := range pending {
+		requests = append(requests, media.MoveRequest{MediaID: file.ID})
+	}
+
+	a.emitSchedule(ScheduleActivity{Job: "tidy", Phase: "started"})
+	tidySummary, err := a.runTidy(requests, false, media.SafetyStandard, false, nil)
+	if err != nil {
+		a.emitSchedule(ScheduleActivity{Job: "tidy", Phase: "failed", Error: err.Error()})
+		return
+	}
+	a.emitSchedule(ScheduleActivity{Job: "tidy", Phase: "finished", Summary: tidySummary, NextRun: next})
+}
+
+func (a *App) runScheduledVerify(spec schedule.Spec) {
+	next := spec.Next(time.Now()).Format(time.RFC3339)
+	a.emitSchedule(ScheduleActivity{Job: "verify", Phase: "started"})
+	summary, err := a.VerifyLibrary(0)
+	if err != nil {
+		a.emitSchedule(ScheduleActivity{Job: "verify", Phase: "failed", Error: err.Error(), NextRun: next})
+		return
+	}
+	a.emitSchedule(ScheduleActivity{Job: "verify", Phase: "finished", Summary: summary, NextRun: next})
+}
+
+func (a *App) runScheduledMaintenance(spec schedule.Spec) {
+	next := spec.Next(time.Now()).Format(time.RFC3339)
+	a.emitSchedule(ScheduleActivity{Job: "maintenance", Phase: "started"})
+	summary, err := a.RunMaintenance(false)
+	if err != nil {
+		a.emitSchedule(ScheduleActivity{Job: "maintenance", Phase: "failed", Error: err.Error(), NextRun: next})
+		return
+	}
+	a.emitSchedule(ScheduleActivity{Job: "maintenance", Phase: "finished", Summary: summary, NextRun: next})
+}
+
+func (a *App) emitSchedule(activity ScheduleActivity) {
+	a.emit("", events.ScheduleActivity, activity)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+)
+
+const (
+	// settingsDebounce collapses the burst of writes editors make on save.
+	settingsDebounce = 500 * time.Millisecond
+	// settingsRetry is how often a deferred reload checks for a finished job.
+	settingsRetry = 2 * time.Second
+)
+
+// SettingsChanged is emitted after settings.toml changes on disk. Pending
+// means the reload waits for the running scan or tidy to finish.
+type SettingsChanged struct {
+	Applied bool   `json:"applied"`
+	Pending bool   `json:"pending"`
+	Profile string `json:"profile,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// watchSettings reloads settings.toml when it is edited outside the app.
+// The folder is watched rather than the file because editors often save by
+// replacing the file.
+func (a *App) watchSettings() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		a.logger.Error("watch settings", "error", err)
+		return
+	}
+	defer watcher.Close()
+
+	// On first run the folder only appears once the wizard saves settings.
+	dir := filepath.Dir(a.settingsPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		a.logger.Error("watch settings", "error", err)
+		return
+	}
+	if err := watcher.Add(dir); err != nil {
+		a.logger.Error("watch settings", "error", err)
+		return
+	}
+
+	name := filepath.Base(a.settingsPath)
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Base(event.Name) != name || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			timer.Reset(settingsDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			a.logger.Warn("settings watcher", "error", err)
+		case <-timer.C:
+			if !a.applySettingsChange() {
+				timer.Reset(settingsRetry)
+			}
+		}
+	}
+}
+
+// applySettingsChange validates and swaps in the edited settings. It returns
+// false when a job is running and the reload has to be retried later.
+func (a *App) applySettingsChange() bool {
+	if _, err := config.Load(a.settingsPath); err != nil {
+		a.logger.Warn("edited settings rejected", "error", err)
+		a.emit("", events.SettingsChanged, SettingsChanged{Error: err.Error()})
+		return true
+	}
+
+	if !a.jobMu.TryLock() {
+		a.emit("", events.SettingsChanged, SettingsChanged{Pending: true})
+		return false
+	}
+	defer a.jobMu.Unlock()
+
+	if err := a.reloadSettings(); err != nil {
+		a.logger.Error("reload edited settings", "error", err)
+		a.emit("", events.SettingsChanged, SettingsChanged{Error: err.Error()})
+		return true
+	}
+	a.logger.Info("settings reloaded after edit", "profile", a.settings.ProfileName())
+	a.emit("", events.SettingsChanged, SettingsChanged{Applied: true, Profile: a.settings.ProfileName()})
+	return true
+}
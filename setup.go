@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+)
+
+// Install modes reported by GetAppInfo.
+const (
+	modeDevelopment = "development"
+	modePortable    = "portable"
+	modeInstalled   = "installed"
+)
+
+// portableMarker, placed next to the executable, keeps settings and data in
+// the executable's folder, e.g. for USB-stick installs.
+const portableMarker = "portable"
+
+// appLocation describes where settings and data live.
+type appLocation struct {
+	mode         string
+	settingsPath string
+	dataRoot     string
+}
+
+// resolveLocation picks the settings and data folders. Development builds
+// use the working directory; a portable marker or settings.toml next to the
+// executable selects portable mode; otherwise the OS folders are used and a
+// settings.toml left in the working directory by older builds is migrated.
+func resolveLocation() appLocation {
+	if devBuild {
+		root, err := os.Getwd()
+		if err != nil {
+			root = "."
+		}
+		return appLocation{mode: modeDevelopment, settingsPath: filepath.Join(root, "settings.toml"), dataRoot: root}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		dir := filepath.Dir(exe)
+		for _, name := range []string{portableMarker, "settings.toml"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return appLocation{mode: modePortable, settingsPath: filepath.Join(dir, "settings.toml"), dataRoot: dir}
+			}
+		}
+	}
+
+	configDir, cfgErr := config.ConfigDir()
+	dataDir, dataErr := config.DataDir()
+	if cfgErr != nil || dataErr != nil {
+		root, _ := os.Getwd()
+		return appLocation{mode: modePortable, settingsPath: filepath.Join(root, "settings.toml"), dataRoot: root}
+	}
+
+	loc := appLocation{mode: modeInstalled, settingsPath: filepath.Join(configDir, "settings.toml"), dataRoot: dataDir}
+	if _, err := os.Stat(loc.settingsPath); errors.Is(err, os.ErrNotExist) {
+		if wd, err := os.Getwd(); err == nil {
+			legacy := filepath.Join(wd, "settings.toml")
+			if _, err := os.Stat(legacy); err == nil {
+				_ = config.MigrateSettings(legacy, loc.settingsPath)
+			}
+		}
+	}
+	return loc
+}
+
+// IsFirstRun reports whether no settings file exists yet, so the UI should
+// show the setup wizard.
+func (a *App) IsFirstRun() bool {
+	_, err := os.Stat(a.settingsPath)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// GetDefaultSettings returns starter settings with platform default paths
+// for the wizard to prefill.
+func (a *App) GetDefaultSettings() config.Settings {
+	return config.Defaults()
+}
+
+// InitializeSettings writes the wizard's settings, completed with defaults,
+// as a new settings file and loads it. An existing file is never replaced.
+func (a *App) InitializeSettings(defaults config.Settings) (config.Settings, error) {
+	if !a.IsFirstRun() {
+		return config.Settings{}, errors.New("settings already exist at " + a.settingsPath)
+	}
+
+	defaults.FillDefaults()
+	if err := config.Write(a.settingsPath, defaults); err != nil {
+		return config.Settings{}, err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return config.Settings{}, err
+	}
+	a.logger.Info("settings initialised", "path", a.settingsPath)
+	a.recovery = a.checkIntegrity()
+	return *a.settings, nil
+}
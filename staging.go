@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ScanFolderOptions configures an ad-hoc scan by ScanFolders.
+type ScanFolderOptions struct {
+	// Incremental skips files whose size and modification time are
+	// unchanged since they were last scanned.
+	Incremental bool `json:"incremental"`
+	// Policy is "copy" (the default) or "skip" for files whose content is
+	// already in the library, as for ImportFolders. Staging scans, which
+	// cannot see the library, ignore it.
+	Policy string `json:"policy"`
+	// Staging scans into the staging database rather than the library, so
+	// the result can be looked at and thrown away with DiscardStaging.
+	Staging bool `json:"staging"`
+}
+
+// ScanFolders scans folders outside the configured sources, such as a USB
+// stick, without changing the settings.
+func (a *App) ScanFolders(paths []string, opts ScanFolderOptions) (media.Summary, error) {
+	if a.scanner == nil || a.settings == nil {
+		return media.Summary{}, errors.New("scanner not initialised")
+	}
+	if len(paths) == 0 {
+		return media.Summary{}, errors.New("no folders to scan")
+	}
+	for _, path := range paths {
+		if media.IsRcloneRemote(path) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil {
+			return media.Summary{}, err
+		} else if !info.IsDir() {
+			return media.Summary{}, fmt.Errorf("%s is not a folder", path)
+		}
+	}
+	known, err := media.ParseImportPolicy(opts.Policy)
+	if err != nil {
+		return media.Summary{}, err
+	}
+	if !a.jobMu.TryLock() {
+		return media.Summary{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	job := media.Options{Sources: paths, Incremental: opts.Incremental}
+	if !opts.Staging {
+		job.Known = known
+		return a.scanSources(job)
+	}
+
+	staging, err := a.openStaging()
+	if err != nil {
+		return media.Summary{}, err
+	}
+	defer staging.Close()
+	return a.scanWith(media.NewScanner(staging), job, true)
+}
+
+// StagingInfo describes the staging area: what it holds and which folders
+// staging scans covered.
+type StagingInfo struct {
+	Staged  storage.StagingSummary `json:"staged"`
+	Sources []string               `json:"sources"`
+}
+
+// StagingCommitSummary reports staged scans committed into the library.
+type StagingCommitSummary struct {
+	Commit  storage.StagingCommit `json:"commit"`
+	Sources []string              `json:"sources"`
+}
+
+// GetStaging returns what staging scans recorded, or an empty StagingInfo
+// when there is nothing staged.
+func (a *App) GetStaging() (StagingInfo, error) {
+	info := StagingInfo{Sources: []string{}}
+	if a.store == nil || a.settings == nil {
+		return info, errors.New("store not initialised")
+	}
+	if !a.hasStaging() {
+		return info, nil
+	}
+	sources, err := a.stagedSources()
+	if err != nil {
+		return info, err
+	}
+	info.Sources = sources
+	info.Staged, err = a.store.StagingSummary(a.ctx, a.settings.StagingPath(a.dataRoot))
+	return info, err
+}
+
+// ListStagedMedia lists the files staging scans recorded, like ListMedia
+// does for the library.
+func (a *App) ListStagedMedia(filter storage.MediaFilter) ([]storage.MediaFile, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings not loaded")
+	}
+	if !a.hasStaging() {
+		return []storage.MediaFile{}, nil
+	}
+	staging, err := a.openStaging()
+	if err != nil {
+		return nil, err
+	}
+	defer staging.Close()
+	return staging.ListMedia(a.ctx, filter)
+}
+
+// CommitStaging moves everything staging scans recorded into the library,
+// as if the folders had been scanned into it, and then discards the
+// staging database.
+func (a *App) CommitStaging() (StagingCommitSummary, error) {
+	var summary StagingCommitSummary
+	if a.store == nil || a.settings == nil {
+		return summary, errors.New("store not initialised")
+	}
+	if !a.hasStaging() {
+		return summary, errors.New("nothing is staged")
+	}
+	if !a.jobMu.TryLock() {
+		return summary, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	sources, err := a.stagedSources()
+	if err != nil {
+		return summary, err
+	}
+	summary.Sources = sources
+	path := a.settings.StagingPath(a.dataRoot)
+	if summary.Commit, err = a.store.CommitStaging(a.ctx, path); err != nil {
+		return summary, err
+	}
+	a.logger.Info("staging committed", "sources", sources,
+		"files", summary.Commit.Files, "known", summary.Commit.Known, "junk", summary.Commit.Junk)
+	a.recordSnapshot(storage.SnapshotScan, 0)
+	return summary, a.removeStaging()
+}
+
+// DiscardStaging deletes the staging database and everything staging
+// scans recorded in it; the scanned files themselves are left alone.
+func (a *App) DiscardStaging() error {
+	if a.settings == nil {
+		return errors.New("settings not loaded")
+	}
+	if !a.jobMu.TryLock() {
+		return errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := a.removeStaging(); err != nil {
+		return err
+	}
+	a.logger.Info("staging discarded")
+	return nil
+}
+
+// removeStaging deletes the staging database; callers must hold jobMu so
+// no staging scan has it open.
+func (a *App) removeStaging() error {
+	path := a.settings.StagingPath(a.dataRoot)
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove staging: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasStaging reports whether a staging scan left a database behind.
+func (a *App) hasStaging() bool {
+	_, err := os.Stat(a.settings.StagingPath(a.dataRoot))
+	return err == nil
+}
+
+// stagedSources lists the folders of the staging scans, oldest first and
+// each once.
+func (a *App) stagedSources() ([]string, error) {
+	staging, err := a.openStaging()
+	if err != nil {
+		return nil, err
+	}
+	defer staging.Close()
+	sessions, err := staging.ListScanSessions(a.ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	sources := []string{}
+	seen := make(map[string]bool)
+	for i := len(sessions) - 1; i >= 0; i-- {
+		for _, source := range sessions[i].Sources {
+			if !seen[source] {
+				seen[source] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources, nil
+}
+
+// openStaging opens the staging database, creating it on first use.
+func (a *App) openStaging() (*storage.Store, error) {
+	store, err := storage.New(a.settings.StagingPath(a.dataRoot))
+	if err != nil {
+		return nil, fmt.Errorf("open staging: %w", err)
+	}
+	return store, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+)
+
+// detectTools locates the optional external tools named in the settings.
+func (a *App) detectTools() {
+	a.exiftool = media.LocateExiftool(a.settings.Tools.Exiftool)
+	if a.exiftool.Available {
+		a.logger.Info("exiftool detected", "path", a.exiftool.Path, "version", a.exiftool.Version)
+	}
+
+	a.ffprobe = media.LocateFFprobe(a.settings.Tools.FFprobe)
+	var probe *media.FFprobe
+	if a.ffprobe.Available {
+		probe = media.NewFFprobe(a.ffprobe.Path)
+		a.logger.Info("ffprobe detected", "path", a.ffprobe.Path, "version", a.ffprobe.Version, "posters", probe.CanRenderPosters())
+	}
+	// The media handler reads probe from its own goroutines.
+	a.settingsMu.Lock()
+	a.probe = probe
+	a.settingsMu.Unlock()
+
+	a.rclone = media.LocateRclone(a.settings.Tools.Rclone)
+	if a.rclone.Available {
+		a.logger.Info("rclone detected", "path", a.rclone.Path, "version", a.rclone.Version)
+	}
+
+	a.tesseract = media.LocateTesseract(a.settings.Tools.Tesseract)
+	if a.tesseract.Available {
+		a.logger.Info("tesseract detected", "path", a.tesseract.Path, "version", a.tesseract.Version)
+	}
+
+	a.gphoto2 = media.LocateGphoto2(a.settings.Tools.Gphoto2)
+	if a.gphoto2.Available {
+		a.logger.Info("gphoto2 detected", "path", a.gphoto2.Path, "version", a.gphoto2.Version)
+	}
+}
+
+// GetTools reports which optional external tools were found and are used.
+func (a *App) GetTools() []media.ToolInfo {
+	return []media.ToolInfo{a.exiftool, a.ffprobe, a.rclone, a.tesseract, a.gphoto2}
+}
+
+// SetToolPath persists where an external tool lives: empty to look it up on
+// PATH, "off" to stop using it, or the executable's path. The tools are
+// detected again and the result returned.
+func (a *App) SetToolPath(name, path string) ([]media.ToolInfo, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings not loaded")
+	}
+	if !a.jobMu.TryLock() {
+		return nil, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	if err := config.SetTool(a.settingsPath, name, path); err != nil {
+		return nil, err
+	}
+	if err := a.reloadSettings(); err != nil {
+		return nil, err
+	}
+	return a.GetTools(), nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// ListTrashedMedia returns a page of the media rows removed from the
+// library, such as by removing files or merging duplicates, most recent
+// first.
+func (a *App) ListTrashedMedia(page storage.Page) (storage.TrashPage, error) {
+	if a.store == nil {
+		return storage.TrashPage{}, errors.New("store not initialised")
+	}
+	return a.store.ListTrashedMedia(a.ctx, page)
+}
+
+// RestoreMedia puts trashed rows back into the library with their tags,
+// rating, EXIF fields and album memberships. Only the index entry comes
+// back: a file removed from disk stays removed.
+func (a *App) RestoreMedia(ids []int64) (storage.TrashRestore, error) {
+	if a.store == nil {
+		return storage.TrashRestore{}, errors.New("store not initialised")
+	}
+	if len(ids) == 0 {
+		return storage.TrashRestore{Conflicts: []string{}}, nil
+	}
+	if err := a.checkWritable(); err != nil {
+		return storage.TrashRestore{}, err
+	}
+	if !a.jobMu.TryLock() {
+		return storage.TrashRestore{}, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	result, err := a.store.RestoreMedia(a.ctx, ids)
+	if err != nil {
+		return result, err
+	}
+	a.logger.Info("restored media", "count", result.Restored, "conflicts", len(result.Conflicts))
+	return result, nil
+}
+
+// PurgeTrash deletes the rows trashed more than olderThanDays days ago for
+// good; zero empties the trash. It returns how many rows went.
+func (a *App) PurgeTrash(olderThanDays int) (int, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	if olderThanDays < 0 {
+		return 0, errors.New("olderThanDays must not be negative")
+	}
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+	var before time.Time
+	if olderThanDays > 0 {
+		before = time.Now().AddDate(0, 0, -olderThanDays)
+	}
+	purged, err := a.store.PurgeTrash(a.ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	a.logger.Info("purged trash", "count", purged)
+	return purged, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deadlyedge/wails-tabs/internal/config"
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/media"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+)
+
+// VerifyLibrary re-hashes a share of the library, least recently verified
+// files first, and compares the result with the hashes recorded by scans.
+// samplePercent is between 1 and 100; 0 uses the configured share. It
+// reports verify:progress events and yields to scans and tidy runs.
+func (a *App) VerifyLibrary(samplePercent int) (media.VerifySummary, error) {
+	if a.store == nil || a.settings == nil {
+		return media.VerifySummary{}, errors.New("store not initialised")
+	}
+	if samplePercent == 0 {
+		samplePercent = a.settings.Schedule.VerifySamplePercent
+	}
+	if samplePercent < 1 || samplePercent > 100 {
+		return media.VerifySummary{}, errors.New("sample percent must be between 1 and 100")
+	}
+
+	a.verifyMu.Lock()
+	if a.cancelVerify != nil {
+		a.verifyMu.Unlock()
+		return media.VerifySummary{}, errors.New("a verification is already running")
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelVerify = cancel
+	a.verifyMu.Unlock()
+	defer func() {
+		a.verifyMu.Lock()
+		a.cancelVerify()
+		a.cancelVerify = nil
+		a.verifyMu.Unlock()
+	}()
+
+	total, err := a.store.CountVerifiable(ctx)
+	if err != nil {
+		return media.VerifySummary{}, err
+	}
+	limit := 0
+	if samplePercent < 100 {
+		limit = max(1, total*samplePercent/100)
+	}
+	files, err := a.store.ListVerificationCandidates(ctx, limit)
+	if err != nil {
+		return media.VerifySummary{}, err
+	}
+
+	jobID := events.NewJobID("verify")
+	a.logger.Info("verification started", "jobId", jobID, "files", len(files), "samplePercent", samplePercent)
+	opts := media.VerifyOptions{Gate: a.gate, Throttle: a.throttle, Acquire: a.acquireIdle}
+	summary, err := media.NewVerifier(a.store).Run(ctx, files, opts, func(p media.VerifyProgress) {
+		a.emit(jobID, events.VerifyProgress, p)
+	})
+	if err != nil {
+		a.logger.Error("verification stopped", "jobId", jobID, "error", err, "checked", summary.Checked)
+		a.notifyJob(config.NotifyVerify, "Verification", err, "")
+		return summary, err
+	}
+	a.logger.Info("verification finished", "jobId", jobID,
+		"checked", summary.Checked,
+		"mismatched", summary.Mismatched,
+		"changed", summary.Changed,
+		"missing", summary.Missing,
+		"failed", summary.Failed,
+		"durationMs", summary.DurationMS,
+	)
+	if summary.Mismatched > 0 || summary.Missing > 0 {
+		a.logger.Warn("possible corruption found", "jobId", jobID, "mismatched", summary.Mismatched, "missing", summary.Missing)
+	}
+	a.notifyJob(config.NotifyVerify, "Verification", nil,
+		fmt.Sprintf("%d files checked, %d mismatched, %d missing", summary.Checked, summary.Mismatched, summary.Missing))
+	return summary, nil
+}
+
+// CancelVerify stops the running verification after the file in progress.
+// It reports whether a verification was running.
+func (a *App) CancelVerify() bool {
+	a.verifyMu.Lock()
+	defer a.verifyMu.Unlock()
+	if a.cancelVerify == nil {
+		return false
+	}
+	a.cancelVerify()
+	return true
+}
+
+// ListVerificationIssues returns the possible corruption report: files whose
+// latest verification found other content than scanned, or no file at all.
+func (a *App) ListVerificationIssues() ([]storage.VerificationResult, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListVerificationIssues(a.ctx)
+}
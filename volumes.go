@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/wails-tabs/internal/events"
+	"github.com/deadlyedge/wails-tabs/internal/storage"
+	"github.com/deadlyedge/wails-tabs/internal/volume"
+)
+
+// volumePollInterval controls how often the recorded volumes are compared
+// with the mounted ones.
+const volumePollInterval = 10 * time.Second
+
+// VolumeState is emitted when a volume scanned files live on is unplugged,
+// comes back, or comes back under another mount point or drive letter.
+type VolumeState struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	MountPoint string `json:"mountPoint"`
+	Online     bool   `json:"online"`
+	// Rebound counts the files moved to the new mount point.
+	Rebound int64 `json:"rebound,omitempty"`
+}
+
+// ListVolumes returns the drives and shares scanned files live on.
+func (a *App) ListVolumes() ([]storage.Volume, error) {
+	if a.store == nil {
+		return nil, errors.New("store not initialised")
+	}
+	return a.store.ListVolumes(a.ctx)
+}
+
+// RebindVolume moves the files stored below oldRoot to the same place below
+// newRoot, such as after a NAS share was mounted at another path or drive
+// letter, without rescanning. newRoot must exist. It returns how many files
+// moved; the action log keeps the paths it recorded.
+func (a *App) RebindVolume(oldRoot, newRoot string) (int64, error) {
+	if a.store == nil {
+		return 0, errors.New("store not initialised")
+	}
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+	oldRoot, newRoot = strings.TrimSpace(oldRoot), strings.TrimSpace(newRoot)
+	if oldRoot == "" || newRoot == "" {
+		return 0, errors.New("both the old and the new root are required")
+	}
+	if info, err := os.Stat(newRoot); err != nil {
+		return 0, err
+	} else if !info.IsDir() {
+		return 0, fmt.Errorf("%s is not a folder", newRoot)
+	}
+	if !a.jobMu.TryLock() {
+		return 0, errBusy
+	}
+	defer a.jobMu.Unlock()
+
+	moved, err := a.store.RebindRoot(a.ctx, oldRoot, newRoot)
+	if err != nil {
+		return 0, err
+	}
+	a.logger.Info("files re-bound", "from", oldRoot, "to", newRoot, "files", moved)
+	return moved, nil
+}
+
+// watchVolumes keeps the online state and mount points of the recorded
+// volumes current and reports memory cards as they are inserted.
+func (a *App) watchVolumes() {
+	ticker := time.NewTicker(volumePollInterval)
+	defer ticker.Stop()
+
+	cards := make(map[string]bool)
+	for {
+		a.checkVolumes()
+		a.checkCards(cards)
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) checkVolumes() {
+	if a.store == nil {
+		return
+	}
+	known, err := a.store.ListVolumes(a.ctx)
+	if err != nil || len(known) == 0 {
+		return
+	}
+	mounted, err := volume.Mounted()
+	if err != nil {
+		return
+	}
+
+	for _, v := range known {
+		m, ok := mountedAs(mounted, v)
+		state := VolumeState{ID: v.ID, Label: v.Label, MountPoint: v.MountPoint, Online: ok}
+		switch {
+		case !ok:
+			if !v.Online {
+				continue
+			}
+			if err := a.store.SetVolumeOffline(a.ctx, v.ID); err != nil {
+				a.logger.Warn("mark volume offline", "volume", v.ID, "error", err)
+				continue
+			}
+			a.logger.Info("volume offline", "volume", v.ID, "label", v.Label, "mountPoint", v.MountPoint)
+		case m.MountPoint != v.MountPoint:
+			moved, err := a.store.RebindVolume(a.ctx, v.ID, m.MountPoint)
+			if err != nil {
+				a.logger.Warn("rebind volume", "volume", v.ID, "mountPoint", m.MountPoint, "error", err)
+				continue
+			}
+			a.logger.Info("volume moved", "volume", v.ID, "from", v.MountPoint, "to", m.MountPoint, "files", moved)
+			state.MountPoint, state.Rebound = m.MountPoint, moved
+		case !v.Online:
+			if err := a.store.SaveVolume(a.ctx, storage.Volume{ID: m.ID, Label: m.Label, MountPoint: m.MountPoint, Removable: m.Removable}); err != nil {
+				a.logger.Warn("mark volume online", "volume", v.ID, "error", err)
+				continue
+			}
+			a.logger.Info("volume online", "volume", v.ID, "label", v.Label, "mountPoint", v.MountPoint)
+		default:
+			continue
+		}
+		a.emit("", events.VolumeState, state)
+	}
+}
+
+// mountedAs finds v among the mounted volumes, preferring its recorded
+// mount point when several mounts share its ID.
+func mountedAs(mounted []volume.Info, v storage.Volume) (volume.Info, bool) {
+	var found volume.Info
+	ok := false
+	for _, m := range mounted {
+		if m.ID != v.ID {
+			continue
+		}
+		if m.MountPoint == v.MountPoint {
+			return m, true
+		}
+		if !ok {
+			found, ok = m, true
+		}
+	}
+	return found, ok
+}